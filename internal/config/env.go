@@ -62,3 +62,25 @@ func (e *envLoader) setBool(key string, target *bool) {
 		*target = strings.ToLower(v) == "true" || v == "1"
 	}
 }
+
+// setFloat 如果环境变量存在，设置浮点数值
+func (e *envLoader) setFloat(key string, target *float64) {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			*target = f
+		}
+	}
+}
+
+// setStrings 如果环境变量存在，按逗号分隔设置字符串列表
+func (e *envLoader) setStrings(key string, target *[]string) {
+	if v := os.Getenv(key); v != "" {
+		var list []string
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+		*target = list
+	}
+}
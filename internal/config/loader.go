@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -39,7 +40,12 @@ func defaultConfig() Config {
 			Timeout:  60,
 		},
 		Feishu: FeishuConfig{
-			Enabled: false,
+			Enabled:          false,
+			DedupCachePath:   "data/feishu_dedup.json",
+			DedupCacheDays:   7,
+			DedupBatchSize:   50,
+			DedupConcurrency: 3,
+			BatchSize:        500,
 		},
 		Logging: LoggingConfig{
 			Level:  "info",
@@ -52,6 +58,70 @@ func defaultConfig() Config {
 				MaxMs: 3000,
 			},
 		},
+		History: HistoryConfig{
+			Enabled: true,
+			Dir:     "data/history",
+		},
+		Storage: StorageConfig{
+			Path: "data/xianyu.db",
+		},
+		Postgres: PostgresConfig{
+			MaxConns:  4,
+			BatchSize: 500,
+		},
+		Tracker: TrackerConfig{
+			StatePath:   "data/tracker.json",
+			TickMinutes: 5,
+			MaxAgeDays:  30,
+		},
+		Scoring: ScoringConfig{
+			VelocityHours: 24,
+		},
+		Deals: DealsConfig{
+			Threshold:       0.3,
+			MinPeers:        5,
+			TitleSimilarity: 0.5,
+			ReferenceDays:   30,
+		},
+		Dup: DupConfig{
+			MaxHashDistance: 6,
+			TitleSimilarity: 0.8,
+			IndexPath:       "data/dup_index.json",
+			IndexDays:       30,
+		},
+		Watch: WatchConfig{
+			ListPath:        "configs/watchlist.yaml",
+			StatePath:       "data/watch_state.json",
+			TickMinutes:     1,
+			IntervalMinutes: 30,
+			Pages:           1,
+		},
+		Notify: NotifyConfig{
+			DedupHours: 24,
+			DedupPath:  "data/notify_dedup.json",
+			SummaryTop: 5,
+			MutePath:   "data/muted_sellers.json",
+		},
+		Digest: DigestConfig{
+			Period:    "daily",
+			Hour:      9,
+			Weekday:   1,
+			Top:       10,
+			StatePath: "data/digest_state.json",
+			SMTPPort:  587,
+			StartTLS:  true,
+		},
+		Webhook: WebhookConfig{
+			MaxRetries:     3,
+			BackoffSeconds: 2,
+			TimeoutSeconds: 10,
+			DeadLetterPath: "data/webhook_dead_letter.jsonl",
+		},
+		Media: MediaConfig{
+			Concurrency: 4,
+			MaxFileMB:   20,
+			MaxTotalMB:  2048,
+		},
 	}
 }
 
@@ -104,6 +174,15 @@ func loadFromEnv(cfg *Config) {
 	loader.setString("FEISHU_APP_SECRET", &cfg.Feishu.AppSecret)
 	loader.setString("FEISHU_APP_TOKEN", &cfg.Feishu.AppToken)
 	loader.setString("FEISHU_TABLE_TOKEN", &cfg.Feishu.TableToken)
+	loader.setString("FEISHU_VERIFICATION_TOKEN", &cfg.Feishu.VerificationToken)
+	loader.setString("FEISHU_ENCRYPT_KEY", &cfg.Feishu.EncryptKey)
+	loader.setString("FEISHU_DEDUP_CACHE_PATH", &cfg.Feishu.DedupCachePath)
+	loader.setInt("FEISHU_DEDUP_CACHE_DAYS", &cfg.Feishu.DedupCacheDays)
+	loader.setInt("FEISHU_DEDUP_BATCH_SIZE", &cfg.Feishu.DedupBatchSize)
+	loader.setInt("FEISHU_DEDUP_CONCURRENCY", &cfg.Feishu.DedupConcurrency)
+	loader.setBool("FEISHU_UPSERT", &cfg.Feishu.Upsert)
+	loader.setInt("FEISHU_BATCH_SIZE", &cfg.Feishu.BatchSize)
+	loader.setString("FEISHU_RESUME_PATH", &cfg.Feishu.ResumePath)
 
 	// Logging配置
 	loader.setString("LOGGING_LEVEL", &cfg.Logging.Level)
@@ -113,6 +192,97 @@ func loadFromEnv(cfg *Config) {
 	loader.setBool("ANTI_BOT_ENABLED", &cfg.AntiBot.Enabled)
 	loader.setInt("ANTI_BOT_DELAY_MIN_MS", &cfg.AntiBot.Delay.MinMs)
 	loader.setInt("ANTI_BOT_DELAY_MAX_MS", &cfg.AntiBot.Delay.MaxMs)
+
+	// Entity配置
+	loader.setBool("ENTITY_ENABLED", &cfg.Entity.Enabled)
+	loader.setString("ENTITY_DICT_PATH", &cfg.Entity.DictPath)
+
+	// History配置
+	loader.setBool("HISTORY_ENABLED", &cfg.History.Enabled)
+	loader.setString("HISTORY_DIR", &cfg.History.Dir)
+
+	// Storage配置
+	loader.setBool("STORAGE_ENABLED", &cfg.Storage.Enabled)
+	loader.setString("STORAGE_PATH", &cfg.Storage.Path)
+	loader.setBool("STORAGE_READ_SOURCE", &cfg.Storage.ReadSource)
+
+	// Postgres配置
+	loader.setString("POSTGRES_DSN", &cfg.Postgres.DSN)
+	loader.setInt("POSTGRES_MAX_CONNS", &cfg.Postgres.MaxConns)
+	loader.setInt("POSTGRES_BATCH_SIZE", &cfg.Postgres.BatchSize)
+	loader.setBool("POSTGRES_DETAILS", &cfg.Postgres.Details)
+
+	// Tracker配置
+	loader.setBool("TRACKER_ENABLED", &cfg.Tracker.Enabled)
+	loader.setString("TRACKER_STATE_PATH", &cfg.Tracker.StatePath)
+	loader.setInt("TRACKER_TICK_MINUTES", &cfg.Tracker.TickMinutes)
+	loader.setInt("TRACKER_MAX_AGE_DAYS", &cfg.Tracker.MaxAgeDays)
+
+	// Scoring配置
+	loader.setInt("SCORING_VELOCITY_HOURS", &cfg.Scoring.VelocityHours)
+
+	// Deals配置
+	loader.setFloat("DEALS_THRESHOLD", &cfg.Deals.Threshold)
+	loader.setInt("DEALS_MIN_PEERS", &cfg.Deals.MinPeers)
+	loader.setFloat("DEALS_TITLE_SIMILARITY", &cfg.Deals.TitleSimilarity)
+	loader.setInt("DEALS_REFERENCE_DAYS", &cfg.Deals.ReferenceDays)
+	loader.setBool("DEALS_FEISHU_COLUMN", &cfg.Deals.FeishuColumn)
+
+	// Risk配置
+	loader.setBool("RISK_ENABLED", &cfg.Risk.Enabled)
+	loader.setString("RISK_RULES_PATH", &cfg.Risk.RulesPath)
+
+	// Duplicates配置
+	loader.setBool("DUP_ENABLED", &cfg.Dup.Enabled)
+	loader.setString("DUP_IMAGE_DIR", &cfg.Dup.ImageDir)
+	loader.setInt("DUP_MAX_HASH_DISTANCE", &cfg.Dup.MaxHashDistance)
+	loader.setFloat("DUP_TITLE_SIMILARITY", &cfg.Dup.TitleSimilarity)
+	loader.setString("DUP_INDEX_PATH", &cfg.Dup.IndexPath)
+	loader.setInt("DUP_INDEX_DAYS", &cfg.Dup.IndexDays)
+
+	// Watch配置
+	loader.setBool("WATCH_ENABLED", &cfg.Watch.Enabled)
+	loader.setString("WATCH_LIST_PATH", &cfg.Watch.ListPath)
+	loader.setString("WATCH_STATE_PATH", &cfg.Watch.StatePath)
+	loader.setInt("WATCH_TICK_MINUTES", &cfg.Watch.TickMinutes)
+	loader.setInt("WATCH_INTERVAL_MINUTES", &cfg.Watch.IntervalMinutes)
+	loader.setInt("WATCH_PAGES", &cfg.Watch.Pages)
+
+	// Notify配置
+	loader.setInt("NOTIFY_DEDUP_HOURS", &cfg.Notify.DedupHours)
+	loader.setString("NOTIFY_DEDUP_PATH", &cfg.Notify.DedupPath)
+	loader.setInt("NOTIFY_SUMMARY_TOP", &cfg.Notify.SummaryTop)
+	loader.setString("NOTIFY_MUTE_PATH", &cfg.Notify.MutePath)
+
+	// Digest配置
+	loader.setBool("DIGEST_ENABLED", &cfg.Digest.Enabled)
+	loader.setString("DIGEST_PERIOD", &cfg.Digest.Period)
+	loader.setInt("DIGEST_HOUR", &cfg.Digest.Hour)
+	loader.setInt("DIGEST_WEEKDAY", &cfg.Digest.Weekday)
+	loader.setInt("DIGEST_TOP", &cfg.Digest.Top)
+	loader.setString("DIGEST_SUBJECT", &cfg.Digest.Subject)
+	loader.setString("DIGEST_TEMPLATE_PATH", &cfg.Digest.TemplatePath)
+	loader.setString("DIGEST_STATE_PATH", &cfg.Digest.StatePath)
+	loader.setString("DIGEST_SMTP_HOST", &cfg.Digest.SMTPHost)
+	loader.setInt("DIGEST_SMTP_PORT", &cfg.Digest.SMTPPort)
+	loader.setString("DIGEST_USERNAME", &cfg.Digest.Username)
+	loader.setString("DIGEST_PASSWORD", &cfg.Digest.Password)
+	loader.setString("DIGEST_FROM", &cfg.Digest.From)
+	loader.setStrings("DIGEST_TO", &cfg.Digest.To)
+	loader.setBool("DIGEST_STARTTLS", &cfg.Digest.StartTLS)
+	loader.setBool("DIGEST_SKIP_VERIFY", &cfg.Digest.SkipVerify)
+
+	// Webhook配置
+	loader.setInt("WEBHOOK_MAX_RETRIES", &cfg.Webhook.MaxRetries)
+	loader.setInt("WEBHOOK_BACKOFF_SECONDS", &cfg.Webhook.BackoffSeconds)
+	loader.setInt("WEBHOOK_TIMEOUT_SECONDS", &cfg.Webhook.TimeoutSeconds)
+	loader.setString("WEBHOOK_DEAD_LETTER_PATH", &cfg.Webhook.DeadLetterPath)
+
+	// Media配置
+	loader.setInt("MEDIA_CONCURRENCY", &cfg.Media.Concurrency)
+	loader.setInt("MEDIA_MAX_FILE_MB", &cfg.Media.MaxFileMB)
+	loader.setInt("MEDIA_MAX_TOTAL_MB", &cfg.Media.MaxTotalMB)
+	loader.setBool("MEDIA_VIDEOS", &cfg.Media.Videos)
 }
 
 // Validate 验证配置
@@ -131,5 +301,92 @@ func (c Config) Validate() error {
 		}
 	}
 
+	if c.Feishu.DedupCacheDays < 1 {
+		return fmt.Errorf("feishu.dedup_cache_days 必须大于等于 1")
+	}
+	if c.Feishu.DedupBatchSize < 1 || c.Feishu.DedupBatchSize > 50 {
+		return fmt.Errorf("feishu.dedup_batch_size 必须在 1-50 之间")
+	}
+	if c.Feishu.DedupConcurrency < 1 {
+		return fmt.Errorf("feishu.dedup_concurrency 必须大于等于 1")
+	}
+	if c.Feishu.BatchSize < 1 || c.Feishu.BatchSize > 500 {
+		return fmt.Errorf("feishu.batch_size 必须在 1-500 之间")
+	}
+
+	if c.Entity.Enabled && c.Entity.DictPath == "" {
+		return fmt.Errorf("实体识别已启用，但缺少词典路径（entity.dict_path）")
+	}
+
+	if c.Watch.Enabled && c.Watch.ListPath == "" {
+		return fmt.Errorf("启用关注列表时必须配置 watch.list_path")
+	}
+
+	for i, ch := range c.Notify.Channels {
+		switch ch.Type {
+		case "dingtalk", "wecom", "slack":
+			if ch.Webhook == "" {
+				return fmt.Errorf("通知通道 %d 缺少 webhook 地址", i+1)
+			}
+		case "feishu":
+			if ch.Webhook == "" && ch.ChatID == "" {
+				return fmt.Errorf("飞书通知通道 %d 需配置 webhook 或 chat_id", i+1)
+			}
+			if ch.Webhook == "" && (c.Feishu.AppID == "" || c.Feishu.AppSecret == "") {
+				return fmt.Errorf("飞书通知通道 %d 通过 chat_id 发送时需配置 feishu.app_id 与 feishu.app_secret", i+1)
+			}
+		default:
+			return fmt.Errorf("通知通道 %d 类型无效: %q（支持 dingtalk, wecom, slack, feishu）", i+1, ch.Type)
+		}
+	}
+
+	for i, t := range c.Webhook.Targets {
+		if !strings.HasPrefix(t.URL, "http://") && !strings.HasPrefix(t.URL, "https://") {
+			return fmt.Errorf("webhook %d 地址无效: %q", i+1, t.URL)
+		}
+		if t.Shape != "" && t.Shape != "product" && t.Shape != "raw" {
+			return fmt.Errorf("webhook %d 数据格式无效: %q（支持 product, raw）", i+1, t.Shape)
+		}
+	}
+
+	for i, sc := range c.Sinks {
+		if sc.Type == "" {
+			return fmt.Errorf("输出目标 %d 缺少类型（sinks[].type）", i+1)
+		}
+	}
+
+	if c.Digest.Enabled {
+		if c.Digest.Period != "daily" && c.Digest.Period != "weekly" {
+			return fmt.Errorf("无效的摘要周期: %s（支持 daily, weekly）", c.Digest.Period)
+		}
+		if c.Digest.Hour < 0 || c.Digest.Hour > 23 || c.Digest.Weekday < 0 || c.Digest.Weekday > 6 {
+			return fmt.Errorf("无效的摘要发送时间: hour=%d weekday=%d", c.Digest.Hour, c.Digest.Weekday)
+		}
+		if c.Digest.SMTPHost == "" || c.Digest.From == "" || len(c.Digest.To) == 0 {
+			return fmt.Errorf("邮件摘要已启用，但缺少 SMTP 配置（digest.smtp_host、from 或 to）")
+		}
+		if !c.History.Enabled {
+			return fmt.Errorf("邮件摘要需要启用历史快照（history.enabled）")
+		}
+	}
+
+	if c.Storage.Enabled && c.Storage.Path == "" {
+		return fmt.Errorf("SQLite 存储已启用，但缺少数据库路径（storage.path）")
+	}
+	if c.Storage.ReadSource && !c.Storage.Enabled {
+		return fmt.Errorf("storage.read_source 需要启用 SQLite 存储（storage.enabled）")
+	}
+
+	if c.Postgres.MaxConns < 1 {
+		return fmt.Errorf("无效的 postgres.max_conns: %d（至少为 1）", c.Postgres.MaxConns)
+	}
+	if c.Postgres.BatchSize < 1 || c.Postgres.BatchSize > 5000 {
+		return fmt.Errorf("无效的 postgres.batch_size: %d（范围 1-5000）", c.Postgres.BatchSize)
+	}
+
+	if c.Risk.Enabled && c.Risk.RulesPath == "" {
+		return fmt.Errorf("卖家风险评估已启用，但缺少规则路径（risk.rules_path）")
+	}
+
 	return nil
 }
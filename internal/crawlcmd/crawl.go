@@ -1,12 +1,19 @@
 package crawlcmd
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"xianyu_aner/internal/config"
 	"xianyu_aner/internal/service"
+	"xianyu_aner/pkg/deals"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/sink"
+	"xianyu_aner/pkg/util"
 )
 
 // CrawlCommand 爬取命令
@@ -23,7 +30,7 @@ func NewCrawlCommand(flags *Flags) *CrawlCommand {
 func (c *CrawlCommand) Run() error {
 	// 显示版本
 	if c.flags.ShowVersion {
-		fmt.Println("xianyu_aner crawl v1.0.0")
+		util.Println("xianyu_aner crawl v1.0.0")
 		return nil
 	}
 
@@ -31,15 +38,44 @@ func (c *CrawlCommand) Run() error {
 	cfg := config.Load()
 	cfg.Browser.Headless = c.flags.Headless
 
+	// 确定输出目标（参数有误时在爬取前报错）
+	specs, err := c.sinkSpecs(cfg)
+	if err != nil {
+		return err
+	}
+
+	// 数据写入标准输出时，横幅与进度信息改写到标准错误，便于接 jq 等管道（os.Stdout 保持不变，只承载数据）
+	if usesStdout(specs) {
+		util.Progress = os.Stderr
+	}
+
 	// 打印启动信息
 	printBanner()
 
+	// 打开历史快照存储（失败不影响爬取）
+	historyStore, err := service.OpenHistory(cfg.History)
+	if err != nil {
+		log.Printf("打开历史快照存储失败，本次不记录: %v", err)
+	}
+	if historyStore != nil {
+		defer historyStore.Close()
+	}
+
 	// 创建服务
-	fetcher := service.NewFetcher(cfg)
-	pusher := service.NewPusher(cfg)
+	fetcher := service.NewFetcher(cfg).WithHistory(historyStore)
+	pusher := service.NewPusher(cfg).WithHistory(historyStore)
+
+	// 与飞书今日表格对账（失败时继续使用现有索引）
+	if c.flags.Reconcile {
+		if n, err := pusher.ReconcileDedup(time.Now()); err != nil {
+			log.Printf("去重索引对账失败，继续使用现有索引: %v", err)
+		} else {
+			util.Printf("去重索引对账完成：今日表格 %d 条记录\n", n)
+		}
+	}
 
 	// 执行爬取流程
-	result, err := c.executeCrawl(cfg, fetcher, pusher)
+	result, err := c.executeCrawl(cfg, fetcher, pusher, historyStore, specs)
 	if err != nil {
 		return err
 	}
@@ -49,57 +85,191 @@ func (c *CrawlCommand) Run() error {
 	return nil
 }
 
-func (c *CrawlCommand) executeCrawl(cfg config.Config, fetcher *service.Fetcher, pusher *service.Pusher) (*service.Result, error) {
+func (c *CrawlCommand) executeCrawl(cfg config.Config, fetcher *service.Fetcher, pusher *service.Pusher, historyStore *history.Store, specs []sink.Spec) (*service.Result, error) {
 	startTime := time.Now()
 
 	// 步骤1: 获取 Cookie
-	fmt.Printf("\n[步骤 1/4] 获取登录 Cookie (无头模式: %v)...\n", cfg.Browser.Headless)
+	util.Printf("\n[步骤 1/3] 获取登录 Cookie (无头模式: %v)...\n", cfg.Browser.Headless)
 	mtopClient, err := fetcher.InitClient()
 	if err != nil {
 		return nil, fmt.Errorf("初始化客户端失败: %w", err)
 	}
 
+	// 打开输出目标；流式目标（jsonl、csv、tsv）在每页解析后立即写入，爬取中途失败时已写入的数据保留
+	// 爬取失败时放弃其余目标，不以空结果覆盖已有文件（成功时下方已 Close，Abort 无操作）
+	ctx := context.Background()
+	fanout := service.OpenSinks(ctx, service.NewSinkRegistry(cfg, pusher, mtopClient), specs)
+	defer fanout.Abort()
+
 	// 步骤2: 爬取数据
-	fmt.Printf("\n[步骤 2/4] 爬取猜你喜欢数据 (页数: %d)...\n", c.flags.Pages)
-	items, err := fetcher.Fetch(mtopClient, c.flags.Pages, c.flags.MinWant, c.flags.Days)
+	util.Printf("\n[步骤 2/3] 爬取猜你喜欢数据 (页数: %d)...\n", c.flags.Pages)
+	items, err := fetcher.FetchPages(mtopClient, c.flags.Pages, c.flags.MinWant, c.flags.Days, func(page int, pageItems []mtop.FeedItem) {
+		util.Printf("  第 %d 页: %d 条\n", page, len(pageItems))
+		fanout.Stream(ctx, pageItems)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("爬取失败: %w", err)
 	}
-	fmt.Printf("爬取完成！获取到 %d 条数据\n", len(items))
+	util.Printf("爬取完成！获取到 %d 条数据\n", len(items))
 
-	// 步骤3: 保存到文件
-	fmt.Printf("\n[步骤 3/4] 保存数据到文件: %s\n", c.flags.Output)
-	if err := service.SaveToFile(items, c.flags.Output); err != nil {
-		log.Printf("保存文件失败: %v", err)
+	var found []deals.Deal
+	if c.flags.DetectDeals {
+		found = service.DetectFeedDeals(cfg.Deals, historyStore, pusher.Extractor(), items)
+		printDeals(found)
+		pusher.WithDeals(found)
+		notifyDeals(cfg, found, items)
 	}
 
-	// 步骤4: 推送到飞书（可选）
-	if c.flags.PushFeishu {
-		fmt.Printf("\n[步骤 4/4] 推送到飞书多维表格...\n")
-		if err := pusher.Push(mtopClient, items); err != nil {
-			log.Printf("推送失败: %v", err)
+	var mediaCount int
+	if c.flags.MediaDir != "" {
+		util.Printf("\n[媒体归档] 归档商品图片到: %s\n", c.flags.MediaDir)
+		archive, err := service.NewMediaArchive(cfg.Media, c.flags.MediaDir, mtopClient)
+		if err != nil {
+			log.Printf("初始化媒体归档失败，已跳过: %v", err)
+		} else {
+			mediaCount = archive.ArchiveFeedItems(items).Downloaded
+			pusher.WithMedia(archive)
 		}
-	} else {
-		fmt.Println("\n[步骤 4/4] 跳过飞书推送")
 	}
 
-	return &service.Result{
+	// 步骤3: 写入其余输出目标（各目标相互隔离，某个目标失败不影响其他目标）
+	util.Printf("\n[步骤 3/3] 写入 %d 个输出目标...\n", len(specs))
+	fanout.Write(ctx, items)
+	fanout.Flush(ctx)
+	fanout.Close()
+
+	result := &service.Result{
 		TotalItems: len(items),
+		DealCount:  len(found),
+		MediaCount: mediaCount,
 		Duration:   time.Since(startTime),
-	}, nil
+		Sinks:      fanout.Stats(),
+	}
+
+	// 发送爬取汇总卡片到飞书群（配置了 summary 的飞书通道）
+	service.SendCrawlSummary(cfg, result, items)
+	return result, nil
+}
+
+// sinkSpecs 确定输出目标: --sink 参数 > 配置文件 sinks > 默认
+// 默认按 --format 格式输出到 --output 指定的文件，配置了 webhook.targets 时同时推送 Webhook
+// --push-feishu 时始终包含飞书推送
+func (c *CrawlCommand) sinkSpecs(cfg config.Config) ([]sink.Spec, error) {
+	format := c.flags.Format
+	if format == "" {
+		format = "json"
+	}
+	if !fileFormats[format] {
+		return nil, fmt.Errorf("不支持的输出格式 %q（支持: json, jsonl, csv, tsv, xlsx, parquet）", format)
+	}
+
+	var specs []sink.Spec
+	switch {
+	case len(c.flags.Sinks) > 0:
+		for _, arg := range c.flags.Sinks {
+			spec, err := sink.ParseSpec(arg)
+			if err != nil {
+				return nil, err
+			}
+			specs = append(specs, spec)
+		}
+	case len(cfg.Sinks) > 0:
+		specs = service.SinkSpecs(cfg.Sinks)
+	default:
+		specs = append(specs, sink.Spec{Type: format, Path: c.flags.Output})
+		if len(cfg.Webhook.Targets) > 0 {
+			specs = append(specs, sink.Spec{Type: "webhook"})
+		}
+	}
+
+	hasFeishu, stdout := false, 0
+	for i := range specs {
+		// 与 --format 同类型的文件输出未指定路径时使用 --output
+		if specs[i].Type == format && specs[i].Path == "" {
+			specs[i].Path = c.flags.Output
+		}
+		hasFeishu = hasFeishu || specs[i].Type == "feishu"
+		if specs[i].Path == sink.StdoutPath {
+			stdout++
+		}
+	}
+	if stdout > 1 {
+		return nil, fmt.Errorf("只能有一个输出目标写入标准输出（-）")
+	}
+	if c.flags.PushFeishu && !hasFeishu {
+		specs = append(specs, sink.Spec{Type: "feishu"})
+	}
+	return specs, nil
+}
+
+// usesStdout 是否有输出目标写入标准输出
+func usesStdout(specs []sink.Spec) bool {
+	for _, spec := range specs {
+		if spec.Path == sink.StdoutPath {
+			return true
+		}
+	}
+	return false
+}
+
+// fileFormats --format 支持的文件格式（即默认输出目标的类型）
+var fileFormats = map[string]bool{"json": true, "jsonl": true, "csv": true, "tsv": true, "xlsx": true, "parquet": true}
+
+func printDeals(found []deals.Deal) {
+	util.Printf("\n[低价检测] 发现 %d 个低于同类市场价的商品\n", len(found))
+	for i, d := range found {
+		util.Printf("  %d. %s (ID: %s)\n     %s\n", i+1, util.TruncateString(d.Title, 30), d.ItemID, d.Basis)
+	}
+}
+
+// notifyDeals 将低价商品推送到配置的通知通道（未配置时跳过）
+func notifyDeals(cfg config.Config, found []deals.Deal, items []mtop.FeedItem) {
+	router, err := service.NewNotifyRouter(cfg.Notify, cfg.Feishu)
+	if err != nil {
+		log.Printf("通知通道初始化失败，已跳过低价提醒: %v", err)
+		return
+	}
+	evaluator, err := service.NewRiskEvaluator(cfg.Risk)
+	if err != nil {
+		log.Printf("卖家风险评估初始化失败，低价提醒不附带风险等级: %v", err)
+	}
+	service.NotifyDeals(router, evaluator, found, items)
 }
 
 func printBanner() {
-	fmt.Println("========================================")
-	fmt.Println("  闲鱼数据爬取工具")
-	fmt.Println("========================================")
+	util.Println("========================================")
+	util.Println("  闲鱼数据爬取工具")
+	util.Println("========================================")
 }
 
 func printSummary(result *service.Result) {
-	fmt.Println("\n========================================")
-	fmt.Println("  任务完成统计")
-	fmt.Println("========================================")
-	fmt.Printf("爬取商品数: %d\n", result.TotalItems)
-	fmt.Printf("总耗时: %.2f 秒\n", result.Duration.Seconds())
-	fmt.Println("========================================")
+	util.Println("\n========================================")
+	util.Println("  任务完成统计")
+	util.Println("========================================")
+	util.Printf("爬取商品数: %d\n", result.TotalItems)
+	if result.DealCount > 0 {
+		util.Printf("低价商品数: %d\n", result.DealCount)
+	}
+	if result.MediaCount > 0 {
+		util.Printf("归档媒体数: %d\n", result.MediaCount)
+	}
+	if len(result.Sinks) > 0 {
+		util.Println("输出目标:")
+		for _, s := range result.Sinks {
+			mark := "✅"
+			if !s.OK() {
+				mark = "❌"
+			}
+			util.Printf("  %s %s: 写入 %d 条", mark, s.Name, s.Items)
+			if s.Failed > 0 {
+				util.Printf("，失败 %d 条", s.Failed)
+			}
+			util.Printf("，耗时 %.2f 秒\n", s.Duration.Seconds())
+			if s.Err != "" {
+				util.Printf("     错误: %s\n", s.Err)
+			}
+		}
+	}
+	util.Printf("总耗时: %.2f 秒\n", result.Duration.Seconds())
+	util.Println("========================================")
 }
@@ -1,6 +1,9 @@
 package crawlcmd
 
-import "flag"
+import (
+	"flag"
+	"strings"
+)
 
 // Flags 命令行参数
 type Flags struct {
@@ -9,7 +12,12 @@ type Flags struct {
 	MinWant     int
 	Days        int
 	Output      string
+	Format      string // --format 默认输出文件格式: json、jsonl、csv、tsv、xlsx、parquet
 	PushFeishu  bool
+	Reconcile   bool // --reconcile-dedup 推送前与飞书今日表格对账，重建本地去重索引
+	DetectDeals bool
+	MediaDir    string
+	Sinks       []string // --sink 输出目标，可重复指定
 	Headless    bool
 	ShowVersion bool
 }
@@ -21,21 +29,52 @@ func ParseFlags() *Flags {
 		pages       = flag.Int("pages", 10, "爬取页数")
 		minWant     = flag.Int("min-want", 1, "最低想要人数")
 		days        = flag.Int("days", 14, "发布时间范围（天数）")
-		output      = flag.String("output", "feed_result.json", "输出文件路径")
+		output      = flag.String("output", "feed_result.json", "输出文件路径，- 表示标准输出（横幅与进度改写到标准错误）")
+		format      = flag.String("format", "json", "输出文件格式: json、jsonl、csv、tsv、xlsx、parquet（未指定 --output 时扩展名随格式变化）")
 		pushFeishu  = flag.Bool("push-feishu", false, "是否推送到飞书")
+		reconcile   = flag.Bool("reconcile-dedup", false, "推送前全量读取飞书今日表格，重建本地去重索引（表格中手动删除过记录时使用）")
+		detectDeals = flag.Bool("detect-deals", false, "是否检测低于同类市场价的商品")
+		mediaDir    = flag.String("archive-media", "", "归档商品图片/视频的目录（为空时不归档）")
 		headless    = flag.Bool("headless", true, "是否使用无头浏览器")
 		showVersion = flag.Bool("version", false, "显示版本信息")
+		sinks       stringList
 	)
+	flag.Var(&sinks, "sink", "输出目标 type[:path]，可重复指定，如 --sink json:out.json --sink jsonl:out.jsonl --sink feishu --sink webhook")
 	flag.Parse()
 
+	// 未显式指定 --output 时，默认文件名的扩展名与 --format 保持一致
+	*format = strings.ToLower(strings.TrimSpace(*format))
+	outputSet := false
+	flag.Visit(func(f *flag.Flag) { outputSet = outputSet || f.Name == "output" })
+	if !outputSet && *format != "" && *format != "json" {
+		*output = "feed_result." + *format
+	}
+
 	return &Flags{
 		ConfigPath:  *configPath,
 		Pages:       *pages,
 		MinWant:     *minWant,
 		Days:        *days,
 		Output:      *output,
+		Format:      *format,
 		PushFeishu:  *pushFeishu,
+		Reconcile:   *reconcile,
+		DetectDeals: *detectDeals,
+		MediaDir:    *mediaDir,
+		Sinks:       sinks,
 		Headless:    *headless,
 		ShowVersion: *showVersion,
 	}
 }
+
+// stringList 可重复指定的字符串参数
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
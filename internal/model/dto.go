@@ -2,6 +2,8 @@ package model
 
 import (
 	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/scoring"
 )
 
 // FeedRequest 猜你喜欢请求参数
@@ -10,6 +12,8 @@ type FeedRequest struct {
 	MachID      string `form:"machId"`
 	MinWantCount int `form:"minWantCount" binding:"omitempty,min=0"` // 最低想要人数
 	DaysWithin   int `form:"daysWithin" binding:"omitempty,min=0"`    // 发布时间范围（天）
+	Sort         string `form:"sort" binding:"omitempty,oneof=score"` // 排序方式：score 按热度得分降序
+	Explain      bool   `form:"explain"`                              // 是否返回各因子得分明细
 }
 
 // FeedResponse 猜你喜欢响应
@@ -27,6 +31,13 @@ type FeedData struct {
 	Items  interface{} `json:"items"`
 }
 
+// ScoredFeedItem 带热度得分的商品
+type ScoredFeedItem struct {
+	mtop.FeedItem
+	Score        float64                `json:"score"`                  // 热度得分（0-100）
+	ScoreFactors []scoring.Contribution `json:"scoreFactors,omitempty"` // 各因子得分明细
+}
+
 // HealthResponse 健康检查响应
 type HealthResponse struct {
 	Status string `json:"status"`
@@ -56,7 +67,99 @@ type FeishuPushResponse struct {
 
 // FeishuPushData 飞书推送数据
 type FeishuPushData struct {
-	RecordsCreated int    `json:"recordsCreated"`
-	RecordsUpdated int    `json:"recordsUpdated"`
-	TableToken     string `json:"tableToken"`
+	RecordsCreated int                  `json:"recordsCreated"`
+	RecordsUpdated int                  `json:"recordsUpdated"`
+	RecordsFailed  int                  `json:"recordsFailed,omitempty"` // 推送失败的记录数
+	TableToken     string               `json:"tableToken"`
+	Chunks         []feishu.ChunkResult `json:"chunks,omitempty"` // 分批推送的各批结果（含失败批次的商品ID）
+}
+
+// HistoryResponse 商品历史观测响应
+type HistoryResponse struct {
+	Success bool        `json:"success"`
+	Data    HistoryData `json:"data"`
+}
+
+// HistoryData 商品历史观测数据
+type HistoryData struct {
+	ItemID       string      `json:"itemId"`
+	Total        int         `json:"total"`
+	Observations interface{} `json:"observations"`
+}
+
+// HistoryListResponse 历史统计列表响应（降价、想要增长等）
+type HistoryListResponse struct {
+	Success bool            `json:"success"`
+	Data    HistoryListData `json:"data"`
+}
+
+// HistoryListData 历史统计列表数据
+type HistoryListData struct {
+	Hours int         `json:"hours"`
+	Total int         `json:"total"`
+	Items interface{} `json:"items"`
+}
+
+// TrackRequest 添加生命周期跟踪请求
+type TrackRequest struct {
+	ItemIDs []string `json:"itemIds" binding:"required,min=1"`
+}
+
+// TrackerResponse 生命周期跟踪响应
+type TrackerResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+}
+
+// WatchEntryRequest 添加/修改关注项请求
+type WatchEntryRequest struct {
+	ID              string `json:"id"`
+	Kind            string `json:"kind" binding:"required"`   // item / keyword / seller
+	Target          string `json:"target" binding:"required"` // 商品ID / 关键词 / 卖家ID
+	Name            string `json:"name"`
+	Filter          string `json:"filter"`
+	IntervalMinutes int    `json:"intervalMinutes"`
+	Disabled        bool   `json:"disabled"`
+}
+
+// WatchResponse 关注列表响应
+type WatchResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+}
+
+// DigestResponse 邮件摘要响应
+type DigestResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+}
+
+// ExportRequest 导出请求参数（抓取猜你喜欢并以文件形式下载）
+type ExportRequest struct {
+	Format       string `form:"format" binding:"omitempty,oneof=csv tsv xlsx"` // 文件格式，默认 csv
+	Pages        int    `form:"pages" binding:"omitempty,min=1,max=10"`
+	MachID       string `form:"machId"`
+	MinWantCount int    `form:"minWantCount" binding:"omitempty,min=0"` // 最低想要人数
+	DaysWithin   int    `form:"daysWithin" binding:"omitempty,min=0"`   // 发布时间范围（天）
+	HotWant      int    `form:"hotWant" binding:"omitempty,min=1"`      // xlsx 热门高亮的想要人数阈值
+}
+
+// StorageResponse SQLite 存储查询响应
+type StorageResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+}
+
+// ItemListData 商品列表数据
+type ItemListData struct {
+	Total  int         `json:"total"` // 满足条件的总数
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+	Items  interface{} `json:"items"`
+}
+
+// ItemData 单个商品及其观测
+type ItemData struct {
+	Item         interface{} `json:"item"`
+	Observations interface{} `json:"observations"`
 }
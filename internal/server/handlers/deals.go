@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"xianyu_aner/internal/config"
+	"xianyu_aner/internal/model"
+	"xianyu_aner/internal/service"
+	"xianyu_aner/pkg/entity"
+	"xianyu_aner/pkg/history"
+)
+
+// DealsHandler 低价商品检测处理器
+type DealsHandler struct {
+	cfg       config.DealsConfig
+	store     *history.Store
+	extractor *entity.Extractor
+}
+
+// NewDealsHandler 创建低价商品检测处理器
+func NewDealsHandler(cfg config.DealsConfig, store *history.Store, extractor *entity.Extractor) *DealsHandler {
+	return &DealsHandler{cfg: cfg, store: store, extractor: extractor}
+}
+
+// HandleDeals 检测最近观测到的低价商品（以历史观测为参考）
+func (h *DealsHandler) HandleDeals(c *gin.Context) {
+	if h.store == nil {
+		c.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Success: false,
+			Error:   "低价检测依赖历史快照存储，请设置 history.enabled",
+		})
+		return
+	}
+
+	cfg := h.cfg
+	if pct, err := strconv.ParseFloat(c.Query("threshold"), 64); err == nil && pct > 0 && pct < 100 {
+		cfg.Threshold = pct / 100
+	}
+	cfg.MinPeers = queryInt(c, "minPeers", cfg.MinPeers)
+	hours := queryInt(c, "hours", 24)
+
+	now := time.Now()
+	found := service.DetectHistoryDeals(cfg, h.store, h.extractor,
+		now.Add(-cfg.GetReferenceWindow()), now.Add(-time.Duration(hours)*time.Hour))
+	c.JSON(http.StatusOK, model.HistoryListResponse{
+		Success: true,
+		Data: model.HistoryListData{
+			Hours: hours,
+			Total: len(found),
+			Items: found,
+		},
+	})
+}
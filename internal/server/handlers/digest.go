@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"xianyu_aner/internal/model"
+	"xianyu_aner/internal/service"
+)
+
+// DigestHandler 邮件摘要处理器
+type DigestHandler struct {
+	digester *service.Digester
+}
+
+// NewDigestHandler 创建邮件摘要处理器
+func NewDigestHandler(digester *service.Digester) *DigestHandler {
+	return &DigestHandler{digester: digester}
+}
+
+// HandlePreview 预览本期摘要：默认返回 HTML，format=json 时返回摘要数据
+func (h *DigestHandler) HandlePreview(c *gin.Context) {
+	if !h.checkDigester(c) {
+		return
+	}
+	if c.Query("format") == "json" {
+		c.JSON(http.StatusOK, model.DigestResponse{Success: true, Data: h.digester.Build()})
+		return
+	}
+	html, err := h.digester.Preview()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{Success: false, Error: err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+}
+
+// HandleSend 立即发送本期摘要
+func (h *DigestHandler) HandleSend(c *gin.Context) {
+	if !h.checkDigester(c) {
+		return
+	}
+	report, err := h.digester.Send(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, model.ErrorResponse{Success: false, Error: "发送邮件摘要失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, model.DigestResponse{Success: true, Data: report})
+}
+
+// checkDigester 检查邮件摘要是否可用
+func (h *DigestHandler) checkDigester(c *gin.Context) bool {
+	if h.digester == nil {
+		c.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Success: false,
+			Error:   "邮件摘要未启用，请设置 digest.enabled",
+		})
+		return false
+	}
+	return true
+}
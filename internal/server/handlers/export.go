@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"xianyu_aner/internal/model"
+	"xianyu_aner/internal/service"
+	"xianyu_aner/pkg/export"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/storage"
+)
+
+// exportBatch 每写出多少行刷新一次响应
+const exportBatch = 200
+
+// ExportHandler 导出处理器
+type ExportHandler struct {
+	mtopClient   *mtop.Client
+	historyStore *history.Store
+	converter    *service.Converter
+	db           *storage.DB // SQLite 存储（可为 nil）
+}
+
+// NewExportHandler 创建导出处理器
+func NewExportHandler(mtopClient *mtop.Client, historyStore *history.Store) *ExportHandler {
+	return &ExportHandler{
+		mtopClient:   mtopClient,
+		historyStore: historyStore,
+		converter:    service.NewConverter(),
+	}
+}
+
+// WithStorage 抓取的商品同时写入 SQLite 存储
+func (h *ExportHandler) WithStorage(db *storage.DB) *ExportHandler {
+	h.db = db
+	return h
+}
+
+// HandleExport 抓取猜你喜欢并以 CSV/TSV/xlsx 文件下载（列顺序同飞书表格字段）
+func (h *ExportHandler) HandleExport(c *gin.Context) {
+	var req model.ExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "参数错误: format 必须是 csv、tsv 或 xlsx，pages 必须是 1-10 之间的整数",
+		})
+		return
+	}
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+	if req.Pages == 0 {
+		req.Pages = 1
+	}
+	if req.DaysWithin == 0 {
+		req.DaysWithin = 7
+	}
+
+	log.Printf("收到导出请求: format=%s, pages=%d, machId=%s", req.Format, req.Pages, req.MachID)
+	items, err := h.mtopClient.GuessYouLike(req.MachID, req.Pages, mtop.GuessYouLikeOptions{
+		MinWantCount: req.MinWantCount,
+		DaysWithin:   req.DaysWithin,
+	})
+	if err != nil {
+		log.Printf("获取数据失败: %v", err)
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("获取数据失败: %v", err),
+		})
+		return
+	}
+	service.RecordFeedItems(h.historyStore, items)
+	service.StoreFeedItems(h.db, "server", items)
+
+	write := h.writeCSV
+	if req.Format == "xlsx" {
+		write = h.writeXLSX
+	}
+	if err := write(c, req, items); err != nil {
+		log.Printf("导出失败: %v", err)
+		return
+	}
+	log.Printf("导出完成: %d 条商品", len(items))
+}
+
+// attachment 设置下载文件名
+func attachment(c *gin.Context, format string) {
+	filename := fmt.Sprintf("xianyu_%s.%s", time.Now().Format("20060102_150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+}
+
+// writeCSV 分批转换写出 CSV/TSV，避免大量商品一次性占用内存
+// 响应头发出后出错只能中断输出，错误仅记录日志
+func (h *ExportHandler) writeCSV(c *gin.Context, req model.ExportRequest, items []mtop.FeedItem) error {
+	opts := export.CSVOptions{}
+	contentType := "text/csv; charset=utf-8"
+	if req.Format == "tsv" {
+		opts.Comma = '\t'
+		contentType = "text/tab-separated-values; charset=utf-8"
+	}
+	attachment(c, req.Format)
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+
+	w := export.NewCSVWriter(c.Writer, opts)
+	if err := w.WriteHeader(); err != nil {
+		return err
+	}
+	for start := 0; start < len(items); start += exportBatch {
+		end := min(start+exportBatch, len(items))
+		if err := w.Write(h.converter.FeedItemsToBasicProducts(items[start:end])); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+	}
+	return nil
+}
+
+// writeXLSX 生成 Excel 报表（生成完成后再写出，失败时仍可返回错误响应）
+func (h *ExportHandler) writeXLSX(c *gin.Context, req model.ExportRequest, items []mtop.FeedItem) error {
+	w := export.NewXLSXWriter(export.XLSXOptions{HotWantCount: req.HotWant})
+	w.Write(h.converter.FeedItemsToBasicProducts(items))
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("生成 xlsx 失败: %v", err),
+		})
+		return err
+	}
+	attachment(c, req.Format)
+	c.Data(http.StatusOK, export.XLSXContentType, buf.Bytes())
+	return nil
+}
@@ -4,20 +4,44 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"xianyu_aner/internal/model"
+	"xianyu_aner/internal/service"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/lifecycle"
 	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/scoring"
+	"xianyu_aner/pkg/storage"
 )
 
 // FeedHandler Feed处理器
 type FeedHandler struct {
-	mtopClient *mtop.Client
+	mtopClient   *mtop.Client
+	historyStore *history.Store
+	tracker      *lifecycle.Tracker
+	scorer       *scoring.Engine
+	velocity     time.Duration // 想要增长速度的统计窗口
+	db           *storage.DB   // SQLite 存储（可为 nil）
 }
 
 // NewFeedHandler 创建Feed处理器
-func NewFeedHandler(mtopClient *mtop.Client) *FeedHandler {
-	return &FeedHandler{mtopClient: mtopClient}
+func NewFeedHandler(mtopClient *mtop.Client, historyStore *history.Store, tracker *lifecycle.Tracker, scorer *scoring.Engine, velocity time.Duration) *FeedHandler {
+	return &FeedHandler{
+		mtopClient:   mtopClient,
+		historyStore: historyStore,
+		tracker:      tracker,
+		scorer:       scorer,
+		velocity:     velocity,
+	}
+}
+
+// WithStorage 抓取的商品同时写入 SQLite 存储
+func (h *FeedHandler) WithStorage(db *storage.DB) *FeedHandler {
+	h.db = db
+	return h
 }
 
 // HandleFeed 处理猜你喜欢请求
@@ -41,17 +65,40 @@ func (h *FeedHandler) HandleFeed(c *gin.Context) {
 	}
 
 	h.logSuccess(items)
+	service.RecordFeedItems(h.historyStore, items)
+	service.StoreFeedItems(h.db, "server", items)
+	service.TrackFeedItems(h.tracker, items)
 	c.JSON(http.StatusOK, model.FeedResponse{
 		Success: true,
 		Data: model.FeedData{
 			Total:  len(items),
 			Pages:  req.Pages,
 			MachID: req.MachID,
-			Items:  items,
+			Items:  h.scoreItems(req, items),
 		},
 	})
 }
 
+// scoreItems 计算热度得分，按需排序（未配置评分引擎时原样返回）
+func (h *FeedHandler) scoreItems(req model.FeedRequest, items []mtop.FeedItem) interface{} {
+	if h.scorer == nil {
+		return items
+	}
+
+	results := service.ScoreFeedItems(h.scorer, h.historyStore, h.velocity, items)
+	scored := make([]model.ScoredFeedItem, len(items))
+	for i, item := range items {
+		scored[i] = model.ScoredFeedItem{FeedItem: item, Score: results[i].Score}
+		if req.Explain {
+			scored[i].ScoreFactors = results[i].Contributions
+		}
+	}
+	if req.Sort == "score" {
+		sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	}
+	return scored
+}
+
 func (h *FeedHandler) applyDefaults(req model.FeedRequest) model.FeedRequest {
 	if req.Pages == 0 {
 		req.Pages = 1
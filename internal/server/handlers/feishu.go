@@ -59,6 +59,21 @@ func (h *FeishuHandler) HandleFeishuPush(c *gin.Context) {
 
 	// 调用飞书客户端推送数据
 	result, err := h.feishuClient.PushToBitable(appToken, tableToken, req.Products)
+	if err != nil && result != nil {
+		// 部分批次失败：返回各批结果，调用方可按失败的商品ID重试
+		log.Printf("部分推送失败: %v", err)
+		c.JSON(http.StatusBadGateway, model.FeishuPushResponse{
+			Success: false,
+			Message: fmt.Sprintf("推送失败: %v", err),
+			Data: model.FeishuPushData{
+				RecordsCreated: result.Data.RecordsCreated,
+				RecordsFailed:  result.Data.RecordsFailed,
+				TableToken:     result.Data.TableToken,
+				Chunks:         result.Data.Chunks,
+			},
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("推送失败: %v", err)
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -78,6 +93,7 @@ func (h *FeishuHandler) HandleFeishuPush(c *gin.Context) {
 			RecordsCreated: result.Data.RecordsCreated,
 			RecordsUpdated: result.Data.RecordsUpdated,
 			TableToken:     result.Data.TableToken,
+			Chunks:         result.Data.Chunks,
 		},
 	})
 }
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"xianyu_aner/internal/config"
+	"xianyu_aner/internal/model"
+	"xianyu_aner/internal/service"
+	"xianyu_aner/pkg/feishu"
+)
+
+// maxCallbackBody 回调请求体大小上限
+const maxCallbackBody = 1 << 20
+
+// FeishuCallbackHandler 飞书卡片回调处理器
+type FeishuCallbackHandler struct {
+	config  config.FeishuConfig
+	actions *service.CardActions
+}
+
+// NewFeishuCallbackHandler 创建飞书卡片回调处理器
+func NewFeishuCallbackHandler(cfg config.FeishuConfig, actions *service.CardActions) *FeishuCallbackHandler {
+	return &FeishuCallbackHandler{config: cfg, actions: actions}
+}
+
+// HandleCallback 处理飞书回调：URL 校验与卡片按钮操作
+// URL 校验只校验 Verification Token（配置时）后返回 challenge；
+// 卡片操作在配置了 Encrypt Key（或旧版回调配置了 Verification Token）时必须带有效签名与时间戳，并校验 token。
+// 两者都未配置时拒绝卡片操作（操作会修改关注列表、屏蔽列表与多维表格）
+func (h *FeishuCallbackHandler) HandleCallback(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCallbackBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{Success: false, Error: "读取请求失败: " + err.Error()})
+		return
+	}
+
+	cb, err := feishu.ParseCallback(body, h.config.EncryptKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{Success: false, Error: "无效的回调请求: " + err.Error()})
+		return
+	}
+	if h.config.VerificationToken != "" && cb.Token != h.config.VerificationToken {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{Success: false, Error: feishu.ErrInvalidToken.Error()})
+		return
+	}
+
+	if cb.Type == feishu.CallbackURLVerification {
+		c.JSON(http.StatusOK, gin.H{"challenge": cb.Challenge})
+		return
+	}
+
+	// 卡片操作会修改数据，未配置任何校验方式时拒绝
+	if h.config.EncryptKey == "" && h.config.VerificationToken == "" {
+		log.Printf("⚠️ 拒绝飞书卡片操作：未配置 feishu.encrypt_key 或 feishu.verification_token")
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{Success: false, Error: "未配置 feishu.encrypt_key 或 feishu.verification_token，拒绝卡片操作"})
+		return
+	}
+	// 新版回调只在配置 Encrypt Key 时签名，旧版消息卡片回调始终以 Verification Token 签名
+	if h.config.EncryptKey != "" || cb.Legacy {
+		timestamp := c.GetHeader(feishu.HeaderTimestamp)
+		signature := c.GetHeader(feishu.HeaderSignature)
+		if signature == "" || !feishu.VerifySignature(timestamp, c.GetHeader(feishu.HeaderNonce), h.config.EncryptKey, h.config.VerificationToken, body, signature) {
+			log.Printf("⚠️ 飞书回调签名校验失败")
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse{Success: false, Error: feishu.ErrInvalidSignature.Error()})
+			return
+		}
+		if !feishu.VerifyTimestamp(timestamp, time.Now()) {
+			log.Printf("⚠️ 飞书回调时间戳无效: %q", timestamp)
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse{Success: false, Error: feishu.ErrInvalidTimestamp.Error()})
+			return
+		}
+	}
+	if h.actions == nil {
+		c.JSON(http.StatusOK, feishu.CardActionResponse(cb, "error", "卡片操作未启用", nil))
+		return
+	}
+
+	toastType, toast, card := h.actions.Handle(cb)
+	c.JSON(http.StatusOK, feishu.CardActionResponse(cb, toastType, toast, card))
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"xianyu_aner/internal/model"
+	"xianyu_aner/pkg/history"
+)
+
+// HistorySource 历史查询数据源：history.Store（JSONL 快照）或 service.StorageHistory（SQLite）
+type HistorySource interface {
+	History(itemID string) []history.Observation
+	PriceDrops(since time.Time) []history.PriceChange
+	FastestWantGrowth(since time.Time, limit int) []history.WantGrowth
+}
+
+// HistoryHandler 历史快照处理器
+type HistoryHandler struct {
+	store HistorySource
+}
+
+// NewHistoryHandler 创建历史快照处理器，store 为 nil 时接口返回 503
+func NewHistoryHandler(store HistorySource) *HistoryHandler {
+	return &HistoryHandler{store: store}
+}
+
+// HandleItemHistory 查询单个商品的历史观测
+func (h *HistoryHandler) HandleItemHistory(c *gin.Context) {
+	if !h.checkStore(c) {
+		return
+	}
+
+	itemID := c.Param("id")
+	observations := h.store.History(itemID)
+	c.JSON(http.StatusOK, model.HistoryResponse{
+		Success: true,
+		Data: model.HistoryData{
+			ItemID:       itemID,
+			Total:        len(observations),
+			Observations: observations,
+		},
+	})
+}
+
+// HandlePriceDrops 查询时间窗口内降价的商品
+func (h *HistoryHandler) HandlePriceDrops(c *gin.Context) {
+	if !h.checkStore(c) {
+		return
+	}
+
+	hours := queryInt(c, "hours", 24)
+	drops := h.store.PriceDrops(time.Now().Add(-time.Duration(hours) * time.Hour))
+	c.JSON(http.StatusOK, model.HistoryListResponse{
+		Success: true,
+		Data: model.HistoryListData{
+			Hours: hours,
+			Total: len(drops),
+			Items: drops,
+		},
+	})
+}
+
+// HandleWantGrowth 查询时间窗口内想要人数增长最快的商品
+func (h *HistoryHandler) HandleWantGrowth(c *gin.Context) {
+	if !h.checkStore(c) {
+		return
+	}
+
+	hours := queryInt(c, "hours", 24)
+	limit := queryInt(c, "limit", 20)
+	growth := h.store.FastestWantGrowth(time.Now().Add(-time.Duration(hours)*time.Hour), limit)
+	c.JSON(http.StatusOK, model.HistoryListResponse{
+		Success: true,
+		Data: model.HistoryListData{
+			Hours: hours,
+			Total: len(growth),
+			Items: growth,
+		},
+	})
+}
+
+// checkStore 检查历史存储是否可用
+func (h *HistoryHandler) checkStore(c *gin.Context) bool {
+	if h.store == nil {
+		c.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Success: false,
+			Error:   "历史快照存储未启用，请设置 history.enabled 或 storage.read_source",
+		})
+		return false
+	}
+	return true
+}
+
+// queryInt 读取正整数查询参数，缺失或非法时返回默认值
+func queryInt(c *gin.Context, key string, defaultVal int) int {
+	if v, err := strconv.Atoi(c.Query(key)); err == nil && v > 0 {
+		return v
+	}
+	return defaultVal
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"xianyu_aner/internal/model"
+	"xianyu_aner/pkg/storage"
+)
+
+// StorageHandler SQLite 存储查询处理器
+type StorageHandler struct {
+	db *storage.DB
+}
+
+// NewStorageHandler 创建存储查询处理器
+func NewStorageHandler(db *storage.DB) *StorageHandler {
+	return &StorageHandler{db: db}
+}
+
+// HandleItems 按条件查询商品
+func (h *StorageHandler) HandleItems(c *gin.Context) {
+	if !h.checkDB(c) {
+		return
+	}
+
+	filter, err := itemFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{Success: false, Error: "参数错误: " + err.Error()})
+		return
+	}
+	items, total, err := h.db.Items(c.Request.Context(), filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.StorageResponse{
+		Success: true,
+		Data: model.ItemListData{
+			Total:  total,
+			Limit:  filter.Limit,
+			Offset: filter.Offset,
+			Items:  items,
+		},
+	})
+}
+
+// HandleItem 查询单个商品及其在时间范围内的观测
+func (h *StorageHandler) HandleItem(c *gin.Context) {
+	if !h.checkDB(c) {
+		return
+	}
+
+	from, err := queryTime(c, "from")
+	var to time.Time
+	if err == nil {
+		to, err = queryTime(c, "to")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{Success: false, Error: "参数错误: " + err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	item, ok, err := h.db.Item(ctx, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{Success: false, Error: "商品不存在"})
+		return
+	}
+	observations, err := h.db.Observations(ctx, item.ItemID, from, to)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.StorageResponse{
+		Success: true,
+		Data:    model.ItemData{Item: item, Observations: observations},
+	})
+}
+
+// HandleRuns 最近的爬取批次
+func (h *StorageHandler) HandleRuns(c *gin.Context) {
+	if !h.checkDB(c) {
+		return
+	}
+
+	runs, err := h.db.Runs(c.Request.Context(), queryInt(c, "limit", 20))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.StorageResponse{Success: true, Data: runs})
+}
+
+// HandleSellers 最近出现的卖家
+func (h *StorageHandler) HandleSellers(c *gin.Context) {
+	if !h.checkDB(c) {
+		return
+	}
+
+	sellers, err := h.db.Sellers(c.Request.Context(), queryInt(c, "limit", 50))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.StorageResponse{Success: true, Data: sellers})
+}
+
+// itemFilter 解析商品查询参数
+func itemFilter(c *gin.Context) (storage.Filter, error) {
+	f := storage.Filter{
+		Keyword: c.Query("q"),
+		Seller:  c.Query("seller"),
+		Status:  c.Query("status"),
+		MinWant: queryInt(c, "minWant", 0),
+		Sort:    c.Query("sort"),
+		Limit:   queryInt(c, "limit", 50),
+		Offset:  queryInt(c, "offset", 0),
+	}
+	switch f.Sort {
+	case "", storage.SortLastSeen, storage.SortWant, storage.SortPrice, storage.SortNew:
+	default:
+		return f, fmt.Errorf("sort 必须是 last_seen、want、price 或 new")
+	}
+	if f.Limit > 500 {
+		f.Limit = 500
+	}
+
+	var err error
+	if f.MinPrice, err = queryFloat(c, "minPrice"); err != nil {
+		return f, err
+	}
+	if f.MaxPrice, err = queryFloat(c, "maxPrice"); err != nil {
+		return f, err
+	}
+	if f.Since, err = queryTime(c, "since"); err != nil {
+		return f, err
+	}
+	if f.Until, err = queryTime(c, "until"); err != nil {
+		return f, err
+	}
+	if hours := queryInt(c, "hours", 0); hours > 0 && f.Since.IsZero() {
+		f.Since = time.Now().Add(-time.Duration(hours) * time.Hour)
+	}
+	return f, nil
+}
+
+// queryFloat 读取非负数查询参数，缺失时返回 0
+func queryFloat(c *gin.Context, key string) (float64, error) {
+	v := c.Query(key)
+	if v == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("%s 必须是非负数", key)
+	}
+	return f, nil
+}
+
+// queryTime 读取时间查询参数（RFC3339 或 2006-01-02，按本地时区），缺失时返回零值
+func queryTime(c *gin.Context, key string) (time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%s 必须是 RFC3339 时间或 YYYY-MM-DD 日期", key)
+}
+
+// checkDB 检查 SQLite 存储是否可用
+func (h *StorageHandler) checkDB(c *gin.Context) bool {
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Success: false,
+			Error:   "SQLite 存储未启用，请设置 storage.enabled",
+		})
+		return false
+	}
+	return true
+}
+
+func (h *StorageHandler) handleError(c *gin.Context, err error) {
+	c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+		Success: false,
+		Error:   fmt.Sprintf("查询数据库失败: %v", err),
+	})
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"xianyu_aner/internal/model"
+	"xianyu_aner/pkg/lifecycle"
+)
+
+// TrackerHandler 生命周期跟踪处理器
+type TrackerHandler struct {
+	tracker *lifecycle.Tracker
+}
+
+// NewTrackerHandler 创建生命周期跟踪处理器
+func NewTrackerHandler(tracker *lifecycle.Tracker) *TrackerHandler {
+	return &TrackerHandler{tracker: tracker}
+}
+
+// HandleStats 查询跟踪统计（各阶段数量、成交天数等）
+func (h *TrackerHandler) HandleStats(c *gin.Context) {
+	if !h.checkTracker(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, model.TrackerResponse{
+		Success: true,
+		Data:    h.tracker.Stats(),
+	})
+}
+
+// HandleItem 查询单个商品的跟踪记录与状态变化
+func (h *TrackerHandler) HandleItem(c *gin.Context) {
+	if !h.checkTracker(c) {
+		return
+	}
+
+	item, ok := h.tracker.Item(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Success: false,
+			Error:   "商品未被跟踪",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.TrackerResponse{
+		Success: true,
+		Data:    item,
+	})
+}
+
+// HandleTrack 手动添加跟踪商品
+func (h *TrackerHandler) HandleTrack(c *gin.Context) {
+	if !h.checkTracker(c) {
+		return
+	}
+
+	var req model.TrackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "参数错误: itemIds 不能为空",
+		})
+		return
+	}
+
+	added := 0
+	for _, id := range req.ItemIDs {
+		if h.tracker.Track(id, "", "", 0) {
+			added++
+		}
+	}
+	c.JSON(http.StatusOK, model.TrackerResponse{
+		Success: true,
+		Data:    gin.H{"added": added},
+	})
+}
+
+// checkTracker 检查跟踪器是否可用
+func (h *TrackerHandler) checkTracker(c *gin.Context) bool {
+	if h.tracker == nil {
+		c.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Success: false,
+			Error:   "生命周期跟踪未启用，请设置 tracker.enabled",
+		})
+		return false
+	}
+	return true
+}
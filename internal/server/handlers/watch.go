@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"xianyu_aner/internal/model"
+	"xianyu_aner/pkg/watch"
+)
+
+// WatchHandler 关注列表处理器
+type WatchHandler struct {
+	watcher *watch.Watcher
+}
+
+// NewWatchHandler 创建关注列表处理器
+func NewWatchHandler(watcher *watch.Watcher) *WatchHandler {
+	return &WatchHandler{watcher: watcher}
+}
+
+// HandleList 查询全部关注项
+func (h *WatchHandler) HandleList(c *gin.Context) {
+	if !h.checkWatcher(c) {
+		return
+	}
+	c.JSON(http.StatusOK, model.WatchResponse{
+		Success: true,
+		Data:    h.watcher.List(),
+	})
+}
+
+// HandleGet 查询单个关注项及其检查状态
+func (h *WatchHandler) HandleGet(c *gin.Context) {
+	if !h.checkWatcher(c) {
+		return
+	}
+	entry, state, ok := h.watcher.Get(c.Param("id"))
+	if !ok {
+		h.notFound(c)
+		return
+	}
+	c.JSON(http.StatusOK, model.WatchResponse{
+		Success: true,
+		Data:    gin.H{"entry": entry, "state": state},
+	})
+}
+
+// HandleCreate 添加关注项
+func (h *WatchHandler) HandleCreate(c *gin.Context) {
+	if !h.checkWatcher(c) {
+		return
+	}
+	req, ok := h.bindEntry(c)
+	if !ok {
+		return
+	}
+	entry, err := h.watcher.Add(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{Success: false, Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, model.WatchResponse{Success: true, Data: entry})
+}
+
+// HandleUpdate 修改关注项
+func (h *WatchHandler) HandleUpdate(c *gin.Context) {
+	if !h.checkWatcher(c) {
+		return
+	}
+	req, ok := h.bindEntry(c)
+	if !ok {
+		return
+	}
+	entry, err := h.watcher.Update(c.Param("id"), req)
+	if errors.Is(err, watch.ErrEntryNotFound) {
+		h.notFound(c)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{Success: false, Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, model.WatchResponse{Success: true, Data: entry})
+}
+
+// HandleDelete 删除关注项
+func (h *WatchHandler) HandleDelete(c *gin.Context) {
+	if !h.checkWatcher(c) {
+		return
+	}
+	err := h.watcher.Remove(c.Param("id"))
+	if errors.Is(err, watch.ErrEntryNotFound) {
+		h.notFound(c)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{Success: false, Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, model.WatchResponse{Success: true, Data: gin.H{"deleted": c.Param("id")}})
+}
+
+// HandleCheck 立即检查关注项，返回触发的事件
+func (h *WatchHandler) HandleCheck(c *gin.Context) {
+	if !h.checkWatcher(c) {
+		return
+	}
+	events, err := h.watcher.Check(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, watch.ErrEntryNotFound) {
+		h.notFound(c)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, model.ErrorResponse{Success: false, Error: "检查失败: " + err.Error()})
+		return
+	}
+	if events == nil {
+		events = []watch.Event{}
+	}
+	c.JSON(http.StatusOK, model.WatchResponse{Success: true, Data: events})
+}
+
+// HandleEvents 查询最近触发的事件
+// 参数: limit 返回数量，默认 50
+func (h *WatchHandler) HandleEvents(c *gin.Context) {
+	if !h.checkWatcher(c) {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{Success: false, Error: "参数错误: limit 必须为正整数"})
+		return
+	}
+	c.JSON(http.StatusOK, model.WatchResponse{Success: true, Data: h.watcher.Events(limit)})
+}
+
+// bindEntry 解析关注项请求
+func (h *WatchHandler) bindEntry(c *gin.Context) (watch.Entry, bool) {
+	var req model.WatchEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Success: false,
+			Error:   "参数错误: kind 与 target 不能为空",
+		})
+		return watch.Entry{}, false
+	}
+	return watch.Entry{
+		ID:              req.ID,
+		Kind:            watch.Kind(req.Kind),
+		Target:          req.Target,
+		Name:            req.Name,
+		Filter:          req.Filter,
+		IntervalMinutes: req.IntervalMinutes,
+		Disabled:        req.Disabled,
+	}, true
+}
+
+func (h *WatchHandler) notFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, model.ErrorResponse{Success: false, Error: "关注项不存在"})
+}
+
+// checkWatcher 检查关注列表是否可用
+func (h *WatchHandler) checkWatcher(c *gin.Context) bool {
+	if h.watcher == nil {
+		c.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Success: false,
+			Error:   "关注列表未启用，请设置 watch.enabled",
+		})
+		return false
+	}
+	return true
+}
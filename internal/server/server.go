@@ -11,8 +11,16 @@ import (
 	"github.com/gin-gonic/gin"
 	"xianyu_aner/internal/config"
 	"xianyu_aner/internal/server/handlers"
+	"xianyu_aner/internal/service"
+	"xianyu_aner/pkg/entity"
 	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/lifecycle"
 	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/notify"
+	"xianyu_aner/pkg/scoring"
+	"xianyu_aner/pkg/storage"
+	"xianyu_aner/pkg/watch"
 )
 
 // Server HTTP服务器
@@ -22,6 +30,18 @@ type Server struct {
 	mtopClient   *mtop.Client
 	feishuClient *feishu.Client
 	feishuConfig *feishu.BitableConfig
+	historyStore *history.Store
+	storage      *storage.DB
+	tracker      *lifecycle.Tracker
+	scorer       *scoring.Engine
+	extractor    *entity.Extractor
+	watcher      *watch.Watcher
+	notifier     *notify.Router
+	cardActions  *service.CardActions
+	digester     *service.Digester
+	stopTracker  context.CancelFunc
+	stopWatcher  context.CancelFunc
+	stopDigest   context.CancelFunc
 	httpServer   *http.Server
 }
 
@@ -54,12 +74,88 @@ func (s *Server) initializeClients() {
 		s.feishuClient = feishu.NewClient(feishu.ClientConfig{
 			AppID:     s.config.Feishu.AppID,
 			AppSecret: s.config.Feishu.AppSecret,
+			BatchSize: s.config.Feishu.BatchSize,
 		})
 		s.feishuConfig = &feishu.BitableConfig{
 			AppToken:   s.config.Feishu.AppToken,
 			TableToken: s.config.Feishu.TableToken,
 		}
 	}
+
+	// 打开历史快照存储（如果启用）
+	store, err := service.OpenHistory(s.config.History)
+	if err != nil {
+		log.Printf("⚠️ 打开历史快照存储失败，历史接口不可用: %v", err)
+	}
+	s.historyStore = store
+
+	// 打开 SQLite 存储（如果启用）
+	db, err := service.OpenStorage(s.config.Storage)
+	if err != nil {
+		log.Printf("⚠️ 打开 SQLite 存储失败，存储接口不可用: %v", err)
+	}
+	s.storage = db
+
+	// 创建生命周期跟踪器（如果启用）
+	tracker, err := service.NewLifecycleTracker(s.config.Tracker, s.mtopClient)
+	if err != nil {
+		log.Printf("⚠️ 创建生命周期跟踪器失败，跟踪接口不可用: %v", err)
+	}
+	s.tracker = tracker
+
+	// 创建热度评分引擎
+	scorer, err := service.NewScoringEngine(s.config.Scoring)
+	if err != nil {
+		log.Printf("⚠️ 热度评分配置无效，/feed 不返回得分: %v", err)
+	}
+	s.scorer = scorer
+
+	// 创建实体提取器（如果启用，用于低价检测归组）
+	extractor, err := service.NewEntityExtractor(s.config.Entity)
+	if err != nil {
+		log.Printf("⚠️ 实体识别初始化失败，低价检测仅按标题归组: %v", err)
+	}
+	s.extractor = extractor
+
+	// 创建关注列表调度器（如果启用）
+	watcher, err := service.NewWatcher(s.config.Watch, s.mtopClient)
+	if err != nil {
+		log.Printf("⚠️ 加载关注列表失败，关注接口不可用: %v", err)
+	}
+	s.watcher = watcher
+
+	// 创建通知路由器（如果配置了通道），关注事件推送到 IM 群机器人
+	notifier, err := service.NewNotifyRouter(s.config.Notify, s.config.Feishu)
+	if err != nil {
+		log.Printf("⚠️ 通知通道初始化失败，已禁用消息通知: %v", err)
+	}
+	s.notifier = notifier
+	riskEvaluator, err := service.NewRiskEvaluator(s.config.Risk)
+	if err != nil {
+		log.Printf("⚠️ 卖家风险评估初始化失败，关注提醒不附带风险等级: %v", err)
+	}
+	service.NotifyWatchEvents(s.watcher, s.notifier, riskEvaluator)
+
+	// 创建出站 Webhook（如果配置了推送地址），关注事件以签名 JSON 推送
+	webhooks, err := service.NewWebhooks(s.config.Webhook)
+	if err != nil {
+		log.Printf("⚠️ Webhook 初始化失败，已禁用 Webhook 推送: %v", err)
+	}
+	service.DeliverWatchEvents(s.watcher, webhooks)
+
+	// 创建飞书卡片操作处理器（与通知路由器共用屏蔽卖家列表）
+	cardActions, err := service.NewCardActions(s.config, s.watcher, s.notifier.MuteList())
+	if err != nil {
+		log.Printf("⚠️ 卡片操作初始化失败，卡片按钮不可用: %v", err)
+	}
+	s.cardActions = cardActions
+
+	// 创建邮件摘要（如果启用，数据来自历史快照）
+	digester, err := service.NewDigester(s.config.Digest, s.historyStore)
+	if err != nil {
+		log.Printf("⚠️ 邮件摘要初始化失败，已禁用邮件摘要: %v", err)
+	}
+	s.digester = digester
 }
 
 // setupMiddleware 设置中间件
@@ -72,9 +168,18 @@ func (s *Server) setupMiddleware() {
 // setupRoutes 设置路由
 func (s *Server) setupRoutes() {
 	// 创建handlers
-	feedHandler := handlers.NewFeedHandler(s.mtopClient)
+	feedHandler := handlers.NewFeedHandler(s.mtopClient, s.historyStore, s.tracker,
+		s.scorer, s.config.Scoring.GetVelocityWindow()).WithStorage(s.storage)
 	healthHandler := handlers.NewHealthHandler()
 	feishuHandler := handlers.NewFeishuHandler(s.feishuClient, s.feishuConfig)
+	historyHandler := handlers.NewHistoryHandler(s.historySource())
+	trackerHandler := handlers.NewTrackerHandler(s.tracker)
+	dealsHandler := handlers.NewDealsHandler(s.config.Deals, s.historyStore, s.extractor)
+	watchHandler := handlers.NewWatchHandler(s.watcher)
+	callbackHandler := handlers.NewFeishuCallbackHandler(s.config.Feishu, s.cardActions)
+	digestHandler := handlers.NewDigestHandler(s.digester)
+	exportHandler := handlers.NewExportHandler(s.mtopClient, s.historyStore).WithStorage(s.storage)
+	storageHandler := handlers.NewStorageHandler(s.storage)
 
 	// API v1路由组
 	v1 := s.engine.Group("/api/v1")
@@ -82,12 +187,45 @@ func (s *Server) setupRoutes() {
 		v1.GET("/health", healthHandler.HandleHealth)
 		v1.GET("/feed", feedHandler.HandleFeed)
 		v1.POST("/feishu/push", feishuHandler.HandleFeishuPush)
+		v1.GET("/history/items/:id", historyHandler.HandleItemHistory)
+		v1.GET("/history/price-drops", historyHandler.HandlePriceDrops)
+		v1.GET("/history/want-growth", historyHandler.HandleWantGrowth)
+		v1.GET("/tracker/stats", trackerHandler.HandleStats)
+		v1.GET("/tracker/items/:id", trackerHandler.HandleItem)
+		v1.POST("/tracker/items", trackerHandler.HandleTrack)
+		v1.GET("/deals", dealsHandler.HandleDeals)
+		v1.GET("/watchlist", watchHandler.HandleList)
+		v1.POST("/watchlist", watchHandler.HandleCreate)
+		v1.GET("/watchlist/events", watchHandler.HandleEvents)
+		v1.GET("/watchlist/:id", watchHandler.HandleGet)
+		v1.PUT("/watchlist/:id", watchHandler.HandleUpdate)
+		v1.DELETE("/watchlist/:id", watchHandler.HandleDelete)
+		v1.POST("/watchlist/:id/check", watchHandler.HandleCheck)
+		v1.POST("/feishu/callback", callbackHandler.HandleCallback)
+		v1.GET("/digest/preview", digestHandler.HandlePreview)
+		v1.POST("/digest/send", digestHandler.HandleSend)
+		v1.GET("/export", exportHandler.HandleExport)
+		v1.GET("/items", storageHandler.HandleItems)
+		v1.GET("/items/:id", storageHandler.HandleItem)
+		v1.GET("/runs", storageHandler.HandleRuns)
+		v1.GET("/sellers", storageHandler.HandleSellers)
 	}
 
 	// 根路径
 	s.engine.GET("/", s.handleRoot)
 }
 
+// historySource 历史接口的数据来源：设置了 storage.read_source 时读取 SQLite，否则读取 JSONL 快照
+func (s *Server) historySource() handlers.HistorySource {
+	if s.config.Storage.ReadSource && s.storage != nil {
+		return service.StorageHistory{DB: s.storage}
+	}
+	if s.historyStore != nil {
+		return s.historyStore
+	}
+	return nil
+}
+
 // Start 启动服务器
 func (s *Server) Start() error {
 	s.httpServer = &http.Server{
@@ -98,11 +236,53 @@ func (s *Server) Start() error {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	if s.tracker != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopTracker = cancel
+		go s.tracker.Run(ctx, s.config.Tracker.GetTick(), func(err error) {
+			log.Printf("⚠️ 生命周期跟踪出错: %v", err)
+		})
+	}
+
+	if s.watcher != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopWatcher = cancel
+		go s.watcher.Run(ctx, s.config.Watch.GetTick(), func(err error) {
+			log.Printf("⚠️ 关注列表检查出错: %v", err)
+		})
+	}
+
+	if s.digester != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopDigest = cancel
+		go s.digester.Run(ctx, time.Minute, func(err error) {
+			log.Printf("⚠️ 发送邮件摘要失败: %v", err)
+		})
+	}
+
 	log.Printf("🚀 API服务器启动在 http://localhost:%d", s.config.Server.Port)
 	log.Println("📋 可用的接口:")
 	log.Println("   GET  /api/v1/health      - 健康检查")
 	log.Println("   GET  /api/v1/feed        - 获取猜你喜欢")
 	log.Println("   POST /api/v1/feishu/push - 推送到飞书表格")
+	log.Println("   GET  /api/v1/history/items/:id   - 商品历史观测")
+	log.Println("   GET  /api/v1/history/price-drops - 近期降价商品")
+	log.Println("   GET  /api/v1/history/want-growth - 想要人数增长最快")
+	log.Println("   GET  /api/v1/tracker/stats       - 生命周期统计")
+	log.Println("   GET  /api/v1/tracker/items/:id   - 商品生命周期")
+	log.Println("   POST /api/v1/tracker/items       - 添加跟踪商品")
+	log.Println("   GET  /api/v1/deals               - 低于市场价的商品")
+	log.Println("   GET  /api/v1/watchlist           - 关注列表（POST 添加）")
+	log.Println("   GET  /api/v1/watchlist/:id       - 关注项详情（PUT 修改 / DELETE 删除）")
+	log.Println("   POST /api/v1/watchlist/:id/check - 立即检查关注项")
+	log.Println("   GET  /api/v1/watchlist/events    - 最近关注提醒")
+	log.Println("   POST /api/v1/feishu/callback     - 飞书卡片回调")
+	log.Println("   GET  /api/v1/digest/preview      - 预览邮件摘要（POST /digest/send 立即发送）")
+	log.Println("   GET  /api/v1/export              - 导出猜你喜欢为 CSV/TSV/xlsx")
+	log.Println("   GET  /api/v1/items               - 查询 SQLite 中的商品")
+	log.Println("   GET  /api/v1/items/:id           - 商品详情与观测")
+	log.Println("   GET  /api/v1/runs                - 最近的爬取批次")
+	log.Println("   GET  /api/v1/sellers             - 最近出现的卖家")
 	log.Println("   GET  /                   - API文档")
 
 	return s.httpServer.ListenAndServe()
@@ -110,6 +290,27 @@ func (s *Server) Start() error {
 
 // Stop 停止服务器
 func (s *Server) Stop(ctx context.Context) error {
+	if s.stopTracker != nil {
+		s.stopTracker()
+	}
+	if s.tracker != nil {
+		defer s.tracker.Save()
+	}
+	if s.stopWatcher != nil {
+		s.stopWatcher()
+	}
+	if s.watcher != nil {
+		defer s.watcher.Save()
+	}
+	if s.stopDigest != nil {
+		s.stopDigest()
+	}
+	if s.historyStore != nil {
+		defer s.historyStore.Close()
+	}
+	if s.storage != nil {
+		defer s.storage.Close()
+	}
 	if s.httpServer != nil {
 		return s.httpServer.Shutdown(ctx)
 	}
@@ -148,6 +349,8 @@ func (s *Server) handleRoot(c *gin.Context) {
         .method { display: inline-block; padding: 5px 12px; border-radius: 4px; color: white; font-weight: bold; font-size: 12px; margin-right: 10px; }
         .get { background: #28a745; }
         .post { background: #007bff; }
+        .put { background: #fd7e14; }
+        .delete { background: #dc3545; }
         .path { font-weight: bold; font-size: 16px; }
         .desc { margin-top: 10px; color: #666; }
         .params { margin-top: 15px; background: white; padding: 15px; border-radius: 5px; }
@@ -175,10 +378,13 @@ func (s *Server) handleRoot(c *gin.Context) {
             <div class="params">
                 <strong>请求参数:</strong><br><br>
                 <code>pages</code>: 爬取页数，默认 1，范围 1-10<br>
-                <code>machId</code>: 推荐码/机器ID，可选<br><br>
+                <code>machId</code>: 推荐码/机器ID，可选<br>
+                <code>sort</code>: 排序方式，<code>score</code> 按热度得分降序，可选<br>
+                <code>explain</code>: 为 true 时返回各因子得分明细，可选<br><br>
                 <strong>示例:</strong><br>
                 <code>curl http://localhost:8080/api/v1/feed?pages=3</code><br>
-                <code>curl http://localhost:8080/api/v1/feed?pages=2&machId=xxx</code>
+                <code>curl http://localhost:8080/api/v1/feed?pages=2&machId=xxx</code><br>
+                <code>curl http://localhost:8080/api/v1/feed?sort=score&explain=true</code>
             </div>
         </div>
 
@@ -196,6 +402,171 @@ func (s *Server) handleRoot(c *gin.Context) {
                 <code>curl -X POST http://localhost:8080/api/v1/feishu/push \<br>&nbsp;&nbsp;-H "Content-Type: application/json" \<br>&nbsp;&nbsp;-d '{"date":"2024-01-15","products":[...]}'</code>
             </div>
         </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/history/items/:id</span>
+            <div class="desc">查询商品的全部历史观测（价格、想要人数、浏览、收藏、状态）</div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/history/price-drops</span>
+            <div class="desc">近期降价商品，按降价幅度排序</div>
+            <div class="params">
+                <code>hours</code>: 时间窗口（小时），默认 24<br>
+            </div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/history/want-growth</span>
+            <div class="desc">想要人数增长最快的商品</div>
+            <div class="params">
+                <code>hours</code>: 时间窗口（小时），默认 24<br>
+                <code>limit</code>: 返回数量，默认 20<br>
+            </div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/tracker/stats</span>
+            <div class="desc">生命周期统计：各阶段商品数、改价次数、平均/中位成交天数</div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/tracker/items/:id</span>
+            <div class="desc">查询商品的生命周期阶段与状态/价格变化记录</div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method post">POST</span>
+            <span class="path">/api/v1/tracker/items</span>
+            <div class="desc">手动添加跟踪商品</div>
+            <div class="params">
+                <code>itemIds</code>: 商品ID列表 (必需)<br>
+            </div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/deals</span>
+            <div class="desc">低于同类中位价的商品（同类按分类 + 品牌型号或标题相似度归组），附比价依据</div>
+            <div class="params">
+                <code>hours</code>: 候选商品的观测时间窗口（小时），默认 24<br>
+                <code>threshold</code>: 低于中位价的百分比阈值，默认取配置（30）<br>
+                <code>minPeers</code>: 同类商品最少数量，默认取配置（5）<br>
+            </div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="method post">POST</span>
+            <span class="path">/api/v1/watchlist</span>
+            <div class="desc">查询/添加关注项（商品、关键词、卖家），修改会写回关注列表文件</div>
+            <div class="params">
+                <strong>请求参数 (JSON):</strong><br><br>
+                <code>kind</code>: item / keyword / seller (必需)<br>
+                <code>target</code>: 商品ID / 关键词 / 卖家ID (必需)<br>
+                <code>name</code>: 备注名称，可选<br>
+                <code>filter</code>: 过滤表达式，如 <code>price &lt; 3000 &amp;&amp; title ~ "pro"</code>，可选<br>
+                <code>intervalMinutes</code>: 检查间隔（分钟），可选<br><br>
+                <strong>示例:</strong><br>
+                <code>curl -X POST http://localhost:8080/api/v1/watchlist \<br>&nbsp;&nbsp;-H "Content-Type: application/json" \<br>&nbsp;&nbsp;-d '{"kind":"keyword","target":"switch","filter":"price &lt;= 1500"}'</code>
+            </div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="method put">PUT</span>
+            <span class="method delete">DELETE</span>
+            <span class="path">/api/v1/watchlist/:id</span>
+            <div class="desc">查询关注项及检查状态 / 修改 / 删除</div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method post">POST</span>
+            <span class="path">/api/v1/watchlist/:id/check</span>
+            <div class="desc">立即检查关注项，返回触发的事件（new_match / price_drop / back_in_stock / sold）</div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/watchlist/events</span>
+            <div class="desc">最近触发的关注提醒</div>
+            <div class="params">
+                <code>limit</code>: 返回数量，默认 50<br>
+            </div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method post">POST</span>
+            <span class="path">/api/v1/feishu/callback</span>
+            <div class="desc">飞书卡片回调：响应 URL 校验，校验签名/Token 后执行关注、忽略、已购买、屏蔽卖家并原地更新卡片</div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/digest/preview</span>
+            <div class="desc">预览本期邮件摘要（热门商品、与上一期相比的价格/想要变化）</div>
+            <div class="params">
+                <code>format</code>: 默认返回 HTML，json 返回摘要数据<br>
+            </div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method post">POST</span>
+            <span class="path">/api/v1/digest/send</span>
+            <div class="desc">立即通过 SMTP 发送本期邮件摘要，并记录为上一期</div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/export</span>
+            <div class="desc">抓取猜你喜欢并下载为表格文件（UTF-8 BOM、中文表头，列顺序同飞书表格字段）</div>
+            <div class="params">
+                <code>format</code>: csv（默认）、tsv 或 xlsx（按采集日期分工作表，热门商品高亮）<br>
+                <code>hotWant</code>: xlsx 热门高亮的想要人数阈值，默认 10<br>
+                <code>pages</code>, <code>machId</code>, <code>minWantCount</code>, <code>daysWithin</code>: 同 /api/v1/feed<br>
+            </div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/items</span>
+            <div class="desc">按条件查询 SQLite 中的商品（需启用 storage）</div>
+            <div class="params">
+                <code>q</code>: 标题关键词，<code>seller</code>: 卖家昵称，<code>status</code>: 商品状态<br>
+                <code>minPrice</code>, <code>maxPrice</code>, <code>minWant</code>: 价格与想要人数范围<br>
+                <code>since</code>, <code>until</code>: 最近出现时间范围（RFC3339 或 YYYY-MM-DD），或 <code>hours</code>: 最近 N 小时<br>
+                <code>sort</code>: last_seen（默认）、want、price 或 new<br>
+                <code>limit</code>: 返回数量，默认 50，最大 500；<code>offset</code>: 偏移<br><br>
+                <strong>示例:</strong><br>
+                <code>curl "http://localhost:8080/api/v1/items?q=switch&amp;maxPrice=1500&amp;sort=want"</code>
+            </div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/items/:id</span>
+            <div class="desc">商品详情与观测记录</div>
+            <div class="params">
+                <code>from</code>, <code>to</code>: 观测时间范围（RFC3339 或 YYYY-MM-DD），可选<br>
+            </div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/runs</span>
+            <div class="desc">最近的爬取批次（来源、状态、商品数、起止时间）</div>
+        </div>
+
+        <div class="endpoint">
+            <span class="method get">GET</span>
+            <span class="path">/api/v1/sellers</span>
+            <div class="desc">最近出现的卖家</div>
+        </div>
     </div>
 </body>
 </html>`
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/notify"
+	"xianyu_aner/pkg/watch"
+)
+
+// 处理状态（写入多维表格的处理状态字段）
+var actionStatus = map[string]string{
+	feishu.ActionIgnore: "已忽略",
+	feishu.ActionBought: "已购买",
+}
+
+// actionLabels 操作完成后卡片上显示的文字
+var actionLabels = map[string]string{
+	feishu.ActionWatch:      "已关注",
+	feishu.ActionIgnore:     "已忽略",
+	feishu.ActionBought:     "已购买",
+	feishu.ActionMuteSeller: "已屏蔽卖家",
+}
+
+// CardActions 处理飞书商品卡片上的按钮操作
+type CardActions struct {
+	watcher *watch.Watcher         // 为 nil 时不支持"关注"
+	muted   *notify.MuteList       // 屏蔽卖家列表
+	bitable *feishu.BitableService // 未启用飞书多维表格时为 nil
+	now     func() time.Time
+}
+
+// NewCardActions 创建卡片操作处理器
+// muted 为 nil 时从 notify.mute_path 加载（与通知路由器共用时传入路由器的屏蔽列表）
+func NewCardActions(cfg config.Config, watcher *watch.Watcher, muted *notify.MuteList) (*CardActions, error) {
+	if muted == nil {
+		var err error
+		if muted, err = notify.LoadMuteList(cfg.Notify.MutePath); err != nil {
+			return nil, err
+		}
+	}
+	a := &CardActions{watcher: watcher, muted: muted, now: time.Now}
+	if cfg.Feishu.Enabled && cfg.Feishu.AppToken != "" {
+		client := feishu.NewClient(feishu.ClientConfig{AppID: cfg.Feishu.AppID, AppSecret: cfg.Feishu.AppSecret})
+		a.bitable = feishu.NewBitableService(client, feishu.BitableConfig{AppToken: cfg.Feishu.AppToken})
+	}
+	return a, nil
+}
+
+// Handle 执行卡片回调中的操作，返回提示类型、提示文字与更新后的卡片（失败时卡片为 nil，保持原样）
+func (a *CardActions) Handle(cb *feishu.Callback) (string, string, *feishu.Card) {
+	action, data := feishu.ParseItemAction(cb.Value)
+	toast, err := a.Apply(action, data)
+	if err != nil {
+		log.Printf("⚠️ 卡片操作失败 (action=%s, item=%s): %v", action, data.ItemID, err)
+		return "error", err.Error(), nil
+	}
+	log.Printf("✅ 卡片操作 %s: %s (ID: %s, 操作人: %s)", action, data.Title, data.ItemID, cb.OpenID)
+	return "success", toast, ActionResultCard(action, data, a.now())
+}
+
+// Apply 对商品执行操作，返回提示文字
+func (a *CardActions) Apply(action string, d feishu.ItemCardData) (string, error) {
+	if d.ItemID == "" {
+		return "", fmt.Errorf("卡片缺少商品ID")
+	}
+	switch action {
+	case feishu.ActionWatch:
+		return a.watch(d)
+	case feishu.ActionMuteSeller:
+		if d.SellerNick == "" {
+			return "", fmt.Errorf("卡片缺少卖家信息")
+		}
+		added, err := a.muted.Add(d.SellerNick)
+		if err != nil {
+			return "", err
+		}
+		if !added {
+			return fmt.Sprintf("卖家 %s 已在屏蔽列表中", d.SellerNick), nil
+		}
+		return fmt.Sprintf("已屏蔽卖家 %s，不再提醒其商品", d.SellerNick), nil
+	case feishu.ActionIgnore, feishu.ActionBought:
+		return a.setStatus(d, actionStatus[action])
+	}
+	return "", fmt.Errorf("未知的操作: %s", action)
+}
+
+// watch 将商品加入关注列表
+func (a *CardActions) watch(d feishu.ItemCardData) (string, error) {
+	if a.watcher == nil {
+		return "", fmt.Errorf("关注列表未启用（watch.enabled）")
+	}
+	for _, e := range a.watcher.List() {
+		if e.Kind == watch.KindItem && e.Target == d.ItemID {
+			return "该商品已在关注列表中", nil
+		}
+	}
+	if _, err := a.watcher.Add(watch.Entry{Kind: watch.KindItem, Target: d.ItemID, Name: d.Title}); err != nil {
+		return "", err
+	}
+	return "已加入关注列表，降价或售出时提醒", nil
+}
+
+// setStatus 更新多维表格中的处理状态
+func (a *CardActions) setStatus(d feishu.ItemCardData, status string) (string, error) {
+	if a.bitable == nil {
+		return fmt.Sprintf("已标记为%s（未启用飞书多维表格）", status), nil
+	}
+	tableID, n, err := a.bitable.SetItemStatus(d.ItemID, status)
+	if err != nil {
+		return "", err
+	}
+	if tableID == "" {
+		return fmt.Sprintf("已标记为%s（多维表格中未找到该商品）", status), nil
+	}
+	return fmt.Sprintf("已标记为%s，更新 %d 条记录", status, n), nil
+}
+
+// ActionResultCard 操作完成后的卡片：保留商品信息，去掉操作按钮并注明处理结果
+func ActionResultCard(action string, d feishu.ItemCardData, at time.Time) *feishu.Card {
+	d.Buttons = nil
+	label := actionLabels[action]
+	if label == "" {
+		label = action
+	}
+	d.Footer = strings.TrimSpace(fmt.Sprintf("✅ %s · %s", label, at.Format("01-02 15:04")))
+	card := feishu.ItemCard(d)
+	card.Header["template"] = feishu.CardGrey
+	return card
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"time"
 
+	"xianyu_aner/pkg/entity"
 	"xianyu_aner/pkg/feishu"
 	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/sink"
 	"xianyu_aner/pkg/util"
 )
 
@@ -29,6 +31,7 @@ func (c *Converter) FeedItemToBasicProduct(item mtop.FeedItem) feishu.Product {
 		Title:         item.Title,
 		CoverURL:      item.ImageURL,
 		DetailURL:     BuildDetailURL(item.ItemID),
+		CategoryID:    item.CategoryID,
 	}
 }
 
@@ -56,13 +59,19 @@ func (c *Converter) ItemDetailToProduct(detail *mtop.ItemDetail) feishu.Product
 		SellerNick:     detail.SellerNick,
 		SellerCity:     detail.SellerCity,
 		SellerCredit:   detail.SellerCredit,
+		SellerItemCount: detail.SellerItemCount,
+		SellerSoldCount: detail.SellerSoldCount,
+		SellerRegDays:  detail.SellerRegDays,
+		ShopLevel:      detail.ShopLevel,
 		FreeShip:       util.BoolToYesNo(detail.FreeShipping),
 		Tags:           util.StringsJoin(detail.Tags, ", "),
 		Condition:      detail.Condition,
 		Description:    detail.Description,
 		VideoURL:       detail.VideoURL,
 		CoverURL:       detail.ImageURL,
+		ImageList:      detail.ImageList,
 		DetailURL:      BuildDetailURL(detail.ItemID),
+		CategoryID:     detail.CategoryID,
 	}
 }
 
@@ -75,6 +84,10 @@ func (c *Converter) MergeDetailToProduct(basic feishu.Product, detail *mtop.Item
 	result.SellerNick = detail.SellerNick
 	result.SellerCity = detail.SellerCity
 	result.SellerCredit = detail.SellerCredit
+	result.SellerItemCount = detail.SellerItemCount
+	result.SellerSoldCount = detail.SellerSoldCount
+	result.SellerRegDays = detail.SellerRegDays
+	result.ShopLevel = detail.ShopLevel
 	result.FreeShip = util.BoolToYesNo(detail.FreeShipping)
 	result.Tags = util.StringsJoin(detail.Tags, ", ")
 	result.Description = detail.Description
@@ -82,9 +95,26 @@ func (c *Converter) MergeDetailToProduct(basic feishu.Product, detail *mtop.Item
 	if detail.ImageURL != "" {
 		result.CoverURL = detail.ImageURL
 	}
+	result.ImageList = detail.ImageList
+	if detail.CategoryID != 0 {
+		result.CategoryID = detail.CategoryID
+	}
 	return result
 }
 
+// ApplyEntity 使用实体提取器识别品牌/型号/规格并写入产品
+func (c *Converter) ApplyEntity(product feishu.Product, extractor *entity.Extractor) feishu.Product {
+	if extractor == nil {
+		return product
+	}
+	ent := extractor.Extract(product.CategoryID, product.Title, product.Description)
+	product.Brand = ent.Brand
+	product.Model = ent.Model
+	product.Spec = ent.Spec
+	product.EntityConfidence = ent.Confidence
+	return product
+}
+
 // BuildDetailURL 构建商品详情URL
 func BuildDetailURL(itemID string) string {
 	return fmt.Sprintf("https://www.goofish.com/item?id=%s", itemID)
@@ -93,5 +123,8 @@ func BuildDetailURL(itemID string) string {
 // Result 执行结果
 type Result struct {
 	TotalItems int
+	DealCount  int // 低价商品数（启用 --detect-deals 时）
+	MediaCount int // 新归档的媒体文件数（启用 --archive-media 时）
 	Duration   time.Duration
+	Sinks      []sink.Stats // 各输出目标的写入统计
 }
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"time"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/deals"
+	"xianyu_aner/pkg/entity"
+	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+)
+
+// NewDealAnalyzer 根据配置创建低价检测分析器
+func NewDealAnalyzer(cfg config.DealsConfig) *deals.Analyzer {
+	return deals.NewAnalyzer(deals.Options{
+		Threshold:       cfg.Threshold,
+		MinPeers:        cfg.MinPeers,
+		TitleSimilarity: cfg.TitleSimilarity,
+	})
+}
+
+// DetectFeedDeals 以历史观测为参考，检测本批猜你喜欢商品中的低价商品
+// store 为 nil 时仅在本批商品内部比价
+func DetectFeedDeals(cfg config.DealsConfig, store *history.Store, extractor *entity.Extractor, items []mtop.FeedItem) []deals.Deal {
+	analyzer := NewDealAnalyzer(cfg)
+	LoadDealReference(analyzer, store, extractor, time.Now().Add(-cfg.GetReferenceWindow()), nil)
+
+	candidates := make([]deals.Listing, 0, len(items))
+	for _, item := range items {
+		candidates = append(candidates, newListing(item.ItemID, item.Title, item.CategoryID, history.ParsePrice(item.Price), extractor))
+	}
+	return analyzer.Detect(candidates)
+}
+
+// DetectHistoryDeals 在历史观测中检测低价商品：since 之后观测到的商品作为候选，refSince 之后的作为参考
+func DetectHistoryDeals(cfg config.DealsConfig, store *history.Store, extractor *entity.Extractor, refSince, since time.Time) []deals.Deal {
+	analyzer := NewDealAnalyzer(cfg)
+	var candidates []deals.Listing
+	LoadDealReference(analyzer, store, extractor, refSince, func(obs history.Observation, l deals.Listing) bool {
+		if obs.CapturedAt >= since.UnixMilli() {
+			candidates = append(candidates, l)
+			return false
+		}
+		return true
+	})
+	return analyzer.Detect(candidates)
+}
+
+// LoadDealReference 将 since 之后观测到的商品（取最新观测）加入参考集
+// keep 不为 nil 时由其决定是否加入
+func LoadDealReference(analyzer *deals.Analyzer, store *history.Store, extractor *entity.Extractor, since time.Time, keep func(history.Observation, deals.Listing) bool) {
+	if store == nil {
+		return
+	}
+	for _, obs := range store.LatestSince(since) {
+		l := newListing(obs.ItemID, obs.Title, obs.CategoryID, obs.PriceValue, extractor)
+		if keep == nil || keep(obs, l) {
+			analyzer.Add(l)
+		}
+	}
+}
+
+// ApplyDeals 将低价检测结果写入产品（用于飞书"低于市场价%"/"比价依据"列）
+func ApplyDeals(products []feishu.Product, found []deals.Deal) []feishu.Product {
+	if len(found) == 0 {
+		return products
+	}
+	byItem := make(map[string]deals.Deal, len(found))
+	for _, d := range found {
+		byItem[d.ItemID] = d
+	}
+	for i := range products {
+		if d, ok := byItem[products[i].ItemID]; ok {
+			products[i].DealDiscount = d.DiscountPct
+			products[i].DealBasis = d.Basis
+		}
+	}
+	return products
+}
+
+// newListing 构建比价商品，提取器不为 nil 时识别品牌型号
+func newListing(itemID, title string, categoryID int, price float64, extractor *entity.Extractor) deals.Listing {
+	l := deals.Listing{
+		ItemID:     itemID,
+		Title:      title,
+		CategoryID: categoryID,
+		Price:      price,
+	}
+	if extractor != nil {
+		ent := extractor.Extract(categoryID, title, "")
+		l.Brand = ent.Brand
+		l.Model = ent.Model
+	}
+	return l
+}
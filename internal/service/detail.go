@@ -0,0 +1,61 @@
+package service
+
+import (
+	"sync"
+
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/util"
+)
+
+// DetailCache 一次爬取内共享的商品详情：飞书、PostgreSQL、Parquet 等输出目标都需要详情时，同一商品只请求一次
+// 获取失败的商品同样记录，其他输出目标不再重复请求
+type DetailCache struct {
+	client  *mtop.Client
+	mu      sync.Mutex
+	details map[string]*mtop.ItemDetail
+	errs    map[string]error
+}
+
+// NewDetailCache 创建商品详情缓存
+func NewDetailCache(client *mtop.Client) *DetailCache {
+	return &DetailCache{
+		client:  client,
+		details: make(map[string]*mtop.ItemDetail),
+		errs:    make(map[string]error),
+	}
+}
+
+// Fetch 获取商品详情，已获取过（含失败）的商品直接返回上次的结果
+func (c *DetailCache) Fetch(itemID string) (*mtop.ItemDetail, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if detail, ok := c.details[itemID]; ok {
+		return detail, nil
+	}
+	if err, ok := c.errs[itemID]; ok {
+		return nil, err
+	}
+	detail, err := c.client.FetchItemDetail(itemID)
+	if err != nil {
+		c.errs[itemID] = err
+		return nil, err
+	}
+	c.details[itemID] = detail
+	return detail, nil
+}
+
+// FetchItems 获取一批商品的详情，失败的商品跳过
+func (c *DetailCache) FetchItems(items []mtop.FeedItem) []*mtop.ItemDetail {
+	details := make([]*mtop.ItemDetail, 0, len(items))
+	for i, item := range items {
+		detail, err := c.Fetch(item.ItemID)
+		if err != nil {
+			util.Printf("[失败 %d/%d] 获取详情失败: %s (ID: %s): %v\n",
+				i+1, len(items), util.TruncateString(item.Title, 30), item.ItemID, err)
+			continue
+		}
+		details = append(details, detail)
+	}
+	return details
+}
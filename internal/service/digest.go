@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"log"
+	"sync"
+	"time"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/digest"
+	"xianyu_aner/pkg/history"
+)
+
+// Digester 邮件摘要：从历史快照收集热门商品，渲染 HTML 并通过 SMTP 发送
+type Digester struct {
+	cfg      config.DigestConfig
+	store    *history.Store
+	mailer   *digest.Mailer
+	tmpl     *template.Template
+	state    *digest.State
+	schedule digest.Schedule
+	now      func() time.Time
+	mu       sync.Mutex // 避免定时发送与接口触发同时发送
+}
+
+// NewDigester 根据配置创建邮件摘要，未启用时返回 nil
+func NewDigester(cfg config.DigestConfig, store *history.Store) (*Digester, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if store == nil {
+		return nil, fmt.Errorf("邮件摘要需要启用历史快照")
+	}
+	smtpCfg := digest.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		From:     cfg.From,
+		To:       cfg.To,
+		StartTLS: cfg.StartTLS,
+	}
+	if cfg.SkipVerify {
+		smtpCfg.TLSConfig = &tls.Config{ServerName: cfg.SMTPHost, InsecureSkipVerify: true}
+	}
+	mailer, err := digest.NewMailer(smtpCfg)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := digest.LoadTemplate(cfg.TemplatePath)
+	if err != nil {
+		return nil, err
+	}
+	state, err := digest.LoadState(cfg.StatePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Digester{
+		cfg:      cfg,
+		store:    store,
+		mailer:   mailer,
+		tmpl:     tmpl,
+		state:    state,
+		schedule: digest.Schedule{Period: cfg.Period, Hour: cfg.Hour, Weekday: time.Weekday(cfg.Weekday)},
+		now:      time.Now,
+	}, nil
+}
+
+// Build 收集本期摘要数据：统计区间为上一期发送至今（首期为一个周期）
+func (d *Digester) Build() digest.Report {
+	now := d.now()
+	last := d.state.Last()
+	from := now.Add(-d.schedule.Length())
+	if last != nil && last.SentAt.After(from) {
+		from = last.SentAt
+	}
+	candidates := DigestCandidates(d.store, from)
+	items := digest.Collect(candidates, last, d.cfg.Top)
+	return digest.NewReport(d.subject(now), d.cfg.Period, from, now, len(candidates), items)
+}
+
+// Preview 渲染本期摘要 HTML（不发送、不记录）
+func (d *Digester) Preview() (string, error) {
+	return digest.Render(d.tmpl, d.Build())
+}
+
+// Send 发送本期摘要并记录为上一期
+func (d *Digester) Send(ctx context.Context) (digest.Report, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	report := d.Build()
+	html, err := digest.Render(d.tmpl, report)
+	if err != nil {
+		return report, err
+	}
+	if err := d.mailer.Send(ctx, report.Title, html); err != nil {
+		return report, err
+	}
+	if err := d.state.Record(report); err != nil {
+		log.Printf("⚠️ 保存摘要状态失败: %v", err)
+	}
+	log.Printf("📧 已发送邮件摘要: %s（%d 个商品，收件人 %d 个）", report.Title, len(report.Items), len(d.cfg.To))
+	return report, nil
+}
+
+// Run 按发送计划定时发送摘要，直到 ctx 取消（首次启动且无发送记录时立即发送）
+func (d *Digester) Run(ctx context.Context, tick time.Duration, onError func(error)) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		if d.schedule.Due(d.now(), d.state.Last()) {
+			if _, err := d.Send(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// subject 邮件标题
+func (d *Digester) subject(now time.Time) string {
+	if d.cfg.Subject != "" {
+		return d.cfg.Subject
+	}
+	name := "日报"
+	if d.cfg.Period == digest.PeriodWeekly {
+		name = "周报"
+	}
+	return fmt.Sprintf("闲鱼热门商品%s %s", name, now.Format("2006-01-02"))
+}
+
+// DigestCandidates 返回 since 以来有观测的商品（取各商品最新一次观测）
+// 每次生成摘要时读入 crawl 等进程新写入的观测，不依赖服务启动时加载的数据
+func DigestCandidates(store *history.Store, since time.Time) []digest.Item {
+	if store == nil {
+		return nil
+	}
+	var items []digest.Item
+	for _, obs := range store.LatestSince(since) {
+		items = append(items, digest.Item{
+			ItemID:    obs.ItemID,
+			Title:     obs.Title,
+			Price:     obs.PriceValue,
+			WantCount: obs.WantCount,
+			ImageURL:  obs.ImageURL,
+			URL:       BuildDetailURL(obs.ItemID),
+		})
+	}
+	return items
+}
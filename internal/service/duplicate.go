@@ -0,0 +1,83 @@
+package service
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/duplicate"
+	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/media"
+)
+
+// NewDuplicateDetector 根据配置创建重复商品检测器，未启用时返回 nil
+// 配置了 index_path 时加载历史商品索引，新商品也与之前检测过的商品比较；图片来源在检测时由 DuplicateSource 确定
+func NewDuplicateDetector(cfg config.DupConfig) *duplicate.Detector {
+	if !cfg.Enabled {
+		return nil
+	}
+	detector := duplicate.NewDetector(nil, duplicate.Options{
+		MaxHashDistance: cfg.MaxHashDistance,
+		TitleSimilarity: cfg.TitleSimilarity,
+	})
+	if cfg.IndexPath != "" {
+		index, err := duplicate.LoadIndex(cfg.IndexPath, cfg.GetIndexRetention())
+		if err != nil {
+			log.Printf("加载重复检测索引失败，本次只比较本批商品: %v", err)
+		} else {
+			detector.WithIndex(index)
+		}
+	}
+	return detector
+}
+
+// DuplicateSource 重复检测的图片来源（不访问网络），应在本次图片归档完成后调用：
+// 本次运行的媒体归档清单包含刚下载的图片；image_dir 为媒体归档目录时重新加载其清单
+// （归档文件按内容哈希命名，只能通过清单查找），否则按图片URL文件名在 image_dir 中查找
+func DuplicateSource(cfg config.DupConfig, archive *MediaArchive) duplicate.ImageSource {
+	var sources duplicate.Sources
+	if manifest := archive.Manifest(); manifest != nil {
+		sources = append(sources, manifest)
+	}
+	if cfg.ImageDir != "" {
+		if _, err := os.Stat(filepath.Join(cfg.ImageDir, media.ManifestFile)); err != nil {
+			sources = append(sources, duplicate.DirSource{Dir: cfg.ImageDir})
+		} else if manifest, err := media.LoadManifest(cfg.ImageDir); err != nil {
+			log.Printf("加载媒体清单失败，已跳过 %s 中的图片: %v", cfg.ImageDir, err)
+		} else {
+			sources = append(sources, manifest)
+		}
+	}
+	return sources
+}
+
+// ApplyDuplicates 检测重复商品并写入簇ID与簇大小（detector 为 nil 时原样返回）
+// source 为本次检测的图片来源，检测后保存历史商品索引
+func ApplyDuplicates(detector *duplicate.Detector, source duplicate.ImageSource, products []feishu.Product) []feishu.Product {
+	if detector == nil || len(products) == 0 {
+		return products
+	}
+
+	listings := make([]duplicate.Listing, len(products))
+	for i, p := range products {
+		images := append([]string{p.CoverURL}, p.ImageList...)
+		listings[i] = duplicate.Listing{
+			ItemID:    p.ItemID,
+			Title:     p.Title,
+			SellerID:  p.SellerNick,
+			ImageURLs: images,
+		}
+	}
+
+	detector.SetSource(source)
+	result := detector.Detect(listings)
+	if err := detector.Index().Save(); err != nil {
+		log.Printf("保存重复检测索引失败: %v", err)
+	}
+	for i := range products {
+		products[i].DupCluster = result.ClusterOf[products[i].ItemID]
+		products[i].DupCount = result.Size(products[i].ItemID)
+	}
+	return products
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/notify"
+	"xianyu_aner/pkg/util"
+)
+
+// FeishuMessenger 飞书群消息卡片通道：自定义机器人 webhook 或应用机器人 im/v1/messages
+// 实现 notify.Notifier，可加入通知路由器
+type FeishuMessenger struct {
+	name    string
+	bot     *feishu.BotWebhook // webhook 方式
+	client  *feishu.Client     // 应用机器人方式
+	chatID  string
+	summary bool
+	actions bool // 卡片是否显示操作按钮
+}
+
+// NewFeishuMessenger 根据通道配置创建飞书消息通道
+// 配置了 webhook 时使用自定义机器人，否则以应用凭证向 chat_id 发送
+func NewFeishuMessenger(ch config.NotifyChannelConfig, fsCfg config.FeishuConfig) (*FeishuMessenger, error) {
+	m := &FeishuMessenger{name: ch.Name, chatID: ch.ChatID, summary: ch.Summary, actions: ch.Actions}
+	if m.name == "" {
+		m.name = "feishu"
+	}
+	switch {
+	case ch.Webhook != "":
+		m.bot = feishu.NewBotWebhook(ch.Webhook, ch.Secret)
+	case ch.ChatID != "":
+		if fsCfg.AppID == "" || fsCfg.AppSecret == "" {
+			return nil, fmt.Errorf("飞书通道 %s 通过 chat_id 发送需配置 app_id 与 app_secret", m.name)
+		}
+		m.client = feishu.NewClient(feishu.ClientConfig{AppID: fsCfg.AppID, AppSecret: fsCfg.AppSecret})
+	default:
+		return nil, fmt.Errorf("飞书通道 %s 需配置 webhook 或 chat_id", m.name)
+	}
+	return m, nil
+}
+
+// Name 通道名称
+func (m *FeishuMessenger) Name() string { return m.name }
+
+// Send 以商品卡片发送提醒
+func (m *FeishuMessenger) Send(ctx context.Context, alert notify.Alert) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return m.SendCard(feishu.ItemCard(m.itemCardData(alert)))
+}
+
+// SendCard 发送任意卡片
+func (m *FeishuMessenger) SendCard(card *feishu.Card) error {
+	if m.bot != nil {
+		return m.bot.SendCard(card)
+	}
+	_, err := m.client.SendCard(feishu.ReceiveIDChat, m.chatID, card)
+	return err
+}
+
+// itemCardData 将提醒转换为卡片数据；应用机器人方式会上传封面图，失败时以链接展示
+func (m *FeishuMessenger) itemCardData(a notify.Alert) feishu.ItemCardData {
+	d := feishu.ItemCardData{
+		Heading:    a.KindLabel(),
+		ItemID:     a.ItemID,
+		Title:      a.Title,
+		Price:      a.Price,
+		OldPrice:   a.OldPrice,
+		WantCount:  a.WantCount,
+		SellerNick: a.Seller,
+		RiskLevel:  a.RiskLevel,
+		Reason:     a.Reason,
+		DetailURL:  a.URL,
+		ImageURL:   a.ImageURL,
+	}
+	if a.Source != "" {
+		d.Footer = "来源: " + a.Source
+	}
+	if m.client != nil && a.ImageURL != "" {
+		key, err := m.client.UploadImageURL(a.ImageURL)
+		if err != nil {
+			log.Printf("上传封面图失败，以链接展示: %v", err)
+		} else {
+			d.ImageKey = key
+		}
+	}
+	if m.actions && a.ItemID != "" {
+		d.Buttons = feishu.ItemActionButtons(d)
+	}
+	return d
+}
+
+// SendCrawlSummary 向配置了 summary 的飞书通道发送爬取汇总卡片（热门商品按想要人数排序）
+func SendCrawlSummary(cfg config.Config, result *Result, items []mtop.FeedItem) {
+	var targets []*FeishuMessenger
+	for _, ch := range cfg.Notify.Channels {
+		if ch.Type != "feishu" || !ch.Summary {
+			continue
+		}
+		m, err := NewFeishuMessenger(ch, cfg.Feishu)
+		if err != nil {
+			log.Printf("飞书汇总通道初始化失败: %v", err)
+			continue
+		}
+		targets = append(targets, m)
+	}
+	if len(targets) == 0 || result == nil {
+		return
+	}
+
+	evaluator, err := NewRiskEvaluator(cfg.Risk)
+	if err != nil {
+		log.Printf("卖家风险评估初始化失败，汇总卡片不显示风险: %v", err)
+	}
+	card := feishu.SummaryCard(BuildSummaryCardData(result, items, cfg.Notify.SummaryTop, FeedRiskLevels(evaluator, items, nil)))
+	for _, m := range targets {
+		if err := m.SendCard(card); err != nil {
+			log.Printf("发送爬取汇总到 %s 失败: %v", m.Name(), err)
+		} else {
+			util.Printf("[飞书] 已发送爬取汇总到 %s\n", m.Name())
+		}
+	}
+}
+
+// BuildSummaryCardData 构建爬取汇总卡片数据，riskLevels 为 商品ID -> 卖家风险等级（可为 nil）
+func BuildSummaryCardData(result *Result, items []mtop.FeedItem, top int, riskLevels map[string]string) feishu.SummaryCardData {
+	stats := []feishu.CardField{
+		{Label: "爬取商品数", Value: fmt.Sprintf("%d", result.TotalItems), Short: true},
+		{Label: "耗时", Value: fmt.Sprintf("%.1f 秒", result.Duration.Seconds()), Short: true},
+	}
+	if result.DealCount > 0 {
+		stats = append(stats, feishu.CardField{Label: "低价商品数", Value: fmt.Sprintf("%d", result.DealCount), Short: true})
+	}
+	if result.MediaCount > 0 {
+		stats = append(stats, feishu.CardField{Label: "归档媒体数", Value: fmt.Sprintf("%d", result.MediaCount), Short: true})
+	}
+
+	hot := make([]mtop.FeedItem, len(items))
+	copy(hot, items)
+	sort.SliceStable(hot, func(i, j int) bool { return hot[i].WantCount > hot[j].WantCount })
+	if top > 0 && len(hot) > top {
+		hot = hot[:top]
+	}
+	var cardItems []feishu.ItemCardData
+	for _, item := range hot {
+		if item.WantCount == 0 {
+			break
+		}
+		cardItems = append(cardItems, feishu.ItemCardData{
+			ItemID:    item.ItemID,
+			Title:     item.Title,
+			Price:     history.ParsePrice(item.Price),
+			WantCount: item.WantCount,
+			RiskLevel: riskLevels[item.ItemID],
+			DetailURL: BuildDetailURL(item.ItemID),
+		})
+	}
+
+	return feishu.SummaryCardData{
+		Title: fmt.Sprintf("闲鱼爬取汇总 %s", time.Now().Format("2006-01-02 15:04")),
+		Stats: stats,
+		Items: cardItems,
+	}
+}
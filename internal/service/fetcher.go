@@ -6,12 +6,14 @@ import (
 	"os"
 
 	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/history"
 	"xianyu_aner/pkg/mtop"
 )
 
 // Fetcher 数据获取服务（通用，可供 server 和 crawl 使用）
 type Fetcher struct {
-	cfg config.Config
+	cfg     config.Config
+	history *history.Store // 历史快照存储（可选）
 }
 
 // NewFetcher 创建获取服务
@@ -19,6 +21,12 @@ func NewFetcher(cfg config.Config) *Fetcher {
 	return &Fetcher{cfg: cfg}
 }
 
+// WithHistory 设置历史快照存储，每次获取数据后自动记录观测
+func (f *Fetcher) WithHistory(store *history.Store) *Fetcher {
+	f.history = store
+	return f
+}
+
 // InitClient 初始化 MTOP 客户端
 func (f *Fetcher) InitClient() (*mtop.Client, error) {
 	cookieResult, err := mtop.GetCookiesWithBrowser(mtop.BrowserConfig{
@@ -40,12 +48,24 @@ func (f *Fetcher) InitClient() (*mtop.Client, error) {
 
 // Fetch 获取猜你喜欢数据
 func (f *Fetcher) Fetch(mtopClient *mtop.Client, pages, minWant, days int) ([]mtop.FeedItem, error) {
-	return mtopClient.GuessYouLike("", pages, mtop.GuessYouLikeOptions{
+	return f.FetchPages(mtopClient, pages, minWant, days, nil)
+}
+
+// FetchPages 获取猜你喜欢数据，每页解析后调用 onPage（可为 nil）
+func (f *Fetcher) FetchPages(mtopClient *mtop.Client, pages, minWant, days int, onPage func(page int, items []mtop.FeedItem)) ([]mtop.FeedItem, error) {
+	items, err := mtopClient.GuessYouLike("", pages, mtop.GuessYouLikeOptions{
 		MaxPages:     pages,
 		StartPage:    1,
 		MinWantCount: minWant,
 		DaysWithin:   days,
+		OnPage:       onPage,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	RecordFeedItems(f.history, items)
+	return items, nil
 }
 
 // SaveToFile 保存数据到文件
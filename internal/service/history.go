@@ -0,0 +1,38 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+)
+
+// OpenHistory 根据配置打开历史快照存储，未启用时返回 nil
+func OpenHistory(cfg config.HistoryConfig) (*history.Store, error) {
+	if !cfg.Enabled || cfg.Dir == "" {
+		return nil, nil
+	}
+	return history.Open(cfg.Dir)
+}
+
+// RecordFeedItems 记录猜你喜欢商品观测（store 为 nil 时忽略，失败仅记录日志）
+func RecordFeedItems(store *history.Store, items []mtop.FeedItem) {
+	if store == nil || len(items) == 0 {
+		return
+	}
+	if err := store.Record(history.FromFeedItems(items, time.Now())...); err != nil {
+		log.Printf("记录历史快照失败: %v", err)
+	}
+}
+
+// RecordItemDetail 记录商品详情观测（store 为 nil 时忽略，失败仅记录日志）
+func RecordItemDetail(store *history.Store, detail *mtop.ItemDetail) {
+	if store == nil || detail == nil {
+		return
+	}
+	if err := store.Record(history.FromItemDetail(detail, time.Now())); err != nil {
+		log.Printf("记录历史快照失败: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/media"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/util"
+)
+
+// MediaArchive 媒体归档服务（封装归档器与是否下载视频的配置）
+type MediaArchive struct {
+	archiver *media.Archiver
+	videos   bool
+}
+
+// NewMediaArchive 创建媒体归档服务，dir 为空时返回 nil
+// 复用 MTOP 客户端的 HTTP 客户端（代理/超时）与反爬虫延迟
+func NewMediaArchive(cfg config.MediaConfig, dir string, client *mtop.Client) (*MediaArchive, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	opts := media.Options{
+		Concurrency:   cfg.Concurrency,
+		MaxFileBytes:  cfg.GetMaxFileBytes(),
+		MaxTotalBytes: cfg.GetMaxTotalBytes(),
+	}
+	var archiver *media.Archiver
+	var err error
+	if client != nil {
+		opts.Throttle = client.Throttle
+		archiver, err = media.NewArchiver(dir, client.HTTPClient(), opts)
+	} else {
+		archiver, err = media.NewArchiver(dir, nil, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &MediaArchive{archiver: archiver, videos: cfg.Videos}, nil
+}
+
+// Manifest 返回归档清单（archive 为 nil 时返回 nil）
+func (m *MediaArchive) Manifest() *media.Manifest {
+	if m == nil {
+		return nil
+	}
+	return m.archiver.Manifest()
+}
+
+// ArchiveFeedItems 归档猜你喜欢商品的主图/视频（archive 为 nil 时忽略）
+func (m *MediaArchive) ArchiveFeedItems(items []mtop.FeedItem) media.Report {
+	if m == nil {
+		return media.Report{}
+	}
+	return m.archive(media.FeedAssets(items, m.videos))
+}
+
+// ArchiveDetails 归档商品详情的全部图片/视频（archive 为 nil 时忽略）
+func (m *MediaArchive) ArchiveDetails(details []*mtop.ItemDetail) media.Report {
+	if m == nil || len(details) == 0 {
+		return media.Report{}
+	}
+	var assets []media.Asset
+	for _, detail := range details {
+		assets = append(assets, media.DetailAssets(detail, m.videos)...)
+	}
+	return m.archive(assets)
+}
+
+// archive 执行归档，失败仅记录日志
+func (m *MediaArchive) archive(assets []media.Asset) media.Report {
+	report, err := m.archiver.Archive(context.Background(), assets)
+	if err != nil {
+		log.Printf("保存媒体清单失败: %v", err)
+	}
+	util.Printf("媒体归档：新下载 %d 个，已存在 %d 个，失败 %d 个（%.1f MB）\n",
+		report.Downloaded, report.Skipped, report.Failed, float64(report.Bytes)/(1<<20))
+	return report
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/deals"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/notify"
+	"xianyu_aner/pkg/risk"
+	"xianyu_aner/pkg/util"
+	"xianyu_aner/pkg/watch"
+)
+
+// notifyTimeout 单条提醒的发送超时（含限流等待）
+const notifyTimeout = 2 * time.Minute
+
+// NewNotifyRouter 根据配置创建通知路由器，未配置通道时返回 nil
+// fsCfg 提供飞书应用凭证（飞书通道通过 chat_id 发送时使用）
+func NewNotifyRouter(cfg config.NotifyConfig, fsCfg config.FeishuConfig) (*notify.Router, error) {
+	if len(cfg.Channels) == 0 {
+		return nil, nil
+	}
+	muted, err := notify.LoadMuteList(cfg.MutePath)
+	if err != nil {
+		return nil, err
+	}
+	router := notify.NewRouter(notify.RouterOptions{DedupTTL: cfg.GetDedupTTL(), DedupPath: cfg.DedupPath, Muted: muted})
+	for _, ch := range cfg.Channels {
+		tmpl, err := notify.ParseTemplate(ch.Template)
+		if err != nil {
+			return nil, fmt.Errorf("通知通道 %s: %w", ch.Name, err)
+		}
+		var n notify.Notifier
+		switch ch.Type {
+		case "dingtalk":
+			n = notify.NewDingTalk(ch.Name, ch.Webhook, ch.Secret, tmpl)
+		case "wecom":
+			n = notify.NewWeCom(ch.Name, ch.Webhook, tmpl)
+		case "slack":
+			n = notify.NewSlack(ch.Name, ch.Webhook, tmpl)
+		case "feishu":
+			if n, err = NewFeishuMessenger(ch, fsCfg); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("未知的通知通道类型: %s", ch.Type)
+		}
+		router.Add(n, notify.Route{Kinds: ch.Kinds, RatePerMinute: ch.RatePerMinute})
+	}
+	// 去重记录读取失败时仍可发送，只是可能重复提醒
+	if err := router.LoadDedup(); err != nil {
+		log.Printf("读取通知去重记录失败，本次仅在内存中去重: %v", err)
+	}
+	return router, nil
+}
+
+// AlertFromDeal 将低价商品转换为提醒，item 用于补充想要人数与封面图，riskLevel 为卖家风险等级（未评估时为空）
+func AlertFromDeal(d deals.Deal, item mtop.FeedItem, riskLevel string) notify.Alert {
+	return notify.Alert{
+		Kind:      notify.KindDeal,
+		ItemID:    d.ItemID,
+		Title:     d.Title,
+		Price:     d.Price,
+		WantCount: item.WantCount,
+		Seller:    item.SellerNick,
+		RiskLevel: riskLevel,
+		URL:       BuildDetailURL(d.ItemID),
+		ImageURL:  item.ImageURL,
+		Reason:    d.Basis,
+	}
+}
+
+// AlertFromWatchEvent 将关注事件转换为提醒，evaluator 不为 nil 时评估卖家风险
+func AlertFromWatchEvent(e watch.Event, evaluator *risk.Evaluator) notify.Alert {
+	return notify.Alert{
+		Kind:      string(e.Type),
+		ItemID:    e.ItemID,
+		Title:     e.Title,
+		Price:     e.Price,
+		OldPrice:  e.OldPrice,
+		Seller:    e.Seller,
+		RiskLevel: WatchEventRiskLevel(evaluator, e),
+		URL:       BuildDetailURL(e.ItemID),
+		ImageURL:  e.ImageURL,
+		Source:    e.EntryName,
+		At:        e.Time(),
+	}
+}
+
+// NotifyDeals 推送低价商品提醒，router 为 nil 时不做任何事；evaluator 不为 nil 时提醒附带卖家风险等级
+func NotifyDeals(router *notify.Router, evaluator *risk.Evaluator, found []deals.Deal, items []mtop.FeedItem) notify.Report {
+	if router == nil || len(found) == 0 {
+		return notify.Report{}
+	}
+	byID := make(map[string]mtop.FeedItem, len(items))
+	for _, item := range items {
+		byID[item.ItemID] = item
+	}
+	levels := FeedRiskLevels(evaluator, items, found)
+	alerts := make([]notify.Alert, 0, len(found))
+	for _, d := range found {
+		alerts = append(alerts, AlertFromDeal(d, byID[d.ItemID], levels[d.ItemID]))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout*time.Duration(len(alerts)))
+	defer cancel()
+	report := router.NotifyAll(ctx, alerts)
+	logNotifyReport("低价提醒", report)
+	return report
+}
+
+// NotifyWatchEvents 将关注事件转发到通知路由器，watcher 或 router 为 nil 时不做任何事
+func NotifyWatchEvents(watcher *watch.Watcher, router *notify.Router, evaluator *risk.Evaluator) {
+	if watcher == nil || router == nil {
+		return
+	}
+	watcher.OnEvent(func(e watch.Event) {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		report := router.Notify(ctx, AlertFromWatchEvent(e, evaluator))
+		for name, msg := range report.Errors {
+			log.Printf("关注提醒发送失败 [%s]: %s", name, msg)
+		}
+	})
+}
+
+// logNotifyReport 打印通知发送统计
+func logNotifyReport(label string, report notify.Report) {
+	util.Printf("[%s] 已发送 %d 条，去重 %d 条，失败 %d 条\n", label, report.Sent, report.Deduped, report.Failed)
+	for name, msg := range report.Errors {
+		log.Printf("%s发送失败 [%s]: %s", label, name, msg)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/postgres"
+	"xianyu_aner/pkg/util"
+)
+
+// postgresSink crawl 输出到 PostgreSQL：每页解析后立即批量写入，
+// details 不为 nil 时获取商品详情（与其他输出目标共享），补充收藏数并写入 SKU 表
+type postgresSink struct {
+	opts    postgres.Options
+	details *DetailCache
+	store   *postgres.Store
+	result  postgres.Result
+}
+
+func (s *postgresSink) Streaming() bool { return true }
+
+func (s *postgresSink) Open(ctx context.Context) error {
+	store, err := postgres.Open(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.store = store
+	return nil
+}
+
+func (s *postgresSink) Write(ctx context.Context, items []mtop.FeedItem) error {
+	at := time.Now()
+	res, err := s.store.UpsertItems(ctx, items, at)
+	if err != nil {
+		return err
+	}
+	s.result.Inserted += res.Inserted
+	s.result.Updated += res.Updated
+	s.result.Observations += res.Observations
+	if s.details == nil {
+		return nil
+	}
+
+	// 详情写入同一采集时间的商品与观测（覆盖而不新增），只累计 SKU 数
+	res, err = s.store.UpsertDetails(ctx, s.details.FetchItems(items), at)
+	if err != nil {
+		return err
+	}
+	s.result.SKUs += res.SKUs
+	return nil
+}
+
+func (s *postgresSink) Flush(ctx context.Context) error { return nil }
+
+// Abort 已写入的数据保留，只关闭连接
+func (s *postgresSink) Abort() error {
+	s.store.Close()
+	return nil
+}
+
+func (s *postgresSink) Close() error {
+	s.store.Close()
+	util.Printf("PostgreSQL: 新增商品 %d，更新 %d，观测 %d，SKU %d\n",
+		s.result.Inserted, s.result.Updated, s.result.Observations, s.result.SKUs)
+	return nil
+}
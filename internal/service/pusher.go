@@ -2,11 +2,19 @@ package service
 
 import (
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/deals"
+	"xianyu_aner/pkg/duplicate"
+	"xianyu_aner/pkg/entity"
 	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/history"
 	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/risk"
+	"xianyu_aner/pkg/scoring"
 	"xianyu_aner/pkg/util"
 )
 
@@ -14,73 +22,340 @@ import (
 type Pusher struct {
 	cfg       config.Config
 	converter *Converter
+	extractor *entity.Extractor   // 实体提取器（未启用时为 nil）
+	history   *history.Store      // 历史快照存储（可选）
+	scorer    *scoring.Engine     // 热度评分引擎（初始化失败时为 nil）
+	deals     []deals.Deal        // 低价检测结果（可选）
+	risk      *risk.Evaluator     // 卖家风险评估器（未启用时为 nil）
+	dup       *duplicate.Detector // 重复商品检测器（未启用时为 nil）
+	media     *MediaArchive       // 媒体归档（可选）
+	dedup     *feishu.DedupCache  // 本地去重索引（未启用或加载失败时为 nil）
 }
 
 // NewPusher 创建推送服务
 func NewPusher(cfg config.Config) *Pusher {
+	extractor, err := NewEntityExtractor(cfg.Entity)
+	if err != nil {
+		log.Printf("实体识别初始化失败，已跳过: %v", err)
+	}
+	scorer, err := NewScoringEngine(cfg.Scoring)
+	if err != nil {
+		log.Printf("热度评分初始化失败，已跳过: %v", err)
+	}
+	riskEvaluator, err := NewRiskEvaluator(cfg.Risk)
+	if err != nil {
+		log.Printf("卖家风险评估初始化失败，已跳过: %v", err)
+	}
+	var dedup *feishu.DedupCache
+	if cfg.Feishu.DedupCachePath != "" {
+		if dedup, err = feishu.LoadDedupCache(cfg.Feishu.DedupCachePath, cfg.Feishu.GetDedupCacheRetention()); err != nil {
+			log.Printf("加载本地去重索引失败，本次逐个查询飞书: %v", err)
+		}
+	}
 	return &Pusher{
 		cfg:       cfg,
 		converter: NewConverter(),
+		extractor: extractor,
+		scorer:    scorer,
+		risk:      riskEvaluator,
+		dup:       NewDuplicateDetector(cfg.Dup),
+		dedup:     dedup,
+	}
+}
+
+// WithHistory 设置历史快照存储，获取详情后自动记录观测
+func (p *Pusher) WithHistory(store *history.Store) *Pusher {
+	p.history = store
+	return p
+}
+
+// WithDeals 设置低价检测结果，启用 deals.feishu_column 时写入飞书
+func (p *Pusher) WithDeals(found []deals.Deal) *Pusher {
+	p.deals = found
+	return p
+}
+
+// WithMedia 设置媒体归档，获取详情后归档商品全部图片
+func (p *Pusher) WithMedia(archive *MediaArchive) *Pusher {
+	p.media = archive
+	return p
+}
+
+// Extractor 返回实体提取器（未启用时为 nil）
+func (p *Pusher) Extractor() *entity.Extractor {
+	return p.extractor
+}
+
+// NewEntityExtractor 根据配置创建实体提取器，未启用时返回 nil
+func NewEntityExtractor(cfg config.EntityConfig) (*entity.Extractor, error) {
+	if !cfg.Enabled || cfg.DictPath == "" {
+		return nil, nil
+	}
+	dict, err := entity.LoadDictionary(cfg.DictPath)
+	if err != nil {
+		return nil, err
+	}
+	return entity.NewExtractor(dict), nil
+}
+
+// Push 推送数据到飞书（四阶段流程），商品详情通过 details 获取（与其他输出目标共享）
+func (p *Pusher) Push(details *DetailCache, items []mtop.FeedItem) error {
+	bitableService, err := p.bitableService()
+	if err != nil {
+		return err
+	}
+
+	// 先重新推送上次失败的商品，失败时保留在续传文件中，不影响本次推送
+	p.resumePending(bitableService)
+
+	// 执行四阶段推送流程
+	return p.executeFourStagePush(details, bitableService, items)
+}
+
+// resumePending 重新推送续传文件中的商品，仍然失败的商品写回续传文件
+// 记录了 client_token 的批次沿用原 token 单批推送，原请求实际已写入时飞书不会重复创建。
+// 推送期间不持有文件锁：完成后在锁内移除已处理的记录并追加仍然失败的商品，期间其他进程追加的记录不受影响
+func (p *Pusher) resumePending(bitableService *feishu.BitableService) {
+	path := p.cfg.Feishu.ResumePath
+	if path == "" {
+		return
+	}
+	pending, err := feishu.UpdatePendingPushes(path, func(pending []feishu.PendingPush) []feishu.PendingPush { return pending })
+	if err != nil {
+		log.Printf("读取续传文件失败，已跳过: %v", err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	util.Printf("\n[续传] 重新推送上次失败的 %d 组商品...\n", len(pending))
+	done := make(map[string]bool, len(pending))
+	var remaining []feishu.PendingPush
+	for _, entry := range pending {
+		done[entry.ID] = true
+		date, err := time.ParseInLocation("2006-01-02", entry.Date, time.Local)
+		if err != nil {
+			log.Printf("[续传] 日期无效，已丢弃 %d 条商品: %q", len(entry.Products), entry.Date)
+			continue
+		}
+		var resp *feishu.PushToBitableResponse
+		if entry.ClientToken != "" {
+			resp, err = bitableService.PushChunkToDateTable(date, entry.ClientToken, entry.Products)
+		} else {
+			resp, err = bitableService.PushProductsToDateTable(date, entry.Products)
+		}
+		if err != nil {
+			util.Printf("[续传] %s 推送失败: %v\n", entry.Date, err)
+			remaining = append(remaining, pendingEntries(entry.Date, entry.Products, resp, err)...)
+			continue
+		}
+		util.Printf("[续传] %s 推送成功：创建 %d 条，更新 %d 条\n", entry.Date, resp.Data.RecordsCreated, resp.Data.RecordsUpdated)
+	}
+
+	_, err = feishu.UpdatePendingPushes(path, func(current []feishu.PendingPush) []feishu.PendingPush {
+		kept := make([]feishu.PendingPush, 0, len(current)+len(remaining))
+		for _, entry := range current {
+			if !done[entry.ID] {
+				kept = append(kept, entry)
+			}
+		}
+		return append(kept, remaining...)
+	})
+	if err != nil {
+		log.Printf("写入续传文件失败: %v", err)
+	}
+}
+
+// savePending 将推送失败的商品追加到续传文件，返回写入的商品数（未启用续传或写入失败时返回 0）
+func (p *Pusher) savePending(date time.Time, products []feishu.Product, resp *feishu.PushToBitableResponse, pushErr error) int {
+	path := p.cfg.Feishu.ResumePath
+	entries := pendingEntries(date.Format("2006-01-02"), products, resp, pushErr)
+	if path == "" || len(entries) == 0 {
+		return 0
+	}
+	_, err := feishu.UpdatePendingPushes(path, func(pending []feishu.PendingPush) []feishu.PendingPush {
+		return append(pending, entries...)
+	})
+	if err != nil {
+		log.Printf("写入续传文件失败: %v", err)
+		return 0
+	}
+	n := 0
+	for _, entry := range entries {
+		n += len(entry.Products)
+	}
+	return n
+}
+
+// pendingEntries 将推送失败的商品整理为续传记录：失败的批次各自一条并记录其 client_token，
+// 不属于任何批次的失败商品（如缺少记录ID）合为一条；整体失败时全部商品合为一条
+func pendingEntries(date string, products []feishu.Product, resp *feishu.PushToBitableResponse, pushErr error) []feishu.PendingPush {
+	now := time.Now()
+	failedIDs := feishu.FailedItemIDs(pushErr)
+	if failedIDs == nil {
+		if len(products) == 0 {
+			return nil
+		}
+		return []feishu.PendingPush{{Date: date, Error: pushErr.Error(), FailedAt: now, Products: products}}
+	}
+
+	// 同一商品ID可能有多条（价格/想要人数不同），按出现顺序逐条分配
+	queue := make(map[string][]feishu.Product)
+	for _, product := range products {
+		if failedIDs[product.ItemID] {
+			queue[product.ItemID] = append(queue[product.ItemID], product)
+		}
+	}
+	take := func(id string) (feishu.Product, bool) {
+		q := queue[id]
+		if len(q) == 0 {
+			return feishu.Product{}, false
+		}
+		queue[id] = q[1:]
+		return q[0], true
+	}
+
+	var entries []feishu.PendingPush
+	if resp != nil {
+		for _, chunk := range resp.Data.Chunks {
+			if chunk.Error == "" {
+				continue
+			}
+			entry := feishu.PendingPush{Date: date, Error: chunk.Error, FailedAt: now, ClientToken: chunk.ClientToken}
+			for _, id := range chunk.ItemIDs {
+				if product, ok := take(id); ok {
+					entry.Products = append(entry.Products, product)
+				}
+			}
+			if len(entry.Products) > 0 {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	var rest []feishu.Product
+	for _, product := range products {
+		if p, ok := take(product.ItemID); ok {
+			rest = append(rest, p)
+		}
 	}
+	if len(rest) > 0 {
+		entries = append(entries, feishu.PendingPush{Date: date, Error: pushErr.Error(), FailedAt: now, Products: rest})
+	}
+	return entries
 }
 
-// Push 推送数据到飞书（四阶段流程）
-func (p *Pusher) Push(mtopClient *mtop.Client, items []mtop.FeedItem) error {
+// ReconcileDedup 全量读取指定日期的飞书表格，重建本地去重索引，返回索引中的记录数
+// 表格不存在时不做处理
+func (p *Pusher) ReconcileDedup(date time.Time) (int, error) {
+	if p.dedup == nil {
+		return 0, fmt.Errorf("未启用本地去重索引（feishu.dedup_cache_path）")
+	}
+	bitableService, err := p.bitableService()
+	if err != nil {
+		return 0, err
+	}
+	tableID, err := bitableService.GetTableByDate(date)
+	if err != nil || tableID == "" {
+		return 0, err
+	}
+	return bitableService.ReconcileDedupCache(tableID)
+}
+
+// bitableService 创建飞书多维表格服务
+func (p *Pusher) bitableService() (*feishu.BitableService, error) {
 	if p.cfg.Feishu.AppID == "" || p.cfg.Feishu.AppSecret == "" {
-		return fmt.Errorf("缺少飞书配置（app_id 或 app_secret）")
+		return nil, fmt.Errorf("缺少飞书配置（app_id 或 app_secret）")
 	}
 
 	// 创建飞书客户端
 	fsClient := feishu.NewClient(feishu.ClientConfig{
 		AppID:     p.cfg.Feishu.AppID,
 		AppSecret: p.cfg.Feishu.AppSecret,
+		BatchSize: p.cfg.Feishu.BatchSize,
 	})
 
 	bitableConfig := feishu.BitableConfig{
-		AppToken:   p.cfg.Feishu.AppToken,
-		TableToken: p.cfg.Feishu.TableToken,
+		AppToken:          p.cfg.Feishu.AppToken,
+		TableToken:        p.cfg.Feishu.TableToken,
+		SearchBatchSize:   p.cfg.Feishu.DedupBatchSize,
+		SearchConcurrency: p.cfg.Feishu.DedupConcurrency,
+		Upsert:            p.cfg.Feishu.Upsert,
 	}
-	bitableService := feishu.NewBitableService(fsClient, bitableConfig)
-
-	// 执行四阶段推送流程
-	return p.executeFourStagePush(mtopClient, bitableService, items)
+	return feishu.NewBitableService(fsClient, bitableConfig).WithDedupCache(p.dedup), nil
 }
 
-func (p *Pusher) executeFourStagePush(mtopClient *mtop.Client, bitableService *feishu.BitableService, items []mtop.FeedItem) error {
+func (p *Pusher) executeFourStagePush(details *DetailCache, bitableService *feishu.BitableService, items []mtop.FeedItem) error {
 	deduplicator := NewDeduplicator()
 
 	// 阶段1：转换为基础产品结构
-	fmt.Println("\n[阶段1/4] 转换为基础产品结构（去重用）...")
+	util.Println("\n[阶段1/4] 转换为基础产品结构（去重用）...")
 	basicProducts := p.converter.FeedItemsToBasicProducts(items)
-	fmt.Printf("转换完成：%d 条基础记录\n", len(basicProducts))
+	util.Printf("转换完成：%d 条基础记录\n", len(basicProducts))
 
 	// 阶段2：去重查询
-	fmt.Println("\n[阶段2/4] 查询飞书表格进行去重...")
+	util.Println("\n[阶段2/4] 查询飞书表格进行去重...")
 	uniqueProducts, err := p.deduplicate(bitableService, deduplicator, basicProducts)
 	if err != nil {
 		return fmt.Errorf("去重失败: %w", err)
 	}
 
 	if len(uniqueProducts) == 0 {
-		fmt.Println("\n[完成] 没有新数据需要推送")
+		util.Println("\n[完成] 没有新数据需要推送")
 		return nil
 	}
 
 	// 阶段3：获取详情
-	fmt.Println("\n[阶段3/4] 获取商品详情...")
-	finalProducts := p.enrichDetails(mtopClient, uniqueProducts)
+	util.Println("\n[阶段3/4] 获取商品详情...")
+	finalProducts := p.enrichDetails(details, uniqueProducts)
+	finalProducts = ApplyScores(p.scorer, p.history, p.cfg.Scoring.GetVelocityWindow(), finalProducts)
+	if p.cfg.Deals.FeishuColumn {
+		finalProducts = ApplyDeals(finalProducts, p.deals)
+	}
+	finalProducts = ApplyRisk(p.risk, finalProducts, p.deals)
+	if p.dup != nil {
+		// 图片来源在详情图片归档之后确定，包含本次刚下载的图片
+		finalProducts = ApplyDuplicates(p.dup, DuplicateSource(p.cfg.Dup, p.media), finalProducts)
+	}
 
 	// 阶段4：推送到飞书
-	fmt.Println("\n[阶段4/4] 推送到飞书...")
-	resp, err := bitableService.PushProductsToDateTable(time.Now(), finalProducts)
+	util.Println("\n[阶段4/4] 推送到飞书...")
+	now := time.Now()
+	resp, err := bitableService.PushProductsToDateTable(now, finalProducts)
 	if err != nil {
+		if resp != nil {
+			printChunkResults(resp)
+		}
+		if n := p.savePending(now, finalProducts, resp, err); n > 0 {
+			return fmt.Errorf("%w（%d 条商品已写入续传文件 %s，下次推送时重试）", err, n, p.cfg.Feishu.ResumePath)
+		}
 		return err
 	}
 
-	fmt.Printf("推送成功！创建记录数: %d\n", resp.Data.RecordsCreated)
+	if p.cfg.Feishu.Upsert {
+		// 阶段2中价格与想要人数均未变化的商品也计入未变化
+		util.Printf("推送成功！新增 %d 条，更新 %d 条，未变化 %d 条\n", resp.Data.RecordsCreated, resp.Data.RecordsUpdated,
+			resp.Data.RecordsUnchanged+len(basicProducts)-len(uniqueProducts))
+		return nil
+	}
+	util.Printf("推送成功！创建记录数: %d\n", resp.Data.RecordsCreated)
 	return nil
 }
 
+// printChunkResults 输出分批推送的各批结果
+func printChunkResults(resp *feishu.PushToBitableResponse) {
+	util.Printf("推送部分失败：创建 %d 条，更新 %d 条，失败 %d 条\n",
+		resp.Data.RecordsCreated, resp.Data.RecordsUpdated, resp.Data.RecordsFailed)
+	for _, chunk := range resp.Data.Chunks {
+		if chunk.Error == "" {
+			util.Printf("  第 %d 批：%d 条，成功\n", chunk.Index+1, chunk.Records)
+			continue
+		}
+		util.Printf("  第 %d 批：%d 条，失败: %s\n", chunk.Index+1, chunk.Records, chunk.Error)
+		util.Printf("    失败商品ID: %s\n", strings.Join(chunk.ItemIDs, ", "))
+	}
+}
+
 func (p *Pusher) deduplicate(bitableService *feishu.BitableService, deduplicator *Deduplicator, products []feishu.Product) ([]feishu.Product, error) {
 	today := time.Now()
 	tableID, created, err := bitableService.GetOrCreateTableByDate(today)
@@ -95,33 +370,37 @@ func (p *Pusher) deduplicate(bitableService *feishu.BitableService, deduplicator
 		if err != nil {
 			return nil, err
 		}
-		fmt.Printf("去重完成：%d 条新记录（过滤掉 %d 条重复记录）\n",
+		util.Printf("去重完成：%d 条新记录（过滤掉 %d 条重复记录）\n",
 			len(uniqueProducts), len(products)-len(uniqueProducts))
 	} else {
 		// 新创建的表格，全部保留
 		uniqueProducts = products
-		fmt.Println("新表格创建，保留所有记录")
+		util.Println("新表格创建，保留所有记录")
 	}
 
 	return uniqueProducts, nil
 }
 
-func (p *Pusher) enrichDetails(mtopClient *mtop.Client, products []feishu.Product) []feishu.Product {
+func (p *Pusher) enrichDetails(cache *DetailCache, products []feishu.Product) []feishu.Product {
 	finalProducts := make([]feishu.Product, 0, len(products))
+	var details []*mtop.ItemDetail
 
 	for i, basic := range products {
-		fmt.Printf("[处理 %d/%d] 正在获取详情: %s (ID: %s)...\n",
+		util.Printf("[处理 %d/%d] 正在获取详情: %s (ID: %s)...\n",
 			i+1, len(products), util.TruncateString(basic.Title, 30), basic.ItemID)
 
-		detail, err := mtopClient.FetchItemDetail(basic.ItemID)
+		detail, err := cache.Fetch(basic.ItemID)
 		if err != nil {
-			fmt.Printf("[失败 %d/%d] 获取详情失败，使用基础数据: %v\n", i+1, len(products), err)
-			finalProducts = append(finalProducts, basic)
+			util.Printf("[失败 %d/%d] 获取详情失败，使用基础数据: %v\n", i+1, len(products), err)
+			finalProducts = append(finalProducts, p.converter.ApplyEntity(basic, p.extractor))
 		} else {
+			RecordItemDetail(p.history, detail)
+			details = append(details, detail)
 			enriched := p.converter.MergeDetailToProduct(basic, detail)
-			finalProducts = append(finalProducts, enriched)
+			finalProducts = append(finalProducts, p.converter.ApplyEntity(enriched, p.extractor))
 		}
 	}
 
+	p.media.ArchiveDetails(details)
 	return finalProducts
 }
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/deals"
+	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/risk"
+	"xianyu_aner/pkg/watch"
+)
+
+// NewRiskEvaluator 根据配置创建卖家风险评估器，未启用时返回 nil
+func NewRiskEvaluator(cfg config.RiskConfig) (*risk.Evaluator, error) {
+	if !cfg.Enabled || cfg.RulesPath == "" {
+		return nil, nil
+	}
+	rules, err := risk.LoadRules(cfg.RulesPath)
+	if err != nil {
+		return nil, err
+	}
+	return risk.NewEvaluator(rules), nil
+}
+
+// ApplyRisk 评估卖家风险并写入产品（evaluator 为 nil 时原样返回）
+// found 为低价检测结果，用于"远低于市场价"类规则
+func ApplyRisk(evaluator *risk.Evaluator, products []feishu.Product, found []deals.Deal) []feishu.Product {
+	if evaluator == nil || len(products) == 0 {
+		return products
+	}
+
+	discounts := dealDiscounts(found)
+	subjects := make([]risk.Subject, len(products))
+	for i, p := range products {
+		subjects[i] = risk.Subject{
+			ItemID:          p.ItemID,
+			Title:           p.Title,
+			Description:     p.Description,
+			Price:           history.ParsePrice(p.Price),
+			SellerNick:      p.SellerNick,
+			SellerCredit:    p.SellerCredit,
+			SellerRegDays:   p.SellerRegDays,
+			SellerItemCount: p.SellerItemCount,
+			SellerSoldCount: p.SellerSoldCount,
+			ShopLevel:       p.ShopLevel,
+			DealDiscount:    discounts[p.ItemID],
+		}
+	}
+	risk.CountIdentical(subjects)
+
+	for i, s := range subjects {
+		a := evaluator.Evaluate(s)
+		products[i].RiskLevel = a.Level
+		products[i].RiskReasons = a.ReasonText()
+	}
+	return products
+}
+
+// FeedRiskLevels 按列表页字段评估卖家风险（用于低价提醒与爬取汇总卡片），返回 商品ID -> 风险等级
+// 列表页没有注册天数、在售数量等详情字段，依赖这些字段的规则不会命中；evaluator 为 nil 时返回 nil
+func FeedRiskLevels(evaluator *risk.Evaluator, items []mtop.FeedItem, found []deals.Deal) map[string]string {
+	if evaluator == nil || len(items) == 0 {
+		return nil
+	}
+
+	discounts := dealDiscounts(found)
+	subjects := make([]risk.Subject, len(items))
+	for i, item := range items {
+		subjects[i] = risk.Subject{
+			ItemID:       item.ItemID,
+			Title:        item.Title,
+			Price:        history.ParsePrice(item.Price),
+			SellerNick:   item.SellerNick,
+			SellerCredit: item.SellerCredit,
+			ShopLevel:    item.ShopLevel,
+			DealDiscount: discounts[item.ItemID],
+		}
+	}
+	risk.CountIdentical(subjects)
+
+	levels := make(map[string]string, len(subjects))
+	for _, s := range subjects {
+		levels[s.ItemID] = evaluator.Evaluate(s).Level
+	}
+	return levels
+}
+
+// WatchEventRiskLevel 按关注事件携带的商品与卖家信息评估卖家风险，evaluator 为 nil 时返回空
+func WatchEventRiskLevel(evaluator *risk.Evaluator, e watch.Event) string {
+	if evaluator == nil || e.Type == watch.EventSold {
+		return ""
+	}
+	return evaluator.Evaluate(risk.Subject{
+		ItemID:       e.ItemID,
+		Title:        e.Title,
+		Price:        e.Price,
+		SellerNick:   e.Seller,
+		SellerCredit: e.SellerCredit,
+	}).Level
+}
+
+// dealDiscounts 低价检测结果：商品ID -> 低于市场价的百分比
+func dealDiscounts(found []deals.Deal) map[string]float64 {
+	discounts := make(map[string]float64, len(found))
+	for _, d := range found {
+		discounts[d.ItemID] = d.DiscountPct
+	}
+	return discounts
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"time"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/scoring"
+)
+
+// NewScoringEngine 根据配置创建热度评分引擎
+func NewScoringEngine(cfg config.ScoringConfig) (*scoring.Engine, error) {
+	return scoring.NewEngine(scoring.Weights(cfg.Weights))
+}
+
+// ScoreFeedItems 计算猜你喜欢商品的热度得分（结果与输入顺序一致）
+// store 不为 nil 时使用历史观测计算想要增长速度
+func ScoreFeedItems(engine *scoring.Engine, store *history.Store, velocityWindow time.Duration, items []mtop.FeedItem) []scoring.Result {
+	signals := make([]scoring.Signals, 0, len(items))
+	for _, item := range items {
+		signals = append(signals, withVelocity(scoring.FromFeedItem(item), store, velocityWindow))
+	}
+	return engine.ScoreAll(signals)
+}
+
+// ApplyScores 计算产品热度得分并写入曝光热度字段（engine 为 nil 时原样返回）
+func ApplyScores(engine *scoring.Engine, store *history.Store, velocityWindow time.Duration, products []feishu.Product) []feishu.Product {
+	if engine == nil || len(products) == 0 {
+		return products
+	}
+	signals := make([]scoring.Signals, 0, len(products))
+	for _, p := range products {
+		signals = append(signals, withVelocity(scoring.FromProduct(p), store, velocityWindow))
+	}
+	for i, r := range engine.ScoreAll(signals) {
+		products[i].ExposureHeat = r.Heat()
+	}
+	return products
+}
+
+// withVelocity 从历史观测补充想要增长速度
+func withVelocity(s scoring.Signals, store *history.Store, window time.Duration) scoring.Signals {
+	if store == nil {
+		return s
+	}
+	if v, ok := store.WantVelocity(s.ItemID, time.Now().Add(-window)); ok {
+		s.WantPerHour = v
+		s.HasVelocity = true
+	}
+	return s
+}
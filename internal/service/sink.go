@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/postgres"
+	"xianyu_aner/pkg/sink"
+)
+
+// NewSinkRegistry 创建输出目标注册表：内置文件输出（json、jsonl、csv、tsv、xlsx、parquet）、SQLite 存储、PostgreSQL，以及飞书多维表格与出站 Webhook
+// mtopClient 用于飞书推送以及 PostgreSQL、Parquet 输出时补充商品详情，各目标共享同一份详情缓存，同一商品只请求一次
+func NewSinkRegistry(cfg config.Config, pusher *Pusher, mtopClient *mtop.Client) *sink.Registry {
+	r := sink.NewRegistry()
+	var details *DetailCache
+	if mtopClient != nil {
+		details = NewDetailCache(mtopClient)
+	}
+	converter := NewConverter()
+	r.Register("jsonl", sink.NewJSONLFactory(converter.FeedItemsToBasicProducts))
+	r.Register("csv", sink.NewCSVFactory(',', converter.FeedItemsToBasicProducts))
+	r.Register("tsv", sink.NewCSVFactory('\t', converter.FeedItemsToBasicProducts))
+	r.Register("xlsx", sink.NewXLSXFactory(converter.FeedItemsToBasicProducts))
+	var fetch sink.DetailFetcher
+	if details != nil {
+		fetch = details.FetchItems
+	}
+	r.Register("parquet", sink.NewParquetFactory(fetch))
+	r.Register("sqlite", func(spec sink.Spec) (sink.Sink, error) {
+		path := spec.Path
+		if path == "" {
+			path = cfg.Storage.Path
+		}
+		return &storageSink{path: path}, nil
+	})
+	r.Register("postgres", func(spec sink.Spec) (sink.Sink, error) {
+		dsn, err := cfg.Postgres.GetDSN(spec.Path)
+		if err != nil {
+			return nil, err
+		}
+		s := &postgresSink{opts: postgres.Options{
+			DSN:       dsn,
+			MaxConns:  cfg.Postgres.MaxConns,
+			BatchSize: cfg.Postgres.BatchSize,
+		}}
+		if spec.Option("details", strconv.FormatBool(cfg.Postgres.Details)) == "true" {
+			if details == nil {
+				return nil, fmt.Errorf("获取商品详情需要 MTOP 客户端")
+			}
+			s.details = details
+		}
+		return s, nil
+	})
+	r.Register("feishu", func(spec sink.Spec) (sink.Sink, error) {
+		if pusher == nil {
+			return nil, fmt.Errorf("飞书推送不可用")
+		}
+		if details == nil {
+			return nil, fmt.Errorf("获取商品详情需要 MTOP 客户端")
+		}
+		return &feishuSink{pusher: pusher, details: details}, nil
+	})
+	r.Register("webhook", func(spec sink.Spec) (sink.Sink, error) {
+		hooks, err := NewWebhooks(cfg.Webhook)
+		if err != nil {
+			return nil, err
+		}
+		if hooks.Len() == 0 {
+			return nil, fmt.Errorf("未配置 webhook.targets")
+		}
+		return &webhookSink{hooks: hooks}, nil
+	})
+	return r
+}
+
+// SinkSpecs 将配置文件中的输出目标转换为 sink.Spec
+func SinkSpecs(list []config.SinkConfig) []sink.Spec {
+	specs := make([]sink.Spec, 0, len(list))
+	for _, c := range list {
+		specs = append(specs, sink.Spec{Type: c.Type, Name: c.Name, Path: c.Path, Options: c.Options})
+	}
+	return specs
+}
+
+// OpenSinks 创建并打开输出目标，创建或打开失败的目标计入统计但不影响其他目标
+func OpenSinks(ctx context.Context, registry *sink.Registry, specs []sink.Spec) *sink.Fanout {
+	fanout := sink.NewFanout()
+	for _, spec := range specs {
+		s, err := registry.New(spec)
+		if err != nil {
+			fanout.AddFailed(spec, err)
+			continue
+		}
+		fanout.Add(spec, s)
+	}
+	fanout.Open(ctx)
+	return fanout
+}
+
+// feishuSink 推送到飞书多维表格（四阶段流程）
+type feishuSink struct {
+	pusher  *Pusher
+	details *DetailCache
+}
+
+func (s *feishuSink) Open(ctx context.Context) error  { return nil }
+func (s *feishuSink) Flush(ctx context.Context) error { return nil }
+func (s *feishuSink) Close() error                    { return nil }
+func (s *feishuSink) Abort() error                    { return nil }
+
+func (s *feishuSink) Write(ctx context.Context, items []mtop.FeedItem) error {
+	return s.pusher.Push(s.details, items)
+}
+
+// webhookSink 推送到出站 Webhook
+type webhookSink struct {
+	hooks *Webhooks
+}
+
+func (s *webhookSink) Open(ctx context.Context) error  { return nil }
+func (s *webhookSink) Flush(ctx context.Context) error { return nil }
+func (s *webhookSink) Close() error                    { return nil }
+func (s *webhookSink) Abort() error                    { return nil }
+
+func (s *webhookSink) Write(ctx context.Context, items []mtop.FeedItem) error {
+	report := s.hooks.DeliverFeedItems(ctx, items)
+	if report.Failed > 0 {
+		return fmt.Errorf("%d/%d 批推送失败（已写入死信文件）: %s", report.Failed, report.Batches, report.Errors[0])
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/storage"
+)
+
+// OpenStorage 根据配置打开 SQLite 存储，未启用时返回 nil
+func OpenStorage(cfg config.StorageConfig) (*storage.DB, error) {
+	if !cfg.Enabled || cfg.Path == "" {
+		return nil, nil
+	}
+	return storage.Open(cfg.Path)
+}
+
+// StoreFeedItems 将一次抓取的商品作为一个批次写入数据库（db 为 nil 时忽略，失败仅记录日志）
+func StoreFeedItems(db *storage.DB, source string, items []mtop.FeedItem) {
+	if db == nil || len(items) == 0 {
+		return
+	}
+	ctx := context.Background()
+	runID, err := db.StartRun(ctx, source)
+	if err != nil {
+		log.Printf("写入数据库失败: %v", err)
+		return
+	}
+	status := storage.RunFinished
+	if _, err := db.UpsertItems(ctx, runID, "feed", items); err != nil {
+		log.Printf("写入数据库失败: %v", err)
+		status = storage.RunFailed
+	}
+	if err := db.FinishRun(ctx, runID, status); err != nil {
+		log.Printf("写入数据库失败: %v", err)
+	}
+}
+
+// StorageHistory 以 SQLite 存储作为历史查询数据源（查询失败时记录日志并返回空结果）
+type StorageHistory struct {
+	DB *storage.DB
+}
+
+// History 商品的全部观测记录
+func (h StorageHistory) History(itemID string) []history.Observation {
+	list, err := h.DB.Observations(context.Background(), itemID, time.Time{}, time.Time{})
+	if err != nil {
+		log.Printf("查询数据库失败: %v", err)
+	}
+	return list
+}
+
+// PriceDrops 自 since 以来降价的商品
+func (h StorageHistory) PriceDrops(since time.Time) []history.PriceChange {
+	drops, err := h.DB.PriceDrops(context.Background(), since)
+	if err != nil {
+		log.Printf("查询数据库失败: %v", err)
+	}
+	return drops
+}
+
+// FastestWantGrowth 自 since 以来想要人数增长最快的商品
+func (h StorageHistory) FastestWantGrowth(since time.Time, limit int) []history.WantGrowth {
+	growth, err := h.DB.FastestWantGrowth(context.Background(), since, limit)
+	if err != nil {
+		log.Printf("查询数据库失败: %v", err)
+	}
+	return growth
+}
+
+// storageSink crawl 输出到 SQLite：每次爬取记为一个批次，每页解析后立即写入
+type storageSink struct {
+	path  string
+	db    *storage.DB
+	runID int64
+}
+
+func (s *storageSink) Streaming() bool { return true }
+
+func (s *storageSink) Open(ctx context.Context) error {
+	db, err := storage.Open(s.path)
+	if err != nil {
+		return err
+	}
+	runID, err := db.StartRun(ctx, "crawl")
+	if err != nil {
+		db.Close()
+		return err
+	}
+	s.db, s.runID = db, runID
+	return nil
+}
+
+func (s *storageSink) Write(ctx context.Context, items []mtop.FeedItem) error {
+	_, err := s.db.UpsertItems(ctx, s.runID, "feed", items)
+	return err
+}
+
+func (s *storageSink) Flush(ctx context.Context) error { return nil }
+
+// Abort 爬取失败：已写入的商品保留，批次记为失败
+func (s *storageSink) Abort() error {
+	return s.finish(storage.RunFailed)
+}
+
+func (s *storageSink) Close() error {
+	return s.finish(storage.RunFinished)
+}
+
+// finish 结束批次并关闭数据库
+func (s *storageSink) finish(status string) error {
+	err := s.db.FinishRun(context.Background(), s.runID, status)
+	if cerr := s.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
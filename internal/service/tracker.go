@@ -0,0 +1,32 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/lifecycle"
+	"xianyu_aner/pkg/mtop"
+)
+
+// NewLifecycleTracker 根据配置创建生命周期跟踪器，未启用时返回 nil
+func NewLifecycleTracker(cfg config.TrackerConfig, fetcher lifecycle.DetailFetcher) (*lifecycle.Tracker, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	schedule := lifecycle.DefaultSchedule()
+	if cfg.MaxAgeDays > 0 {
+		schedule.MaxAge = time.Duration(cfg.MaxAgeDays) * 24 * time.Hour
+	}
+	return lifecycle.NewTracker(fetcher, schedule, cfg.StatePath)
+}
+
+// TrackFeedItems 将猜你喜欢商品加入生命周期跟踪（tracker 为 nil 时忽略）
+func TrackFeedItems(tracker *lifecycle.Tracker, items []mtop.FeedItem) {
+	if tracker == nil || len(items) == 0 {
+		return
+	}
+	if added := tracker.TrackFeedItems(items); added > 0 {
+		log.Printf("新增生命周期跟踪商品 %d 个", added)
+	}
+}
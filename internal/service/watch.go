@@ -0,0 +1,27 @@
+package service
+
+import (
+	"log"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/watch"
+)
+
+// NewWatcher 根据配置创建关注列表调度器，未启用时返回 nil
+// 触发的事件默认写入日志
+func NewWatcher(cfg config.WatchConfig, source watch.Source) (*watch.Watcher, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	watcher, err := watch.NewWatcher(source, cfg.ListPath, cfg.StatePath, watch.Options{
+		Interval: cfg.GetInterval(),
+		Pages:    cfg.Pages,
+	})
+	if err != nil {
+		return nil, err
+	}
+	watcher.OnEvent(func(e watch.Event) {
+		log.Printf("🔔 关注提醒 %s (ID: %s)", e.Summary(), e.ItemID)
+	})
+	return watcher, nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"xianyu_aner/internal/config"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/watch"
+	"xianyu_aner/pkg/webhook"
+)
+
+// Webhook 事件分类（webhook.targets[].events）
+const (
+	WebhookEventCrawl = "crawl" // 爬取结果，事件类型 crawl.items
+	WebhookEventWatch = "watch" // 关注提醒，事件类型 watch.<new_match|price_drop|...>
+)
+
+// 商品数据格式
+const (
+	WebhookShapeProduct = "product" // feishu.Product（与飞书表格字段一致）
+	WebhookShapeRaw     = "raw"     // 原始 mtop.FeedItem
+)
+
+// defaultWebhookBatch 每个请求的默认商品数
+const defaultWebhookBatch = 100
+
+// webhookTimeout 单个事件推送（含重试）的总超时
+const webhookTimeout = 5 * time.Minute
+
+// webhookTarget 推送地址
+type webhookTarget struct {
+	sink      *webhook.Sink
+	shape     string
+	batchSize int
+	events    map[string]bool // 为空表示全部
+}
+
+// Webhooks 出站 Webhook 集合，爬取流程与服务端任务共用
+type Webhooks struct {
+	targets   []webhookTarget
+	converter *Converter
+}
+
+// WebhookReport 推送统计
+type WebhookReport struct {
+	Batches   int // 请求批次数（按推送地址累计）
+	Delivered int // 成功批次数
+	Failed    int // 失败批次数（已写入死信文件）
+	Errors    []string
+}
+
+// NewWebhooks 根据配置创建出站 Webhook，未配置推送地址时返回 nil
+func NewWebhooks(cfg config.WebhookConfig) (*Webhooks, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, nil
+	}
+	deadLetter := webhook.NewDeadLetter(cfg.DeadLetterPath)
+	w := &Webhooks{converter: NewConverter()}
+	for _, t := range cfg.Targets {
+		sink, err := webhook.New(t.URL, webhook.Options{
+			Name:       t.Name,
+			Secret:     t.Secret,
+			Source:     "xianyu_aner",
+			MaxRetries: cfg.MaxRetries,
+			Backoff:    time.Duration(cfg.BackoffSeconds) * time.Second,
+			Timeout:    time.Duration(cfg.TimeoutSeconds) * time.Second,
+			DeadLetter: deadLetter,
+		})
+		if err != nil {
+			return nil, err
+		}
+		target := webhookTarget{sink: sink, shape: t.Shape, batchSize: t.BatchSize}
+		if target.shape == "" {
+			target.shape = WebhookShapeProduct
+		}
+		if target.batchSize <= 0 {
+			target.batchSize = defaultWebhookBatch
+		}
+		if len(t.Events) > 0 {
+			target.events = make(map[string]bool, len(t.Events))
+			for _, e := range t.Events {
+				target.events[e] = true
+			}
+		}
+		w.targets = append(w.targets, target)
+	}
+	return w, nil
+}
+
+// Len 推送地址数量（nil 安全）
+func (w *Webhooks) Len() int {
+	if w == nil {
+		return 0
+	}
+	return len(w.targets)
+}
+
+// DeliverFeedItems 分批推送爬取结果（nil 安全）
+func (w *Webhooks) DeliverFeedItems(ctx context.Context, items []mtop.FeedItem) WebhookReport {
+	var report WebhookReport
+	if w == nil || len(items) == 0 {
+		return report
+	}
+	var products []interface{}
+	for _, t := range w.targets {
+		if t.events != nil && !t.events[WebhookEventCrawl] {
+			continue
+		}
+		if t.shape == WebhookShapeRaw {
+			for _, batch := range webhook.Batches(items, t.batchSize) {
+				report.add(t.sink, deliver(ctx, t.sink, "crawl.items", batch))
+			}
+			continue
+		}
+		if products == nil {
+			for _, p := range w.converter.FeedItemsToBasicProducts(items) {
+				products = append(products, p)
+			}
+		}
+		for _, batch := range webhook.Batches(products, t.batchSize) {
+			report.add(t.sink, deliver(ctx, t.sink, "crawl.items", batch))
+		}
+	}
+	return report
+}
+
+// DeliverWatchEvent 推送关注提醒（nil 安全）
+func (w *Webhooks) DeliverWatchEvent(ctx context.Context, e watch.Event) WebhookReport {
+	var report WebhookReport
+	if w == nil {
+		return report
+	}
+	for _, t := range w.targets {
+		if t.events != nil && !t.events[WebhookEventWatch] {
+			continue
+		}
+		report.add(t.sink, deliver(ctx, t.sink, "watch."+string(e.Type), []watch.Event{e}))
+	}
+	return report
+}
+
+// DeliverWatchEvents 将关注列表触发的事件推送到 Webhook（任一为 nil 时不处理）
+func DeliverWatchEvents(watcher *watch.Watcher, hooks *Webhooks) {
+	if watcher == nil || hooks.Len() == 0 {
+		return
+	}
+	watcher.OnEvent(func(e watch.Event) {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+		defer cancel()
+		report := hooks.DeliverWatchEvent(ctx, e)
+		for _, msg := range report.Errors {
+			log.Printf("关注提醒 Webhook 推送失败: %s", msg)
+		}
+	})
+}
+
+// deliver 推送一批数据
+func deliver[T any](ctx context.Context, sink *webhook.Sink, event string, batch []T) error {
+	_, err := sink.Deliver(ctx, event, batch)
+	return err
+}
+
+// add 累计一批的推送结果
+func (r *WebhookReport) add(sink *webhook.Sink, err error) {
+	r.Batches++
+	if err != nil {
+		r.Failed++
+		r.Errors = append(r.Errors, fmt.Sprintf("[%s] %v", sink.Name(), err))
+		return
+	}
+	r.Delivered++
+}
@@ -0,0 +1,190 @@
+package deals
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Listing 参与比价的商品
+type Listing struct {
+	ItemID     string  `json:"itemId"`
+	Title      string  `json:"title"`
+	CategoryID int     `json:"categoryId,omitempty"`
+	Brand      string  `json:"brand,omitempty"`
+	Model      string  `json:"model,omitempty"`
+	Price      float64 `json:"price"`
+}
+
+// Deal 低于市场价的商品
+type Deal struct {
+	Listing
+	DiscountPct float64    `json:"discountPct"` // 低于同类中位价的百分比
+	Group       GroupStats `json:"group"`       // 比价依据（不含该商品本身）
+	Basis       string     `json:"basis"`       // 可读的比价说明
+}
+
+// Options 检测参数
+type Options struct {
+	Threshold       float64 // 低于中位价的比例阈值，0.3 表示低于中位价 30% 以上
+	MinPeers        int     // 同类商品（不含自身）最少数量，不足时不判定
+	TitleSimilarity float64 // 无品牌型号时按标题归组的最低相似度（0-1）
+}
+
+// DefaultOptions 默认检测参数
+func DefaultOptions() Options {
+	return Options{
+		Threshold:       0.3,
+		MinPeers:        5,
+		TitleSimilarity: 0.5,
+	}
+}
+
+// Analyzer 低价商品分析器
+// 同类商品按"分类ID + 品牌型号"归组；未识别出品牌型号时，在同一分类内按标题相似度归组
+type Analyzer struct {
+	mu     sync.Mutex
+	opts   Options
+	groups []*group
+	byKey  map[string]*group
+	byItem map[string]*group
+}
+
+// NewAnalyzer 创建分析器，未设置的参数使用默认值
+func NewAnalyzer(opts Options) *Analyzer {
+	def := DefaultOptions()
+	if opts.Threshold <= 0 || opts.Threshold >= 1 {
+		opts.Threshold = def.Threshold
+	}
+	if opts.MinPeers <= 0 {
+		opts.MinPeers = def.MinPeers
+	}
+	if opts.TitleSimilarity <= 0 || opts.TitleSimilarity > 1 {
+		opts.TitleSimilarity = def.TitleSimilarity
+	}
+	return &Analyzer{
+		opts:   opts,
+		byKey:  make(map[string]*group),
+		byItem: make(map[string]*group),
+	}
+}
+
+// Add 加入参考商品（同一商品重复加入时更新价格），价格无效的商品忽略
+func (a *Analyzer) Add(listings ...Listing) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, l := range listings {
+		a.add(l)
+	}
+}
+
+func (a *Analyzer) add(l Listing) *group {
+	if l.ItemID == "" || l.Price <= 0 {
+		return nil
+	}
+	if g, ok := a.byItem[l.ItemID]; ok {
+		g.prices[l.ItemID] = l.Price
+		return g
+	}
+
+	g := a.findGroup(l)
+	g.prices[l.ItemID] = l.Price
+	a.byItem[l.ItemID] = g
+	return g
+}
+
+// findGroup 查找或创建商品所属分组
+func (a *Analyzer) findGroup(l Listing) *group {
+	if key := entityKey(l); key != "" {
+		if g, ok := a.byKey[key]; ok {
+			return g
+		}
+		return a.newGroup(&group{key: key, label: entityLabel(l), categoryID: l.CategoryID, byEntity: true})
+	}
+
+	grams := titleGrams(l.Title)
+	var best *group
+	bestSim := 0.0
+	for _, g := range a.groups {
+		if g.byEntity || g.categoryID != l.CategoryID {
+			continue
+		}
+		if sim := jaccard(grams, g.grams); sim > bestSim {
+			best, bestSim = g, sim
+		}
+	}
+	if best != nil && bestSim >= a.opts.TitleSimilarity {
+		return best
+	}
+
+	key := fmt.Sprintf("c%d|t%d", l.CategoryID, len(a.groups))
+	return a.newGroup(&group{key: key, label: truncate(l.Title, 20), categoryID: l.CategoryID, grams: grams})
+}
+
+func (a *Analyzer) newGroup(g *group) *group {
+	g.prices = make(map[string]float64)
+	a.groups = append(a.groups, g)
+	a.byKey[g.key] = g
+	return g
+}
+
+// Detect 将候选商品加入参考集，并返回低于同类中位价超过阈值的商品（按折扣从大到小排序）
+func (a *Analyzer) Detect(candidates []Listing) []Deal {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	groups := make([]*group, len(candidates))
+	for i, l := range candidates {
+		groups[i] = a.add(l)
+	}
+
+	var deals []Deal
+	for i, l := range candidates {
+		g := groups[i]
+		if g == nil {
+			continue
+		}
+		stats := g.stats(l.ItemID)
+		if stats.Count < a.opts.MinPeers || stats.Median <= 0 {
+			continue
+		}
+		discount := (stats.Median - l.Price) / stats.Median
+		if discount < a.opts.Threshold {
+			continue
+		}
+		deals = append(deals, Deal{
+			Listing:     l,
+			DiscountPct: discount * 100,
+			Group:       stats,
+			Basis: fmt.Sprintf("同类「%s」%d 件，中位价 %.2f（IQR %.2f-%.2f），本商品 %.2f，低 %.1f%%",
+				stats.Label, stats.Count, stats.Median, stats.Q1, stats.Q3, l.Price, discount*100),
+		})
+	}
+
+	sort.Slice(deals, func(i, j int) bool {
+		if deals[i].DiscountPct != deals[j].DiscountPct {
+			return deals[i].DiscountPct > deals[j].DiscountPct
+		}
+		return deals[i].ItemID < deals[j].ItemID
+	})
+	return deals
+}
+
+// Groups 返回所有分组的价格统计（按商品数量从多到少排序）
+func (a *Analyzer) Groups() []GroupStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats := make([]GroupStats, 0, len(a.groups))
+	for _, g := range a.groups {
+		stats = append(stats, g.stats(""))
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Key < stats[j].Key
+	})
+	return stats
+}
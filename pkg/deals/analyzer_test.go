@@ -0,0 +1,87 @@
+package deals
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestAnalyzer_DetectByEntity(t *testing.T) {
+	a := NewAnalyzer(Options{Threshold: 0.3, MinPeers: 4})
+
+	var pool []Listing
+	for i, price := range []float64{3000, 3100, 2900, 3200, 3050} {
+		pool = append(pool, Listing{
+			ItemID: fmt.Sprintf("p%d", i), Title: "iPhone 13 128G", CategoryID: 1,
+			Brand: "Apple", Model: "iPhone 13", Price: price,
+		})
+	}
+	a.Add(pool...)
+
+	deals := a.Detect([]Listing{
+		{ItemID: "cheap", Title: "自用苹果13", CategoryID: 1, Brand: "Apple", Model: "iPhone 13", Price: 1800},
+		{ItemID: "normal", Title: "iPhone13", CategoryID: 1, Brand: "Apple", Model: "iPhone 13", Price: 2800},
+		// 不同分类同型号不参与比较
+		{ItemID: "other", Title: "iPhone 13 手机壳", CategoryID: 2, Brand: "Apple", Model: "iPhone 13", Price: 20},
+	})
+
+	if len(deals) != 1 || deals[0].ItemID != "cheap" {
+		t.Fatalf("Detect() = %+v, want 仅 cheap", deals)
+	}
+	d := deals[0]
+	// 同批候选商品互为参考：cheap 的同类为 5 个参考商品 + normal
+	if d.Group.Count != 6 || d.Group.Median != 3025 {
+		t.Errorf("比价依据错误: %+v", d.Group)
+	}
+	if want := (3025.0 - 1800) / 3025 * 100; math.Abs(d.DiscountPct-want) > 1e-9 {
+		t.Errorf("DiscountPct = %.2f, want %.2f", d.DiscountPct, want)
+	}
+	if d.Basis == "" {
+		t.Error("Basis 不应为空")
+	}
+}
+
+func TestAnalyzer_TitleSimilarityGrouping(t *testing.T) {
+	a := NewAnalyzer(Options{Threshold: 0.3, MinPeers: 3, TitleSimilarity: 0.4})
+
+	a.Add(
+		Listing{ItemID: "1", Title: "任天堂 Switch OLED 白色", CategoryID: 5, Price: 1800},
+		Listing{ItemID: "2", Title: "任天堂Switch OLED 白色 国行", CategoryID: 5, Price: 1900},
+		Listing{ItemID: "3", Title: "任天堂 switch oled 白色 99新", CategoryID: 5, Price: 1850},
+		Listing{ItemID: "4", Title: "乐高积木 城堡", CategoryID: 5, Price: 200},
+	)
+
+	deals := a.Detect([]Listing{{ItemID: "new", Title: "任天堂 Switch OLED 白色 急出", CategoryID: 5, Price: 1000}})
+	if len(deals) != 1 {
+		t.Fatalf("Detect() = %+v, want 1 个低价商品", deals)
+	}
+	if deals[0].Group.Count != 3 {
+		t.Errorf("标题相似分组数量 = %d, want 3", deals[0].Group.Count)
+	}
+
+	groups := a.Groups()
+	if len(groups) != 2 || groups[0].Count != 4 {
+		t.Errorf("Groups() = %+v", groups)
+	}
+}
+
+func TestAnalyzer_MinPeers(t *testing.T) {
+	a := NewAnalyzer(Options{Threshold: 0.2, MinPeers: 5})
+	a.Add(
+		Listing{ItemID: "1", Title: "x", Brand: "B", Price: 100},
+		Listing{ItemID: "2", Title: "x", Brand: "B", Price: 100},
+	)
+	if deals := a.Detect([]Listing{{ItemID: "3", Title: "x", Brand: "B", Price: 10}}); len(deals) != 0 {
+		t.Errorf("同类不足时不应判定低价: %+v", deals)
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4}
+	if got := quantile(sorted, 0.5); got != 2.5 {
+		t.Errorf("median = %v, want 2.5", got)
+	}
+	if got := quantile(sorted, 0.25); got != 1.75 {
+		t.Errorf("q1 = %v, want 1.75", got)
+	}
+}
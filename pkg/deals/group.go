@@ -0,0 +1,119 @@
+package deals
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// GroupStats 同类商品价格统计
+type GroupStats struct {
+	Key    string  `json:"key"`
+	Label  string  `json:"label"` // 可读的分组描述（品牌型号或代表标题）
+	Count  int     `json:"count"`
+	Median float64 `json:"median"`
+	Q1     float64 `json:"q1"`
+	Q3     float64 `json:"q3"`
+	IQR    float64 `json:"iqr"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// group 同类商品分组
+type group struct {
+	key        string
+	label      string
+	categoryID int
+	byEntity   bool                // 是否按品牌型号分组（否则按标题相似度）
+	grams      map[string]struct{} // 代表标题的字符二元组（标题分组使用）
+	prices     map[string]float64  // 商品ID -> 价格
+}
+
+// stats 计算分组价格统计，exclude 不为空时排除该商品
+func (g *group) stats(exclude string) GroupStats {
+	prices := make([]float64, 0, len(g.prices))
+	for id, p := range g.prices {
+		if id != exclude {
+			prices = append(prices, p)
+		}
+	}
+	s := GroupStats{Key: g.key, Label: g.label, Count: len(prices)}
+	if len(prices) == 0 {
+		return s
+	}
+	sort.Float64s(prices)
+	s.Min = prices[0]
+	s.Max = prices[len(prices)-1]
+	s.Median = quantile(prices, 0.5)
+	s.Q1 = quantile(prices, 0.25)
+	s.Q3 = quantile(prices, 0.75)
+	s.IQR = s.Q3 - s.Q1
+	return s
+}
+
+// quantile 线性插值分位数（sorted 已升序）
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+// entityKey 品牌型号分组键，无品牌型号时返回空
+func entityKey(l Listing) string {
+	if l.Brand == "" && l.Model == "" {
+		return ""
+	}
+	return fmt.Sprintf("c%d|%s|%s", l.CategoryID, strings.ToLower(l.Brand), strings.ToLower(l.Model))
+}
+
+// entityLabel 品牌型号分组描述
+func entityLabel(l Listing) string {
+	return strings.TrimSpace(l.Brand + " " + l.Model)
+}
+
+// titleGrams 标题归一化后的字符二元组（忽略大小写、空白与标点）
+func titleGrams(title string) map[string]struct{} {
+	var runes []rune
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, r)
+		}
+	}
+	grams := make(map[string]struct{})
+	if len(runes) == 1 {
+		grams[string(runes)] = struct{}{}
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])] = struct{}{}
+	}
+	return grams
+}
+
+// jaccard 两个集合的 Jaccard 相似度
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for k := range a {
+		if _, ok := b[k]; ok {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// truncate 截断标题用于展示
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
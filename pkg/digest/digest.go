@@ -0,0 +1,210 @@
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"xianyu_aner/pkg/util"
+)
+
+// 摘要周期
+const (
+	PeriodDaily  = "daily"
+	PeriodWeekly = "weekly"
+)
+
+// Item 摘要中的单个商品
+type Item struct {
+	ItemID    string        `json:"itemId"`
+	Title     string        `json:"title"`
+	Price     float64       `json:"price"`
+	WantCount int           `json:"wantCount"`
+	ImageURL  string        `json:"imageUrl,omitempty"`
+	URL       string        `json:"url,omitempty"`
+	New       bool          `json:"new"`            // 上一期摘要中没有该商品
+	Prev      *SnapshotItem `json:"prev,omitempty"` // 上一期摘要中的数据
+}
+
+// PriceDelta 价格变化（降价为负数，无上一期数据时为 0）
+func (i Item) PriceDelta() float64 {
+	if i.Prev == nil || i.Prev.Price <= 0 {
+		return 0
+	}
+	return i.Price - i.Prev.Price
+}
+
+// WantDelta 想要人数变化（无上一期数据时为 0）
+func (i Item) WantDelta() int {
+	if i.Prev == nil {
+		return 0
+	}
+	return i.WantCount - i.Prev.WantCount
+}
+
+// Report 一期摘要数据（与渲染模板分离）
+type Report struct {
+	Title      string    `json:"title"`
+	Period     string    `json:"period"`
+	From       time.Time `json:"from"`       // 统计区间起点（上一期发送时间或一个周期前）
+	To         time.Time `json:"to"`         // 统计区间终点
+	Candidates int       `json:"candidates"` // 区间内观测到的商品数
+	NewCount   int       `json:"newCount"`   // 新上榜商品数
+	DropCount  int       `json:"dropCount"`  // 降价商品数
+	Items      []Item    `json:"items"`
+}
+
+// Collect 从候选商品中选出想要人数最多的 top 个，并与上一期摘要比较计算变化
+// prev 为 nil 表示首期摘要，所有商品都不标记为新上榜
+func Collect(candidates []Item, prev *Snapshot, top int) []Item {
+	items := make([]Item, len(candidates))
+	copy(items, candidates)
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].WantCount != items[j].WantCount {
+			return items[i].WantCount > items[j].WantCount
+		}
+		return items[i].ItemID < items[j].ItemID
+	})
+	if top > 0 && len(items) > top {
+		items = items[:top]
+	}
+	for i := range items {
+		if prev == nil {
+			continue
+		}
+		p, ok := prev.Items[items[i].ItemID]
+		if !ok {
+			items[i].New = true
+			continue
+		}
+		items[i].Prev = &p
+	}
+	return items
+}
+
+// NewReport 组装摘要数据并统计新上榜/降价数量
+func NewReport(title, period string, from, to time.Time, candidates int, items []Item) Report {
+	r := Report{Title: title, Period: period, From: from, To: to, Candidates: candidates, Items: items}
+	for _, item := range items {
+		if item.New {
+			r.NewCount++
+		}
+		if item.PriceDelta() < 0 {
+			r.DropCount++
+		}
+	}
+	return r
+}
+
+// ==================== 发送状态 ====================
+
+// SnapshotItem 上一期摘要中商品的价格与想要人数
+type SnapshotItem struct {
+	Price     float64 `json:"price"`
+	WantCount int     `json:"wantCount"`
+}
+
+// Snapshot 上一期摘要
+type Snapshot struct {
+	SentAt time.Time               `json:"sentAt"`
+	Items  map[string]SnapshotItem `json:"items"`
+}
+
+// State 摘要发送状态，持久化上一期摘要用于计算变化与判断下次发送时间
+type State struct {
+	mu   sync.Mutex
+	path string
+	last *Snapshot
+}
+
+// LoadState 加载发送状态，文件不存在时返回空状态
+func LoadState(path string) (*State, error) {
+	s := &State{path: path}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取摘要状态失败: %w", err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("解析摘要状态失败: %w", err)
+	}
+	s.last = &snap
+	return s, nil
+}
+
+// Last 上一期摘要，尚未发送过时返回 nil
+func (s *State) Last() *Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Record 记录已发送的摘要并写入状态文件
+func (s *State) Record(r Report) error {
+	snap := &Snapshot{SentAt: r.To, Items: make(map[string]SnapshotItem, len(r.Items))}
+	for _, item := range r.Items {
+		snap.Items[item.ItemID] = SnapshotItem{Price: item.Price, WantCount: item.WantCount}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = snap
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化摘要状态失败: %w", err)
+	}
+	if err := util.WriteFileAtomic(s.path, data, 0644); err != nil {
+		return fmt.Errorf("写入摘要状态失败: %w", err)
+	}
+	return nil
+}
+
+// ==================== 发送计划 ====================
+
+// Schedule 发送计划：每天 Hour 点，或每周 Weekday 的 Hour 点
+type Schedule struct {
+	Period  string
+	Hour    int
+	Weekday time.Weekday
+}
+
+// Length 周期长度
+func (s Schedule) Length() time.Duration {
+	if s.Period == PeriodWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Latest 返回不晚于 now 的最近一个计划发送时间
+func (s Schedule) Latest(now time.Time) time.Time {
+	t := time.Date(now.Year(), now.Month(), now.Day(), s.Hour, 0, 0, 0, now.Location())
+	if t.After(now) {
+		t = t.AddDate(0, 0, -1)
+	}
+	if s.Period == PeriodWeekly {
+		back := (int(t.Weekday()) - int(s.Weekday) + 7) % 7
+		t = t.AddDate(0, 0, -back)
+	}
+	return t
+}
+
+// Due 判断是否到了发送时间：上一期发送于最近一个计划时间之前（或从未发送）
+func (s Schedule) Due(now time.Time, last *Snapshot) bool {
+	if last == nil {
+		return true
+	}
+	return last.SentAt.Before(s.Latest(now))
+}
@@ -0,0 +1,281 @@
+package digest
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"html"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollect_Deltas(t *testing.T) {
+	candidates := []Item{
+		{ItemID: "a", Title: "A", Price: 100, WantCount: 5},
+		{ItemID: "b", Title: "B", Price: 80, WantCount: 20},
+		{ItemID: "c", Title: "C", Price: 50, WantCount: 12},
+		{ItemID: "d", Title: "D", Price: 10, WantCount: 1},
+	}
+
+	// 首期摘要：不标记新上榜
+	first := Collect(candidates, nil, 3)
+	if len(first) != 3 || first[0].ItemID != "b" || first[1].ItemID != "c" || first[2].ItemID != "a" {
+		t.Fatalf("unexpected order: %+v", first)
+	}
+	for _, item := range first {
+		if item.New || item.PriceDelta() != 0 || item.WantDelta() != 0 {
+			t.Errorf("first digest should have no deltas: %+v", item)
+		}
+	}
+
+	prev := &Snapshot{Items: map[string]SnapshotItem{
+		"b": {Price: 100, WantCount: 15},
+		"c": {Price: 40, WantCount: 12},
+	}}
+	items := Collect(candidates, prev, 3)
+	if items[0].PriceDelta() != -20 || items[0].WantDelta() != 5 {
+		t.Errorf("b deltas = %v/%d, want -20/5", items[0].PriceDelta(), items[0].WantDelta())
+	}
+	if items[1].PriceDelta() != 10 || items[1].WantDelta() != 0 {
+		t.Errorf("c deltas = %v/%d, want 10/0", items[1].PriceDelta(), items[1].WantDelta())
+	}
+	if !items[2].New {
+		t.Errorf("a should be new")
+	}
+
+	r := NewReport("日报", PeriodDaily, time.Time{}, time.Time{}, len(candidates), items)
+	if r.NewCount != 1 || r.DropCount != 1 {
+		t.Errorf("NewCount/DropCount = %d/%d, want 1/1", r.NewCount, r.DropCount)
+	}
+
+	out, err := Render(nil, r)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	out = html.UnescapeString(out)
+	for _, want := range []string{"¥80", "（-20）", "想要 20 人", "（+5）", "（+10）", "新上榜"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("html missing %q", want)
+		}
+	}
+}
+
+func TestState_RecordAndSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "digest_state.json")
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	if state.Last() != nil {
+		t.Fatalf("expected empty state")
+	}
+
+	loc := time.FixedZone("CST", 8*3600)
+	sentAt := time.Date(2025, 1, 15, 9, 0, 5, 0, loc) // 周三
+	r := NewReport("日报", PeriodDaily, sentAt.Add(-24*time.Hour), sentAt, 1, []Item{{ItemID: "a", Price: 9.9, WantCount: 3}})
+	if err := state.Record(r); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	reloaded, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	last := reloaded.Last()
+	if last == nil || last.Items["a"].Price != 9.9 || !last.SentAt.Equal(sentAt) {
+		t.Fatalf("unexpected snapshot: %+v", last)
+	}
+
+	daily := Schedule{Period: PeriodDaily, Hour: 9}
+	if daily.Due(sentAt.Add(time.Hour), last) {
+		t.Errorf("daily digest should not be due again on the same day")
+	}
+	if !daily.Due(time.Date(2025, 1, 16, 9, 0, 0, 0, loc), last) {
+		t.Errorf("daily digest should be due next day at 9:00")
+	}
+
+	weekly := Schedule{Period: PeriodWeekly, Hour: 9, Weekday: time.Monday}
+	if got := weekly.Latest(sentAt); !got.Equal(time.Date(2025, 1, 13, 9, 0, 0, 0, loc)) {
+		t.Errorf("weekly Latest = %v", got)
+	}
+	if weekly.Due(time.Date(2025, 1, 19, 23, 0, 0, 0, loc), last) {
+		t.Errorf("weekly digest should not be due before Monday")
+	}
+	if !weekly.Due(time.Date(2025, 1, 20, 9, 30, 0, 0, loc), last) {
+		t.Errorf("weekly digest should be due on Monday")
+	}
+}
+
+// smtpStandIn 本地 SMTP 替身：支持 STARTTLS 与 AUTH PLAIN，记录收到的邮件
+type smtpStandIn struct {
+	ln   net.Listener
+	tls  *tls.Config
+	auth string // 收到的 AUTH PLAIN 凭证（解码后）
+	from string
+	rcpt []string
+	data string
+	done chan struct{}
+}
+
+func newSMTPStandIn(t *testing.T, tlsConfig *tls.Config) *smtpStandIn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpStandIn{ln: ln, tls: tlsConfig, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *smtpStandIn) port() int { return s.ln.Addr().(*net.TCPAddr).Port }
+
+func (s *smtpStandIn) serve() {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	secure := false
+	reply("220 stand-in ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			if s.tls != nil && !secure {
+				reply("250-stand-in")
+				reply("250 STARTTLS")
+			} else {
+				reply("250-stand-in")
+				reply("250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, r, secure = tlsConn, bufio.NewReader(tlsConn), true
+		case "AUTH":
+			parts := strings.Fields(line)
+			raw, _ := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+			s.auth = string(raw)
+			reply("235 ok")
+		case "MAIL":
+			s.from = line
+			reply("250 ok")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var buf strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				buf.WriteString(l)
+			}
+			s.data = buf.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestMailer_SendWithStartTLS(t *testing.T) {
+	// 借用 httptest 的自签名证书（对 127.0.0.1 有效）
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	cert := ts.TLS.Certificates[0]
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	ts.Close()
+
+	server := newSMTPStandIn(t, &tls.Config{Certificates: []tls.Certificate{cert}})
+	mailer, err := NewMailer(SMTPConfig{
+		Host:      "127.0.0.1",
+		Port:      server.port(),
+		Username:  "bot@example.com",
+		Password:  "secret",
+		From:      "bot@example.com",
+		To:        []string{"a@example.com", "b@example.com"},
+		StartTLS:  true,
+		TLSConfig: &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"},
+		Timeout:   5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewMailer: %v", err)
+	}
+
+	if err := mailer.Send(context.Background(), "闲鱼热门商品日报", "<p>你好</p>"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	<-server.done
+
+	if server.auth != "\x00bot@example.com\x00secret" {
+		t.Errorf("auth = %q", server.auth)
+	}
+	if len(server.rcpt) != 2 {
+		t.Errorf("rcpt = %v", server.rcpt)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(server.data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "闲鱼热门商品日报" {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.HasPrefix(msg.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("content-type = %q", msg.Header.Get("Content-Type"))
+	}
+	body, _ := base64.StdEncoding.DecodeString(strings.ReplaceAll(server.data[strings.Index(server.data, "\r\n\r\n")+4:], "\r\n", ""))
+	if string(body) != "<p>你好</p>" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestMailer_RequireStartTLS(t *testing.T) {
+	server := newSMTPStandIn(t, nil)
+	mailer, err := NewMailer(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		From:     "bot@example.com",
+		To:       []string{"a@example.com"},
+		StartTLS: true,
+		Timeout:  5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewMailer: %v", err)
+	}
+	if err := mailer.Send(context.Background(), "s", "b"); err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("expected STARTTLS error, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"time"
+)
+
+// DefaultTemplate 默认 HTML 摘要模板（内联样式，兼容常见邮件客户端）
+const DefaultTemplate = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="margin:0;padding:20px;background:#f5f5f5;font-family:-apple-system,'PingFang SC','Microsoft YaHei',sans-serif;color:#333;">
+<div style="max-width:680px;margin:0 auto;background:#fff;border-radius:8px;padding:24px;">
+  <h2 style="margin:0 0 4px;">{{.Title}}</h2>
+  <p style="margin:0 0 16px;color:#888;font-size:13px;">{{date .From}} ~ {{date .To}} · 观测商品 {{.Candidates}} 个 · 新上榜 {{.NewCount}} · 降价 {{.DropCount}}</p>
+  {{if not .Items}}<p style="color:#888;">本期没有观测到商品。</p>{{end}}
+  <table style="width:100%;border-collapse:collapse;">
+  {{range $i, $item := .Items}}
+    <tr style="border-top:1px solid #eee;">
+      <td style="width:88px;padding:10px 0;vertical-align:top;">
+        {{if $item.ImageURL}}<a href="{{$item.URL}}"><img src="{{$item.ImageURL}}" width="80" height="80" alt="" style="border-radius:4px;object-fit:cover;"></a>{{end}}
+      </td>
+      <td style="padding:10px 8px;vertical-align:top;">
+        <div style="font-size:15px;"><span style="color:#aaa;">{{inc $i}}.</span> <a href="{{$item.URL}}" style="color:#1677ff;text-decoration:none;">{{truncate 60 $item.Title}}</a>{{if $item.New}} <span style="background:#52c41a;color:#fff;font-size:12px;padding:1px 6px;border-radius:3px;">新上榜</span>{{end}}</div>
+        <div style="margin-top:6px;font-size:14px;">
+          <b style="color:#f5222d;">¥{{price $item.Price}}</b>
+          {{with $item.PriceDelta}}<span style="color:{{if lt . 0.0}}#52c41a{{else}}#f5222d{{end}};font-size:12px;">（{{signedPrice .}}）</span>{{end}}
+          <span style="margin-left:12px;color:#666;">想要 {{$item.WantCount}} 人</span>
+          {{with $item.WantDelta}}<span style="color:{{if gt . 0}}#fa8c16{{else}}#888{{end}};font-size:12px;">（{{signed .}}）</span>{{end}}
+        </div>
+      </td>
+    </tr>
+  {{end}}
+  </table>
+  <p style="margin:16px 0 0;color:#aaa;font-size:12px;">变化为与上一期摘要相比；价格单位为元。</p>
+</div>
+</body>
+</html>`
+
+// funcs 模板可用函数
+var funcs = template.FuncMap{
+	"price": func(v float64) string {
+		return trimPrice(fmt.Sprintf("%.2f", v))
+	},
+	"signedPrice": func(v float64) string {
+		s := trimPrice(fmt.Sprintf("%.2f", v))
+		if v > 0 {
+			return "+" + s
+		}
+		return s
+	},
+	"signed": func(v int) string {
+		if v > 0 {
+			return fmt.Sprintf("+%d", v)
+		}
+		return fmt.Sprintf("%d", v)
+	},
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04")
+	},
+	"inc": func(i int) int { return i + 1 },
+	"truncate": func(n int, s string) string {
+		r := []rune(s)
+		if len(r) <= n {
+			return s
+		}
+		return string(r[:n]) + "…"
+	},
+}
+
+// trimPrice 去掉价格末尾多余的 0
+func trimPrice(s string) string {
+	for len(s) > 0 && s[len(s)-1] == '0' {
+		s = s[:len(s)-1]
+	}
+	if len(s) > 0 && s[len(s)-1] == '.' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// ParseTemplate 解析 HTML 摘要模板，text 为空时使用默认模板
+func ParseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New("digest").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析摘要模板失败: %w", err)
+	}
+	return tmpl, nil
+}
+
+// LoadTemplate 从文件加载 HTML 摘要模板，path 为空时使用默认模板
+func LoadTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return ParseTemplate("")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取摘要模板失败: %w", err)
+	}
+	return ParseTemplate(string(data))
+}
+
+// Render 渲染摘要 HTML
+func Render(tmpl *template.Template, r Report) (string, error) {
+	if tmpl == nil {
+		var err error
+		if tmpl, err = ParseTemplate(""); err != nil {
+			return "", err
+		}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r); err != nil {
+		return "", fmt.Errorf("渲染摘要失败: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,363 @@
+package duplicate
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 重复类型
+const (
+	KindRepost = "repost" // 同一卖家换ID重新发布
+	KindCopy   = "copy"   // 不同卖家使用相同图片/标题
+)
+
+// Listing 参与去重检测的商品
+type Listing struct {
+	ItemID    string
+	Title     string
+	SellerID  string   // 卖家ID或昵称，用于区分重发与盗图
+	ImageURLs []string // 主图与图片列表
+}
+
+// Options 检测参数
+type Options struct {
+	MaxHashDistance int     // 判定为同一图片的最大汉明距离
+	TitleSimilarity float64 // 判定为相同标题的最低 Jaccard 相似度
+	MinShingles     int     // 标题 shingle 数不足时不参与标题比较（过短标题误判率高）
+}
+
+// DefaultOptions 默认检测参数
+func DefaultOptions() Options {
+	return Options{
+		MaxHashDistance: 6,
+		TitleSimilarity: 0.8,
+		MinShingles:     5,
+	}
+}
+
+// Cluster 重复商品簇
+type Cluster struct {
+	ID      string   `json:"id"`
+	ItemIDs []string `json:"itemIds"`
+	Kind    string   `json:"kind,omitempty"`    // repost / copy（单个商品为空）
+	Reasons []string `json:"reasons,omitempty"` // 归并依据
+}
+
+// Result 检测结果
+type Result struct {
+	Clusters  []Cluster         `json:"clusters"`  // 所有簇（含单个商品的簇），按大小降序
+	ClusterOf map[string]string `json:"clusterOf"` // 商品ID -> 簇ID
+	Hashed    int               `json:"hashed"`    // 成功计算哈希的图片数
+	Missing   int               `json:"missing"`   // 本地不存在或无法解码的图片数
+}
+
+// Size 返回商品所在簇的大小
+func (r Result) Size(itemID string) int {
+	id := r.ClusterOf[itemID]
+	for _, c := range r.Clusters {
+		if c.ID == id {
+			return len(c.ItemIDs)
+		}
+	}
+	return 0
+}
+
+// Detector 重复商品检测器
+type Detector struct {
+	mu     sync.Mutex
+	opts   Options
+	source ImageSource
+	index  *Index          // 历史商品索引（可选）
+	cache  map[string]Hash // 图片URL -> 哈希（只缓存成功的结果，稍后下载到本地的图片可再次查找）
+}
+
+// NewDetector 创建检测器，未设置的参数使用默认值；source 为 nil 时仅按标题检测
+func NewDetector(source ImageSource, opts Options) *Detector {
+	def := DefaultOptions()
+	if opts.MaxHashDistance <= 0 {
+		opts.MaxHashDistance = def.MaxHashDistance
+	}
+	if opts.TitleSimilarity <= 0 || opts.TitleSimilarity > 1 {
+		opts.TitleSimilarity = def.TitleSimilarity
+	}
+	if opts.MinShingles <= 0 {
+		opts.MinShingles = def.MinShingles
+	}
+	return &Detector{opts: opts, source: source, cache: make(map[string]Hash)}
+}
+
+// WithIndex 设置历史商品索引：商品同时与索引中的历史商品比较，检测结果写入索引（需调用 Index.Save 保存）
+func (d *Detector) WithIndex(index *Index) *Detector {
+	d.index = index
+	return d
+}
+
+// Index 返回历史商品索引（未设置时为 nil）
+func (d *Detector) Index() *Index {
+	return d.index
+}
+
+// SetSource 替换图片来源（如本次运行刚归档了新的图片）
+func (d *Detector) SetSource(source ImageSource) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.source = source
+}
+
+// hash 计算图片哈希（带缓存），counted 记录本次检测已计入统计的图片
+func (d *Detector) hash(imageURL string, counted map[string]bool, result *Result) (Hash, bool) {
+	h, ok := d.cache[imageURL]
+	if !ok && d.source != nil {
+		if p, found := d.source.Path(imageURL); found {
+			if v, err := HashFile(p); err == nil {
+				h, ok = v, true
+				d.cache[imageURL] = h
+			}
+		}
+	}
+	if !counted[imageURL] {
+		counted[imageURL] = true
+		if ok {
+			result.Hashed++
+		} else {
+			result.Missing++
+		}
+	}
+	return h, ok
+}
+
+// node 参与聚类的商品：本批商品或索引中的历史商品
+type node struct {
+	itemID  string
+	title   string
+	seller  string
+	hashes  []Hash
+	cluster string // 索引中记录的簇ID（新商品为空）
+	current bool   // 是否为本批商品
+}
+
+// Detect 对商品进行聚类：任意一对图片哈希足够接近，或标题高度相似，即视为同一商品
+// 设置了索引时本批商品同时与索引中的历史商品比较，簇中包含匹配到的历史商品；
+// 簇ID沿用成员在索引中的簇ID（多个时取最小），全新的簇为 "dup-" + 最小商品ID，之后保持不变
+func (d *Detector) Detect(listings []Listing) Result {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	result := Result{ClusterOf: make(map[string]string)}
+	counted := make(map[string]bool)
+
+	// 本批商品在前，索引中的历史商品（不含本批商品）在后
+	nodes := make([]node, 0, len(listings))
+	current := make(map[string]bool, len(listings))
+	for _, l := range listings {
+		n := node{itemID: l.ItemID, title: l.Title, seller: l.SellerID, current: true}
+		seen := make(map[string]bool)
+		for _, u := range l.ImageURLs {
+			if u == "" || seen[u] {
+				continue
+			}
+			seen[u] = true
+			if h, ok := d.hash(u, counted, &result); ok {
+				n.hashes = append(n.hashes, h)
+			}
+		}
+		if d.index != nil {
+			if prev, ok := d.index.Lookup(l.ItemID); ok {
+				n.cluster = prev.Cluster
+				if len(n.hashes) == 0 {
+					n.hashes = prev.Hashes // 本次找不到图片时沿用上次的哈希
+				}
+			}
+		}
+		nodes = append(nodes, n)
+		current[l.ItemID] = true
+	}
+	if d.index != nil {
+		for _, e := range d.index.snapshot() {
+			if !current[e.ItemID] {
+				nodes = append(nodes, node{itemID: e.ItemID, title: e.Title, seller: e.SellerID, hashes: e.Hashes, cluster: e.Cluster})
+			}
+		}
+	}
+
+	n := len(nodes)
+	uf := newUnionFind(n)
+	reasons := make(map[[2]int]string)
+	shingles := make([]map[string]struct{}, n)
+	for i := range nodes {
+		shingles[i] = Shingles(nodes[i].title, 3)
+	}
+
+	// 只比较本批商品与其他商品，历史商品之间的关系由索引中的簇ID表示
+	for i := 0; i < len(listings); i++ {
+		for j := i + 1; j < n; j++ {
+			if reason := d.match(nodes[i].hashes, nodes[j].hashes, shingles[i], shingles[j]); reason != "" {
+				uf.union(i, j)
+				reasons[[2]int{i, j}] = reason
+			}
+		}
+	}
+	byCluster := make(map[string]int)
+	for i, nd := range nodes {
+		if nd.cluster == "" {
+			continue
+		}
+		if j, ok := byCluster[nd.cluster]; ok {
+			uf.union(j, i)
+		} else {
+			byCluster[nd.cluster] = i
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range nodes {
+		root := uf.find(i)
+		groups[root] = append(groups[root], i)
+	}
+
+	seenAt := time.Now().UnixMilli()
+	for _, members := range groups {
+		if !hasCurrent(nodes, members) {
+			continue
+		}
+		c := Cluster{}
+		sellers := make(map[string]bool)
+		reasonSet := make(map[string]bool)
+		var existing []string
+		for _, i := range members {
+			c.ItemIDs = append(c.ItemIDs, nodes[i].itemID)
+			sellers[nodes[i].seller] = true
+			if nodes[i].cluster != "" {
+				existing = append(existing, nodes[i].cluster)
+			}
+			for _, j := range members {
+				if r, ok := reasons[[2]int{i, j}]; ok && !reasonSet[r] {
+					reasonSet[r] = true
+					c.Reasons = append(c.Reasons, r)
+				}
+			}
+		}
+		sort.Strings(c.ItemIDs)
+		sort.Strings(c.Reasons)
+		c.ID = "dup-" + c.ItemIDs[0]
+		if len(existing) > 0 {
+			sort.Strings(existing)
+			c.ID = existing[0]
+		}
+		if len(members) > 1 {
+			c.Kind = KindCopy
+			if len(sellers) == 1 && !sellers[""] {
+				c.Kind = KindRepost
+			}
+		}
+		for _, id := range c.ItemIDs {
+			result.ClusterOf[id] = c.ID
+		}
+		result.Clusters = append(result.Clusters, c)
+
+		if d.index != nil {
+			for _, i := range members {
+				nd := nodes[i]
+				if nd.current {
+					d.index.put(IndexEntry{ItemID: nd.itemID, Title: nd.title, SellerID: nd.seller, Hashes: nd.hashes, Cluster: c.ID, SeenAt: seenAt})
+				} else {
+					d.index.setCluster(nd.itemID, c.ID)
+				}
+			}
+		}
+	}
+	sort.Slice(result.Clusters, func(i, j int) bool {
+		a, b := result.Clusters[i], result.Clusters[j]
+		if len(a.ItemIDs) != len(b.ItemIDs) {
+			return len(a.ItemIDs) > len(b.ItemIDs)
+		}
+		return a.ID < b.ID
+	})
+	return result
+}
+
+// hasCurrent 簇中是否有本批商品
+func hasCurrent(nodes []node, members []int) bool {
+	for _, i := range members {
+		if nodes[i].current {
+			return true
+		}
+	}
+	return false
+}
+
+// match 判断两个商品是否重复，返回归并依据（不重复返回空）
+func (d *Detector) match(ha, hb []Hash, sa, sb map[string]struct{}) string {
+	for _, a := range ha {
+		for _, b := range hb {
+			if a.Distance(b) <= d.opts.MaxHashDistance {
+				return "相同图片"
+			}
+		}
+	}
+	if len(sa) >= d.opts.MinShingles && len(sb) >= d.opts.MinShingles &&
+		Jaccard(sa, sb) >= d.opts.TitleSimilarity {
+		return "相似标题"
+	}
+	return ""
+}
+
+// Shingles 标题归一化（忽略大小写、空白与标点）后的 k 字符 shingle 集合
+func Shingles(title string, k int) map[string]struct{} {
+	var runes []rune
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, r)
+		}
+	}
+	set := make(map[string]struct{})
+	for i := 0; i+k <= len(runes); i++ {
+		set[string(runes[i:i+k])] = struct{}{}
+	}
+	return set
+}
+
+// Jaccard 两个 shingle 集合的 Jaccard 相似度
+func Jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for k := range a {
+		if _, ok := b[k]; ok {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// unionFind 并查集
+type unionFind struct {
+	parent []int
+}
+
+func newUnionFind(n int) *unionFind {
+	uf := &unionFind{parent: make([]int, n)}
+	for i := range uf.parent {
+		uf.parent[i] = i
+	}
+	return uf
+}
+
+func (u *unionFind) find(x int) int {
+	for u.parent[x] != x {
+		u.parent[x] = u.parent[u.parent[x]]
+		x = u.parent[x]
+	}
+	return x
+}
+
+func (u *unionFind) union(a, b int) {
+	ra, rb := u.find(a), u.find(b)
+	if ra != rb {
+		u.parent[rb] = ra
+	}
+}
@@ -0,0 +1,154 @@
+package duplicate
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const fixtureDir = "testdata"
+
+func TestHashFile_Fixtures(t *testing.T) {
+	a, err := HashFile(filepath.Join(fixtureDir, "photo_a.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aCopy, err := HashFile(filepath.Join(fixtureDir, "photo_a_copy.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := HashFile(filepath.Join(fixtureDir, "photo_b.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 缩小、调亮并重新压缩为 JPEG 的同一图片应非常接近
+	if d := a.Distance(aCopy); d > DefaultOptions().MaxHashDistance {
+		t.Errorf("同一图片的哈希距离 = %d，过大 (%s vs %s)", d, a, aCopy)
+	}
+	if d := a.Distance(b); d <= DefaultOptions().MaxHashDistance {
+		t.Errorf("不同图片的哈希距离 = %d，过小 (%s vs %s)", d, a, b)
+	}
+}
+
+func TestHashFile_Missing(t *testing.T) {
+	if _, err := HashFile(filepath.Join(fixtureDir, "missing.png")); err == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	src := DirSource{Dir: fixtureDir}
+	if p, ok := src.Path("https://img.alicdn.com/bao/uploaded/photo_a.png?x=1"); !ok || filepath.Base(p) != "photo_a.png" {
+		t.Errorf("Path() = %q, %v", p, ok)
+	}
+	if _, ok := src.Path("https://img.alicdn.com/none.jpg"); ok {
+		t.Error("本地不存在的图片应返回 false")
+	}
+}
+
+func TestDetector_Detect(t *testing.T) {
+	src := MapSource{
+		"u/a":      filepath.Join(fixtureDir, "photo_a.png"),
+		"u/a_copy": filepath.Join(fixtureDir, "photo_a_copy.jpg"),
+		"u/b":      filepath.Join(fixtureDir, "photo_b.png"),
+	}
+	d := NewDetector(src, Options{})
+
+	result := d.Detect([]Listing{
+		// 卖家1 用同一张图换ID重发
+		{ItemID: "1", Title: "九成新 索尼耳机 WH-1000XM4", SellerID: "s1", ImageURLs: []string{"u/a"}},
+		{ItemID: "2", Title: "出索尼降噪耳机", SellerID: "s1", ImageURLs: []string{"u/a_copy"}},
+		// 其他卖家照抄标题
+		{ItemID: "3", Title: "99新 Switch OLED 日版 带两个手柄", SellerID: "s2", ImageURLs: []string{"u/b"}},
+		{ItemID: "4", Title: "99新 Switch OLED 日版 带两个手柄！", SellerID: "s3", ImageURLs: []string{"u/missing"}},
+		// 无关商品
+		{ItemID: "5", Title: "乐高积木", SellerID: "s4", ImageURLs: []string{"u/missing"}},
+	})
+
+	if result.ClusterOf["1"] != result.ClusterOf["2"] {
+		t.Errorf("相同图片应归为同一簇: %v", result.ClusterOf)
+	}
+	if result.ClusterOf["3"] != result.ClusterOf["4"] {
+		t.Errorf("相似标题应归为同一簇: %v", result.ClusterOf)
+	}
+	if result.ClusterOf["1"] == result.ClusterOf["3"] || result.ClusterOf["5"] == result.ClusterOf["3"] {
+		t.Errorf("不相关商品不应归为同一簇: %v", result.ClusterOf)
+	}
+	if len(result.ClusterOf) != 5 || result.Size("5") != 1 {
+		t.Errorf("每个商品都应有簇ID: %v", result.ClusterOf)
+	}
+	if result.Hashed != 3 || result.Missing != 1 {
+		t.Errorf("Hashed/Missing = %d/%d, want 3/1", result.Hashed, result.Missing)
+	}
+
+	kinds := make(map[string]string)
+	for _, c := range result.Clusters {
+		kinds[c.ID] = c.Kind
+	}
+	if kinds[result.ClusterOf["1"]] != KindRepost {
+		t.Errorf("同一卖家重发应为 repost: %+v", result.Clusters)
+	}
+	if kinds[result.ClusterOf["3"]] != KindCopy {
+		t.Errorf("不同卖家应为 copy: %+v", result.Clusters)
+	}
+	if kinds[result.ClusterOf["5"]] != "" {
+		t.Errorf("单个商品不应有重复类型: %+v", result.Clusters)
+	}
+}
+
+func TestDetector_IndexAcrossBatches(t *testing.T) {
+	src := MapSource{
+		"u/a":      filepath.Join(fixtureDir, "photo_a.png"),
+		"u/a_copy": filepath.Join(fixtureDir, "photo_a_copy.jpg"),
+		"u/b":      filepath.Join(fixtureDir, "photo_b.png"),
+	}
+	path := filepath.Join(t.TempDir(), "dup_index.json")
+	detect := func(listings ...Listing) Result {
+		t.Helper()
+		index, err := LoadIndex(path, 0)
+		if err != nil {
+			t.Fatalf("LoadIndex() error = %v", err)
+		}
+		result := NewDetector(src, Options{}).WithIndex(index).Detect(listings)
+		if err := index.Save(); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		return result
+	}
+
+	first := detect(Listing{ItemID: "5", Title: "九成新 索尼耳机 WH-1000XM4", SellerID: "s1", ImageURLs: []string{"u/a"}})
+	if first.ClusterOf["5"] != "dup-5" {
+		t.Fatalf("首个商品簇ID = %q, want dup-5", first.ClusterOf["5"])
+	}
+
+	// 下一批中换ID重发的商品与索引中的历史商品归为同一簇，簇ID不变
+	second := detect(
+		Listing{ItemID: "7", Title: "出索尼降噪耳机", SellerID: "s1", ImageURLs: []string{"u/a_copy"}},
+		Listing{ItemID: "8", Title: "乐高积木", SellerID: "s2", ImageURLs: []string{"u/b"}},
+	)
+	if second.ClusterOf["7"] != "dup-5" || second.Size("7") != 2 {
+		t.Errorf("重发商品应归入历史簇: %v", second.ClusterOf)
+	}
+	if second.ClusterOf["8"] != "dup-8" {
+		t.Errorf("无关商品簇ID = %q, want dup-8", second.ClusterOf["8"])
+	}
+	for _, c := range second.Clusters {
+		if c.ID == "dup-5" && c.Kind != KindRepost {
+			t.Errorf("同一卖家重发应为 repost: %+v", c)
+		}
+	}
+
+	// 商品ID更小的新商品加入时簇ID仍保持不变
+	third := detect(Listing{ItemID: "1", Title: "索尼耳机", SellerID: "s3", ImageURLs: []string{"u/a"}})
+	if third.ClusterOf["1"] != "dup-5" || third.Size("1") != 3 {
+		t.Errorf("簇ID应保持不变: %v", third.ClusterOf)
+	}
+
+	index, err := LoadIndex(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := index.Lookup("7"); !ok || e.Cluster != "dup-5" || len(e.Hashes) != 1 || index.Len() != 4 {
+		t.Errorf("索引未正确保存: %+v (共 %d 条)", e, index.Len())
+	}
+}
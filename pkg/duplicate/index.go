@@ -0,0 +1,241 @@
+package duplicate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"xianyu_aner/pkg/util"
+)
+
+// IndexEntry 索引中的历史商品：图片哈希、标题与所在簇，供后续批次识别换ID重发与盗图
+type IndexEntry struct {
+	ItemID   string `json:"itemId"`
+	Title    string `json:"title"`
+	SellerID string `json:"sellerId,omitempty"`
+	Hashes   []Hash `json:"hashes,omitempty"`
+	Cluster  string `json:"cluster"`
+	SeenAt   int64  `json:"seenAt"` // 最近一次参与检测的时间戳（毫秒）
+}
+
+// Index 已检测商品的索引：新批次的商品同时与索引中的历史商品比较，簇ID沿用索引中的记录，跨批次保持不变
+type Index struct {
+	mu        sync.Mutex
+	path      string        // 持久化文件（为空时仅保存在内存）
+	retention time.Duration // 超过该时长未再出现的商品从索引中移除（<=0 时不清理）
+	entries   map[string]*IndexEntry
+	dirty     map[string]bool // 上次保存以来本进程更新的商品，保存时覆盖文件中的记录（其他进程可能同时写入索引文件）
+}
+
+// indexFile 索引文件格式
+type indexFile struct {
+	Items []*IndexEntry `json:"items"`
+}
+
+// ErrIndexCorrupt 索引文件内容无法解析
+var ErrIndexCorrupt = errors.New("重复检测索引文件已损坏")
+
+// LoadIndex 加载索引，文件不存在时返回空索引
+// 文件无法解析时将其重命名为 <path>.corrupt-<时间戳> 保留原内容，按空索引继续
+func LoadIndex(path string, retention time.Duration) (*Index, error) {
+	x := &Index{path: path, retention: retention, entries: make(map[string]*IndexEntry), dirty: make(map[string]bool)}
+	if path == "" {
+		return x, nil
+	}
+	file, err := readIndexFile(path)
+	if errors.Is(err, ErrIndexCorrupt) {
+		if err := moveIndexFileAside(path, err); err != nil {
+			return nil, err
+		}
+		return x, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range file.Items {
+		x.entries[e.ItemID] = e
+	}
+	return x, nil
+}
+
+// readIndexFile 读取索引文件，文件不存在时返回空内容，无法解析时返回 ErrIndexCorrupt
+func readIndexFile(path string) (indexFile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return indexFile{}, nil
+	}
+	if err != nil {
+		return indexFile{}, fmt.Errorf("读取重复检测索引失败: %w", err)
+	}
+	var file indexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return indexFile{}, fmt.Errorf("%w: %v", ErrIndexCorrupt, err)
+	}
+	return file, nil
+}
+
+// moveIndexFileAside 将无法解析的索引文件重命名为 <path>.corrupt-<时间戳>
+func moveIndexFileAside(path string, cause error) error {
+	aside := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102150405"))
+	if err := os.Rename(path, aside); err != nil {
+		return fmt.Errorf("%v，且无法移走损坏的文件: %w", cause, err)
+	}
+	util.Printf("[重复检测] %v，已移至 %s\n", cause, aside)
+	return nil
+}
+
+// Len 索引中的商品数
+func (x *Index) Len() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return len(x.entries)
+}
+
+// Lookup 按商品ID查找索引记录
+func (x *Index) Lookup(itemID string) (IndexEntry, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	e, ok := x.entries[itemID]
+	if !ok {
+		return IndexEntry{}, false
+	}
+	return copyIndexEntry(e), true
+}
+
+// snapshot 返回全部索引记录的副本
+func (x *Index) snapshot() []IndexEntry {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	list := make([]IndexEntry, 0, len(x.entries))
+	for _, e := range x.entries {
+		list = append(list, copyIndexEntry(e))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ItemID < list[j].ItemID })
+	return list
+}
+
+// put 写入或更新商品记录
+func (x *Index) put(e IndexEntry) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	c := copyIndexEntry(&e)
+	x.entries[e.ItemID] = &c
+	x.dirty[e.ItemID] = true
+}
+
+// setCluster 更新历史商品所在的簇（簇合并时），不更新出现时间
+func (x *Index) setCluster(itemID, cluster string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if e, ok := x.entries[itemID]; ok && e.Cluster != cluster {
+		e.Cluster = cluster
+		x.dirty[itemID] = true
+	}
+}
+
+// Save 保存索引：在文件锁内读取文件中的最新索引，以本进程更新的商品覆盖，移除过期商品后原子写入
+func (x *Index) Save() error {
+	if x == nil {
+		return nil
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.path == "" {
+		x.expireLocked()
+		return nil
+	}
+	if len(x.dirty) == 0 && !x.hasExpiredLocked() {
+		return nil
+	}
+
+	unlock, err := util.LockFile(x.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := readIndexFile(x.path)
+	switch {
+	case errors.Is(err, ErrIndexCorrupt):
+		if err := moveIndexFileAside(x.path, err); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		x.mergeLocked(file)
+	}
+	x.expireLocked()
+
+	file = indexFile{Items: make([]*IndexEntry, 0, len(x.entries))}
+	for _, e := range x.entries {
+		file.Items = append(file.Items, e)
+	}
+	sort.Slice(file.Items, func(i, j int) bool { return file.Items[i].ItemID < file.Items[j].ItemID })
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("序列化重复检测索引失败: %w", err)
+	}
+	if err := util.WriteFileAtomic(x.path, data, 0644); err != nil {
+		return fmt.Errorf("写入重复检测索引失败: %w", err)
+	}
+	x.dirty = make(map[string]bool)
+	return nil
+}
+
+// mergeLocked 以文件中的索引为基础保留本进程更新的商品，结果作为新的内存索引，调用方需持有锁
+func (x *Index) mergeLocked(file indexFile) {
+	entries := make(map[string]*IndexEntry, len(file.Items)+len(x.dirty))
+	for _, e := range file.Items {
+		entries[e.ItemID] = e
+	}
+	for id := range x.dirty {
+		if e, ok := x.entries[id]; ok {
+			entries[id] = e
+		}
+	}
+	x.entries = entries
+}
+
+// hasExpiredLocked 是否有需要清理的过期商品，调用方需持有锁
+func (x *Index) hasExpiredLocked() bool {
+	if x.retention <= 0 {
+		return false
+	}
+	cutoff := time.Now().Add(-x.retention).UnixMilli()
+	for _, e := range x.entries {
+		if e.SeenAt < cutoff {
+			return true
+		}
+	}
+	return false
+}
+
+// expireLocked 移除超过保留时长未再出现的商品，调用方需持有锁
+func (x *Index) expireLocked() {
+	if x.retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-x.retention).UnixMilli()
+	for id, e := range x.entries {
+		if e.SeenAt < cutoff {
+			delete(x.entries, id)
+			delete(x.dirty, id)
+		}
+	}
+}
+
+// copyIndexEntry 深拷贝索引记录
+func copyIndexEntry(e *IndexEntry) IndexEntry {
+	c := *e
+	c.Hashes = append([]Hash(nil), e.Hashes...)
+	return c
+}
@@ -0,0 +1,89 @@
+package duplicate
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // 注册 GIF 解码器
+	_ "image/jpeg" // 注册 JPEG 解码器
+	_ "image/png"  // 注册 PNG 解码器
+	"math/bits"
+	"os"
+)
+
+// Hash 64 位感知哈希（dHash）
+type Hash uint64
+
+// Distance 两个哈希的汉明距离（0 表示几乎相同）
+func (h Hash) Distance(other Hash) int {
+	return bits.OnesCount64(uint64(h ^ other))
+}
+
+// String 十六进制表示
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// HashFile 读取本地图片文件并计算感知哈希
+func HashFile(path string) (Hash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("打开图片失败: %w", err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return 0, fmt.Errorf("解码图片失败 %s: %w", path, err)
+	}
+	return HashImage(img), nil
+}
+
+// HashImage 计算图片的差值哈希（dHash）
+// 将图片缩放为 9x8 灰度图，逐行比较相邻像素亮度，对缩放、压缩与轻微调色不敏感
+func HashImage(img image.Image) Hash {
+	const w, h = 9, 8
+	gray := resizeGray(img, w, h)
+
+	var hash Hash
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			if gray[y*w+x] < gray[y*w+x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// resizeGray 区域平均缩放为 w x h 的灰度矩阵
+func resizeGray(img image.Image, w, h int) []float64 {
+	b := img.Bounds()
+	out := make([]float64, w*h)
+	for ty := 0; ty < h; ty++ {
+		y0 := b.Min.Y + ty*b.Dy()/h
+		y1 := b.Min.Y + (ty+1)*b.Dy()/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for tx := 0; tx < w; tx++ {
+			x0 := b.Min.X + tx*b.Dx()/w
+			x1 := b.Min.X + (tx+1)*b.Dx()/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			sum, n := 0.0, 0
+			for y := y0; y < y1 && y < b.Max.Y; y++ {
+				for x := x0; x < x1 && x < b.Max.X; x++ {
+					r, g, bl, _ := img.At(x, y).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+					n++
+				}
+			}
+			if n > 0 {
+				out[ty*w+tx] = sum / float64(n)
+			}
+		}
+	}
+	return out
+}
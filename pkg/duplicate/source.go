@@ -0,0 +1,57 @@
+package duplicate
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// ImageSource 将图片URL映射为本地已下载的文件（去重检测不访问网络）
+type ImageSource interface {
+	Path(imageURL string) (string, bool)
+}
+
+// MapSource 显式的 URL -> 本地路径映射
+type MapSource map[string]string
+
+// Path 查找本地路径
+func (m MapSource) Path(imageURL string) (string, bool) {
+	p, ok := m[imageURL]
+	return p, ok
+}
+
+// Sources 依次在多个来源中查找，返回第一个找到的本地文件
+type Sources []ImageSource
+
+// Path 查找本地路径
+func (s Sources) Path(imageURL string) (string, bool) {
+	for _, src := range s {
+		if p, ok := src.Path(imageURL); ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// DirSource 按URL文件名在目录中查找图片，如 https://img.alicdn.com/bao/O1CN01abc.jpg -> DIR/O1CN01abc.jpg
+type DirSource struct {
+	Dir string
+}
+
+// Path 查找本地路径（文件不存在时返回 false）
+func (d DirSource) Path(imageURL string) (string, bool) {
+	name := imageURL
+	if u, err := url.Parse(imageURL); err == nil && u.Path != "" {
+		name = u.Path
+	}
+	name = path.Base(name)
+	if name == "" || name == "." || name == "/" {
+		return "", false
+	}
+	p := filepath.Join(d.Dir, name)
+	if _, err := os.Stat(p); err != nil {
+		return "", false
+	}
+	return p, true
+}
@@ -0,0 +1,116 @@
+package entity
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dictionary 实体词典（由用户维护的 YAML 文件加载）
+type Dictionary struct {
+	Categories []CategoryRule `yaml:"categories"`
+}
+
+// CategoryRule 品类规则
+type CategoryRule struct {
+	Name        string      `yaml:"name"`         // 品类名称，如 "手机"
+	CategoryIDs []int       `yaml:"category_ids"` // 匹配的闲鱼分类ID（可选）
+	Keywords    []string    `yaml:"keywords"`     // 标题关键词（分类ID缺失时用于判定品类）
+	Brands      []BrandRule `yaml:"brands"`       // 品牌列表
+	Specs       []SpecRule  `yaml:"specs"`        // 规格/容量提取规则
+}
+
+// BrandRule 品牌规则
+type BrandRule struct {
+	Name    string      `yaml:"name"`    // 标准品牌名，如 "Apple"
+	Aliases []string    `yaml:"aliases"` // 别名，如 "苹果"、"iphone"
+	Models  []ModelRule `yaml:"models"`  // 型号列表
+}
+
+// ModelRule 型号规则
+type ModelRule struct {
+	Name    string `yaml:"name"`    // 标准型号名，为空时使用正则匹配到的原文
+	Pattern string `yaml:"pattern"` // 型号正则
+
+	re *regexp.Regexp
+}
+
+// SpecRule 规格规则
+type SpecRule struct {
+	Name    string `yaml:"name"`    // 规格名，如 "capacity"
+	Pattern string `yaml:"pattern"` // 规格正则，存在分组时取第一个分组
+	Format  string `yaml:"format"`  // 输出格式（可选），如 "%sGB"
+
+	re *regexp.Regexp
+}
+
+// LoadDictionary 从 YAML 文件加载实体词典
+func LoadDictionary(path string) (*Dictionary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取词典文件失败: %w", err)
+	}
+	return ParseDictionary(data)
+}
+
+// ParseDictionary 解析 YAML 格式的实体词典并编译正则
+func ParseDictionary(data []byte) (*Dictionary, error) {
+	var dict Dictionary
+	if err := yaml.Unmarshal(data, &dict); err != nil {
+		return nil, fmt.Errorf("解析词典失败: %w", err)
+	}
+	if err := dict.compile(); err != nil {
+		return nil, err
+	}
+	return &dict, nil
+}
+
+// compile 编译词典中的所有正则表达式
+func (d *Dictionary) compile() error {
+	for ci := range d.Categories {
+		cat := &d.Categories[ci]
+		for bi := range cat.Brands {
+			brand := &cat.Brands[bi]
+			for mi := range brand.Models {
+				model := &brand.Models[mi]
+				re, err := regexp.Compile(model.Pattern)
+				if err != nil {
+					return fmt.Errorf("型号正则无效 [%s/%s]: %w", cat.Name, brand.Name, err)
+				}
+				model.re = re
+			}
+		}
+		for si := range cat.Specs {
+			spec := &cat.Specs[si]
+			re, err := regexp.Compile(spec.Pattern)
+			if err != nil {
+				return fmt.Errorf("规格正则无效 [%s/%s]: %w", cat.Name, spec.Name, err)
+			}
+			spec.re = re
+		}
+	}
+	return nil
+}
+
+// hasCategoryID 分类ID是否在品类规则中
+func (c *CategoryRule) hasCategoryID(categoryID int) bool {
+	for _, id := range c.CategoryIDs {
+		if id == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKeyword 标题（已转小写）是否包含品类关键词
+func (c *CategoryRule) matchKeyword(text string) bool {
+	for _, kw := range c.Keywords {
+		if kw != "" && strings.Contains(text, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	return false
+}
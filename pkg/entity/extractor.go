@@ -0,0 +1,233 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 置信度权重
+const (
+	weightBrandInTitle = 0.35 // 品牌出现在标题
+	weightBrandInDesc  = 0.15 // 品牌仅出现在描述
+	weightModelInTitle = 0.40 // 型号出现在标题
+	weightModelInDesc  = 0.20 // 型号仅出现在描述
+	weightSpec         = 0.15 // 提取到规格
+	weightCategoryID   = 0.10 // 分类ID命中词典
+)
+
+// Entity 从标题/描述中提取的商品实体
+type Entity struct {
+	Category   string  `json:"category,omitempty"`   // 命中的品类名
+	Brand      string  `json:"brand,omitempty"`      // 标准品牌名
+	Model      string  `json:"model,omitempty"`      // 标准型号名
+	Spec       string  `json:"spec,omitempty"`       // 规格/容量
+	Confidence float64 `json:"confidence,omitempty"` // 置信度 0-1
+}
+
+// IsEmpty 是否未提取到任何实体
+func (e Entity) IsEmpty() bool {
+	return e.Brand == "" && e.Model == "" && e.Spec == ""
+}
+
+// Key 归一化的商品标识（品牌/型号/规格），用于跨卖家分组
+func (e Entity) Key() string {
+	if e.Brand == "" && e.Model == "" {
+		return ""
+	}
+	return strings.ToLower(strings.Join([]string{e.Brand, e.Model, e.Spec}, "|"))
+}
+
+// Extractor 标题实体提取器
+type Extractor struct {
+	dict *Dictionary
+}
+
+// NewExtractor 创建实体提取器
+func NewExtractor(dict *Dictionary) *Extractor {
+	if dict == nil {
+		dict = &Dictionary{}
+	}
+	return &Extractor{dict: dict}
+}
+
+// Extract 从标题和描述中提取品牌、型号、规格
+// 依次尝试每个适用的品类规则，返回置信度最高的结果
+func (e *Extractor) Extract(categoryID int, title, description string) Entity {
+	lowerTitle := strings.ToLower(title)
+	lowerDesc := strings.ToLower(description)
+
+	var best Entity
+	for i := range e.dict.Categories {
+		cat := &e.dict.Categories[i]
+		idMatched := cat.hasCategoryID(categoryID)
+		if !idMatched && !cat.matchKeyword(lowerTitle) && !hasAnyBrand(cat, lowerTitle) {
+			continue
+		}
+
+		result := e.extractInCategory(cat, title, lowerTitle, description, lowerDesc)
+		if result.IsEmpty() {
+			continue
+		}
+		if idMatched {
+			result.Confidence += weightCategoryID
+		}
+		if result.Confidence > 1 {
+			result.Confidence = 1
+		}
+		if result.Confidence > best.Confidence {
+			best = result
+		}
+	}
+
+	return best
+}
+
+// findBrand 查找品牌：先在标题中匹配全部品牌，都未命中时再匹配描述，返回品牌及是否命中标题
+func findBrand(cat *CategoryRule, lowerTitle, lowerDesc string) (*BrandRule, bool) {
+	for bi := range cat.Brands {
+		if containsAlias(&cat.Brands[bi], lowerTitle) {
+			return &cat.Brands[bi], true
+		}
+	}
+	for bi := range cat.Brands {
+		if containsAlias(&cat.Brands[bi], lowerDesc) {
+			return &cat.Brands[bi], false
+		}
+	}
+	return nil, false
+}
+
+// extractInCategory 在单个品类规则下提取实体
+func (e *Extractor) extractInCategory(cat *CategoryRule, title, lowerTitle, desc, lowerDesc string) Entity {
+	result := Entity{Category: cat.Name}
+
+	// 品牌：所有品牌先匹配标题，标题中没有任何品牌时才匹配描述
+	// （描述中常提到其他品牌作比较，如"比苹果流畅"，不能覆盖标题中的品牌）
+	brand, inTitle := findBrand(cat, lowerTitle, lowerDesc)
+	if brand != nil {
+		result.Brand = brand.Name
+		if inTitle {
+			result.Confidence += weightBrandInTitle
+		} else {
+			result.Confidence += weightBrandInDesc
+		}
+		if model, inTitle := matchModel(brand, title, desc); model != "" {
+			result.Model = model
+			if inTitle {
+				result.Confidence += weightModelInTitle
+			} else {
+				result.Confidence += weightModelInDesc
+			}
+		}
+	}
+
+	// 型号可以在没有品牌别名的情况下直接命中（如 "iPhone15"），同样标题优先
+	if result.Brand == "" {
+		for ti, text := range []string{title, desc} {
+			for bi := range cat.Brands {
+				if model, _ := matchModel(&cat.Brands[bi], text, ""); model != "" {
+					result.Brand = cat.Brands[bi].Name
+					result.Model = model
+					if ti == 0 {
+						result.Confidence += weightModelInTitle
+					} else {
+						result.Confidence += weightModelInDesc
+					}
+					break
+				}
+			}
+			if result.Brand != "" {
+				break
+			}
+		}
+	}
+
+	// 规格只从标题提取，避免描述中的无关数字干扰
+	for si := range cat.Specs {
+		spec := &cat.Specs[si]
+		if value := matchSpec(spec, title); value != "" {
+			result.Spec = value
+			result.Confidence += weightSpec
+			break
+		}
+	}
+
+	return result
+}
+
+// hasAnyBrand 标题中是否包含该品类任一品牌别名
+func hasAnyBrand(cat *CategoryRule, lowerTitle string) bool {
+	for bi := range cat.Brands {
+		if containsAlias(&cat.Brands[bi], lowerTitle) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAlias 文本中是否包含品牌名或别名
+func containsAlias(brand *BrandRule, lowerText string) bool {
+	if lowerText == "" {
+		return false
+	}
+	if brand.Name != "" && strings.Contains(lowerText, strings.ToLower(brand.Name)) {
+		return true
+	}
+	for _, alias := range brand.Aliases {
+		if alias != "" && strings.Contains(lowerText, strings.ToLower(alias)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchModel 匹配型号，返回型号名以及是否在标题中命中
+func matchModel(brand *BrandRule, title, desc string) (string, bool) {
+	for mi := range brand.Models {
+		model := &brand.Models[mi]
+		if model.re == nil {
+			continue
+		}
+		if m := model.re.FindString(title); m != "" {
+			return modelName(model, m), true
+		}
+	}
+	for mi := range brand.Models {
+		model := &brand.Models[mi]
+		if model.re == nil || desc == "" {
+			continue
+		}
+		if m := model.re.FindString(desc); m != "" {
+			return modelName(model, m), false
+		}
+	}
+	return "", false
+}
+
+// modelName 返回标准型号名，未配置时使用匹配原文
+func modelName(model *ModelRule, matched string) string {
+	if model.Name != "" {
+		return model.Name
+	}
+	return strings.TrimSpace(matched)
+}
+
+// matchSpec 匹配规格
+func matchSpec(spec *SpecRule, text string) string {
+	if spec.re == nil {
+		return ""
+	}
+	m := spec.re.FindStringSubmatch(text)
+	if m == nil {
+		return ""
+	}
+	value := m[0]
+	if len(m) > 1 && m[1] != "" {
+		value = m[1]
+	}
+	value = strings.TrimSpace(value)
+	if spec.Format != "" {
+		return fmt.Sprintf(spec.Format, strings.ToUpper(value))
+	}
+	return strings.ToUpper(value)
+}
@@ -0,0 +1,180 @@
+package entity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testDict = `
+categories:
+  - name: 手机
+    category_ids: [126862528]
+    keywords: [手机]
+    brands:
+      - name: Apple
+        aliases: [苹果, iphone]
+        models:
+          - name: iPhone 15 Pro
+            pattern: '(?i)iphone\s*15\s*pro|苹果\s*15\s*pro'
+          - name: iPhone 15
+            pattern: '(?i)iphone\s*15|苹果\s*15'
+      - name: 华为
+        aliases: [huawei]
+        models:
+          - pattern: '(?i)mate\s*\d+'
+    specs:
+      - name: capacity
+        pattern: '(?i)(\d+)\s*(?:g|gb)\b'
+        format: '%sGB'
+  - name: 游戏机
+    keywords: [游戏机]
+    brands:
+      - name: 任天堂
+        aliases: [switch, nintendo]
+        models:
+          - name: Switch OLED
+            pattern: '(?i)switch\s*oled'
+`
+
+func mustExtractor(t *testing.T) *Extractor {
+	t.Helper()
+	dict, err := ParseDictionary([]byte(testDict))
+	if err != nil {
+		t.Fatalf("ParseDictionary() error = %v", err)
+	}
+	return NewExtractor(dict)
+}
+
+func TestExtractor_Extract(t *testing.T) {
+	extractor := mustExtractor(t)
+
+	tests := []struct {
+		name       string
+		categoryID int
+		title      string
+		desc       string
+		wantBrand  string
+		wantModel  string
+		wantSpec   string
+		minConf    float64
+	}{
+		{
+			name:       "标题完整命中",
+			categoryID: 126862528,
+			title:      "自用 iPhone15 Pro 256G 国行",
+			wantBrand:  "Apple",
+			wantModel:  "iPhone 15 Pro",
+			wantSpec:   "256GB",
+			minConf:    0.9,
+		},
+		{
+			name:      "中文别名",
+			title:     "出 苹果15 128gb 电池95",
+			wantBrand: "Apple",
+			wantModel: "iPhone 15",
+			wantSpec:  "128GB",
+			minConf:   0.8,
+		},
+		{
+			name:      "型号使用匹配原文",
+			title:     "华为 Mate60 手机 512G",
+			wantBrand: "华为",
+			wantModel: "Mate60",
+			wantSpec:  "512GB",
+		},
+		{
+			name:      "型号仅在描述中",
+			title:     "任天堂游戏机 九成新",
+			desc:      "switch oled 日版，带两个手柄",
+			wantBrand: "任天堂",
+			wantModel: "Switch OLED",
+		},
+		{
+			name:      "标题品牌优先于描述中提到的品牌",
+			title:     "华为 Mate60 手机",
+			desc:      "用了半年，比苹果流畅",
+			wantBrand: "华为",
+			wantModel: "Mate60",
+		},
+		{
+			name:      "标题无品牌时使用描述",
+			title:     "二手手机 九成新",
+			desc:      "苹果 iPhone15，电池健康 90",
+			wantBrand: "Apple",
+			wantModel: "iPhone 15",
+		},
+		{
+			name:  "未命中",
+			title: "宜家书桌 白色",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractor.Extract(tt.categoryID, tt.title, tt.desc)
+			if got.Brand != tt.wantBrand {
+				t.Errorf("Brand = %q, want %q", got.Brand, tt.wantBrand)
+			}
+			if got.Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", got.Model, tt.wantModel)
+			}
+			if got.Spec != tt.wantSpec {
+				t.Errorf("Spec = %q, want %q", got.Spec, tt.wantSpec)
+			}
+			if got.Confidence < tt.minConf || got.Confidence > 1 {
+				t.Errorf("Confidence = %.2f, want [%.2f, 1]", got.Confidence, tt.minConf)
+			}
+		})
+	}
+}
+
+func TestExtractor_ConfidenceOrdering(t *testing.T) {
+	extractor := mustExtractor(t)
+
+	inTitle := extractor.Extract(0, "任天堂 switch oled", "")
+	inDesc := extractor.Extract(0, "任天堂游戏机", "switch oled")
+	if inTitle.Confidence <= inDesc.Confidence {
+		t.Errorf("标题命中置信度 %.2f 应高于描述命中 %.2f", inTitle.Confidence, inDesc.Confidence)
+	}
+}
+
+func TestEntity_Key(t *testing.T) {
+	a := Entity{Brand: "Apple", Model: "iPhone 15", Spec: "256GB"}
+	b := Entity{Brand: "apple", Model: "IPHONE 15", Spec: "256gb"}
+	if a.Key() != b.Key() {
+		t.Errorf("Key() 应忽略大小写: %q != %q", a.Key(), b.Key())
+	}
+	if (Entity{Spec: "256GB"}).Key() != "" {
+		t.Error("缺少品牌和型号时 Key() 应为空")
+	}
+}
+
+func TestLoadDictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dict.yaml")
+	if err := os.WriteFile(path, []byte(testDict), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dict, err := LoadDictionary(path)
+	if err != nil {
+		t.Fatalf("LoadDictionary() error = %v", err)
+	}
+	if len(dict.Categories) != 2 {
+		t.Errorf("品类数 = %d, want 2", len(dict.Categories))
+	}
+
+	if _, err := ParseDictionary([]byte("categories:\n  - name: x\n    specs:\n      - pattern: '('\n")); err == nil {
+		t.Error("无效正则应返回错误")
+	}
+}
+
+func TestLoadDictionary_ExampleFile(t *testing.T) {
+	dict, err := LoadDictionary("../../configs/entity_dict.example.yaml")
+	if err != nil {
+		t.Fatalf("示例词典无法加载: %v", err)
+	}
+	got := NewExtractor(dict).Extract(0, "iPhone 15 Pro Max 1T 手机", "")
+	if got.Model != "iPhone 15 Pro Max" || got.Spec != "1TB" {
+		t.Errorf("示例词典提取结果 = %+v", got)
+	}
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"xianyu_aner/pkg/feishu"
+)
+
+// TimeLayout 日期时间字段的导出格式
+const TimeLayout = "2006-01-02 15:04:05"
+
+// Column 导出列
+type Column struct {
+	Key   string           // 字段键，对应 feishu.Product 的 json 标签
+	Label string           // 中文表头
+	Type  feishu.FieldType // 字段类型，决定取值格式
+}
+
+// Columns 按 feishu.ProductFields 的 CSVOrder 排序的导出列（CSVOrder 为 0 的字段不导出）
+func Columns() []Column {
+	fields := make([]struct {
+		col   Column
+		order int
+	}, 0, len(feishu.ProductFields))
+	for _, f := range feishu.ProductFields {
+		if f.CSVOrder <= 0 {
+			continue
+		}
+		fields = append(fields, struct {
+			col   Column
+			order int
+		}{Column{Key: f.Key, Label: f.Schema.Label, Type: f.Schema.Type}, f.CSVOrder})
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].order < fields[j].order })
+
+	cols := make([]Column, len(fields))
+	for i, f := range fields {
+		cols[i] = f.col
+	}
+	return cols
+}
+
+// Headers 导出列的中文表头
+func Headers(cols []Column) []string {
+	headers := make([]string, len(cols))
+	for i, c := range cols {
+		headers[i] = c.Label
+	}
+	return headers
+}
+
+// productIndex json 标签 → feishu.Product 字段下标
+var productIndex = func() map[string]int {
+	t := reflect.TypeOf(feishu.Product{})
+	index := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			index[name] = i
+		}
+	}
+	return index
+}()
+
+// Value 读取商品字段的原始值（string、int、int64 或 float64），字段不存在时返回 nil
+func Value(p feishu.Product, key string) interface{} {
+	i, ok := productIndex[key]
+	if !ok {
+		return nil
+	}
+	return reflect.ValueOf(p).Field(i).Interface()
+}
+
+// Time 日期时间字段的值（毫秒时间戳），为 0 或非日期字段时返回零值
+func Time(p feishu.Product, col Column) time.Time {
+	if col.Type != feishu.FieldTypeDateTime {
+		return time.Time{}
+	}
+	ms, ok := Value(p, col.Key).(int64)
+	if !ok || ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// Text 字段的文本形式：日期时间按 TimeLayout 格式化，为 0 的时间与小数置空
+func Text(p feishu.Product, col Column) string {
+	if col.Type == feishu.FieldTypeDateTime {
+		if t := Time(p, col); !t.IsZero() {
+			return t.Format(TimeLayout)
+		}
+		return ""
+	}
+	switch v := Value(p, col.Key).(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		if v == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
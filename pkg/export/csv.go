@@ -0,0 +1,86 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"xianyu_aner/pkg/feishu"
+)
+
+// BOM UTF-8 字节序标记，Excel 据此识别编码，否则中文会乱码
+const BOM = "\ufeff"
+
+// CSVOptions CSV 写入选项
+type CSVOptions struct {
+	Comma rune // 分隔符，默认 ','；TSV 使用 '\t'
+	NoBOM bool // 不写入 UTF-8 BOM
+}
+
+// CSVWriter 流式写入 CSV/TSV：首次写入时输出 BOM 与表头，之后每批商品直接写出
+// 含分隔符、引号或换行的字段（如多行描述）按 RFC 4180 加引号转义
+type CSVWriter struct {
+	out    io.Writer
+	w      *csv.Writer
+	cols   []Column
+	noBOM  bool
+	header bool
+	row    []string
+}
+
+// NewCSVWriter 创建 CSV 写入器
+func NewCSVWriter(out io.Writer, opts CSVOptions) *CSVWriter {
+	w := csv.NewWriter(out)
+	if opts.Comma != 0 {
+		w.Comma = opts.Comma
+	}
+	cols := Columns()
+	return &CSVWriter{out: out, w: w, cols: cols, noBOM: opts.NoBOM, row: make([]string, len(cols))}
+}
+
+// WriteHeader 写入 BOM 与表头（只写一次；Write 会自动调用）
+func (c *CSVWriter) WriteHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	if !c.noBOM {
+		if _, err := io.WriteString(c.out, BOM); err != nil {
+			return err
+		}
+	}
+	return c.w.Write(Headers(c.cols))
+}
+
+// Write 写入一批商品
+func (c *CSVWriter) Write(products []feishu.Product) error {
+	if err := c.WriteHeader(); err != nil {
+		return err
+	}
+	for _, p := range products {
+		for i, col := range c.cols {
+			c.row[i] = cell(Text(p, col), col)
+		}
+		if err := c.w.Write(c.row); err != nil {
+			return err
+		}
+	}
+	return c.w.Error()
+}
+
+// Flush 将缓冲写出到底层 io.Writer
+func (c *CSVWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// cell 文本字段以 = + - @ 开头时加单引号前缀，避免 Excel 将其当作公式执行
+func cell(s string, col Column) string {
+	if col.Type != feishu.FieldTypeText || s == "" {
+		return s
+	}
+	switch s[0] {
+	case '=', '+', '-', '@':
+		return "'" + s
+	}
+	return s
+}
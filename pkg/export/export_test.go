@@ -0,0 +1,294 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/xuri/excelize/v2"
+	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/mtop"
+)
+
+func TestColumns(t *testing.T) {
+	cols := Columns()
+	if len(cols) != 34 {
+		t.Fatalf("len(Columns) = %d, want 34", len(cols))
+	}
+	if cols[0].Key != "itemId" || cols[0].Label != "商品ID" || cols[33].Key != "dupCount" {
+		t.Errorf("unexpected order: first=%+v last=%+v", cols[0], cols[33])
+	}
+	for _, c := range cols {
+		if c.Key == feishu.StatusField {
+			t.Errorf("status field should not be exported")
+		}
+		if _, ok := productIndex[c.Key]; !ok {
+			t.Errorf("column %q has no matching Product field", c.Key)
+		}
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	publish := time.Date(2026, 5, 1, 8, 30, 0, 0, time.Local)
+	products := []feishu.Product{
+		{
+			ItemID:        "1001",
+			Title:         "二手相机, 九成新",
+			Price:         "1999",
+			WantCnt:       12,
+			PublishTimeMs: publish.UnixMilli(),
+			Description:   "第一行\n第二行 \"含引号\"",
+			DealDiscount:  23.5,
+		},
+		{ItemID: "1002", Title: "=HYPERLINK(\"x\")"},
+	}
+
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf, CSVOptions{})
+	if err := w.Write(products[:1]); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Write(products[1:]); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, BOM) {
+		t.Fatalf("missing BOM")
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(out, BOM))).ReadAll()
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("rows = %d, want 3 (header + 2)", len(records))
+	}
+
+	cols := Columns()
+	get := func(row []string, key string) string {
+		for i, c := range cols {
+			if c.Key == key {
+				return row[i]
+			}
+		}
+		t.Fatalf("no column %q", key)
+		return ""
+	}
+	header, row := records[0], records[1]
+	if header[1] != "商品标题" || get(header, "wantCnt") != "想要人数" {
+		t.Errorf("header = %v", header)
+	}
+	checks := map[string]string{
+		"title":            "二手相机, 九成新",
+		"wantCnt":          "12",
+		"publishTimeMs":    "2026-05-01 08:30:00",
+		"captureTimeMs":    "",
+		"description":      "第一行\n第二行 \"含引号\"",
+		"dealDiscount":     "23.5",
+		"entityConfidence": "",
+	}
+	for key, want := range checks {
+		if got := get(row, key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if got := get(records[2], "title"); got != "'=HYPERLINK(\"x\")" {
+		t.Errorf("formula not escaped: %q", got)
+	}
+}
+
+func TestCSVWriter_TSV(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf, CSVOptions{Comma: '\t', NoBOM: true})
+	w.Write([]feishu.Product{{ItemID: "1", Title: "a\tb"}})
+	w.Flush()
+
+	out := buf.String()
+	if strings.HasPrefix(out, BOM) {
+		t.Errorf("unexpected BOM")
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "1\t\"a\tb\"\t") {
+		t.Errorf("tsv output = %q", out)
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	day1 := time.Date(2026, 5, 1, 10, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	x := NewXLSXWriter(XLSXOptions{HotWantCount: 20})
+	x.Write([]feishu.Product{
+		{ItemID: "2", Title: "后一天", Price: "88", WantCnt: 3, CaptureTimeMs: day2.UnixMilli()},
+		{ItemID: "1", Title: "热门", Price: "1999.5", WantCnt: 25, PublishTimeMs: day1.Add(-time.Hour).UnixMilli(),
+			CaptureTimeMs: day1.UnixMilli(), DetailURL: "https://www.goofish.com/item?id=1"},
+		{ItemID: "3", Title: "面议", Price: "面议", CaptureTimeMs: day1.UnixMilli()},
+	})
+	if x.Len() != 3 {
+		t.Fatalf("Len = %d", x.Len())
+	}
+
+	var buf bytes.Buffer
+	if _, err := x.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("open xlsx: %v", err)
+	}
+	defer f.Close()
+
+	if sheets := f.GetSheetList(); strings.Join(sheets, ",") != "2026-05-01,2026-05-02" {
+		t.Fatalf("sheets = %v", sheets)
+	}
+	sheet := "2026-05-01"
+	rows, _ := f.GetRows(sheet)
+	if len(rows) != 3 || rows[0][0] != "商品ID" || rows[1][0] != "1" {
+		t.Fatalf("rows = %v", rows)
+	}
+
+	cell := func(key string, row int) string {
+		for i, c := range Columns() {
+			if c.Key == key {
+				name, _ := excelize.CoordinatesToCellName(i+1, row)
+				return name
+			}
+		}
+		t.Fatalf("no column %q", key)
+		return ""
+	}
+	for _, key := range []string{"price", "wantCnt", "publishTimeMs"} {
+		if typ, _ := f.GetCellType(sheet, cell(key, 2)); typ != excelize.CellTypeUnset && typ != excelize.CellTypeNumber {
+			t.Errorf("%s cell type = %v, want number", key, typ)
+		}
+		if raw, _ := f.GetCellValue(sheet, cell(key, 2), excelize.Options{RawCellValue: true}); raw == "" {
+			t.Errorf("%s is empty", key)
+		} else if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			t.Errorf("%s raw value %q is not numeric", key, raw)
+		}
+	}
+	if v, _ := f.GetCellValue(sheet, cell("publishTimeMs", 2)); v != "2026-05-01 09:00:00" {
+		t.Errorf("publish time = %q", v)
+	}
+	if v, _ := f.GetCellValue(sheet, cell("price", 3)); v != "面议" {
+		t.Errorf("non-numeric price = %q", v)
+	}
+	if ok, link, _ := f.GetCellHyperLink(sheet, cell("detailUrl", 2)); !ok || link != "https://www.goofish.com/item?id=1" {
+		t.Errorf("hyperlink = %v %q", ok, link)
+	}
+
+	panes, _ := f.GetPanes(sheet)
+	if !panes.Freeze || panes.YSplit != 1 {
+		t.Errorf("header not frozen: %+v", panes)
+	}
+	formats, _ := f.GetConditionalFormats(sheet)
+	found := false
+	for _, opts := range formats {
+		for _, o := range opts {
+			found = found || strings.Contains(o.Criteria, ">=20")
+		}
+	}
+	if !found {
+		t.Errorf("hot highlight missing: %+v", formats)
+	}
+}
+
+func TestParquetItems(t *testing.T) {
+	at := time.Date(2025, 1, 15, 10, 0, 0, 0, time.Local)
+	items := []mtop.FeedItem{
+		{ItemID: "1", Title: "iPhone", Price: "¥2,999.5", WantCount: 3, Tags: []string{"包邮", "可小刀"}},
+		{ItemID: "2", Title: "面议", Price: "面议"},
+	}
+	details := []*mtop.ItemDetail{{
+		ItemID: "1", Price: "2899", CollectCount: 4, ItemStatusStr: "在售",
+		SKUList: []mtop.SKU{{SKUID: 9, PriceInCent: 289900, Quantity: 1,
+			PropertyList: []mtop.SKUProperty{{PropertyText: "容量", ValueText: "256G"}}}},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteParquet(&buf, ParquetItems(items, details, at)); err != nil {
+		t.Fatalf("WriteParquet: %v", err)
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	schema := file.Schema().String()
+	for _, want := range []string{
+		"optional int64 price (DECIMAL(18,2))",
+		"required int64 captured_at (TIMESTAMP(isAdjustedToUTC=true,unit=MILLIS))",
+		"required group tags (LIST)",
+		"required group skus (LIST)",
+	} {
+		if !strings.Contains(schema, want) {
+			t.Errorf("schema missing %q:\n%s", want, schema)
+		}
+	}
+
+	rows, err := parquet.Read[ParquetItem](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows", len(rows))
+	}
+	r := rows[0]
+	if !r.HasDetail || r.Price != 289900 || r.CollectCount != 4 || r.Status != "在售" || len(r.Tags) != 2 {
+		t.Errorf("row 0 = %+v", r)
+	}
+	if len(r.SKUs) != 1 || r.SKUs[0].Price != 289900 || r.SKUs[0].Properties[0].Value != "256G" {
+		t.Errorf("skus = %+v", r.SKUs)
+	}
+	if r.CapturedAt != at.UnixMilli() {
+		t.Errorf("captured_at = %d, want %d", r.CapturedAt, at.UnixMilli())
+	}
+	if rows[1].HasDetail || rows[1].Price != 0 {
+		t.Errorf("row 1 = %+v", rows[1])
+	}
+}
+
+func TestWriteParquetPartitions(t *testing.T) {
+	dir := t.TempDir()
+	day1 := time.Date(2025, 1, 15, 23, 0, 0, 0, time.Local)
+	day2 := day1.Add(2 * time.Hour)
+	rows := append(ParquetItems([]mtop.FeedItem{{ItemID: "1"}, {ItemID: "2"}}, nil, day1),
+		ParquetItems([]mtop.FeedItem{{ItemID: "3"}}, nil, day2)...)
+
+	paths, err := WriteParquetPartitions(dir, rows)
+	if err != nil {
+		t.Fatalf("WriteParquetPartitions: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "date=2025-01-15", "part-0.parquet"),
+		filepath.Join(dir, "date=2025-01-16", "part-0.parquet"),
+	}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+	data, _ := os.ReadFile(paths[0])
+	got, err := parquet.Read[ParquetItem](bytes.NewReader(data), int64(len(data)))
+	if err != nil || len(got) != 2 {
+		t.Fatalf("read partition: %d rows, %v", len(got), err)
+	}
+
+	// 同一天再次写入不覆盖已有文件
+	paths, err = WriteParquetPartitions(dir, rows[:1])
+	if err != nil {
+		t.Fatalf("WriteParquetPartitions again: %v", err)
+	}
+	if len(paths) != 1 || filepath.Base(paths[0]) != "part-1.parquet" {
+		t.Errorf("paths = %v, want part-1.parquet", paths)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "date=2025-01-15"))
+	if len(entries) != 2 {
+		t.Errorf("partition has %d entries, want 2 (no temp files)", len(entries))
+	}
+}
@@ -0,0 +1,233 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+)
+
+// ParquetItem Parquet 导出的一行：猜你喜欢商品，获取到详情时补充详情字段与 SKU 列表
+// 时间为毫秒时间戳（TIMESTAMP(MILLIS)），价格为 DECIMAL(18,2)；optional 字段为零值时写入 null
+type ParquetItem struct {
+	ItemID       string   `parquet:"item_id"`
+	Title        string   `parquet:"title"`
+	Price        int64    `parquet:"price,optional,decimal(2:18)"` // 价格（分），为 0 或无法解析时为空
+	WantCount    int32    `parquet:"want_count"`
+	ViewCount    int32    `parquet:"view_count"`
+	CategoryID   int64    `parquet:"category_id,optional"`
+	Location     string   `parquet:"location,optional"`
+	SellerNick   string   `parquet:"seller_nick,optional"`
+	SellerCredit string   `parquet:"seller_credit,optional"`
+	ShopLevel    string   `parquet:"shop_level,optional"`
+	Status       string   `parquet:"status,optional"`
+	Condition    string   `parquet:"condition,optional"`
+	FreeShipping bool     `parquet:"free_shipping"`
+	Tags         []string `parquet:"tags,list"`
+	ImageURL     string   `parquet:"image_url,optional"`
+	VideoURL     string   `parquet:"video_url,optional"`
+	PublishTime  int64    `parquet:"publish_time,optional,timestamp(millisecond)"`
+	CapturedAt   int64    `parquet:"captured_at,timestamp(millisecond)"`
+
+	// 详情字段（HasDetail 为 false 时为空）
+	HasDetail       bool         `parquet:"has_detail"`
+	SubTitle        string       `parquet:"sub_title,optional"`
+	Description     string       `parquet:"description,optional"`
+	CollectCount    int32        `parquet:"collect_count,optional"`
+	SellerID        string       `parquet:"seller_id,optional"`
+	SellerCity      string       `parquet:"seller_city,optional"`
+	SellerRegDays   int32        `parquet:"seller_reg_days,optional"`
+	SellerItemCount int32        `parquet:"seller_item_count,optional"`
+	SellerSoldCount int32        `parquet:"seller_sold_count,optional"`
+	TotalStock      int32        `parquet:"total_stock,optional"`
+	ImageCount      int32        `parquet:"image_count,optional"`
+	SKUs            []ParquetSKU `parquet:"skus,list"`
+}
+
+// ParquetSKU 商品规格
+type ParquetSKU struct {
+	SKUID       int64                `parquet:"sku_id"`
+	InventoryID int64                `parquet:"inventory_id,optional"`
+	Price       int64                `parquet:"price,decimal(2:18)"` // 价格（分）
+	Quantity    int32                `parquet:"quantity"`
+	Properties  []ParquetSKUProperty `parquet:"properties,list"`
+}
+
+// ParquetSKUProperty 规格属性，如 容量=256G
+type ParquetSKUProperty struct {
+	Name  string `parquet:"name"`
+	Value string `parquet:"value"`
+}
+
+// NewParquetItem 由猜你喜欢商品生成一行，at 为采集时间
+func NewParquetItem(item mtop.FeedItem, at time.Time) ParquetItem {
+	return ParquetItem{
+		ItemID:       item.ItemID,
+		Title:        item.Title,
+		Price:        cents(item.Price),
+		WantCount:    int32(item.WantCount),
+		ViewCount:    int32(item.ViewCount),
+		CategoryID:   int64(item.CategoryID),
+		Location:     item.Location,
+		SellerNick:   item.SellerNick,
+		SellerCredit: item.SellerCredit,
+		ShopLevel:    item.ShopLevel,
+		Status:       item.Status,
+		Condition:    item.Condition,
+		FreeShipping: item.FreeShipping,
+		Tags:         item.Tags,
+		ImageURL:     item.ImageURL,
+		VideoURL:     item.VideoURL,
+		PublishTime:  item.PublishTimeTS,
+		CapturedAt:   at.UnixMilli(),
+	}
+}
+
+// AddDetail 补充商品详情：详情中的价格、想要人数等较新，非空时覆盖列表中的值
+func (p *ParquetItem) AddDetail(d *mtop.ItemDetail) {
+	p.HasDetail = true
+	if v := cents(d.Price); v != 0 {
+		p.Price = v
+	}
+	if d.WantCount > 0 {
+		p.WantCount = int32(d.WantCount)
+	}
+	if d.ViewCount > 0 {
+		p.ViewCount = int32(d.ViewCount)
+	}
+	if status := d.ItemStatusStr; status != "" {
+		p.Status = status
+	}
+	if p.PublishTime == 0 {
+		p.PublishTime = d.PublishTimeTS
+	}
+	p.SubTitle = d.SubTitle
+	p.Description = d.Description
+	p.CollectCount = int32(d.CollectCount)
+	p.SellerID = d.SellerID
+	p.SellerCity = d.SellerCity
+	p.SellerRegDays = int32(d.SellerRegDays)
+	p.SellerItemCount = int32(d.SellerItemCount)
+	p.SellerSoldCount = int32(d.SellerSoldCount)
+	p.TotalStock = int32(d.TotalStock)
+	p.ImageCount = int32(len(d.ImageList))
+
+	p.SKUs = make([]ParquetSKU, 0, len(d.SKUList))
+	for _, s := range d.SKUList {
+		price := s.PriceInCent
+		if price == 0 {
+			price = s.Price
+		}
+		sku := ParquetSKU{
+			SKUID:       s.SKUID,
+			InventoryID: s.InventoryID,
+			Price:       int64(price),
+			Quantity:    int32(s.Quantity),
+			Properties:  make([]ParquetSKUProperty, 0, len(s.PropertyList)),
+		}
+		for _, prop := range s.PropertyList {
+			sku.Properties = append(sku.Properties, ParquetSKUProperty{Name: prop.PropertyText, Value: prop.ValueText})
+		}
+		p.SKUs = append(p.SKUs, sku)
+	}
+}
+
+// ParquetItems 生成一批商品的行，details 中有对应商品时补充详情
+func ParquetItems(items []mtop.FeedItem, details []*mtop.ItemDetail, at time.Time) []ParquetItem {
+	byID := make(map[string]*mtop.ItemDetail, len(details))
+	for _, d := range details {
+		if d != nil {
+			byID[d.ItemID] = d
+		}
+	}
+	rows := make([]ParquetItem, 0, len(items))
+	for _, item := range items {
+		row := NewParquetItem(item, at)
+		if d, ok := byID[item.ItemID]; ok {
+			row.AddDetail(d)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// WriteParquet 将商品写为一个 Parquet 文件（Snappy 压缩）
+func WriteParquet(w io.Writer, rows []ParquetItem) error {
+	pw := parquet.NewGenericWriter[ParquetItem](w, parquet.Compression(&parquet.Snappy))
+	if _, err := pw.Write(rows); err != nil {
+		pw.Close()
+		return err
+	}
+	return pw.Close()
+}
+
+// WriteParquetPartitions 按采集日期分区写入目录：dir/date=YYYY-MM-DD/part-N.parquet
+// 同一天再次写入时使用下一个未占用的 N，不覆盖已有文件；返回写入的文件路径
+func WriteParquetPartitions(dir string, rows []ParquetItem) ([]string, error) {
+	partitions := make(map[string][]ParquetItem)
+	for _, row := range rows {
+		date := time.UnixMilli(row.CapturedAt).Format("2006-01-02")
+		partitions[date] = append(partitions[date], row)
+	}
+	dates := make([]string, 0, len(partitions))
+	for date := range partitions {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	paths := make([]string, 0, len(dates))
+	for _, date := range dates {
+		path, err := writePartition(filepath.Join(dir, "date="+date), partitions[date])
+		if err != nil {
+			return paths, fmt.Errorf("写入分区 %s 失败: %w", date, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// writePartition 在分区目录中写入下一个 part 文件（先写临时文件再重命名，读取方不会看到半个文件）
+func writePartition(dir string, rows []ParquetItem) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := ""
+	for n := 0; ; n++ {
+		path = filepath.Join(dir, "part-"+strconv.Itoa(n)+".parquet")
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+	}
+
+	// 临时文件以 . 开头，DuckDB/Spark 按通配符读取时会忽略
+	tmp := filepath.Join(dir, "."+filepath.Base(path)+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	err = WriteParquet(f, rows)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return path, nil
+}
+
+// cents 将价格字符串转换为分，无法解析时为 0
+func cents(price string) int64 {
+	return int64(math.Round(history.ParsePrice(price) * 100))
+}
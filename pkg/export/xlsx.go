@@ -0,0 +1,285 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"xianyu_aner/pkg/feishu"
+)
+
+// DefaultHotWantCount 默认热门商品阈值：想要人数达到该值的行高亮
+const DefaultHotWantCount = 10
+
+// XLSXContentType xlsx 文件的 MIME 类型
+const XLSXContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// numericTextKeys 飞书中为文本、导出 xlsx 时写为数字单元格的字段（便于排序与求和）
+var numericTextKeys = map[string]bool{"price": true, "originalPrice": true}
+
+// XLSXOptions xlsx 写入选项
+type XLSXOptions struct {
+	HotWantCount int // 想要人数达到该值的行高亮，<=0 时使用 DefaultHotWantCount
+}
+
+// XLSXWriter 生成 Excel 报表：按采集日期每天一个工作表，表头冻结并带筛选，
+// 价格/想要人数/发布时间为数字或日期单元格，链接字段为超链接，热门商品整行高亮
+// xlsx 需要在结尾写入整个文件，Write 只按日期归组，WriteTo 时生成工作簿
+type XLSXWriter struct {
+	opts   XLSXOptions
+	cols   []Column
+	sheets map[string][]feishu.Product
+	count  int
+	now    func() time.Time
+}
+
+// xlsxStyles 工作簿中用到的样式
+type xlsxStyles struct {
+	header int
+	date   int
+	price  int
+	link   int
+	hot    int
+}
+
+// NewXLSXWriter 创建 xlsx 写入器
+func NewXLSXWriter(opts XLSXOptions) *XLSXWriter {
+	if opts.HotWantCount <= 0 {
+		opts.HotWantCount = DefaultHotWantCount
+	}
+	return &XLSXWriter{
+		opts:   opts,
+		cols:   Columns(),
+		sheets: make(map[string][]feishu.Product),
+		now:    time.Now,
+	}
+}
+
+// Write 追加一批商品（按采集时间归入对应日期的工作表，缺少采集时间时归入当天）
+func (x *XLSXWriter) Write(products []feishu.Product) {
+	for _, p := range products {
+		t := x.now()
+		if p.CaptureTimeMs > 0 {
+			t = time.UnixMilli(p.CaptureTimeMs)
+		}
+		date := t.Format("2006-01-02")
+		x.sheets[date] = append(x.sheets[date], p)
+		x.count++
+	}
+}
+
+// Len 已写入的商品数
+func (x *XLSXWriter) Len() int {
+	return x.count
+}
+
+// WriteTo 生成工作簿并写出
+func (x *XLSXWriter) WriteTo(w io.Writer) (int64, error) {
+	f, err := x.build()
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return f.WriteTo(w)
+}
+
+// build 生成工作簿，工作表按日期升序；没有数据时生成只有表头的当天工作表
+func (x *XLSXWriter) build() (*excelize.File, error) {
+	f := excelize.NewFile()
+	styles, err := newXLSXStyles(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	dates := make([]string, 0, len(x.sheets))
+	for date := range x.sheets {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	if len(dates) == 0 {
+		dates = []string{x.now().Format("2006-01-02")}
+	}
+
+	for i, date := range dates {
+		if i == 0 {
+			err = f.SetSheetName(f.GetSheetName(0), date)
+		} else {
+			_, err = f.NewSheet(date)
+		}
+		if err == nil {
+			err = x.writeSheet(f, date, x.sheets[date], styles)
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("生成工作表 %s 失败: %w", date, err)
+		}
+	}
+	f.SetActiveSheet(0)
+	return f, nil
+}
+
+// writeSheet 写入一个工作表：表头、数据、列宽、冻结表头、筛选与热门高亮
+func (x *XLSXWriter) writeSheet(f *excelize.File, sheet string, products []feishu.Product, styles xlsxStyles) error {
+	lastCol, _ := excelize.ColumnNumberToName(len(x.cols))
+	lastRow := len(products) + 1
+
+	headers := Headers(x.cols)
+	if err := f.SetSheetRow(sheet, "A1", &headers); err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(sheet, "A1", lastCol+"1", styles.header); err != nil {
+		return err
+	}
+
+	for r, p := range products {
+		for i, col := range x.cols {
+			cell, _ := excelize.CoordinatesToCellName(i+1, r+2)
+			if err := setCell(f, sheet, cell, p, col); err != nil {
+				return err
+			}
+		}
+	}
+
+	hotCol := ""
+	for i, col := range x.cols {
+		name, _ := excelize.ColumnNumberToName(i + 1)
+		if err := f.SetColWidth(sheet, name, name, columnWidth(col)); err != nil {
+			return err
+		}
+		if col.Key == "wantCnt" {
+			hotCol = name
+		}
+		if lastRow < 2 {
+			continue
+		}
+		style := 0
+		switch {
+		case col.Type == feishu.FieldTypeDateTime:
+			style = styles.date
+		case col.Type == feishu.FieldTypeURL:
+			style = styles.link
+		case numericTextKeys[col.Key]:
+			style = styles.price
+		}
+		if style != 0 {
+			if err := f.SetCellStyle(sheet, name+"2", name+strconv.Itoa(lastRow), style); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	if err := f.AutoFilter(sheet, "A1:"+lastCol+strconv.Itoa(lastRow), nil); err != nil {
+		return err
+	}
+	if hotCol == "" || lastRow < 2 {
+		return nil
+	}
+	return f.SetConditionalFormat(sheet, "A2:"+lastCol+strconv.Itoa(lastRow), []excelize.ConditionalFormatOptions{{
+		Type:     "formula",
+		Criteria: fmt.Sprintf("$%s2>=%d", hotCol, x.opts.HotWantCount),
+		Format:   &styles.hot,
+	}})
+}
+
+// setCell 按字段类型写入单元格：数字、日期、超链接或文本，空值不写
+func setCell(f *excelize.File, sheet, cell string, p feishu.Product, col Column) error {
+	switch col.Type {
+	case feishu.FieldTypeNumber:
+		switch v := Value(p, col.Key).(type) {
+		case float64:
+			if v == 0 {
+				return nil
+			}
+			return f.SetCellFloat(sheet, cell, v, -1, 64)
+		case nil:
+			return nil
+		default:
+			return f.SetCellValue(sheet, cell, v)
+		}
+	case feishu.FieldTypeDateTime:
+		t := Time(p, col)
+		if t.IsZero() {
+			return nil
+		}
+		return f.SetCellValue(sheet, cell, wallClock(t))
+	case feishu.FieldTypeURL:
+		link := Text(p, col)
+		if link == "" {
+			return nil
+		}
+		if err := f.SetCellValue(sheet, cell, link); err != nil {
+			return err
+		}
+		return f.SetCellHyperLink(sheet, cell, link, "External")
+	}
+
+	text := Text(p, col)
+	if text == "" {
+		return nil
+	}
+	if numericTextKeys[col.Key] {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+			return f.SetCellFloat(sheet, cell, v, -1, 64)
+		}
+	}
+	return f.SetCellStr(sheet, cell, text)
+}
+
+// newXLSXStyles 创建样式
+func newXLSXStyles(f *excelize.File) (xlsxStyles, error) {
+	var s xlsxStyles
+	var err error
+	dateFmt, priceFmt := "yyyy-mm-dd hh:mm:ss", "0.00"
+	if s.header, err = f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"DDEBF7"}},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	}); err != nil {
+		return s, err
+	}
+	if s.date, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateFmt}); err != nil {
+		return s, err
+	}
+	if s.price, err = f.NewStyle(&excelize.Style{CustomNumFmt: &priceFmt}); err != nil {
+		return s, err
+	}
+	if s.link, err = f.NewStyle(&excelize.Style{Font: &excelize.Font{Color: "1265BE", Underline: "single"}}); err != nil {
+		return s, err
+	}
+	if s.hot, err = f.NewConditionalStyle(&excelize.Style{
+		Font: &excelize.Font{Color: "9C0006"},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFC7CE"}},
+	}); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// columnWidth 列宽
+func columnWidth(col Column) float64 {
+	switch {
+	case col.Key == "title" || col.Key == "description":
+		return 40
+	case col.Type == feishu.FieldTypeURL:
+		return 30
+	case col.Type == feishu.FieldTypeDateTime:
+		return 20
+	case col.Type == feishu.FieldTypeNumber:
+		return 12
+	default:
+		return 16
+	}
+}
+
+// wallClock Excel 日期不含时区，按本地时间的年月日时分秒写入
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
@@ -2,21 +2,36 @@ package feishu
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"xianyu_aner/pkg/util"
 )
 
 // BitableConfig 多维表格配置
 type BitableConfig struct {
-	AppToken   string // 应用 token
-	TableToken string // 数据表 token
-}
+	AppToken          string // 应用 token
+	TableToken        string // 数据表 token
+	SearchBatchSize   int    // 去重时每次查询合并的商品ID数（OR 条件，<=0 或超过 MaxSearchConditions 时使用 MaxSearchConditions）
+	SearchConcurrency int    // 去重时同时进行的查询数（<=0 时使用 DefaultSearchConcurrency）
+	Upsert            bool   // 更新模式：已有商品ID的记录在字段变化时更新，而不是按价格/想要人数新增记录
+}
+
+const (
+	// MaxSearchConditions 查询记录接口单次过滤条件数上限
+	MaxSearchConditions = 50
+	// DefaultSearchConcurrency 去重查询的默认并发数（多维表格接口按应用限频，过高会频繁触发限流）
+	DefaultSearchConcurrency = 3
+)
 
 // BitableService 多维表格服务
 type BitableService struct {
 	client FeishuClient
 	config BitableConfig
+	cache  *DedupCache // 本地去重索引（可选）
 }
 
 // NewBitableService 创建多维表格服务
@@ -27,6 +42,12 @@ func NewBitableService(client FeishuClient, config BitableConfig) *BitableServic
 	}
 }
 
+// WithDedupCache 设置本地去重索引，去重时只有索引未命中的商品ID才查询飞书
+func (s *BitableService) WithDedupCache(cache *DedupCache) *BitableService {
+	s.cache = cache
+	return s
+}
+
 // PushProducts 推送商品列表
 func (s *BitableService) PushProducts(products []Product) (*PushToBitableResponse, error) {
 	return s.client.PushToBitable(s.config.AppToken, s.config.TableToken, products)
@@ -216,20 +237,20 @@ func (s *BitableService) GetOrCreateTableByDate(date time.Time) (tableID string,
 		existingNames = append(existingNames, t.Name)
 		// 查找是否存在同名表格（精确匹配）
 		if t.Name == tableName {
-			fmt.Printf("[DEBUG] 找到已存在表格: %s (ID: %s)\n", tableName, t.TableID)
+			util.Printf("[DEBUG] 找到已存在表格: %s (ID: %s)\n", tableName, t.TableID)
 			return t.TableID, false, nil
 		}
 	}
-	fmt.Printf("[DEBUG] 现有表格: %v, 查找: %s\n", existingNames, tableName)
+	util.Printf("[DEBUG] 现有表格: %v, 查找: %s\n", existingNames, tableName)
 
 	// 创建新表格，包含所有需要的字段
 	fields := s.buildFieldCreates()
-	fmt.Printf("[DEBUG] 开始创建表格: %s\n", tableName)
+	util.Printf("[DEBUG] 开始创建表格: %s\n", tableName)
 	tableInfo, err := s.client.CreateTable(s.config.AppToken, tableName, fields)
 	if err != nil {
 		// 检查是否是表格名重复错误（并发情况下可能被其他进程创建）
 		if strings.Contains(err.Error(), "Duplicated") || strings.Contains(err.Error(), "重复") {
-			fmt.Printf("[DEBUG] 表格名重复，重试查询: %s\n", tableName)
+			util.Printf("[DEBUG] 表格名重复，重试查询: %s\n", tableName)
 			// 等待一小段时间让数据同步
 			time.Sleep(500 * time.Millisecond)
 
@@ -244,7 +265,7 @@ func (s *BitableService) GetOrCreateTableByDate(date time.Time) (tableID string,
 			for _, t := range tables {
 				retryNames = append(retryNames, t.Name)
 				if t.Name == tableName {
-					fmt.Printf("[DEBUG] 重试找到表格: %s (ID: %s)\n", tableName, t.TableID)
+					util.Printf("[DEBUG] 重试找到表格: %s (ID: %s)\n", tableName, t.TableID)
 					return t.TableID, false, nil
 				}
 			}
@@ -253,7 +274,9 @@ func (s *BitableService) GetOrCreateTableByDate(date time.Time) (tableID string,
 		return "", false, fmt.Errorf("创建表格失败: %w", err)
 	}
 
-	fmt.Printf("[DEBUG] 表格创建成功: %s (ID: %s)\n", tableName, tableInfo.TableID)
+	util.Printf("[DEBUG] 表格创建成功: %s (ID: %s)\n", tableName, tableInfo.TableID)
+	// 新建的表格为空，去重时无需再查询
+	s.cache.Replace(tableInfo.TableID, nil)
 	return tableInfo.TableID, true, nil
 }
 
@@ -282,7 +305,7 @@ func (s *BitableService) EnsureTableFields(tableID string) error {
 		return fmt.Errorf("获取现有字段失败: %w", err)
 	}
 
-	fmt.Printf("[DEBUG] 现有字段数: %d\n", len(existingFields))
+	util.Printf("[DEBUG] 现有字段数: %d\n", len(existingFields))
 
 	// 统计字段创建结果
 	successCount := 0
@@ -304,16 +327,16 @@ func (s *BitableService) EnsureTableFields(tableID string) error {
 				},
 			}
 
-			fmt.Printf("[创建字段] %s (fieldName=%s, type=%d)\n", pf.Schema.Label, fieldName, pf.Schema.Type)
+			util.Printf("[创建字段] %s (fieldName=%s, type=%d)\n", pf.Schema.Label, fieldName, pf.Schema.Type)
 
 			fieldID, err := s.client.CreateField(s.config.AppToken, tableID, field)
 			if err != nil {
 				// 打印详细错误用于调试
-				fmt.Printf("[ERROR] 创建字段失败!\n")
-				fmt.Printf("  - 中文名称: %s\n", pf.Schema.Label)
-				fmt.Printf("  - 字段标识: %s\n", fieldName)
-				fmt.Printf("  - 字段类型: %d\n", pf.Schema.Type)
-				fmt.Printf("  - 错误信息: %v\n", err)
+				util.Printf("[ERROR] 创建字段失败!\n")
+				util.Printf("  - 中文名称: %s\n", pf.Schema.Label)
+				util.Printf("  - 字段标识: %s\n", fieldName)
+				util.Printf("  - 字段类型: %d\n", pf.Schema.Type)
+				util.Printf("  - 错误信息: %v\n", err)
 				failCount++
 				failedFields = append(failedFields, pf.Schema.Label)
 				// 继续创建其他字段，不直接返回错误
@@ -321,22 +344,22 @@ func (s *BitableService) EnsureTableFields(tableID string) error {
 			}
 
 			successCount++
-			fmt.Printf("[SUCCESS] 创建字段成功: %s (fieldID=%s)\n", pf.Schema.Label, fieldID)
+			util.Printf("[SUCCESS] 创建字段成功: %s (fieldID=%s)\n", pf.Schema.Label, fieldID)
 		} else {
 			skipCount++
 		}
 	}
 
 	// 打印统计信息
-	fmt.Printf("\n[字段创建统计]\n")
-	fmt.Printf("  成功: %d\n", successCount)
-	fmt.Printf("  跳过(已存在): %d\n", skipCount)
-	fmt.Printf("  失败: %d\n", failCount)
+	util.Printf("\n[字段创建统计]\n")
+	util.Printf("  成功: %d\n", successCount)
+	util.Printf("  跳过(已存在): %d\n", skipCount)
+	util.Printf("  失败: %d\n", failCount)
 
 	if failCount > 0 {
-		fmt.Printf("\n[失败字段列表]\n")
+		util.Printf("\n[失败字段列表]\n")
 		for i, name := range failedFields {
-			fmt.Printf("  %d. %s\n", i+1, name)
+			util.Printf("  %d. %s\n", i+1, name)
 		}
 		return fmt.Errorf("有 %d 个字段创建失败", failCount)
 	}
@@ -347,33 +370,49 @@ func (s *BitableService) EnsureTableFields(tableID string) error {
 // PushProductsToDateTable 推送商品数据到指定日期的表格
 // 如果表格不存在则创建，如果字段不存在则自动创建
 func (s *BitableService) PushProductsToDateTable(date time.Time, products []Product) (*PushToBitableResponse, error) {
+	return s.pushToDateTable(date, products, "")
+}
+
+// PushChunkToDateTable 重新推送之前失败的一批商品：流程同 PushProductsToDateTable，
+// 但作为单批推送并沿用原批次的 client_token，原请求实际已写入时不会重复创建记录
+func (s *BitableService) PushChunkToDateTable(date time.Time, clientToken string, products []Product) (*PushToBitableResponse, error) {
+	return s.pushToDateTable(date, products, clientToken)
+}
+
+// pushToDateTable clientToken 为空时按批次大小分批推送，否则以该 client_token 单批推送
+func (s *BitableService) pushToDateTable(date time.Time, products []Product, clientToken string) (*PushToBitableResponse, error) {
 	// 获取或创建表格
 	tableID, created, err := s.GetOrCreateTableByDate(date)
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Printf("[DEBUG] 推送数据到表格: tableID=%s, 商品数=%d\n", tableID, len(products))
+	util.Printf("[DEBUG] 推送数据到表格: tableID=%s, 商品数=%d\n", tableID, len(products))
 
 	// 如果是新创建的表格，字段已在创建时定义，无需再检查
 	// 如果是已存在的表格，确保所有字段都存在
 	if !created {
-		fmt.Printf("[DEBUG] 表格已存在，检查字段...\n")
+		util.Printf("[DEBUG] 表格已存在，检查字段...\n")
 		if err := s.EnsureTableFields(tableID); err != nil {
 			return nil, fmt.Errorf("确保字段存在失败: %w", err)
 		}
 
+		// 更新模式：按商品ID更新或创建记录
+		if s.config.Upsert {
+			return s.upsertProducts(tableID, products, clientToken)
+		}
+
 		// 去重：过滤掉已存在的商品
 		products, err = s.DeduplicateProducts(tableID, products)
 		if err != nil {
 			return nil, fmt.Errorf("去重失败: %w", err)
 		}
-		fmt.Printf("[DEBUG] 去重后商品数: %d\n", len(products))
+		util.Printf("[DEBUG] 去重后商品数: %d\n", len(products))
 	}
 
 	// 如果去重后没有数据需要推送，直接返回
 	if len(products) == 0 {
-		fmt.Println("[去重] 所有商品都已存在，无需推送")
+		util.Println("[去重] 所有商品都已存在，无需推送")
 		resp := &PushToBitableResponse{
 			Success: true,
 			Message: "所有商品都已存在",
@@ -383,12 +422,29 @@ func (s *BitableService) PushProductsToDateTable(date time.Time, products []Prod
 	}
 
 	// 推送数据
-	resp, err := s.client.PushToBitable(s.config.AppToken, tableID, products)
-	if err != nil {
-		return nil, fmt.Errorf("推送数据失败: %w", err)
+	// 部分批次失败时仍返回响应，其中包含各批结果
+	resp, pushErr := s.createRecords(tableID, products, clientToken)
+	if pushErr != nil {
+		if resp == nil {
+			return nil, fmt.Errorf("推送数据失败: %w", pushErr)
+		}
+		pushErr = fmt.Errorf("推送数据失败: %w", pushErr)
 	}
 
-	return resp, nil
+	// 推送成功的商品记录到本地去重索引，索引写入失败不影响推送结果
+	failed := FailedItemIDs(pushErr)
+	keys := make([]ProductKey, 0, len(products))
+	for _, product := range products {
+		if !failed[product.ItemID] {
+			keys = append(keys, s.buildProductKey(product.ItemID, product.Price, product.WantCnt))
+		}
+	}
+	s.cache.Add(tableID, keys...)
+	if err := s.cache.Save(); err != nil {
+		util.Printf("[去重] 保存本地去重索引失败: %v\n", err)
+	}
+
+	return resp, pushErr
 }
 
 // PushProductsToTodayTable 推送商品数据到今天的表格
@@ -441,101 +497,435 @@ func (s *BitableService) buildProductKey(itemID, price string, wantCnt int) Prod
 
 // extractProductKeyFromRecord 从飞书记录中提取商品唯一标识
 func (s *BitableService) extractProductKeyFromRecord(record map[string]interface{}, fieldNameMapping map[string]string) (ProductKey, error) {
-	// 获取商品ID
-	itemID := ""
-	if v, ok := record[fieldNameMapping["itemId"]]; ok {
-		if str, ok := v.(string); ok {
-			itemID = str
-		}
-	}
+	wantCnt, _ := strconv.Atoi(recordText(record[fieldNameMapping["wantCnt"]]))
+	return ProductKey{
+		ItemID:  recordText(record[fieldNameMapping["itemId"]]),
+		Price:   recordText(record[fieldNameMapping["price"]]),
+		WantCnt: wantCnt,
+	}, nil
+}
 
-	// 获取价格
-	price := ""
-	if v, ok := record[fieldNameMapping["price"]]; ok {
-		if str, ok := v.(string); ok {
-			price = str
+// recordText 将飞书记录中的字段值规范化为字符串，用于比较
+// 文本字段可能返回字符串或富文本片段数组，数字与日期返回 float64，超链接返回 {link, text}
+func recordText(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case bool:
+		return strconv.FormatBool(val)
+	case map[string]string:
+		return val["link"]
+	case map[string]interface{}:
+		if link, ok := val["link"]; ok {
+			return recordText(link)
 		}
-	}
-
-	// 获取想要人数
-	wantCnt := 0
-	if v, ok := record[fieldNameMapping["wantCnt"]]; ok {
-		switch val := v.(type) {
-		case float64:
-			wantCnt = int(val)
-		case int:
-			wantCnt = val
+		return recordText(val["text"])
+	case []interface{}:
+		var b strings.Builder
+		for _, seg := range val {
+			b.WriteString(recordText(seg))
 		}
+		return b.String()
+	default:
+		return fmt.Sprint(val)
 	}
-
-	return ProductKey{
-		ItemID:  itemID,
-		Price:   price,
-		WantCnt: wantCnt,
-	}, nil
 }
 
 // DeduplicateProducts 对商品列表进行去重
-// 改进的去重逻辑：逐个根据商品ID查询，而不是一次性获取所有记录
+// 将需要查询的商品ID按 SearchBatchSize 合并为 OR 条件批量查询，设置了本地去重索引时只查询索引未命中的商品ID
 // 去重标准：tableId + itemId + 价格 + 想要人数
 func (s *BitableService) DeduplicateProducts(tableID string, products []Product) ([]Product, error) {
-	fieldNameMapping := GetFieldNameMapping()
-	itemIDFieldName := fieldNameMapping["itemId"]
+	// 按商品ID分组（保持首次出现的顺序）
+	var itemIDs []string
+	keysByItemID := make(map[string][]ProductKey)
+	for _, product := range products {
+		if _, ok := keysByItemID[product.ItemID]; !ok {
+			itemIDs = append(itemIDs, product.ItemID)
+		}
+		keysByItemID[product.ItemID] = append(keysByItemID[product.ItemID],
+			s.buildProductKey(product.ItemID, product.Price, product.WantCnt))
+	}
+
+	// 本地索引无法确定结果的商品ID才查询飞书
+	var misses []string
+	for _, itemID := range itemIDs {
+		if !s.cache.Covers(tableID, itemID, keysByItemID[itemID]) {
+			misses = append(misses, itemID)
+		}
+	}
+	existingKeys, queries, err := s.searchExistingKeys(tableID, misses)
+	if err != nil {
+		return nil, err
+	}
 
+	// 检查每个商品是否重复
 	filteredProducts := make([]Product, 0, len(products))
 	duplicateCount := 0
-
-	// 按商品ID分组，同时去除本地重复
-	productsByItemID := make(map[string][]Product)
 	for _, product := range products {
-		productsByItemID[product.ItemID] = append(productsByItemID[product.ItemID], product)
-	}
-
-	// 对每个商品ID进行查询
-	for itemID, itemProducts := range productsByItemID {
-		// 构建过滤条件：根据商品ID查询
-		filter := FilterInfo{
-			Conjunction: "and",
-			Conditions: []Condition{
-				{
-					FieldName: itemIDFieldName,
-					Operator:  "is",
-					Value:     []string{itemID},
-				},
-			},
+		key := s.buildProductKey(product.ItemID, product.Price, product.WantCnt)
+		if !existingKeys[key] && !s.cache.Contains(tableID, key) {
+			filteredProducts = append(filteredProducts, product)
+			existingKeys[key] = true // 标记为已存在，防止本次推送中重复
+		} else {
+			duplicateCount++
 		}
+	}
+
+	if s.cache != nil {
+		util.Printf("[去重] %d 个商品ID，本地索引命中 %d 个，其余合并为 %d 次飞书查询\n",
+			len(itemIDs), len(itemIDs)-len(misses), queries)
+	} else {
+		util.Printf("[去重] %d 个商品ID，合并为 %d 次飞书查询\n", len(itemIDs), queries)
+	}
+	if err := s.cache.Save(); err != nil {
+		util.Printf("[去重] 保存本地去重索引失败: %v\n", err)
+	}
+	if duplicateCount > 0 {
+		util.Printf("[去重] 过滤掉 %d 条重复记录\n", duplicateCount)
+	}
 
-		// 查询该商品ID的现有记录
-		existingRecords, err := s.client.SearchRecords(s.config.AppToken, tableID, filter)
+	return filteredProducts, nil
+}
+
+// searchExistingKeys 查询商品ID的现有记录，返回记录的 key 集合与查询次数
+// 查询到的记录写入本地去重索引，并标记这些商品ID已查询过
+func (s *BitableService) searchExistingKeys(tableID string, itemIDs []string) (map[ProductKey]bool, int, error) {
+	records, queries, err := s.searchRecordsByItemIDs(tableID, itemIDs)
+	if err != nil {
+		return nil, queries, err
+	}
+
+	fieldNameMapping := GetFieldNameMapping()
+	existingKeys := make(map[ProductKey]bool)
+	keys := make([]ProductKey, 0, len(records))
+	for _, record := range records {
+		key, err := s.extractProductKeyFromRecord(record, fieldNameMapping)
 		if err != nil {
-			return nil, fmt.Errorf("查询商品ID=%s的记录失败: %w", itemID, err)
+			continue
 		}
+		existingKeys[key] = true
+		keys = append(keys, key)
+	}
+	s.cache.Add(tableID, keys...)
+	for _, itemID := range itemIDs {
+		s.cache.MarkChecked(tableID, itemID)
+	}
+	return existingKeys, queries, nil
+}
 
-		// 构建已存在记录的 key 集合（用于同一 itemID 下的去重）
-		existingKeys := make(map[ProductKey]bool)
-		for _, record := range existingRecords {
-			key, err := s.extractProductKeyFromRecord(record, fieldNameMapping)
+// searchRecordsByItemIDs 将商品ID按 SearchBatchSize 合并为 OR 条件，以 SearchConcurrency 并发查询现有记录
+// 返回全部记录与查询次数
+func (s *BitableService) searchRecordsByItemIDs(tableID string, itemIDs []string) ([]map[string]interface{}, int, error) {
+	if len(itemIDs) == 0 {
+		return nil, 0, nil
+	}
+
+	batchSize := s.config.SearchBatchSize
+	if batchSize <= 0 || batchSize > MaxSearchConditions {
+		batchSize = MaxSearchConditions
+	}
+	concurrency := s.config.SearchConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultSearchConcurrency
+	}
+	itemIDField := GetFieldNameMapping()["itemId"]
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, concurrency)
+		firstErr error
+		queries  int
+		all      []map[string]interface{}
+	)
+	for start := 0; start < len(itemIDs); start += batchSize {
+		batch := itemIDs[start:min(start+batchSize, len(itemIDs))]
+
+		sem <- struct{}{}
+		mu.Lock()
+		failed := firstErr != nil
+		queries++
+		mu.Unlock()
+		if failed {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(batch []string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			records, err := s.client.SearchRecords(s.config.AppToken, tableID, itemIDFilter(itemIDField, batch))
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				continue
+				if firstErr == nil {
+					firstErr = fmt.Errorf("查询商品ID=%s等 %d 个商品的记录失败: %w", batch[0], len(batch), err)
+				}
+				return
 			}
-			existingKeys[key] = true
+			all = append(all, records...)
+		}(batch)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, queries, firstErr
+	}
+	return all, queries, nil
+}
+
+// itemIDFilter 构建按商品ID查询的过滤条件（任一商品ID匹配）
+func itemIDFilter(fieldName string, itemIDs []string) FilterInfo {
+	conditions := make([]Condition, 0, len(itemIDs))
+	for _, itemID := range itemIDs {
+		conditions = append(conditions, Condition{FieldName: fieldName, Operator: "is", Value: []string{itemID}})
+	}
+	return FilterInfo{Conjunction: "or", Conditions: conditions}
+}
+
+// ReconcileDedupCache 全量读取远程表的记录，重建该表的本地去重索引，返回索引中的记录数
+// 用于飞书中手动删除或修改记录后，避免本地索引把这些商品误判为重复
+func (s *BitableService) ReconcileDedupCache(tableID string) (int, error) {
+	if s.cache == nil {
+		return 0, fmt.Errorf("未启用本地去重索引")
+	}
+	records, err := s.client.GetTableRecords(s.config.AppToken, tableID)
+	if err != nil {
+		return 0, fmt.Errorf("获取表格记录失败: %w", err)
+	}
+	fieldNameMapping := GetFieldNameMapping()
+	keys := make([]ProductKey, 0, len(records))
+	for _, record := range records {
+		key, err := s.extractProductKeyFromRecord(record, fieldNameMapping)
+		if err != nil || key.ItemID == "" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	s.cache.Replace(tableID, keys)
+	if err := s.cache.Save(); err != nil {
+		return 0, fmt.Errorf("保存本地去重索引失败: %w", err)
+	}
+	return s.cache.Len(tableID), nil
+}
+
+// upsertIgnoredFields 判断记录是否变化时忽略的字段（每次采集都会变化）
+var upsertIgnoredFields = map[string]bool{"captureTimeMs": true}
+
+// UpsertProducts 按商品ID更新或创建记录：表格中没有的商品创建记录，字段有变化的记录通过 batch_update 更新，
+// 其余计为未变化。价格或想要人数变化时，同时写入上次价格与想要变化。
+// 匹配到的记录缺少记录ID时无法更新，与创建失败的商品一起计入失败并通过 *PushError 返回
+// 同一商品ID有多条记录时（旧版按价格/想要人数新增的记录）更新采集时间最新的一条
+func (s *BitableService) UpsertProducts(tableID string, products []Product) (*PushToBitableResponse, error) {
+	return s.upsertProducts(tableID, products, "")
+}
+
+// createRecords 创建记录：clientToken 为空时分批推送，否则以该 client_token 单批推送
+func (s *BitableService) createRecords(tableID string, products []Product, clientToken string) (*PushToBitableResponse, error) {
+	if clientToken != "" {
+		return s.client.PushChunk(s.config.AppToken, tableID, clientToken, products)
+	}
+	return s.client.PushToBitable(s.config.AppToken, tableID, products)
+}
+
+func (s *BitableService) upsertProducts(tableID string, products []Product, clientToken string) (*PushToBitableResponse, error) {
+	fieldNameMapping := GetFieldNameMapping()
+
+	// 同一商品ID只保留最后一条（保持首次出现的顺序）
+	var itemIDs []string
+	latest := make(map[string]Product)
+	for _, product := range products {
+		if _, ok := latest[product.ItemID]; !ok {
+			itemIDs = append(itemIDs, product.ItemID)
 		}
+		latest[product.ItemID] = product
+	}
 
-		// 检查每个商品是否重复
-		for _, product := range itemProducts {
-			key := s.buildProductKey(product.ItemID, product.Price, product.WantCnt)
-			if !existingKeys[key] {
-				filteredProducts = append(filteredProducts, product)
-				existingKeys[key] = true // 标记为已存在，防止本次推送中重复
-			} else {
-				duplicateCount++
+	records, queries, err := s.searchRecordsByItemIDs(tableID, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]map[string]interface{})
+	for _, record := range records {
+		itemID := recordText(record[fieldNameMapping["itemId"]])
+		if old, ok := existing[itemID]; ok &&
+			ParseTimestamp(old[fieldNameMapping["captureTimeMs"]]) >= ParseTimestamp(record[fieldNameMapping["captureTimeMs"]]) {
+			continue
+		}
+		existing[itemID] = record
+	}
+
+	var (
+		creates   []Product
+		updates   []RecordUpdate
+		staleKeys []ProductKey // 更新后不再存在的旧 key
+		newKeys   []ProductKey
+		noRecord  []string // 匹配到的记录缺少记录ID，无法更新
+	)
+	unchanged := 0
+	for _, itemID := range itemIDs {
+		product := latest[itemID]
+		key := s.buildProductKey(product.ItemID, product.Price, product.WantCnt)
+		record, ok := existing[itemID]
+		if !ok {
+			creates = append(creates, product)
+			newKeys = append(newKeys, key)
+			continue
+		}
+		recordID, _ := record[RecordIDKey].(string)
+		if recordID == "" {
+			noRecord = append(noRecord, itemID)
+			continue
+		}
+		fields := buildRecordFields(product, fieldNameMapping)
+		if !recordChanged(record, fields) {
+			unchanged++
+			continue
+		}
+
+		oldKey, _ := s.extractProductKeyFromRecord(record, fieldNameMapping)
+		if oldKey.Price != key.Price || oldKey.WantCnt != key.WantCnt {
+			fields[fieldNameMapping[LastPriceField]] = oldKey.Price
+			fields[fieldNameMapping[WantDeltaField]] = key.WantCnt - oldKey.WantCnt
+			staleKeys = append(staleKeys, oldKey)
+		}
+		updates = append(updates, RecordUpdate{RecordID: recordID, Fields: fields})
+		newKeys = append(newKeys, key)
+	}
+	util.Printf("[更新模式] %d 个商品ID（%d 次查询）：新增 %d，更新 %d，未变化 %d，缺少记录ID %d\n",
+		len(itemIDs), queries, len(creates), len(updates), unchanged, len(noRecord))
+
+	resp := &PushToBitableResponse{Success: true}
+	resp.Data.TableToken = tableID
+	resp.Data.RecordsUnchanged = unchanged
+
+	// 缺少记录ID与创建失败的商品合并为一个 *PushError，调用方可按商品ID重试
+	var pushErr *PushError
+	if len(noRecord) > 0 {
+		pushErr = &PushError{
+			Total:   len(itemIDs),
+			Failed:  len(noRecord),
+			ItemIDs: noRecord,
+			Err:     fmt.Errorf("%d 条已有记录缺少记录ID，无法更新", len(noRecord)),
+		}
+	}
+	if len(creates) > 0 {
+		// 部分批次失败时继续更新已有记录，最后一并返回错误
+		created, err := s.createRecords(tableID, creates, clientToken)
+		if created == nil {
+			return nil, fmt.Errorf("推送数据失败: %w", err)
+		}
+		resp.Data.RecordsCreated = created.Data.RecordsCreated
+		resp.Data.Chunks = created.Data.Chunks
+		if err != nil {
+			failed := FailedItemIDs(err)
+			if pushErr == nil {
+				pushErr = &PushError{Total: len(itemIDs), Err: err}
 			}
+			kept := newKeys[:0]
+			for _, key := range newKeys {
+				if failed[key.ItemID] {
+					pushErr.Failed++
+					pushErr.ItemIDs = append(pushErr.ItemIDs, key.ItemID)
+				} else {
+					kept = append(kept, key)
+				}
+			}
+			newKeys = kept
 		}
 	}
+	if pushErr != nil {
+		resp.Data.RecordsFailed = pushErr.Failed
+	}
+	if len(updates) > 0 {
+		n, err := s.client.BatchUpdateRecords(s.config.AppToken, tableID, updates)
+		resp.Data.RecordsUpdated = n
+		if err != nil {
+			return resp, fmt.Errorf("更新记录失败（已创建 %d 条，已更新 %d 条）: %w", resp.Data.RecordsCreated, n, err)
+		}
+	}
+	resp.Message = fmt.Sprintf("新增 %d 条，更新 %d 条，未变化 %d 条",
+		resp.Data.RecordsCreated, resp.Data.RecordsUpdated, resp.Data.RecordsUnchanged)
+
+	// 同步本地去重索引：更新后的记录不再对应旧的价格/想要人数
+	s.cache.Remove(tableID, staleKeys...)
+	s.cache.Add(tableID, newKeys...)
+	if err := s.cache.Save(); err != nil {
+		util.Printf("[去重] 保存本地去重索引失败: %v\n", err)
+	}
 
-	if duplicateCount > 0 {
-		fmt.Printf("[去重] 过滤掉 %d 条重复记录\n", duplicateCount)
+	if pushErr != nil {
+		resp.Success = false
+		resp.Message = fmt.Sprintf("%s，%d 条推送失败", resp.Message, resp.Data.RecordsFailed)
+		return resp, fmt.Errorf("推送数据失败: %w", pushErr)
 	}
+	return resp, nil
+}
 
-	return filteredProducts, nil
+// recordChanged 判断新字段与现有记录相比是否有变化（忽略 upsertIgnoredFields，新字段为空时不比较）
+func recordChanged(record map[string]interface{}, fields map[string]interface{}) bool {
+	for name, value := range fields {
+		if upsertIgnoredFields[name] {
+			continue
+		}
+		if recordText(record[name]) != recordText(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusSearchTables 更新处理状态时最多查找的数据表数（按日期从新到旧）
+const statusSearchTables = 30
+
+// SetItemStatus 在最近一张包含该商品的日期表中更新处理状态，返回数据表ID与更新的记录数
+// 未找到商品记录时返回空数据表ID
+func (s *BitableService) SetItemStatus(itemID, status string) (string, int, error) {
+	tables, err := s.client.GetBitableTableInfos(s.config.AppToken)
+	if err != nil {
+		return "", 0, fmt.Errorf("获取表格列表失败: %w", err)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name > tables[j].Name })
+	if len(tables) > statusSearchTables {
+		tables = tables[:statusSearchTables]
+	}
+
+	filter := itemIDFilter(GetFieldNameMapping()["itemId"], []string{itemID})
+	for _, table := range tables {
+		records, err := s.client.SearchRecords(s.config.AppToken, table.TableID, filter)
+		if err != nil {
+			return "", 0, fmt.Errorf("查询商品ID=%s的记录失败: %w", itemID, err)
+		}
+		if len(records) == 0 {
+			continue
+		}
+
+		// 旧表可能缺少处理状态字段
+		if err := s.EnsureTableFields(table.TableID); err != nil {
+			return "", 0, err
+		}
+		updated := 0
+		for _, record := range records {
+			recordID, _ := record[RecordIDKey].(string)
+			if recordID == "" {
+				continue
+			}
+			if err := s.client.UpdateRecord(s.config.AppToken, table.TableID, recordID, map[string]interface{}{StatusField: status}); err != nil {
+				return table.TableID, updated, fmt.Errorf("更新记录 %s 失败: %w", recordID, err)
+			}
+			updated++
+		}
+		return table.TableID, updated, nil
+	}
+	return "", 0, nil
 }
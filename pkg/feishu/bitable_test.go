@@ -1,7 +1,14 @@
 package feishu
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 )
@@ -148,6 +155,13 @@ type MockClient struct {
 	createdTables      []string
 	createdFields      map[string][]string
 	pushedRecordsCount int
+	records            []map[string]interface{} // 表格中已有的记录（SearchRecords 按商品ID过滤）
+
+	mu          sync.Mutex // 去重查询会并发调用 SearchRecords
+	searchCalls int
+	filters     []FilterInfo
+	updates     []RecordUpdate
+	pushed      []Product
 }
 
 func (m *MockClient) GetTenantAccessToken() (string, error) {
@@ -177,10 +191,17 @@ func (m *MockClient) CreateField(appToken, tableToken string, field FieldCreate)
 
 func (m *MockClient) PushToBitable(appToken, tableToken string, products []Product) (*PushToBitableResponse, error) {
 	m.pushedRecordsCount = len(products)
-	return &PushToBitableResponse{
+	m.pushed = append(m.pushed, products...)
+	resp := &PushToBitableResponse{
 		Success: true,
 		Message: "推送成功",
-	}, nil
+	}
+	resp.Data.RecordsCreated = len(products)
+	return resp, nil
+}
+
+func (m *MockClient) PushChunk(appToken, tableToken, clientToken string, products []Product) (*PushToBitableResponse, error) {
+	return m.PushToBitable(appToken, tableToken, products)
 }
 
 func (m *MockClient) CreateRecord(appToken, tableToken string, product Product) error {
@@ -189,13 +210,32 @@ func (m *MockClient) CreateRecord(appToken, tableToken string, product Product)
 }
 
 func (m *MockClient) GetTableRecords(appToken, tableToken string) ([]map[string]interface{}, error) {
-	// 返回空记录列表用于测试
-	return []map[string]interface{}{}, nil
+	return append([]map[string]interface{}{}, m.records...), nil
 }
 
 func (m *MockClient) SearchRecords(appToken, tableToken string, filter FilterInfo) ([]map[string]interface{}, error) {
-	// 返回空记录列表用于测试
-	return []map[string]interface{}{}, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.searchCalls++
+	m.filters = append(m.filters, filter)
+	var found []map[string]interface{}
+	for _, record := range m.records {
+		for _, cond := range filter.Conditions {
+			if len(cond.Value) > 0 && record[cond.FieldName] == cond.Value[0] {
+				found = append(found, record)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (m *MockClient) UpdateRecord(appToken, tableToken, recordID string, fields map[string]interface{}) error {
+	return nil
+}
+
+func (m *MockClient) BatchUpdateRecords(appToken, tableToken string, records []RecordUpdate) (int, error) {
+	m.updates = append(m.updates, records...)
+	return len(records), nil
 }
 
 // TestBitableService_GetOrCreateTableByDate 测试获取或创建表格
@@ -383,6 +423,605 @@ func TestBitableService_PushProductsToDateTable(t *testing.T) {
 	})
 }
 
+// TestBitableService_DedupCache 测试本地去重索引：命中时不查询飞书，推送后写入索引，对账后以远程表为准
+func TestBitableService_DedupCache(t *testing.T) {
+	testDate := time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC)
+	existingFields := make(map[string]string)
+	for _, pf := range ProductFields {
+		existingFields[pf.Key] = "field_" + pf.Key
+	}
+	mapping := GetFieldNameMapping()
+	record := func(itemID, price string, wantCnt int) map[string]interface{} {
+		return map[string]interface{}{mapping["itemId"]: itemID, mapping["price"]: price, mapping["wantCnt"]: float64(wantCnt)}
+	}
+	products := []Product{
+		{ItemID: "item1", Price: "100", WantCnt: 1},
+		{ItemID: "item2", Price: "200", WantCnt: 2},
+	}
+	path := filepath.Join(t.TempDir(), "dedup.json")
+
+	// 第一次推送：item1 已在飞书中，逐个查询
+	cache, err := LoadDedupCache(path, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("LoadDedupCache() error = %v", err)
+	}
+	mockClient := &MockClient{
+		tables:  []TableInfo{{TableID: "tbl123", Name: "2026-01-11"}},
+		fields:  existingFields,
+		records: []map[string]interface{}{record("item1", "100", 1)},
+	}
+	service := NewBitableService(mockClient, BitableConfig{AppToken: "app123"}).WithDedupCache(cache)
+	if _, err := service.PushProductsToDateTable(testDate, products); err != nil {
+		t.Fatalf("PushProductsToDateTable() error = %v", err)
+	}
+	if mockClient.pushedRecordsCount != 1 || mockClient.searchCalls != 1 {
+		t.Errorf("pushed=%d searches=%d, want 1 1", mockClient.pushedRecordsCount, mockClient.searchCalls)
+	}
+
+	// 新进程重新加载索引：已推送和已查询到的商品都命中，只查询新商品
+	cache, err = LoadDedupCache(path, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("LoadDedupCache() error = %v", err)
+	}
+	if n := cache.Len("tbl123"); n != 2 {
+		t.Fatalf("索引记录数 = %d, want 2", n)
+	}
+	mockClient.searchCalls, mockClient.pushedRecordsCount = 0, 0
+	service = NewBitableService(mockClient, BitableConfig{AppToken: "app123"}).WithDedupCache(cache)
+	more := append(products, Product{ItemID: "item3", Price: "300"}, Product{ItemID: "item2", Price: "180", WantCnt: 2})
+	unique, err := service.DeduplicateProducts("tbl123", more)
+	if err != nil {
+		t.Fatalf("DeduplicateProducts() error = %v", err)
+	}
+	if len(unique) != 2 || mockClient.searchCalls != 1 {
+		t.Errorf("unique=%d searches=%d, want 2 1", len(unique), mockClient.searchCalls)
+	}
+	if conds := mockClient.filters[len(mockClient.filters)-1].Conditions; len(conds) != 2 {
+		t.Errorf("只应查询未命中的 2 个商品ID，got %v", conds)
+	}
+
+	// 同一进程中再次去重：已查询过的商品ID不再查询
+	mockClient.searchCalls = 0
+	if _, err := service.DeduplicateProducts("tbl123", more); err != nil {
+		t.Fatalf("DeduplicateProducts() error = %v", err)
+	}
+	if mockClient.searchCalls != 0 {
+		t.Errorf("searches = %d, want 0", mockClient.searchCalls)
+	}
+
+	// 飞书中删除了 item2 的记录，对账后不再视为重复
+	n, err := service.ReconcileDedupCache("tbl123")
+	if err != nil {
+		t.Fatalf("ReconcileDedupCache() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("对账后索引记录数 = %d, want 1", n)
+	}
+	mockClient.searchCalls = 0
+	unique, err = service.DeduplicateProducts("tbl123", products)
+	if err != nil {
+		t.Fatalf("DeduplicateProducts() error = %v", err)
+	}
+	if len(unique) != 1 || unique[0].ItemID != "item2" || mockClient.searchCalls != 0 {
+		t.Errorf("unique=%v searches=%d", unique, mockClient.searchCalls)
+	}
+}
+
+// TestBitableService_UpsertProducts 测试更新模式：按商品ID新增、更新或跳过未变化的记录
+func TestBitableService_UpsertProducts(t *testing.T) {
+	testDate := time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC)
+	existingFields := make(map[string]string)
+	for _, pf := range ProductFields {
+		existingFields[pf.Key] = "field_" + pf.Key
+	}
+	m := GetFieldNameMapping()
+	mockClient := &MockClient{
+		tables: []TableInfo{{TableID: "tbl123", Name: "2026-01-11"}},
+		fields: existingFields,
+		records: []map[string]interface{}{
+			{RecordIDKey: "rec0", m["itemId"]: "item1", m["price"]: "120", m["wantCnt"]: float64(0), m["captureTimeMs"]: float64(500)},
+			{RecordIDKey: "rec1", m["itemId"]: "item1", m["price"]: "100", m["wantCnt"]: float64(1), m["title"]: "A", m["captureTimeMs"]: float64(1000)},
+			// 飞书返回的文本字段可能是富文本片段，超链接字段带 text
+			{RecordIDKey: "rec2", m["itemId"]: "item2", m["price"]: "200", m["wantCnt"]: float64(2),
+				m["title"]:     []interface{}{map[string]interface{}{"text": "B", "type": "text"}},
+				m["detailUrl"]: map[string]interface{}{"link": "https://x/2", "text": "https://x/2"}},
+			{RecordIDKey: "rec3", m["itemId"]: "item3", m["price"]: "300", m["wantCnt"]: float64(3), m["title"]: "C"},
+			// 缺少记录ID的记录无法更新，计为失败
+			{m["itemId"]: "item5", m["price"]: "500", m["wantCnt"]: float64(5)},
+		},
+	}
+	cache, _ := LoadDedupCache("", 0)
+	cache.Add("tbl123", ProductKey{ItemID: "item1", Price: "100", WantCnt: 1})
+	service := NewBitableService(mockClient, BitableConfig{AppToken: "app123", Upsert: true}).WithDedupCache(cache)
+
+	products := []Product{
+		{ItemID: "item1", Price: "90", WantCnt: 4, Title: "A", CaptureTimeMs: 2000},
+		{ItemID: "item2", Price: "200", WantCnt: 2, Title: "B", DetailURL: "https://x/2", CaptureTimeMs: 2000},
+		{ItemID: "item3", Price: "300", WantCnt: 3, Title: "C2"},
+		{ItemID: "item4", Price: "400", WantCnt: 1},
+		{ItemID: "item5", Price: "500", WantCnt: 5},
+	}
+	resp, err := service.PushProductsToDateTable(testDate, products)
+	if resp == nil {
+		t.Fatalf("PushProductsToDateTable() error = %v", err)
+	}
+	if failed := FailedItemIDs(err); len(failed) != 1 || !failed["item5"] {
+		t.Errorf("FailedItemIDs() = %v (err=%v), want item5", failed, err)
+	}
+	if resp.Success || resp.Data.RecordsCreated != 1 || resp.Data.RecordsUpdated != 2 ||
+		resp.Data.RecordsUnchanged != 1 || resp.Data.RecordsFailed != 1 {
+		t.Errorf("created=%d updated=%d unchanged=%d failed=%d, want 1 2 1 1", resp.Data.RecordsCreated,
+			resp.Data.RecordsUpdated, resp.Data.RecordsUnchanged, resp.Data.RecordsFailed)
+	}
+	if len(mockClient.pushed) != 1 || mockClient.pushed[0].ItemID != "item4" {
+		t.Errorf("pushed = %+v", mockClient.pushed)
+	}
+
+	updates := make(map[string]map[string]interface{})
+	for _, u := range mockClient.updates {
+		updates[u.RecordID] = u.Fields
+	}
+	// 同一商品ID有多条记录时更新采集时间最新的一条，并记录上次价格与想要变化
+	rec1 := updates["rec1"]
+	if rec1 == nil || rec1[m["price"]] != "90" || rec1[m[LastPriceField]] != "100" || rec1[m[WantDeltaField]] != 3 {
+		t.Errorf("rec1 update = %v", rec1)
+	}
+	// 只有标题变化时不写变化跟踪字段
+	rec3 := updates["rec3"]
+	if rec3 == nil || rec3[m["title"]] != "C2" {
+		t.Errorf("rec3 update = %v", rec3)
+	}
+	if _, ok := rec3[m[LastPriceField]]; ok {
+		t.Errorf("rec3 不应写入上次价格: %v", rec3)
+	}
+	if _, ok := updates["rec0"]; ok {
+		t.Error("不应更新旧记录 rec0")
+	}
+
+	// 本地索引中旧的价格/想要人数被替换
+	if cache.Contains("tbl123", ProductKey{ItemID: "item1", Price: "100", WantCnt: 1}) ||
+		!cache.Contains("tbl123", ProductKey{ItemID: "item1", Price: "90", WantCnt: 4}) {
+		t.Error("更新后本地索引未同步")
+	}
+}
+
+// TestBitableService_DeduplicateBatched 测试去重查询按批次合并为 OR 条件
+func TestBitableService_DeduplicateBatched(t *testing.T) {
+	mapping := GetFieldNameMapping()
+	var products []Product
+	mockClient := &MockClient{}
+	for i := 0; i < 120; i++ {
+		id := fmt.Sprintf("item%d", i)
+		products = append(products, Product{ItemID: id, Price: "10"})
+		if i%10 == 0 {
+			mockClient.records = append(mockClient.records, map[string]interface{}{mapping["itemId"]: id, mapping["price"]: "10", mapping["wantCnt"]: float64(0)})
+		}
+	}
+	products = append(products, Product{ItemID: "item1", Price: "10"}) // 本次推送中重复
+
+	service := NewBitableService(mockClient, BitableConfig{AppToken: "app123", SearchConcurrency: 2})
+	unique, err := service.DeduplicateProducts("tbl123", products)
+	if err != nil {
+		t.Fatalf("DeduplicateProducts() error = %v", err)
+	}
+	if len(unique) != 108 {
+		t.Errorf("unique = %d, want 108", len(unique))
+	}
+	if unique[0].ItemID != "item1" || unique[len(unique)-1].ItemID != "item119" {
+		t.Errorf("应保持原有顺序: first=%s last=%s", unique[0].ItemID, unique[len(unique)-1].ItemID)
+	}
+	if mockClient.searchCalls != 3 {
+		t.Errorf("searches = %d, want 3", mockClient.searchCalls)
+	}
+	total := 0
+	for _, f := range mockClient.filters {
+		if f.Conjunction != "or" || len(f.Conditions) > MaxSearchConditions {
+			t.Errorf("filter conjunction=%s conditions=%d", f.Conjunction, len(f.Conditions))
+		}
+		total += len(f.Conditions)
+	}
+	if total != 120 {
+		t.Errorf("查询的商品ID数 = %d, want 120", total)
+	}
+
+	// 批次大小可配置
+	mockClient.searchCalls = 0
+	service = NewBitableService(mockClient, BitableConfig{AppToken: "app123", SearchBatchSize: 7})
+	if _, err := service.DeduplicateProducts("tbl123", products[:20]); err != nil {
+		t.Fatalf("DeduplicateProducts() error = %v", err)
+	}
+	if mockClient.searchCalls != 3 {
+		t.Errorf("searches = %d, want 3", mockClient.searchCalls)
+	}
+}
+
+// TestClient_SearchRecordsRateLimit 测试查询记录触发频率限制时等待后重试
+func TestClient_SearchRecordsRateLimit(t *testing.T) {
+	var searches int
+	mux := http.NewServeMux()
+	mux.HandleFunc(authPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"code":0,"tenant_access_token":"t-token","expire":7200}`)
+	})
+	mux.HandleFunc("/open-apis/bitable/v1/apps/app123/tables/tbl123/records/search", func(w http.ResponseWriter, r *http.Request) {
+		searches++
+		switch {
+		case searches <= 1: // 置为负数时持续限流
+			w.WriteHeader(http.StatusTooManyRequests)
+		case searches == 2:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":99991400,"msg":"request trigger frequency limit"}`)
+		case searches == 3:
+			fmt.Fprint(w, `{"code":1254290,"msg":"TooManyRequest"}`)
+		case searches == 4:
+			fmt.Fprint(w, `{"code":1254291,"msg":"Write conflict"}`)
+		default:
+			fmt.Fprint(w, `{"code":0,"data":{"has_more":false,"items":[{"record_id":"rec1","fields":{"itemId":"1"}}]}}`)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClient(ClientConfig{AppID: "id", AppSecret: "secret", BaseURL: srv.URL})
+	client.retryBackoff = time.Millisecond
+	records, err := client.SearchRecords("app123", "tbl123", itemIDFilter("itemId", []string{"1"}))
+	if err != nil {
+		t.Fatalf("SearchRecords() error = %v", err)
+	}
+	if searches != 5 || len(records) != 1 || records[0][RecordIDKey] != "rec1" {
+		t.Errorf("searches=%d records=%v", searches, records)
+	}
+
+	// 超过重试次数后返回错误
+	searches = -100
+	if _, err := client.SearchRecords("app123", "tbl123", itemIDFilter("itemId", []string{"1"})); err == nil {
+		t.Error("持续限流时应返回错误")
+	}
+}
+
+// TestClient_RetryTransportError 测试连接中断等网络错误按退避重试
+func TestClient_RetryTransportError(t *testing.T) {
+	var searches int
+	mux := http.NewServeMux()
+	mux.HandleFunc(authPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"code":0,"tenant_access_token":"t-token","expire":7200}`)
+	})
+	mux.HandleFunc("/open-apis/bitable/v1/apps/app123/tables/tbl123/records/search", func(w http.ResponseWriter, r *http.Request) {
+		searches++
+		if searches <= 2 { // 置为负数时持续断开
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		fmt.Fprint(w, `{"code":0,"data":{"has_more":false,"items":[{"record_id":"rec1","fields":{"itemId":"1"}}]}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClient(ClientConfig{AppID: "id", AppSecret: "secret", BaseURL: srv.URL})
+	client.retryBackoff = time.Millisecond
+	records, err := client.SearchRecords("app123", "tbl123", itemIDFilter("itemId", []string{"1"}))
+	if err != nil {
+		t.Fatalf("SearchRecords() error = %v", err)
+	}
+	if searches != 3 || len(records) != 1 {
+		t.Errorf("searches=%d records=%v", searches, records)
+	}
+
+	searches = -100
+	if _, err := client.SearchRecords("app123", "tbl123", itemIDFilter("itemId", []string{"1"})); err == nil {
+		t.Error("持续网络错误时应返回错误")
+	}
+}
+
+// TestClient_PushToBitableChunks 测试分批推送：服务端错误重试，失败的批次不影响其余批次
+func TestClient_PushToBitableChunks(t *testing.T) {
+	itemField := GetFieldNameMapping()["itemId"]
+	var mu sync.Mutex
+	calls := make(map[string]int) // 每批第一个商品ID -> 请求次数
+	mux := http.NewServeMux()
+	mux.HandleFunc(authPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"code":0,"tenant_access_token":"t-token","expire":7200}`)
+	})
+	mux.HandleFunc(fmt.Sprintf(bitableBatchPath, "app123", "tbl123"), func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Records []Record `json:"records"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		first := fmt.Sprint(req.Records[0].Fields[itemField])
+		mu.Lock()
+		calls[first]++
+		n := calls[first]
+		mu.Unlock()
+		switch {
+		case first == "3": // 第二批持续失败
+			w.WriteHeader(http.StatusInternalServerError)
+		case first == "1" && n == 1: // 第一批首次请求失败，重试后成功
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": req})
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClient(ClientConfig{AppID: "id", AppSecret: "secret", BaseURL: srv.URL, BatchSize: 2})
+	client.retryBackoff = time.Millisecond
+	products := []Product{{ItemID: "1"}, {ItemID: "2"}, {ItemID: "3"}, {ItemID: "4"}, {ItemID: "5"}}
+	resp, err := client.PushToBitable("app123", "tbl123", products)
+	if err == nil || resp == nil {
+		t.Fatalf("PushToBitable() resp=%v err=%v, want partial failure", resp, err)
+	}
+	if resp.Success || resp.Data.RecordsCreated != 3 || resp.Data.RecordsFailed != 2 || len(resp.Data.Chunks) != 3 {
+		t.Errorf("resp = %+v", resp.Data)
+	}
+	if calls["1"] != 2 || calls["3"] != maxRetries+1 || calls["5"] != 1 {
+		t.Errorf("calls = %v", calls)
+	}
+	chunk := resp.Data.Chunks[1]
+	if chunk.Error == "" || fmt.Sprint(chunk.ItemIDs) != "[3 4]" || resp.Data.Chunks[2].Created != 1 {
+		t.Errorf("chunks = %+v", resp.Data.Chunks)
+	}
+	if failed := FailedItemIDs(err); len(failed) != 2 || !failed["3"] || !failed["4"] {
+		t.Errorf("FailedItemIDs() = %v", failed)
+	}
+}
+
+// TestClient_PushToBitableIdempotent 测试服务端已写入但返回 5xx 时，重试与续传沿用 client_token 不会重复创建记录
+func TestClient_PushToBitableIdempotent(t *testing.T) {
+	var mu sync.Mutex
+	rows := 0                                     // 服务端实际创建的记录数
+	results := make(map[string]json.RawMessage)   // client_token -> 首次创建的结果
+	attempts := make(map[string]int)              // client_token -> 请求次数
+	failAfterCommit := map[string]bool{"1": true} // 首次请求写入后返回 5xx 的批次（按第一个商品ID）
+	itemField := GetFieldNameMapping()["itemId"]
+	mux := http.NewServeMux()
+	mux.HandleFunc(authPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"code":0,"tenant_access_token":"t-token","expire":7200}`)
+	})
+	mux.HandleFunc(fmt.Sprintf(bitableBatchPath, "app123", "tbl123"), func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Records []Record `json:"records"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		token := r.URL.Query().Get("client_token")
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[token]++
+		if token == "" {
+			t.Error("batch_create 请求缺少 client_token")
+		}
+		if result, ok := results[token]; ok { // 飞书按 client_token 幂等：返回首次结果，不重复创建
+			w.Write(result)
+			return
+		}
+		rows += len(req.Records)
+		results[token], _ = json.Marshal(map[string]interface{}{"code": 0, "data": req})
+		if first := fmt.Sprint(req.Records[0].Fields[itemField]); failAfterCommit[first] {
+			delete(failAfterCommit, first)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(results[token])
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClient(ClientConfig{AppID: "id", AppSecret: "secret", BaseURL: srv.URL, BatchSize: 2})
+	client.retryBackoff = time.Millisecond
+	products := []Product{{ItemID: "1"}, {ItemID: "2"}, {ItemID: "3"}}
+	resp, err := client.PushToBitable("app123", "tbl123", products)
+	if err != nil {
+		t.Fatalf("PushToBitable() error = %v", err)
+	}
+	if rows != 3 || resp.Data.RecordsCreated != 3 {
+		t.Errorf("服务端记录数 = %d, created = %d, want 3 3", rows, resp.Data.RecordsCreated)
+	}
+	first, second := resp.Data.Chunks[0].ClientToken, resp.Data.Chunks[1].ClientToken
+	if attempts[first] != 2 || first == second {
+		t.Errorf("重试应沿用同一 client_token，各批 token 不同: attempts=%v", attempts)
+	}
+
+	// 续传时沿用原批次的 client_token，不会重复创建
+	if _, err := client.PushChunk("app123", "tbl123", first, products[:2]); err != nil {
+		t.Fatalf("PushChunk() error = %v", err)
+	}
+	if rows != 3 {
+		t.Errorf("续传后服务端记录数 = %d, want 3", rows)
+	}
+}
+
+// TestClient_BatchUpdateRecordsBatchSize 测试批量更新按配置的批次大小分批
+func TestClient_BatchUpdateRecordsBatchSize(t *testing.T) {
+	var sizes []int
+	mux := http.NewServeMux()
+	mux.HandleFunc(authPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"code":0,"tenant_access_token":"t-token","expire":7200}`)
+	})
+	mux.HandleFunc(fmt.Sprintf(batchUpdatePath, "app123", "tbl123"), func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Records []RecordUpdate `json:"records"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		sizes = append(sizes, len(req.Records))
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": req})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClient(ClientConfig{AppID: "id", AppSecret: "secret", BaseURL: srv.URL, BatchSize: 2})
+	records := []RecordUpdate{{RecordID: "r1"}, {RecordID: "r2"}, {RecordID: "r3"}}
+	n, err := client.BatchUpdateRecords("app123", "tbl123", records)
+	if err != nil || n != 3 || fmt.Sprint(sizes) != "[2 1]" {
+		t.Errorf("BatchUpdateRecords() = %d, %v, batch sizes %v", n, err, sizes)
+	}
+}
+
+// TestPendingPushes 测试续传文件读写，列表为空时删除文件
+func TestPendingPushes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resume", "pending.json")
+	if pending, err := LoadPendingPushes(path); err != nil || len(pending) != 0 {
+		t.Fatalf("LoadPendingPushes() = %v, %v", pending, err)
+	}
+
+	want := []PendingPush{{Date: "2026-05-01", Error: "boom", Products: []Product{{ItemID: "1", Price: "99"}}}}
+	if err := SavePendingPushes(path, want); err != nil {
+		t.Fatalf("SavePendingPushes() error = %v", err)
+	}
+	got, err := LoadPendingPushes(path)
+	if err != nil {
+		t.Fatalf("LoadPendingPushes() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Date != "2026-05-01" || got[0].Products[0].Price != "99" {
+		t.Errorf("pending = %+v", got)
+	}
+
+	if err := SavePendingPushes(path, nil); err != nil {
+		t.Fatalf("SavePendingPushes(nil) error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("续传文件应已删除: %v", err)
+	}
+}
+
+// TestUpdatePendingPushes 测试并发追加不丢失记录，损坏的续传文件被移走而不是覆盖
+func TestUpdatePendingPushes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pending.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := UpdatePendingPushes(path, func(pending []PendingPush) []PendingPush {
+				return append(pending, PendingPush{Date: "2026-05-01", Products: []Product{{ItemID: fmt.Sprint(i)}}})
+			})
+			if err != nil {
+				t.Errorf("UpdatePendingPushes() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	pending, err := LoadPendingPushes(path)
+	if err != nil || len(pending) != 20 {
+		t.Fatalf("pending = %d, %v, want 20", len(pending), err)
+	}
+	ids := make(map[string]bool)
+	for _, p := range pending {
+		ids[p.ID] = true
+	}
+	if len(ids) != 20 || ids[""] {
+		t.Errorf("续传记录ID应唯一且非空: %v", ids)
+	}
+
+	// 损坏的文件移至 .corrupt-*，不被覆盖
+	if err := os.WriteFile(path, []byte("{broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPendingPushes(path); !errors.Is(err, ErrResumeCorrupt) {
+		t.Errorf("LoadPendingPushes() error = %v, want ErrResumeCorrupt", err)
+	}
+	before, err := UpdatePendingPushes(path, func(pending []PendingPush) []PendingPush {
+		return append(pending, PendingPush{Date: "2026-05-02", Products: []Product{{ItemID: "x"}}})
+	})
+	if err != nil || len(before) != 0 {
+		t.Fatalf("UpdatePendingPushes() = %v, %v", before, err)
+	}
+	corrupt, _ := filepath.Glob(path + ".corrupt-*")
+	if len(corrupt) != 1 {
+		t.Fatalf("损坏的文件未保留: %v", corrupt)
+	}
+	if data, _ := os.ReadFile(corrupt[0]); string(data) != "{broken" {
+		t.Errorf("损坏文件内容 = %q", data)
+	}
+	if pending, _ := LoadPendingPushes(path); len(pending) != 1 || pending[0].Date != "2026-05-02" {
+		t.Errorf("pending = %+v", pending)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("锁文件未释放: %v", err)
+	}
+}
+
+// TestDedupCache_Retention 测试超过保留时长的数据表在保存时被移除
+func TestDedupCache_Retention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.json")
+	cache, _ := LoadDedupCache(path, time.Hour)
+	cache.Add("old", ProductKey{ItemID: "1"})
+	cache.Add("new", ProductKey{ItemID: "2"})
+	cache.tables["old"].updated = time.Now().Add(-2 * time.Hour)
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	cache, err := LoadDedupCache(path, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadDedupCache() error = %v", err)
+	}
+	if cache.Len("old") != 0 || !cache.Contains("new", ProductKey{ItemID: "2"}) {
+		t.Errorf("tables = %v", cache.tables)
+	}
+
+	// nil 索引可安全调用
+	var empty *DedupCache
+	empty.Add("t", ProductKey{ItemID: "1"})
+	if empty.Contains("t", ProductKey{ItemID: "1"}) || empty.Covers("t", "1", nil) || empty.Save() != nil {
+		t.Error("nil DedupCache 应视为未命中")
+	}
+}
+
+// TestDedupCache_MergeAndCorrupt 测试两个进程各自保存时合并彼此的记录，损坏的文件被移走
+func TestDedupCache_MergeAndCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.json")
+	a, _ := LoadDedupCache(path, 0)
+	a.Add("tbl", ProductKey{ItemID: "1"}, ProductKey{ItemID: "2"})
+	if err := a.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// server 与 crawl 同时持有索引：b 移除 2，a 新增 3，保存后两边的修改都保留
+	b, _ := LoadDedupCache(path, 0)
+	b.Remove("tbl", ProductKey{ItemID: "2"})
+	b.Add("tbl", ProductKey{ItemID: "4"})
+	a.Add("tbl", ProductKey{ItemID: "3"})
+	if err := b.Save(); err != nil {
+		t.Fatalf("b.Save() error = %v", err)
+	}
+	if err := a.Save(); err != nil {
+		t.Fatalf("a.Save() error = %v", err)
+	}
+	merged, err := LoadDedupCache(path, 0)
+	if err != nil {
+		t.Fatalf("LoadDedupCache() error = %v", err)
+	}
+	for id, want := range map[string]bool{"1": true, "2": false, "3": true, "4": true} {
+		if got := merged.Contains("tbl", ProductKey{ItemID: id}); got != want {
+			t.Errorf("合并后 Contains(%s) = %v, want %v", id, got, want)
+		}
+	}
+	if !a.Contains("tbl", ProductKey{ItemID: "4"}) || a.Contains("tbl", ProductKey{ItemID: "2"}) {
+		t.Error("保存后内存索引应包含其他进程的修改")
+	}
+
+	// 损坏的文件移到 .corrupt-*，按空索引继续
+	os.WriteFile(path, []byte("{broken"), 0644)
+	cache, err := LoadDedupCache(path, 0)
+	if err != nil || cache == nil || cache.Len("tbl") != 0 {
+		t.Fatalf("损坏文件 LoadDedupCache() = %v, %v", cache, err)
+	}
+	if aside, _ := filepath.Glob(path + ".corrupt-*"); len(aside) != 1 {
+		t.Errorf("损坏文件未移走: %v", aside)
+	}
+	cache.Add("tbl", ProductKey{ItemID: "5"})
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if tmps, _ := filepath.Glob(path + "*.tmp"); len(tmps) != 0 {
+		t.Errorf("遗留临时文件: %v", tmps)
+	}
+}
+
 // TestBitableService_GetTableByDate 测试根据日期获取表格
 func TestBitableService_GetTableByDate(t *testing.T) {
 	testDate := time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC)
@@ -0,0 +1,301 @@
+package feishu
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 回调请求头
+const (
+	HeaderTimestamp = "X-Lark-Request-Timestamp"
+	HeaderNonce     = "X-Lark-Request-Nonce"
+	HeaderSignature = "X-Lark-Signature"
+)
+
+// 回调类型
+const (
+	CallbackURLVerification = "url_verification"
+	CallbackCardAction      = "card.action.trigger"
+)
+
+// ErrInvalidSignature 回调签名校验失败
+var ErrInvalidSignature = errors.New("回调签名校验失败")
+
+// ErrInvalidToken 回调 Verification Token 不匹配
+var ErrInvalidToken = errors.New("回调 Verification Token 不匹配")
+
+// ErrInvalidTimestamp 回调时间戳缺失或超出允许的时间偏差（防止重放）
+var ErrInvalidTimestamp = errors.New("回调时间戳无效或已过期")
+
+// MaxCallbackSkew 回调时间戳与本地时间允许的最大偏差
+const MaxCallbackSkew = 5 * time.Minute
+
+// Decrypt 解密回调内容：AES-256-CBC，密钥为 sha256(encryptKey)，密文前 16 字节为 IV
+func Decrypt(encryptKey, encrypted string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("解码密文失败: %w", err)
+	}
+	if len(raw) < aes.BlockSize*2 || len(raw)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("密文长度无效: %d", len(raw))
+	}
+	key := sha256.Sum256([]byte(encryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("创建解密器失败: %w", err)
+	}
+	iv, data := raw[:aes.BlockSize], raw[aes.BlockSize:]
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	// 去除 PKCS7 填充
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(plain) {
+		return nil, fmt.Errorf("解密失败: 填充无效，请检查 Encrypt Key")
+	}
+	return plain[:len(plain)-pad], nil
+}
+
+// Signature 计算回调签名：sha256(timestamp + nonce + encryptKey + body) 的十六进制
+func Signature(timestamp, nonce, encryptKey string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(timestamp + nonce + encryptKey))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyTimestamp 校验回调时间戳（秒）与 now 的偏差不超过 MaxCallbackSkew
+func VerifyTimestamp(timestamp string, now time.Time) bool {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	skew := now.Sub(time.Unix(sec, 0))
+	return skew <= MaxCallbackSkew && skew >= -MaxCallbackSkew
+}
+
+// LegacySignature 计算旧版消息卡片回调签名：sha1(timestamp + nonce + verificationToken + body) 的十六进制
+func LegacySignature(timestamp, nonce, verificationToken string, body []byte) string {
+	h := sha1.New()
+	h.Write([]byte(timestamp + nonce + verificationToken))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifySignature 校验回调签名：encryptKey 非空时校验 sha256 签名，
+// verificationToken 非空时兼容旧版消息卡片回调的 sha1 签名
+func VerifySignature(timestamp, nonce, encryptKey, verificationToken string, body []byte, signature string) bool {
+	if encryptKey != "" && subtle.ConstantTimeCompare([]byte(Signature(timestamp, nonce, encryptKey, body)), []byte(signature)) == 1 {
+		return true
+	}
+	return verificationToken != "" &&
+		subtle.ConstantTimeCompare([]byte(LegacySignature(timestamp, nonce, verificationToken, body)), []byte(signature)) == 1
+}
+
+// Callback 解析后的回调请求
+type Callback struct {
+	Type      string                 // CallbackURLVerification 或 CallbackCardAction
+	Challenge string                 // URL 校验的 challenge
+	Token     string                 // Verification Token
+	EventID   string                 // 事件ID（新版回调）
+	Legacy    bool                   // 是否为旧版消息卡片回调（响应体直接为卡片）
+	OpenID    string                 // 操作者 open_id
+	MessageID string                 // 卡片所在消息ID
+	ChatID    string                 // 卡片所在群ID
+	Value     map[string]interface{} // 按钮携带的数据
+}
+
+// callbackPayload 回调请求体（兼容新旧两种格式）
+type callbackPayload struct {
+	Encrypt   string `json:"encrypt"`
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Token     string `json:"token"`
+
+	// 新版（schema 2.0）
+	Schema string `json:"schema"`
+	Header struct {
+		EventID   string `json:"event_id"`
+		EventType string `json:"event_type"`
+		Token     string `json:"token"`
+	} `json:"header"`
+	Event struct {
+		Operator struct {
+			OpenID string `json:"open_id"`
+		} `json:"operator"`
+		Action struct {
+			Value map[string]interface{} `json:"value"`
+		} `json:"action"`
+		Context struct {
+			OpenMessageID string `json:"open_message_id"`
+			OpenChatID    string `json:"open_chat_id"`
+		} `json:"context"`
+	} `json:"event"`
+
+	// 旧版消息卡片回调
+	OpenID        string `json:"open_id"`
+	OpenMessageID string `json:"open_message_id"`
+	OpenChatID    string `json:"open_chat_id"`
+	Action        *struct {
+		Value map[string]interface{} `json:"value"`
+	} `json:"action"`
+}
+
+// ParseCallback 解析回调请求体，encryptKey 非空且请求体加密时先解密
+func ParseCallback(body []byte, encryptKey string) (*Callback, error) {
+	var p callbackPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("解析回调失败: %w", err)
+	}
+	if p.Encrypt != "" {
+		if encryptKey == "" {
+			return nil, fmt.Errorf("回调内容已加密，但未配置 Encrypt Key")
+		}
+		plain, err := Decrypt(encryptKey, p.Encrypt)
+		if err != nil {
+			return nil, err
+		}
+		p = callbackPayload{}
+		if err := json.Unmarshal(plain, &p); err != nil {
+			return nil, fmt.Errorf("解析解密后的回调失败: %w", err)
+		}
+	}
+
+	switch {
+	case p.Type == CallbackURLVerification:
+		return &Callback{Type: CallbackURLVerification, Challenge: p.Challenge, Token: p.Token}, nil
+	case p.Schema == "2.0" && p.Header.EventType == CallbackCardAction:
+		return &Callback{
+			Type:      CallbackCardAction,
+			Token:     p.Header.Token,
+			EventID:   p.Header.EventID,
+			OpenID:    p.Event.Operator.OpenID,
+			MessageID: p.Event.Context.OpenMessageID,
+			ChatID:    p.Event.Context.OpenChatID,
+			Value:     p.Event.Action.Value,
+		}, nil
+	case p.Action != nil:
+		return &Callback{
+			Type:      CallbackCardAction,
+			Token:     p.Token,
+			Legacy:    true,
+			OpenID:    p.OpenID,
+			MessageID: p.OpenMessageID,
+			ChatID:    p.OpenChatID,
+			Value:     p.Action.Value,
+		}, nil
+	}
+	return nil, fmt.Errorf("不支持的回调类型: %s%s", p.Type, p.Header.EventType)
+}
+
+// CardActionResponse 构建卡片回调响应：toast 提示并原地更新卡片
+// 旧版回调直接返回卡片本身
+func CardActionResponse(cb *Callback, toastType, toast string, card *Card) interface{} {
+	if cb != nil && cb.Legacy {
+		if card == nil {
+			return map[string]interface{}{}
+		}
+		return card
+	}
+	resp := map[string]interface{}{}
+	if toast != "" {
+		resp["toast"] = map[string]string{"type": toastType, "content": toast}
+	}
+	if card != nil {
+		resp["card"] = map[string]interface{}{"type": "raw", "data": card}
+	}
+	return resp
+}
+
+// ==================== 卡片按钮数据 ====================
+
+// 商品卡片操作
+const (
+	ActionWatch      = "watch"       // 关注
+	ActionIgnore     = "ignore"      // 忽略
+	ActionBought     = "bought"      // 已购买
+	ActionMuteSeller = "mute_seller" // 屏蔽卖家
+)
+
+// ItemActionButtons 商品卡片的操作按钮，按钮数据携带重建卡片所需的商品信息
+func ItemActionButtons(d ItemCardData) []CardButton {
+	buttons := []CardButton{
+		{Text: "关注", Value: itemActionValue(ActionWatch, d)},
+		{Text: "忽略", Value: itemActionValue(ActionIgnore, d)},
+		{Text: "已购买", Value: itemActionValue(ActionBought, d)},
+	}
+	if d.SellerNick != "" {
+		buttons = append(buttons, CardButton{Text: "屏蔽卖家", Type: "danger", Value: itemActionValue(ActionMuteSeller, d)})
+	}
+	return buttons
+}
+
+func itemActionValue(action string, d ItemCardData) map[string]interface{} {
+	v := map[string]interface{}{
+		"action":  action,
+		"item_id": d.ItemID,
+		"title":   d.Title,
+		"price":   strconv.FormatFloat(d.Price, 'f', -1, 64),
+	}
+	set := func(key, val string) {
+		if val != "" {
+			v[key] = val
+		}
+	}
+	set("heading", d.Heading)
+	set("seller", d.SellerNick)
+	set("risk", d.RiskLevel)
+	set("url", d.DetailURL)
+	set("image_key", d.ImageKey)
+	set("image_url", d.ImageURL)
+	set("reason", d.Reason)
+	if d.OldPrice > 0 {
+		v["old_price"] = strconv.FormatFloat(d.OldPrice, 'f', -1, 64)
+	}
+	if d.WantCount > 0 {
+		v["want"] = strconv.Itoa(d.WantCount)
+	}
+	return v
+}
+
+// ParseItemAction 从按钮数据还原操作与商品卡片数据
+func ParseItemAction(value map[string]interface{}) (string, ItemCardData) {
+	str := func(key string) string {
+		switch v := value[key].(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return ""
+	}
+	num := func(key string) float64 {
+		f, _ := strconv.ParseFloat(str(key), 64)
+		return f
+	}
+	d := ItemCardData{
+		Heading:    str("heading"),
+		ItemID:     str("item_id"),
+		Title:      str("title"),
+		Price:      num("price"),
+		OldPrice:   num("old_price"),
+		WantCount:  int(num("want")),
+		SellerNick: str("seller"),
+		RiskLevel:  str("risk"),
+		DetailURL:  str("url"),
+		ImageKey:   str("image_key"),
+		ImageURL:   str("image_url"),
+		Reason:     str("reason"),
+	}
+	return str("action"), d
+}
@@ -0,0 +1,274 @@
+package feishu
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 卡片标题颜色
+const (
+	CardBlue   = "blue"
+	CardGreen  = "green"
+	CardOrange = "orange"
+	CardRed    = "red"
+	CardGrey   = "grey"
+)
+
+// CardElement 卡片元素（div、markdown、img、action 等），按飞书消息卡片 JSON 结构组织
+type CardElement map[string]interface{}
+
+// Card 飞书消息卡片
+type Card struct {
+	Config   map[string]interface{} `json:"config,omitempty"`
+	Header   map[string]interface{} `json:"header,omitempty"`
+	Elements []CardElement          `json:"elements"`
+}
+
+// CardField 卡片中的键值字段
+type CardField struct {
+	Label string
+	Value string
+	Short bool // 是否半宽显示（两列排列）
+}
+
+// CardButton 卡片按钮：URL 非空时为跳转按钮，Value 非空时点击会回调
+type CardButton struct {
+	Text  string
+	URL   string
+	Type  string                 // default, primary, danger
+	Value map[string]interface{} // 回调携带的数据
+}
+
+// NewCard 创建卡片，template 为标题颜色（CardBlue 等）
+func NewCard(title, template string) *Card {
+	if template == "" {
+		template = CardBlue
+	}
+	return &Card{
+		Config: map[string]interface{}{"wide_screen_mode": true, "update_multi": true},
+		Header: map[string]interface{}{
+			"template": template,
+			"title":    plainText(title),
+		},
+	}
+}
+
+// Markdown 添加 Markdown 段落
+func (c *Card) Markdown(content string) *Card {
+	if content == "" {
+		return c
+	}
+	c.Elements = append(c.Elements, CardElement{"tag": "markdown", "content": content})
+	return c
+}
+
+// Fields 添加字段组，空值字段不显示
+func (c *Card) Fields(fields ...CardField) *Card {
+	var items []map[string]interface{}
+	for _, f := range fields {
+		if f.Value == "" {
+			continue
+		}
+		items = append(items, map[string]interface{}{
+			"is_short": f.Short,
+			"text":     larkMD(fmt.Sprintf("**%s**\n%s", f.Label, f.Value)),
+		})
+	}
+	if len(items) > 0 {
+		c.Elements = append(c.Elements, CardElement{"tag": "div", "fields": items})
+	}
+	return c
+}
+
+// Image 添加图片，imgKey 需先通过 UploadImage 上传获得
+func (c *Card) Image(imgKey, alt string) *Card {
+	if imgKey == "" {
+		return c
+	}
+	c.Elements = append(c.Elements, CardElement{"tag": "img", "img_key": imgKey, "alt": plainText(alt)})
+	return c
+}
+
+// Divider 添加分割线
+func (c *Card) Divider() *Card {
+	c.Elements = append(c.Elements, CardElement{"tag": "hr"})
+	return c
+}
+
+// Actions 添加按钮组
+func (c *Card) Actions(buttons ...CardButton) *Card {
+	var actions []map[string]interface{}
+	for _, b := range buttons {
+		btn := map[string]interface{}{
+			"tag":  "button",
+			"text": plainText(b.Text),
+			"type": b.Type,
+		}
+		if b.Type == "" {
+			btn["type"] = "default"
+		}
+		if b.URL != "" {
+			btn["url"] = b.URL
+		}
+		if len(b.Value) > 0 {
+			btn["value"] = b.Value
+		}
+		actions = append(actions, btn)
+	}
+	if len(actions) > 0 {
+		c.Elements = append(c.Elements, CardElement{"tag": "action", "actions": actions})
+	}
+	return c
+}
+
+// Note 添加备注（灰色小字）
+func (c *Card) Note(text string) *Card {
+	if text == "" {
+		return c
+	}
+	c.Elements = append(c.Elements, CardElement{"tag": "note", "elements": []interface{}{plainText(text)}})
+	return c
+}
+
+// JSON 序列化卡片
+func (c *Card) JSON() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("序列化卡片失败: %w", err)
+	}
+	return string(data), nil
+}
+
+func plainText(content string) map[string]interface{} {
+	return map[string]interface{}{"tag": "plain_text", "content": content}
+}
+
+func larkMD(content string) map[string]interface{} {
+	return map[string]interface{}{"tag": "lark_md", "content": content}
+}
+
+// ==================== 卡片模板 ====================
+
+// ItemCardData 商品卡片数据
+type ItemCardData struct {
+	Heading     string  // 标题前缀，如 "低价商品"
+	ItemID      string  // 商品ID
+	Title       string  // 商品标题
+	Price       float64 // 价格
+	OldPrice    float64 // 原价（降价时显示）
+	WantCount   int     // 想要人数
+	SellerNick  string  // 卖家昵称
+	RiskLevel   string  // 卖家风险等级 low/medium/high
+	RiskReasons string  // 风险原因
+	Reason      string  // 提醒原因，如比价依据
+	DetailURL   string  // 商品详情链接
+	ImageKey    string  // 已上传的封面图 image_key
+	ImageURL    string  // 封面图原始地址（无 image_key 时以链接展示）
+	Footer      string  // 底部备注
+	Buttons     []CardButton
+}
+
+// riskLabels 风险等级显示文本
+var riskLabels = map[string]string{
+	"low":    "🟢 低",
+	"medium": "🟡 中",
+	"high":   "🔴 高",
+}
+
+// RiskLabel 风险等级显示文本
+func RiskLabel(level string) string {
+	if label, ok := riskLabels[level]; ok {
+		return label
+	}
+	return level
+}
+
+// cardPrice 卡片中的价格文本，去掉多余的小数位
+func cardPrice(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	return "¥" + strings.TrimRight(strings.TrimRight(s, "0"), ".")
+}
+
+// ItemCard 商品提醒卡片：封面、价格、想要人数、卖家风险与"查看商品"按钮
+func ItemCard(d ItemCardData) *Card {
+	title := d.Title
+	if d.Heading != "" {
+		title = fmt.Sprintf("【%s】%s", d.Heading, d.Title)
+	}
+	color := CardOrange
+	if d.RiskLevel == "high" {
+		color = CardRed
+	}
+	card := NewCard(title, color)
+	card.Image(d.ImageKey, d.Title)
+
+	price := cardPrice(d.Price)
+	if d.OldPrice > 0 {
+		price = fmt.Sprintf("%s ~~%s~~", price, cardPrice(d.OldPrice))
+	}
+	want := ""
+	if d.WantCount > 0 {
+		want = fmt.Sprintf("%d 人", d.WantCount)
+	}
+	risk := RiskLabel(d.RiskLevel)
+	if risk != "" && d.RiskReasons != "" {
+		risk += "（" + d.RiskReasons + "）"
+	}
+	card.Fields(
+		CardField{Label: "价格", Value: price, Short: true},
+		CardField{Label: "想要", Value: want, Short: true},
+		CardField{Label: "卖家", Value: d.SellerNick, Short: true},
+		CardField{Label: "卖家风险", Value: risk, Short: true},
+	)
+	card.Markdown(d.Reason)
+	if d.ImageKey == "" && d.ImageURL != "" {
+		card.Markdown(fmt.Sprintf("[查看封面](%s)", d.ImageURL))
+	}
+
+	var buttons []CardButton
+	if d.DetailURL != "" {
+		buttons = append(buttons, CardButton{Text: "查看商品", URL: d.DetailURL, Type: "primary"})
+	}
+	buttons = append(buttons, d.Buttons...)
+	card.Actions(buttons...)
+	card.Note(d.Footer)
+	return card
+}
+
+// SummaryCardData 爬取汇总卡片数据
+type SummaryCardData struct {
+	Title string
+	Stats []CardField    // 统计项
+	Items []ItemCardData // 热门商品
+	Note  string         // 底部备注
+}
+
+// SummaryCard 爬取汇总卡片：统计数据与热门商品列表
+func SummaryCard(d SummaryCardData) *Card {
+	card := NewCard(d.Title, CardBlue)
+	card.Fields(d.Stats...)
+	if len(d.Items) > 0 {
+		var lines []string
+		for i, item := range d.Items {
+			line := fmt.Sprintf("%d. ", i+1)
+			if item.DetailURL != "" {
+				line += fmt.Sprintf("[%s](%s)", item.Title, item.DetailURL)
+			} else {
+				line += item.Title
+			}
+			line += " " + cardPrice(item.Price)
+			if item.WantCount > 0 {
+				line += fmt.Sprintf(" · %d 人想要", item.WantCount)
+			}
+			if item.RiskLevel == "high" {
+				line += " · " + RiskLabel(item.RiskLevel)
+			}
+			lines = append(lines, line)
+		}
+		card.Divider()
+		card.Markdown("**热门商品**\n" + strings.Join(lines, "\n"))
+	}
+	card.Note(d.Note)
+	return card
+}
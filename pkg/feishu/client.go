@@ -2,29 +2,54 @@ package feishu
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"xianyu_aner/pkg/util"
 )
 
 const (
-	// 飞书 API 基础 URL
-	baseURL = "https://open.feishu.cn"
+	// 飞书 API 默认基础 URL
+	defaultBaseURL = "https://open.feishu.cn"
 	// API 路径
 	authPath         = "/open-apis/auth/v3/tenant_access_token/internal"
 	bitablePath      = "/open-apis/bitable/v1/apps/%s/tables/%s/records"
 	bitableBatchPath = "/open-apis/bitable/v1/apps/%s/tables/%s/records/batch_create"
+	batchUpdatePath  = "/open-apis/bitable/v1/apps/%s/tables/%s/records/batch_update"
+
+	// maxBatchRecords 批量创建/更新接口单次最多的记录数（推送时的默认批次大小）
+	maxBatchRecords = 500
+
+	// codeRateLimited 请求频率超限的错误码（部分接口以 HTTP 400 + 该错误码返回）
+	codeRateLimited = 99991400
+	// codeBitableTooManyRequests 多维表格请求过多（TooManyRequest）
+	codeBitableTooManyRequests = 1254290
+	// codeBitableWriteConflict 多维表格写冲突（同一数据表并发写入，稍后重试即可）
+	codeBitableWriteConflict = 1254291
+	// maxRetries 触发频率限制、服务端错误或网络错误时的最大重试次数
+	maxRetries = 5
 )
 
 // Client 飞书客户端
 type Client struct {
-	appID     string
-	appSecret string
-	httpCli   *http.Client
-	token     string
-	tokenExp  int64
+	appID        string
+	appSecret    string
+	baseURL      string
+	httpCli      *http.Client
+	retryBackoff time.Duration // 重试的首次等待时长（频率限制响应未指定重置时间时），之后每次翻倍
+	batchSize    int           // 推送记录时每批的记录数
+
+	mu       sync.Mutex // 保护 token（去重查询会并发调用）
+	token    string
+	tokenExp int64
 }
 
 // FeishuClient 飞书客户端接口，用于测试 mock
@@ -35,25 +60,39 @@ type FeishuClient interface {
 	GetTableFields(appToken, tableToken string) (map[string]string, error)
 	CreateField(appToken, tableToken string, field FieldCreate) (string, error)
 	PushToBitable(appToken, tableToken string, products []Product) (*PushToBitableResponse, error)
+	PushChunk(appToken, tableToken, clientToken string, products []Product) (*PushToBitableResponse, error)
 	CreateRecord(appToken, tableToken string, product Product) error
 	GetTableRecords(appToken, tableToken string) ([]map[string]interface{}, error)
 	SearchRecords(appToken, tableToken string, filter FilterInfo) ([]map[string]interface{}, error)
+	UpdateRecord(appToken, tableToken, recordID string, fields map[string]interface{}) error
+	BatchUpdateRecords(appToken, tableToken string, records []RecordUpdate) (int, error)
 }
 
 // ClientConfig 客户端配置
 type ClientConfig struct {
 	AppID     string
 	AppSecret string
+	BaseURL   string // API 基础地址，为空时使用 https://open.feishu.cn
+	BatchSize int    // 推送记录时每批的记录数（<=0 或超过 500 时使用 500）
 }
 
 // NewClient 创建飞书客户端
 func NewClient(config ClientConfig) *Client {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultBaseURL
+	}
+	if config.BatchSize <= 0 || config.BatchSize > maxBatchRecords {
+		config.BatchSize = maxBatchRecords
+	}
 	return &Client{
 		appID:     config.AppID,
 		appSecret: config.AppSecret,
+		baseURL:   strings.TrimRight(config.BaseURL, "/"),
 		httpCli: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		retryBackoff: time.Second,
+		batchSize:    config.BatchSize,
 	}
 }
 
@@ -67,6 +106,9 @@ type TenantAccessTokenResponse struct {
 
 // GetTenantAccessToken 获取租户访问令牌
 func (c *Client) GetTenantAccessToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	// 如果 token 未过期，直接返回
 	if c.token != "" && c.tokenExp > time.Now().Unix() {
 		return c.token, nil
@@ -83,7 +125,7 @@ func (c *Client) GetTenantAccessToken() (string, error) {
 	}
 
 	// 发送请求
-	resp, err := c.httpCli.Post(baseURL+authPath, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := c.httpCli.Post(c.baseURL+authPath, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("请求失败: %w", err)
 	}
@@ -128,198 +170,320 @@ type CreateRecordsResponse struct {
 	} `json:"data"`
 }
 
-// PushToBitable 推送数据到飞书多维表格
-func (c *Client) PushToBitable(appToken, tableToken string, products []Product) (*PushToBitableResponse, error) {
-
-	// fmt.Printf("[DEBUG] appToken=%s, tableToken=%s\n", maskToken(appToken), maskToken(tableToken))
-
-	// 获取访问令牌
-	token, err := c.GetTenantAccessToken()
-	if err != nil {
-		return nil, fmt.Errorf("获取访问令牌失败: %w", err)
-	}
-
-	// 构建记录
-	records := make([]Record, 0, len(products))
-	fieldNameMapping := GetFieldNameMapping()
-
-	for i, product := range products {
-		fields := make(map[string]interface{})
+// buildRecordFields 构建商品对应的记录字段，空值字段不写入
+func buildRecordFields(product Product, fieldNameMapping map[string]string) map[string]interface{} {
+	fields := make(map[string]interface{})
 
-		// 辅助函数：只在非空时添加字段
-		addField := func(key, value string) {
-			if value != "" {
-				if fieldName, ok := fieldNameMapping[key]; ok {
-					fields[fieldName] = value
-				}
+	// 辅助函数：只在非空时添加字段
+	addField := func(key, value string) {
+		if value != "" {
+			if fieldName, ok := fieldNameMapping[key]; ok {
+				fields[fieldName] = value
 			}
 		}
+	}
 
-		// 商品ID
-		addField("itemId", product.ItemID)
+	// 商品ID
+	addField("itemId", product.ItemID)
 
-		// 商品标题
-		addField("title", product.Title)
+	// 商品标题
+	addField("title", product.Title)
 
-		// 价格
-		addField("price", product.Price)
+	// 价格
+	addField("price", product.Price)
 
-		// 原价
-		addField("originalPrice", product.OriginalPrice)
+	// 原价
+	addField("originalPrice", product.OriginalPrice)
 
-		// 想要人数
-		if fieldName, ok := fieldNameMapping["wantCnt"]; ok {
-			fields[fieldName] = product.WantCnt
-		}
+	// 想要人数
+	if fieldName, ok := fieldNameMapping["wantCnt"]; ok {
+		fields[fieldName] = product.WantCnt
+	}
 
-		// 发布时间戳
-		if fieldName, ok := fieldNameMapping["publishTimeMs"]; ok {
-			if product.PublishTimeMs > 0 {
-				fields[fieldName] = product.PublishTimeMs
-			}
+	// 发布时间戳
+	if fieldName, ok := fieldNameMapping["publishTimeMs"]; ok {
+		if product.PublishTimeMs > 0 {
+			fields[fieldName] = product.PublishTimeMs
 		}
+	}
 
-		// 采集时间戳
-		if fieldName, ok := fieldNameMapping["captureTimeMs"]; ok {
-			if product.CaptureTimeMs > 0 {
-				fields[fieldName] = product.CaptureTimeMs
-			}
+	// 采集时间戳
+	if fieldName, ok := fieldNameMapping["captureTimeMs"]; ok {
+		if product.CaptureTimeMs > 0 {
+			fields[fieldName] = product.CaptureTimeMs
 		}
+	}
 
-		// 卖家昵称
-		addField("sellerNick", product.SellerNick)
+	// 卖家昵称
+	addField("sellerNick", product.SellerNick)
 
-		// 地区
-		addField("sellerCity", product.SellerCity)
+	// 地区
+	addField("sellerCity", product.SellerCity)
 
-		// 包邮
-		addField("freeShip", product.FreeShip)
+	// 包邮
+	addField("freeShip", product.FreeShip)
 
-		// 商品标签
-		addField("tags", product.Tags)
+	// 商品标签
+	addField("tags", product.Tags)
 
-		// 封面URL - 飞书URL字段格式: {"link": "url"}
-		if fieldName, ok := fieldNameMapping["coverUrl"]; ok {
-			if product.CoverURL != "" {
-				fields[fieldName] = map[string]string{"link": product.CoverURL}
-			}
+	// 封面URL - 飞书URL字段格式: {"link": "url"}
+	if fieldName, ok := fieldNameMapping["coverUrl"]; ok {
+		if product.CoverURL != "" {
+			fields[fieldName] = map[string]string{"link": product.CoverURL}
 		}
+	}
 
-		// 商品详情URL - 飞书URL字段格式: {"link": "url"}
-		if fieldName, ok := fieldNameMapping["detailUrl"]; ok {
-			if product.DetailURL != "" {
-				fields[fieldName] = map[string]string{"link": product.DetailURL}
-			}
+	// 商品详情URL - 飞书URL字段格式: {"link": "url"}
+	if fieldName, ok := fieldNameMapping["detailUrl"]; ok {
+		if product.DetailURL != "" {
+			fields[fieldName] = map[string]string{"link": product.DetailURL}
 		}
+	}
 
-		// 曝光热度
-		if fieldName, ok := fieldNameMapping["exposureHeat"]; ok {
-			if product.ExposureHeat > 0 {
-				fields[fieldName] = product.ExposureHeat
-			}
+	// 曝光热度
+	if fieldName, ok := fieldNameMapping["exposureHeat"]; ok {
+		if product.ExposureHeat > 0 {
+			fields[fieldName] = product.ExposureHeat
 		}
+	}
 
-		// ==================== 新增字段 ====================
+	// ==================== 新增字段 ====================
 
-		// 商品热度指标
-		if fieldName, ok := fieldNameMapping["viewCount"]; ok {
-			if product.ViewCount > 0 {
-				fields[fieldName] = product.ViewCount
-			}
+	// 商品热度指标
+	if fieldName, ok := fieldNameMapping["viewCount"]; ok {
+		if product.ViewCount > 0 {
+			fields[fieldName] = product.ViewCount
 		}
-		if fieldName, ok := fieldNameMapping["collectCount"]; ok {
-			if product.CollectCount > 0 {
-				fields[fieldName] = product.CollectCount
-			}
+	}
+	if fieldName, ok := fieldNameMapping["collectCount"]; ok {
+		if product.CollectCount > 0 {
+			fields[fieldName] = product.CollectCount
 		}
+	}
 
-		// 商品属性
-		addField("condition", product.Condition)
+	// 商品属性
+	addField("condition", product.Condition)
 
-		// 卖家信息
-		addField("sellerCredit", product.SellerCredit)
-		if fieldName, ok := fieldNameMapping["sellerItemCount"]; ok {
-			if product.SellerItemCount > 0 {
-				fields[fieldName] = product.SellerItemCount
-			}
+	// 卖家信息
+	addField("sellerCredit", product.SellerCredit)
+	if fieldName, ok := fieldNameMapping["sellerItemCount"]; ok {
+		if product.SellerItemCount > 0 {
+			fields[fieldName] = product.SellerItemCount
 		}
-		if fieldName, ok := fieldNameMapping["sellerSoldCount"]; ok {
-			if product.SellerSoldCount > 0 {
-				fields[fieldName] = product.SellerSoldCount
-			}
+	}
+	if fieldName, ok := fieldNameMapping["sellerSoldCount"]; ok {
+		if product.SellerSoldCount > 0 {
+			fields[fieldName] = product.SellerSoldCount
 		}
+	}
 
-		// 商品描述
-		addField("description", product.Description)
-		addField("subTitle", product.SubTitle)
+	// 商品描述
+	addField("description", product.Description)
+	addField("subTitle", product.SubTitle)
 
-		// 媒体资源 - 视频URL
-		if fieldName, ok := fieldNameMapping["videoUrl"]; ok {
-			if product.VideoURL != "" {
-				fields[fieldName] = map[string]string{"link": product.VideoURL}
-			}
+	// 媒体资源 - 视频URL
+	if fieldName, ok := fieldNameMapping["videoUrl"]; ok {
+		if product.VideoURL != "" {
+			fields[fieldName] = map[string]string{"link": product.VideoURL}
 		}
+	}
 
-		// 商品状态
-		addField("itemStatusStr", product.ItemStatusStr)
+	// 商品状态
+	addField("itemStatusStr", product.ItemStatusStr)
 
-		// 调试日志：打印第一个商品的详细信息
-		if i == 0 {
-			// fmt.Printf("[DEBUG] 商品数据: ID=%s, Title=%s\n", product.ItemID, product.Title)
-			// fmt.Printf("[DEBUG] 字段数据 (数量=%d):\n", len(fields))
-			for k, v := range fields {
-				fmt.Printf("  %s: %v (type: %T)\n", k, v, v)
-			}
+	// 实体识别
+	addField("brand", product.Brand)
+	addField("model", product.Model)
+	addField("spec", product.Spec)
+	if fieldName, ok := fieldNameMapping["entityConfidence"]; ok {
+		if product.EntityConfidence > 0 {
+			fields[fieldName] = product.EntityConfidence
+		}
+	}
+	if fieldName, ok := fieldNameMapping["dealDiscount"]; ok {
+		if product.DealDiscount > 0 {
+			fields[fieldName] = product.DealDiscount
+		}
+	}
+	addField("dealBasis", product.DealBasis)
+	addField("riskLevel", product.RiskLevel)
+	addField("riskReasons", product.RiskReasons)
+	addField("dupCluster", product.DupCluster)
+	if fieldName, ok := fieldNameMapping["dupCount"]; ok {
+		if product.DupCount > 0 {
+			fields[fieldName] = product.DupCount
 		}
+	}
 
-		records = append(records, Record{Fields: fields})
+	return fields
+}
+
+// PushError 分批推送中有批次失败（其余批次照常推送）
+type PushError struct {
+	Failed  int      // 失败的记录数
+	Total   int      // 本次推送的记录数
+	ItemIDs []string // 失败批次中的商品ID
+	Err     error    // 第一个失败批次的错误
+}
+
+func (e *PushError) Error() string {
+	return fmt.Sprintf("%d/%d 条记录推送失败: %v", e.Failed, e.Total, e.Err)
+}
+
+func (e *PushError) Unwrap() error { return e.Err }
+
+// FailedItemIDs 返回推送失败的商品ID集合（err 不是 *PushError 时返回 nil）
+func FailedItemIDs(err error) map[string]bool {
+	var pushErr *PushError
+	if !errors.As(err, &pushErr) {
+		return nil
+	}
+	failed := make(map[string]bool, len(pushErr.ItemIDs))
+	for _, id := range pushErr.ItemIDs {
+		failed[id] = true
 	}
+	return failed
+}
 
-	// 构建批量创建请求
-	reqBody := map[string]interface{}{
-		"records": records,
+// PushToBitable 推送数据到飞书多维表格
+// 按 batchSize（最多 500 条）分批调用 batch_create，频率限制与服务端错误按退避重试；
+// 某一批最终失败时继续推送其余批次，返回包含各批结果的响应与 *PushError。
+// 每批生成一个 client_token 并在重试时复用，服务端已写入但响应失败时重试不会重复创建记录
+func (c *Client) PushToBitable(appToken, tableToken string, products []Product) (*PushToBitableResponse, error) {
+	var batches [][]Product
+	var tokens []string
+	for start := 0; start < len(products); start += c.batchSize {
+		batches = append(batches, products[start:min(start+c.batchSize, len(products))])
+		tokens = append(tokens, newClientToken())
 	}
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("构建请求失败: %w", err)
+	return c.pushBatches(appToken, tableToken, batches, tokens)
+}
+
+// PushChunk 以指定的 client_token 推送一批记录（不再分批，最多 500 条），用于重新推送失败的批次：
+// 沿用原批次的 client_token，原请求实际已写入时飞书不会重复创建。clientToken 为空时生成新的
+func (c *Client) PushChunk(appToken, tableToken, clientToken string, products []Product) (*PushToBitableResponse, error) {
+	if len(products) > maxBatchRecords {
+		return nil, fmt.Errorf("单批最多 %d 条记录，实际 %d 条", maxBatchRecords, len(products))
+	}
+	if clientToken == "" {
+		clientToken = newClientToken()
 	}
+	return c.pushBatches(appToken, tableToken, [][]Product{products}, []string{clientToken})
+}
+
+// pushBatches 逐批调用 batch_create，失败的批次不影响其余批次
+func (c *Client) pushBatches(appToken, tableToken string, batches [][]Product, tokens []string) (*PushToBitableResponse, error) {
+	fieldNameMapping := GetFieldNameMapping()
+	result := &PushToBitableResponse{
+		Success: true,
+		Message: "推送成功",
+	}
+	result.Data.TableToken = tableToken
 
-	// 打印请求体（调试用）
-	// fmt.Printf("[DEBUG] 请求体: %s\n", string(jsonData))
+	total := 0
+	for _, batch := range batches {
+		total += len(batch)
+	}
+	var pushErr *PushError
+	for i, batch := range batches {
+		chunk := ChunkResult{Index: i, Records: len(batch), ClientToken: tokens[i]}
 
-	// 发送请求
-	url := fmt.Sprintf(baseURL+bitableBatchPath, appToken, tableToken)
-	// fmt.Printf("[DEBUG] API URL: %s\n", url) // 调试日志
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+		created, err := c.createRecords(appToken, tableToken, tokens[i], batch, fieldNameMapping, i == 0)
+		if err != nil {
+			chunk.Error = err.Error()
+			for _, product := range batch {
+				chunk.ItemIDs = append(chunk.ItemIDs, product.ItemID)
+			}
+			if pushErr == nil {
+				pushErr = &PushError{Total: total, Err: err}
+			}
+			pushErr.Failed += len(batch)
+			pushErr.ItemIDs = append(pushErr.ItemIDs, chunk.ItemIDs...)
+			util.Printf("[分批推送] 第 %d/%d 批（%d 条）失败: %v\n", i+1, len(batches), len(batch), err)
+		} else if len(batches) > 1 {
+			util.Printf("[分批推送] 第 %d/%d 批（%d 条）成功\n", i+1, len(batches), len(batch))
+		}
+		chunk.Created = created
+		result.Data.RecordsCreated += created
+		result.Data.Chunks = append(result.Data.Chunks, chunk)
+	}
+
+	if pushErr != nil {
+		result.Success = false
+		result.Message = pushErr.Error()
+		result.Data.RecordsFailed = pushErr.Failed
+		return result, pushErr
+	}
+	return result, nil
+}
+
+// newClientToken 生成 batch_create 幂等请求使用的 client_token（UUIDv4）
+func newClientToken() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// createRecords 调用 batch_create 创建一批记录，返回创建的记录数
+// 重试时使用同一个 clientToken，飞书按 client_token 幂等处理
+func (c *Client) createRecords(appToken, tableToken, clientToken string, products []Product, fieldNameMapping map[string]string, debug bool) (int, error) {
+	// 获取访问令牌（长时间分批推送时令牌可能过期，每批重新获取）
+	token, err := c.GetTenantAccessToken()
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+		return 0, fmt.Errorf("获取访问令牌失败: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
+	// 构建记录
+	records := make([]Record, 0, len(products))
+	for i, product := range products {
+		fields := buildRecordFields(product, fieldNameMapping)
 
-	resp, err := c.httpCli.Do(req)
+		// 调试日志：打印第一个商品的详细信息
+		if debug && i == 0 {
+			for k, v := range fields {
+				util.Printf("  %s: %v (type: %T)\n", k, v, v)
+			}
+		}
+
+		records = append(records, Record{Fields: fields})
+	}
+
+	// 构建批量创建请求
+	jsonData, err := json.Marshal(map[string]interface{}{"records": records})
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
+		return 0, fmt.Errorf("构建请求失败: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	// 发送请求
+	url := fmt.Sprintf(c.baseURL+bitableBatchPath, appToken, tableToken) + "?client_token=" + clientToken
+	body, err := c.doWithRetry(func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+		return 0, err
 	}
 
 	// 解析响应
 	var createResp CreateRecordsResponse
 	if err := json.Unmarshal(body, &createResp); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w, 响应内容: %s", err, string(body))
+		return 0, fmt.Errorf("解析响应失败: %w, 响应内容: %s", err, string(body))
 	}
 
 	if createResp.Code != 0 {
 		// 打印详细错误信息
-		fmt.Printf("[ERROR] API 错误响应:\n")
-		fmt.Printf("  Code: %d\n", createResp.Code)
-		fmt.Printf("  Msg: %s\n", createResp.Msg)
+		util.Printf("[ERROR] API 错误响应:\n")
+		util.Printf("  Code: %d\n", createResp.Code)
+		util.Printf("  Msg: %s\n", createResp.Msg)
 
 		// 根据错误码提供更具体的错误信息
 		var errMsg string
@@ -328,25 +492,15 @@ func (c *Client) PushToBitable(appToken, tableToken string, products []Product)
 			errMsg = fmt.Sprintf("字段不存在: %s。请确保所有字段已创建。建议调用 EnsureTableFields() 检查字段", createResp.Msg)
 		case 99991600: // 字段类型不匹配
 			errMsg = fmt.Sprintf("字段类型不匹配: %s。请检查字段定义与数据格式是否一致", createResp.Msg)
-		case 99991400: // 无效的请求参数
-			errMsg = fmt.Sprintf("无效的请求参数: %s。请检查数据格式", createResp.Msg)
 		default:
 			errMsg = createResp.Msg
 		}
 
-		fmt.Printf("  完整响应: %s\n", string(body))
-		return nil, fmt.Errorf("推送失败 (code=%d): %s", createResp.Code, errMsg)
-	}
-
-	// 构建响应
-	result := &PushToBitableResponse{
-		Success: true,
-		Message: "推送成功",
+		util.Printf("  完整响应: %s\n", string(body))
+		return 0, fmt.Errorf("推送失败 (code=%d): %s", createResp.Code, errMsg)
 	}
-	result.Data.RecordsCreated = len(createResp.Data.Records)
-	result.Data.TableToken = tableToken
 
-	return result, nil
+	return len(createResp.Data.Records), nil
 }
 
 // CreateRecord 创建单条记录
@@ -379,7 +533,7 @@ func (c *Client) GetBitableTables(appToken string) ([]string, error) {
 		return nil, fmt.Errorf("获取访问令牌失败: %w", err)
 	}
 
-	url := fmt.Sprintf(baseURL+"/open-apis/bitable/v1/apps/%s/tables", appToken)
+	url := fmt.Sprintf(c.baseURL+"/open-apis/bitable/v1/apps/%s/tables", appToken)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
@@ -428,7 +582,7 @@ func (c *Client) GetBitableTableInfos(appToken string) ([]TableInfo, error) {
 		return nil, fmt.Errorf("获取访问令牌失败: %w", err)
 	}
 
-	url := fmt.Sprintf(baseURL+"/open-apis/bitable/v1/apps/%s/tables", appToken)
+	url := fmt.Sprintf(c.baseURL+"/open-apis/bitable/v1/apps/%s/tables", appToken)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
@@ -513,7 +667,7 @@ func (c *Client) CreateTable(appToken, tableName string, fields []FieldCreate) (
 		return nil, fmt.Errorf("构建请求失败: %w", err)
 	}
 
-	url := fmt.Sprintf(baseURL+"/open-apis/bitable/v1/apps/%s/tables", appToken)
+	url := fmt.Sprintf(c.baseURL+"/open-apis/bitable/v1/apps/%s/tables", appToken)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
@@ -566,7 +720,7 @@ func (c *Client) GetTableFields(appToken, tableToken string) (map[string]string,
 		return nil, fmt.Errorf("获取访问令牌失败: %w", err)
 	}
 
-	url := fmt.Sprintf(baseURL+"/open-apis/bitable/v1/apps/%s/tables/%s/fields", appToken, tableToken)
+	url := fmt.Sprintf(c.baseURL+"/open-apis/bitable/v1/apps/%s/tables/%s/fields", appToken, tableToken)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
@@ -636,9 +790,9 @@ func (c *Client) CreateField(appToken, tableToken string, field FieldCreate) (st
 	}
 
 	// 打印请求体用于调试
-	fmt.Printf("[DEBUG] 创建字段请求体: %s\n", string(jsonData))
+	util.Printf("[DEBUG] 创建字段请求体: %s\n", string(jsonData))
 
-	url := fmt.Sprintf(baseURL+"/open-apis/bitable/v1/apps/%s/tables/%s/fields", appToken, tableToken)
+	url := fmt.Sprintf(c.baseURL+"/open-apis/bitable/v1/apps/%s/tables/%s/fields", appToken, tableToken)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("创建请求失败: %w", err)
@@ -659,7 +813,7 @@ func (c *Client) CreateField(appToken, tableToken string, field FieldCreate) (st
 	}
 
 	// 打印响应用于调试
-	fmt.Printf("[DEBUG] 创建字段响应: %s\n", string(body))
+	util.Printf("[DEBUG] 创建字段响应: %s\n", string(body))
 
 	var createResp CreateFieldResponse
 	if err := json.Unmarshal(body, &createResp); err != nil {
@@ -702,7 +856,19 @@ type GetTableRecordsResponse struct {
 	} `json:"data"`
 }
 
-// GetTableRecords 获取表格中的所有记录（用于去重）
+// RecordIDKey 记录查询结果中保存记录ID的键（飞书字段名不允许以下划线开头，不会冲突）
+const RecordIDKey = "_recordId"
+
+// withRecordID 将记录ID写入字段表
+func withRecordID(fields map[string]interface{}, recordID string) map[string]interface{} {
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	fields[RecordIDKey] = recordID
+	return fields
+}
+
+// GetTableRecords 获取表格中的所有记录（用于去重），记录ID保存在 RecordIDKey 中
 func (c *Client) GetTableRecords(appToken, tableToken string) ([]map[string]interface{}, error) {
 	token, err := c.GetTenantAccessToken()
 	if err != nil {
@@ -713,27 +879,21 @@ func (c *Client) GetTableRecords(appToken, tableToken string) ([]map[string]inte
 	pageToken := ""
 
 	for {
-		url := fmt.Sprintf(baseURL+"/open-apis/bitable/v1/apps/%s/tables/%s/records?page_size=100", appToken, tableToken)
+		url := fmt.Sprintf(c.baseURL+"/open-apis/bitable/v1/apps/%s/tables/%s/records?page_size=100", appToken, tableToken)
 		if pageToken != "" {
 			url += "&page_token=" + pageToken
 		}
 
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("创建请求失败: %w", err)
-		}
-
-		req.Header.Set("Authorization", "Bearer "+token)
-
-		resp, err := c.httpCli.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("请求失败: %w", err)
-		}
-
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
+		body, err := c.doWithRetry(func() (*http.Request, error) {
+			req, err := http.NewRequest("GET", url, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+			return req, nil
+		})
 		if err != nil {
-			return nil, fmt.Errorf("读取响应失败: %w", err)
+			return nil, err
 		}
 
 		var recordsResp GetTableRecordsResponse
@@ -746,7 +906,7 @@ func (c *Client) GetTableRecords(appToken, tableToken string) ([]map[string]inte
 		}
 
 		for _, item := range recordsResp.Data.Items {
-			allRecords = append(allRecords, item.Fields)
+			allRecords = append(allRecords, withRecordID(item.Fields, item.RecordID))
 		}
 
 		if !recordsResp.Data.HasMore {
@@ -759,6 +919,66 @@ func (c *Client) GetTableRecords(appToken, tableToken string) ([]map[string]inte
 	return allRecords, nil
 }
 
+// doWithRetry 发送请求并读取响应体，触发频率限制（HTTP 429 或错误码 99991400/1254290）、
+// 多维表格写冲突（错误码 1254291）、服务端错误（HTTP 5xx）或网络错误（连接失败、超时）时重试
+// 等待时长优先使用响应头 x-ogw-ratelimit-reset（秒），否则按 retryBackoff 指数退避
+// newReq 每次重试都重新创建请求（请求体只能读取一次）
+func (c *Client) doWithRetry(newReq func() (*http.Request, error)) ([]byte, error) {
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, fmt.Errorf("创建请求失败: %w", err)
+		}
+		wait := backoff
+		var reason string
+		resp, err := c.httpCli.Do(req)
+		if err != nil {
+			if attempt >= maxRetries {
+				return nil, fmt.Errorf("请求失败，重试 %d 次后仍失败: %w", maxRetries, err)
+			}
+			reason = fmt.Sprintf("请求失败 (%v)", err)
+		} else {
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				if attempt >= maxRetries {
+					return nil, fmt.Errorf("读取响应失败，重试 %d 次后仍失败: %w", maxRetries, err)
+				}
+				reason = fmt.Sprintf("读取响应失败 (%v)", err)
+			} else if reason = retryReason(resp.StatusCode, body); reason == "" {
+				return body, nil
+			} else if attempt >= maxRetries {
+				return nil, fmt.Errorf("%s，重试 %d 次后仍失败", reason, maxRetries)
+			}
+			if secs, err := strconv.Atoi(resp.Header.Get("x-ogw-ratelimit-reset")); err == nil && secs > 0 {
+				wait = time.Duration(secs) * time.Second
+			}
+		}
+
+		util.Printf("[飞书] %s，%v 后重试 (%d/%d)\n", reason, wait, attempt+1, maxRetries)
+		time.Sleep(wait)
+		backoff *= 2
+	}
+}
+
+// retryReason 响应需要重试时返回原因，否则返回空
+func retryReason(status int, body []byte) string {
+	var r struct {
+		Code int `json:"code"`
+	}
+	json.Unmarshal(body, &r)
+	switch {
+	case status == http.StatusTooManyRequests, r.Code == codeRateLimited, r.Code == codeBitableTooManyRequests:
+		return "触发频率限制"
+	case r.Code == codeBitableWriteConflict:
+		return "多维表格写冲突"
+	case status >= 500:
+		return fmt.Sprintf("服务端错误 (HTTP %d)", status)
+	}
+	return ""
+}
+
 // FilterInfo 记录过滤条件
 type FilterInfo struct {
 	Conjunction string      `json:"conjunction"` // and 或 or
@@ -777,7 +997,7 @@ type SearchRecordsRequest struct {
 	Filter *FilterInfo `json:"filter,omitempty"`
 }
 
-// SearchRecords 根据条件查询记录
+// SearchRecords 根据条件查询记录，记录ID保存在 RecordIDKey 中
 func (c *Client) SearchRecords(appToken, tableToken string, filter FilterInfo) ([]map[string]interface{}, error) {
 	token, err := c.GetTenantAccessToken()
 	if err != nil {
@@ -796,28 +1016,22 @@ func (c *Client) SearchRecords(appToken, tableToken string, filter FilterInfo) (
 			return nil, fmt.Errorf("构建请求失败: %w", err)
 		}
 
-		url := fmt.Sprintf(baseURL+"/open-apis/bitable/v1/apps/%s/tables/%s/records/search?page_size=100", appToken, tableToken)
+		url := fmt.Sprintf(c.baseURL+"/open-apis/bitable/v1/apps/%s/tables/%s/records/search?page_size=100", appToken, tableToken)
 		if pageToken != "" {
 			url += "&page_token=" + pageToken
 		}
 
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-		if err != nil {
-			return nil, fmt.Errorf("创建请求失败: %w", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+token)
-
-		resp, err := c.httpCli.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("请求失败: %w", err)
-		}
-
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
+		body, err := c.doWithRetry(func() (*http.Request, error) {
+			req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			return req, nil
+		})
 		if err != nil {
-			return nil, fmt.Errorf("读取响应失败: %w", err)
+			return nil, err
 		}
 
 		var recordsResp GetTableRecordsResponse
@@ -830,7 +1044,7 @@ func (c *Client) SearchRecords(appToken, tableToken string, filter FilterInfo) (
 		}
 
 		for _, item := range recordsResp.Data.Items {
-			allRecords = append(allRecords, item.Fields)
+			allRecords = append(allRecords, withRecordID(item.Fields, item.RecordID))
 		}
 
 		if !recordsResp.Data.HasMore {
@@ -842,3 +1056,103 @@ func (c *Client) SearchRecords(appToken, tableToken string, filter FilterInfo) (
 
 	return allRecords, nil
 }
+
+// UpdateRecord 更新单条记录的部分字段
+func (c *Client) UpdateRecord(appToken, tableToken, recordID string, fields map[string]interface{}) error {
+	token, err := c.GetTenantAccessToken()
+	if err != nil {
+		return fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+
+	jsonData, err := json.Marshal(Record{Fields: fields})
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %w", err)
+	}
+
+	url := fmt.Sprintf(c.baseURL+bitablePath+"/%s", appToken, tableToken, recordID)
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var updateResp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &updateResp); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	if updateResp.Code != 0 {
+		return fmt.Errorf("更新记录失败 (code=%d): %s", updateResp.Code, updateResp.Msg)
+	}
+	return nil
+}
+
+// RecordUpdate 批量更新中的单条记录
+type RecordUpdate struct {
+	RecordID string                 `json:"record_id"`
+	Fields   map[string]interface{} `json:"fields"`
+}
+
+// BatchUpdateRecords 批量更新记录的部分字段（按 batchSize 分批请求，最多 500 条），返回更新的记录数
+// 某一批失败时返回此前已更新的记录数与错误
+func (c *Client) BatchUpdateRecords(appToken, tableToken string, records []RecordUpdate) (int, error) {
+	token, err := c.GetTenantAccessToken()
+	if err != nil {
+		return 0, fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+
+	url := fmt.Sprintf(c.baseURL+batchUpdatePath, appToken, tableToken)
+	updated := 0
+	for start := 0; start < len(records); start += c.batchSize {
+		batch := records[start:min(start+c.batchSize, len(records))]
+		jsonData, err := json.Marshal(map[string]interface{}{"records": batch})
+		if err != nil {
+			return updated, fmt.Errorf("构建请求失败: %w", err)
+		}
+
+		body, err := c.doWithRetry(func() (*http.Request, error) {
+			req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			return req, nil
+		})
+		if err != nil {
+			return updated, err
+		}
+
+		var updateResp struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+			Data struct {
+				Records []struct {
+					RecordID string `json:"record_id"`
+				} `json:"records"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &updateResp); err != nil {
+			return updated, fmt.Errorf("解析响应失败: %w", err)
+		}
+		if updateResp.Code != 0 {
+			return updated, fmt.Errorf("批量更新记录失败 (code=%d): %s", updateResp.Code, updateResp.Msg)
+		}
+		updated += len(updateResp.Data.Records)
+	}
+	return updated, nil
+}
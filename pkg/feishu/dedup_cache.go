@@ -0,0 +1,373 @@
+package feishu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"xianyu_aner/pkg/util"
+)
+
+// DedupCache 本地去重索引，按 (数据表ID, ProductKey) 记录飞书表格中已有的商品
+// 去重时先查本地索引，只有未命中的商品ID才查询飞书；推送成功后写入索引。
+// 飞书中手动删除或修改的记录不会反映到索引中，需要通过 BitableService.ReconcileDedupCache 与远程表对账
+type DedupCache struct {
+	mu        sync.Mutex
+	path      string        // 持久化文件（为空时仅保存在内存）
+	retention time.Duration // 超过该时长未更新的数据表从索引中移除（<=0 时不清理）
+	tables    map[string]*dedupTable
+	dirty     bool
+}
+
+// dedupTable 单个数据表的索引
+type dedupTable struct {
+	keys    map[ProductKey]bool
+	updated time.Time
+
+	// 以下状态只在本进程内有效，不持久化（其他进程可能同时写入远程表）
+	complete bool            // 已与远程表全量对账（或是本进程新建的表），未命中即不存在
+	checked  map[string]bool // 已查询过远程表的商品ID
+
+	// 上次保存以来本进程的修改，保存时应用到文件中的最新索引上（其他进程可能同时写入索引文件）
+	added    map[ProductKey]bool
+	removed  map[ProductKey]bool
+	replaced bool // 已用远程表全量替换，保存时不保留文件中该表的旧记录
+}
+
+// dedupFile 索引文件格式
+type dedupFile struct {
+	Tables map[string]dedupFileTable `json:"tables"`
+}
+
+type dedupFileTable struct {
+	UpdatedAt time.Time      `json:"updated_at"`
+	Keys      []dedupFileKey `json:"keys"`
+}
+
+type dedupFileKey struct {
+	ItemID  string `json:"item_id"`
+	Price   string `json:"price"`
+	WantCnt int    `json:"want_cnt"`
+}
+
+// ErrDedupCacheCorrupt 去重索引文件内容无法解析
+var ErrDedupCacheCorrupt = errors.New("去重索引文件已损坏")
+
+// LoadDedupCache 加载去重索引，文件不存在时返回空索引
+// 文件无法解析时将其重命名为 <path>.corrupt-<时间戳> 保留原内容，按空索引继续
+func LoadDedupCache(path string, retention time.Duration) (*DedupCache, error) {
+	c := &DedupCache{path: path, retention: retention, tables: make(map[string]*dedupTable)}
+	if path == "" {
+		return c, nil
+	}
+	file, err := readDedupFile(path)
+	if errors.Is(err, ErrDedupCacheCorrupt) {
+		if err := moveDedupFileAside(path, err); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for tableID, ft := range file.Tables {
+		t := c.table(tableID)
+		t.updated = ft.UpdatedAt
+		for _, k := range ft.Keys {
+			t.keys[ProductKey{ItemID: k.ItemID, Price: k.Price, WantCnt: k.WantCnt}] = true
+		}
+	}
+	return c, nil
+}
+
+// readDedupFile 读取索引文件，文件不存在时返回空内容，无法解析时返回 ErrDedupCacheCorrupt
+func readDedupFile(path string) (dedupFile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return dedupFile{}, nil
+	}
+	if err != nil {
+		return dedupFile{}, fmt.Errorf("读取去重索引失败: %w", err)
+	}
+	var file dedupFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return dedupFile{}, fmt.Errorf("%w: %v", ErrDedupCacheCorrupt, err)
+	}
+	return file, nil
+}
+
+// moveDedupFileAside 将无法解析的索引文件重命名为 <path>.corrupt-<时间戳>
+func moveDedupFileAside(path string, cause error) error {
+	aside := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102150405"))
+	if err := os.Rename(path, aside); err != nil {
+		return fmt.Errorf("%v，且无法移走损坏的文件: %w", cause, err)
+	}
+	util.Printf("[去重索引] %v，已移至 %s\n", cause, aside)
+	return nil
+}
+
+// table 返回数据表的索引，不存在时创建，调用方需持有锁
+func (c *DedupCache) table(tableID string) *dedupTable {
+	t, ok := c.tables[tableID]
+	if !ok {
+		t = &dedupTable{
+			keys:    make(map[ProductKey]bool),
+			checked: make(map[string]bool),
+			added:   make(map[ProductKey]bool),
+			removed: make(map[ProductKey]bool),
+		}
+		c.tables[tableID] = t
+	}
+	return t
+}
+
+// Contains 索引中是否已有该商品（nil 安全）
+func (c *DedupCache) Contains(tableID string, key ProductKey) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.tables[tableID]
+	return ok && t.keys[key]
+}
+
+// Covers 判断能否只凭本地索引确定该商品ID的去重结果，无需查询远程表（nil 安全）
+// keys 全部命中，或本进程已查询过该商品ID / 已全量对账时返回 true
+func (c *DedupCache) Covers(tableID, itemID string, keys []ProductKey) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.tables[tableID]
+	if !ok {
+		return false
+	}
+	if t.complete || t.checked[itemID] {
+		return true
+	}
+	for _, key := range keys {
+		if !t.keys[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// Add 记录远程表中已存在的商品（nil 安全）
+func (c *DedupCache) Add(tableID string, keys ...ProductKey) {
+	if c == nil || len(keys) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := c.table(tableID)
+	for _, key := range keys {
+		t.keys[key] = true
+		t.added[key] = true
+		delete(t.removed, key)
+	}
+	t.updated = time.Now()
+	c.dirty = true
+}
+
+// Remove 从索引中移除远程表中已不存在的商品（例如记录被更新为新的价格/想要人数，nil 安全）
+func (c *DedupCache) Remove(tableID string, keys ...ProductKey) {
+	if c == nil || len(keys) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.tables[tableID]
+	if !ok {
+		return
+	}
+	for _, key := range keys {
+		delete(t.keys, key)
+		delete(t.added, key)
+		t.removed[key] = true
+	}
+	t.updated = time.Now()
+	c.dirty = true
+}
+
+// MarkChecked 记录本进程已查询过远程表中该商品ID的全部记录（nil 安全）
+func (c *DedupCache) MarkChecked(tableID, itemID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.table(tableID).checked[itemID] = true
+}
+
+// Replace 用远程表的全量记录替换该数据表的索引（nil 安全）
+func (c *DedupCache) Replace(tableID string, keys []ProductKey) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tables, tableID)
+	t := c.table(tableID)
+	for _, key := range keys {
+		t.keys[key] = true
+		t.added[key] = true
+	}
+	t.updated = time.Now()
+	t.complete = true
+	t.replaced = true
+	c.dirty = true
+}
+
+// Len 数据表索引中的记录数（nil 安全）
+func (c *DedupCache) Len(tableID string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t, ok := c.tables[tableID]; ok {
+		return len(t.keys)
+	}
+	return 0
+}
+
+// Save 在文件锁内读取最新的索引文件，应用本进程的修改、清理过期的数据表后写回（唯一临时文件 + 重命名），
+// 其他进程（server 与 crawl）同时写入的记录得以保留；索引未变化时不写入（nil 安全）
+// 文件无法解析时先移到 <path>.corrupt-<时间戳>，再只写入本进程的索引；没有记录的数据表不写入
+func (c *DedupCache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.path == "" {
+		c.expireLocked()
+		return nil
+	}
+	if !c.dirty && !c.hasExpiredLocked() {
+		return nil
+	}
+
+	unlock, err := util.LockFile(c.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := readDedupFile(c.path)
+	switch {
+	case errors.Is(err, ErrDedupCacheCorrupt):
+		if err := moveDedupFileAside(c.path, err); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		c.mergeLocked(file)
+	}
+	c.expireLocked()
+
+	data, err := json.Marshal(c.fileLocked())
+	if err != nil {
+		return fmt.Errorf("序列化去重索引失败: %w", err)
+	}
+	if err := util.WriteFileAtomic(c.path, data, 0644); err != nil {
+		return fmt.Errorf("写入去重索引失败: %w", err)
+	}
+	for _, t := range c.tables {
+		t.added = make(map[ProductKey]bool)
+		t.removed = make(map[ProductKey]bool)
+		t.replaced = false
+	}
+	c.dirty = false
+	return nil
+}
+
+// mergeLocked 以文件中的索引为基础应用本进程的修改（新增与移除），结果作为新的内存索引，调用方需持有锁
+// 已全量替换的数据表以本进程为准；文件中已没有且本进程未修改的数据表视为被其他进程清理
+func (c *DedupCache) mergeLocked(file dedupFile) {
+	for tableID, t := range c.tables {
+		if _, ok := file.Tables[tableID]; !ok && !t.replaced && len(t.added) == 0 {
+			t.keys = make(map[ProductKey]bool)
+		}
+	}
+	for tableID, ft := range file.Tables {
+		t := c.table(tableID)
+		if t.replaced {
+			continue
+		}
+		keys := make(map[ProductKey]bool, len(ft.Keys)+len(t.added))
+		for _, k := range ft.Keys {
+			key := ProductKey{ItemID: k.ItemID, Price: k.Price, WantCnt: k.WantCnt}
+			if !t.removed[key] {
+				keys[key] = true
+			}
+		}
+		for key := range t.added {
+			keys[key] = true
+		}
+		t.keys = keys
+		if ft.UpdatedAt.After(t.updated) {
+			t.updated = ft.UpdatedAt
+		}
+	}
+}
+
+// hasExpiredLocked 是否有超过保留时长的数据表，调用方需持有锁
+func (c *DedupCache) hasExpiredLocked() bool {
+	if c.retention <= 0 {
+		return false
+	}
+	cutoff := time.Now().Add(-c.retention)
+	for _, t := range c.tables {
+		if t.updated.Before(cutoff) {
+			return true
+		}
+	}
+	return false
+}
+
+// expireLocked 移除超过保留时长的数据表，调用方需持有锁
+func (c *DedupCache) expireLocked() {
+	if c.retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-c.retention)
+	for tableID, t := range c.tables {
+		if t.updated.Before(cutoff) {
+			delete(c.tables, tableID)
+		}
+	}
+}
+
+// fileLocked 转换为索引文件格式，调用方需持有锁
+func (c *DedupCache) fileLocked() dedupFile {
+	file := dedupFile{Tables: make(map[string]dedupFileTable, len(c.tables))}
+	for tableID, t := range c.tables {
+		if len(t.keys) == 0 {
+			continue
+		}
+		keys := make([]dedupFileKey, 0, len(t.keys))
+		for k := range t.keys {
+			keys = append(keys, dedupFileKey{ItemID: k.ItemID, Price: k.Price, WantCnt: k.WantCnt})
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].ItemID != keys[j].ItemID {
+				return keys[i].ItemID < keys[j].ItemID
+			}
+			if keys[i].Price != keys[j].Price {
+				return keys[i].Price < keys[j].Price
+			}
+			return keys[i].WantCnt < keys[j].WantCnt
+		})
+		file.Tables[tableID] = dedupFileTable{UpdatedAt: t.updated, Keys: keys}
+	}
+	return file
+}
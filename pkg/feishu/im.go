@@ -0,0 +1,224 @@
+package feishu
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+)
+
+const (
+	imMessagePath = "/open-apis/im/v1/messages"
+	imImagePath   = "/open-apis/im/v1/images"
+
+	// maxImageBytes 飞书消息图片大小上限（10MB）
+	maxImageBytes = 10 << 20
+)
+
+// 消息接收者 ID 类型
+const (
+	ReceiveIDChat  = "chat_id"
+	ReceiveIDOpen  = "open_id"
+	ReceiveIDUser  = "user_id"
+	ReceiveIDEmail = "email"
+)
+
+// apiResponse 飞书开放平台通用响应
+type apiResponse struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// doAPI 以租户令牌调用开放平台接口，解析通用响应并返回 data
+func (c *Client) doAPI(method, apiURL, contentType string, body io.Reader) (json.RawMessage, error) {
+	token, err := c.GetTenantAccessToken()
+	if err != nil {
+		return nil, fmt.Errorf("获取 token 失败: %w", err)
+	}
+
+	req, err := http.NewRequest(method, apiURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	var apiResp apiResponse
+	if err := json.Unmarshal(data, &apiResp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w, 响应内容: %s", err, string(data))
+	}
+	if apiResp.Code != 0 {
+		return nil, fmt.Errorf("code=%d, msg=%s", apiResp.Code, apiResp.Msg)
+	}
+	return apiResp.Data, nil
+}
+
+// SendCard 通过应用机器人发送消息卡片，返回消息ID
+// receiveIDType 为 ReceiveIDChat 等，机器人需已加入目标群
+func (c *Client) SendCard(receiveIDType, receiveID string, card *Card) (string, error) {
+	content, err := card.JSON()
+	if err != nil {
+		return "", err
+	}
+	reqBody, err := json.Marshal(map[string]string{
+		"receive_id": receiveID,
+		"msg_type":   "interactive",
+		"content":    content,
+	})
+	if err != nil {
+		return "", fmt.Errorf("构建请求失败: %w", err)
+	}
+
+	apiURL := c.baseURL + imMessagePath + "?receive_id_type=" + url.QueryEscape(receiveIDType)
+	data, err := c.doAPI(http.MethodPost, apiURL, "application/json; charset=utf-8", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", fmt.Errorf("发送卡片失败: %w", err)
+	}
+	var result struct {
+		MessageID string `json:"message_id"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("解析消息ID失败: %w", err)
+	}
+	return result.MessageID, nil
+}
+
+// UploadImage 上传消息图片，返回可用于卡片的 image_key
+func (c *Client) UploadImage(filename string, r io.Reader) (string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.WriteField("image_type", "message"); err != nil {
+		return "", fmt.Errorf("构建请求失败: %w", err)
+	}
+	part, err := w.CreateFormFile("image", filename)
+	if err != nil {
+		return "", fmt.Errorf("构建请求失败: %w", err)
+	}
+	if _, err := io.Copy(part, io.LimitReader(r, maxImageBytes+1)); err != nil {
+		return "", fmt.Errorf("读取图片失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("构建请求失败: %w", err)
+	}
+
+	data, err := c.doAPI(http.MethodPost, c.baseURL+imImagePath, w.FormDataContentType(), &buf)
+	if err != nil {
+		return "", fmt.Errorf("上传图片失败: %w", err)
+	}
+	var result struct {
+		ImageKey string `json:"image_key"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("解析 image_key 失败: %w", err)
+	}
+	return result.ImageKey, nil
+}
+
+// UploadImageURL 下载远程图片并上传为消息图片
+func (c *Client) UploadImageURL(imageURL string) (string, error) {
+	if len(imageURL) > 2 && imageURL[:2] == "//" {
+		imageURL = "https:" + imageURL
+	}
+	resp, err := c.httpCli.Get(imageURL)
+	if err != nil {
+		return "", fmt.Errorf("下载图片失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("下载图片失败: HTTP %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxImageBytes {
+		return "", fmt.Errorf("图片过大: %d 字节", resp.ContentLength)
+	}
+
+	name := "cover.jpg"
+	if u, err := url.Parse(imageURL); err == nil && path.Base(u.Path) != "/" && path.Base(u.Path) != "." {
+		name = path.Base(u.Path)
+	}
+	return c.UploadImage(name, resp.Body)
+}
+
+// ==================== 自定义机器人 ====================
+
+// BotWebhook 群自定义机器人（webhook 方式，无需应用凭证）
+type BotWebhook struct {
+	webhook string
+	secret  string // 签名校验密钥（为空时不签名）
+	httpCli *http.Client
+	now     func() time.Time
+}
+
+// NewBotWebhook 创建自定义机器人
+func NewBotWebhook(webhook, secret string) *BotWebhook {
+	return &BotWebhook{
+		webhook: webhook,
+		secret:  secret,
+		httpCli: &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+	}
+}
+
+// BotSign 计算自定义机器人签名：以 timestamp+"\n"+secret 为密钥对空串做 HmacSHA256 后 base64
+func BotSign(timestamp int64, secret string) string {
+	mac := hmac.New(sha256.New, []byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SendCard 发送消息卡片
+func (b *BotWebhook) SendCard(card *Card) error {
+	payload := map[string]interface{}{
+		"msg_type": "interactive",
+		"card":     card,
+	}
+	if b.secret != "" {
+		ts := b.now().Unix()
+		payload["timestamp"] = strconv.FormatInt(ts, 10)
+		payload["sign"] = BotSign(ts, b.secret)
+	}
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %w", err)
+	}
+
+	resp, err := b.httpCli.Post(b.webhook, "application/json; charset=utf-8", bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(data))
+	}
+	var result apiResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("解析响应失败: %w, 响应内容: %s", err, string(data))
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("发送卡片失败 (code=%d): %s", result.Code, result.Msg)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package feishu
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newIMTestServer 模拟飞书开放平台的令牌、消息与图片接口
+func newIMTestServer(t *testing.T, requests map[string]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc(authPath, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"code":0,"tenant_access_token":"t-test","expire":7200}`)
+	})
+	mux.HandleFunc(imMessagePath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t-test" || r.URL.Query().Get("receive_id_type") != ReceiveIDChat {
+			io.WriteString(w, `{"code":99991663,"msg":"invalid request"}`)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests["message"] = body
+		io.WriteString(w, `{"code":0,"msg":"success","data":{"message_id":"om_1"}}`)
+	})
+	mux.HandleFunc(imImagePath, func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("image")
+		if err != nil || r.FormValue("image_type") != "message" {
+			io.WriteString(w, `{"code":234001,"msg":"invalid image"}`)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		requests["image"] = map[string]interface{}{"name": header.Filename, "size": len(data)}
+		io.WriteString(w, `{"code":0,"msg":"success","data":{"image_key":"img_v2_1"}}`)
+	})
+	mux.HandleFunc("/cover.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("fake-png"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestItemCard(t *testing.T) {
+	card := ItemCard(ItemCardData{
+		Heading:     "低价商品",
+		Title:       "索尼 A7M3",
+		Price:       6800,
+		OldPrice:    7200.5,
+		WantCount:   12,
+		SellerNick:  "小王",
+		RiskLevel:   "high",
+		RiskReasons: "新注册账号",
+		DetailURL:   "https://www.goofish.com/item?id=1",
+		ImageKey:    "img_v2_1",
+	})
+	content, err := card.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	for _, want := range []string{`"template":"red"`, "【低价商品】索尼 A7M3", `"img_key":"img_v2_1"`, "¥6800 ~~¥7200.5~~", "12 人", "🔴 高（新注册账号）", `"url":"https://www.goofish.com/item?id=1"`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("卡片缺少 %q: %s", want, content)
+		}
+	}
+
+	// 无 image_key 时以链接展示封面，空字段不显示
+	content, _ = ItemCard(ItemCardData{Title: "镜头", Price: 100, ImageURL: "https://img/x.jpg"}).JSON()
+	if strings.Contains(content, "img_key") || !strings.Contains(content, "[查看封面](https://img/x.jpg)") || strings.Contains(content, "想要") {
+		t.Errorf("无图卡片错误: %s", content)
+	}
+
+	summary, _ := SummaryCard(SummaryCardData{
+		Title: "爬取汇总",
+		Stats: []CardField{{Label: "商品数", Value: "30", Short: true}},
+		Items: []ItemCardData{
+			{Title: "相机", Price: 500, WantCount: 8, DetailURL: "https://x/1"},
+			{Title: "镜头", Price: 90, WantCount: 5, RiskLevel: "high", DetailURL: "https://x/2"},
+		},
+	}).JSON()
+	if !strings.Contains(summary, "1. [相机](https://x/1) ¥500 · 8 人想要") {
+		t.Errorf("汇总卡片错误: %s", summary)
+	}
+	// 高风险卖家的商品在汇总中标注风险等级
+	if !strings.Contains(summary, "2. [镜头](https://x/2) ¥90 · 5 人想要 · 🔴 高") || strings.Count(summary, "🔴") != 1 {
+		t.Errorf("汇总卡片风险标注错误: %s", summary)
+	}
+}
+
+func TestClient_SendCardAndUploadImage(t *testing.T) {
+	requests := make(map[string]map[string]interface{})
+	srv := newIMTestServer(t, requests)
+	client := NewClient(ClientConfig{AppID: "a", AppSecret: "s", BaseURL: srv.URL + "/"})
+
+	imageKey, err := client.UploadImageURL(srv.URL + "/cover.png")
+	if err != nil {
+		t.Fatalf("UploadImageURL() error = %v", err)
+	}
+	if imageKey != "img_v2_1" || requests["image"]["name"] != "cover.png" || requests["image"]["size"] != 8 {
+		t.Errorf("上传图片 = %s, %v", imageKey, requests["image"])
+	}
+
+	messageID, err := client.SendCard(ReceiveIDChat, "oc_1", NewCard("测试", "").Markdown("hello"))
+	if err != nil {
+		t.Fatalf("SendCard() error = %v", err)
+	}
+	msg := requests["message"]
+	if messageID != "om_1" || msg["receive_id"] != "oc_1" || msg["msg_type"] != "interactive" {
+		t.Errorf("发送消息 = %s, %v", messageID, msg)
+	}
+	var card Card
+	if err := json.Unmarshal([]byte(msg["content"].(string)), &card); err != nil || len(card.Elements) != 1 {
+		t.Errorf("消息内容应为卡片 JSON: %v, %v", msg["content"], err)
+	}
+
+	if _, err := client.SendCard(ReceiveIDOpen, "ou_1", NewCard("测试", "")); err == nil || !strings.Contains(err.Error(), "99991663") {
+		t.Errorf("接口错误码应返回错误, got %v", err)
+	}
+}
+
+func TestBotWebhook_Sign(t *testing.T) {
+	var got map[string]interface{}
+	code := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		if code != 0 {
+			w.Write([]byte(`{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`))
+			return
+		}
+		w.Write([]byte(`{"code":0,"msg":"success","data":{}}`))
+	}))
+	defer srv.Close()
+
+	bot := NewBotWebhook(srv.URL, "secret")
+	bot.now = func() time.Time { return time.Unix(1700000000, 0) }
+	if err := bot.SendCard(NewCard("测试", CardGreen)); err != nil {
+		t.Fatalf("SendCard() error = %v", err)
+	}
+	if got["timestamp"] != "1700000000" || got["sign"] != BotSign(1700000000, "secret") || got["msg_type"] != "interactive" {
+		t.Errorf("请求体 = %v", got)
+	}
+	if _, ok := got["card"].(map[string]interface{}); !ok {
+		t.Errorf("card 应为对象: %v", got["card"])
+	}
+
+	code = 19021
+	if err := bot.SendCard(NewCard("测试", "")); err == nil || !strings.Contains(err.Error(), "19021") {
+		t.Errorf("签名失败应返回错误, got %v", err)
+	}
+}
+
+// encryptForTest 按飞书回调加密方式加密（测试用）
+func encryptForTest(t *testing.T, key string, plain []byte) string {
+	t.Helper()
+	sum := sha256.Sum256([]byte(key))
+	block, _ := aes.NewCipher(sum[:])
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	data := append(append([]byte{}, plain...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	iv := bytes.Repeat([]byte{7}, aes.BlockSize)
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, data)
+	return base64.StdEncoding.EncodeToString(append(iv, out...))
+}
+
+func TestParseCallback(t *testing.T) {
+	// 加密的 URL 校验请求
+	plain := []byte(`{"type":"url_verification","challenge":"abc","token":"vt"}`)
+	body := []byte(`{"encrypt":"` + encryptForTest(t, "ek", plain) + `"}`)
+	cb, err := ParseCallback(body, "ek")
+	if err != nil {
+		t.Fatalf("ParseCallback() error = %v", err)
+	}
+	if cb.Type != CallbackURLVerification || cb.Challenge != "abc" || cb.Token != "vt" {
+		t.Errorf("URL 校验 = %+v", cb)
+	}
+	if _, err := ParseCallback(body, "wrong"); err == nil {
+		t.Error("错误的 Encrypt Key 应返回错误")
+	}
+	if _, err := ParseCallback(body, ""); err == nil {
+		t.Error("未配置 Encrypt Key 时加密请求应返回错误")
+	}
+
+	// 签名校验
+	sig := Signature("1700000000", "n1", "ek", body)
+	if !VerifySignature("1700000000", "n1", "ek", "", body, sig) || VerifySignature("1700000001", "n1", "ek", "", body, sig) {
+		t.Error("签名校验结果错误")
+	}
+
+	// 旧版消息卡片回调：sha1(timestamp + nonce + Verification Token + body)
+	legacyBody := []byte(`{"open_id":"ou_1","action":{"value":{"action":"watch"}}}`)
+	legacySig := "2e166b0c795ab95971e237466eb684f6ce823d35"
+	if got := LegacySignature("1700000000", "n1", "vtoken", legacyBody); got != legacySig {
+		t.Errorf("LegacySignature() = %s, want %s", got, legacySig)
+	}
+	if !VerifySignature("1700000000", "n1", "ek", "vtoken", legacyBody, legacySig) {
+		t.Error("旧版签名应通过校验")
+	}
+	if VerifySignature("1700000000", "n1", "vtoken", "", legacyBody, legacySig) {
+		t.Error("旧版签名不应以 Encrypt Key 计算")
+	}
+
+	// 时间戳校验：超出允许偏差的请求视为重放
+	now := time.Unix(1700000000, 0)
+	if !VerifyTimestamp("1700000000", now) || !VerifyTimestamp("1699999800", now) {
+		t.Error("允许偏差内的时间戳应通过校验")
+	}
+	if VerifyTimestamp("1699999000", now) || VerifyTimestamp("1700001000", now) || VerifyTimestamp("", now) {
+		t.Error("过期、超前或缺失的时间戳应校验失败")
+	}
+
+	// 新版卡片回调：按钮数据可还原卡片
+	data := ItemCardData{Heading: "低价商品", ItemID: "1", Title: "相机", Price: 99.5, WantCount: 3, SellerNick: "小王", DetailURL: "https://x/1"}
+	buttons := ItemActionButtons(data)
+	if len(buttons) != 4 || buttons[3].Value["action"] != ActionMuteSeller {
+		t.Fatalf("按钮 = %+v", buttons)
+	}
+	value, _ := json.Marshal(buttons[0].Value)
+	v2 := `{"schema":"2.0","header":{"event_id":"e1","event_type":"card.action.trigger","token":"vt"},
+		"event":{"operator":{"open_id":"ou_1"},"action":{"value":` + string(value) + `},"context":{"open_message_id":"om_1","open_chat_id":"oc_1"}}}`
+	cb, err = ParseCallback([]byte(v2), "")
+	if err != nil || cb.Type != CallbackCardAction || cb.Legacy || cb.MessageID != "om_1" || cb.Token != "vt" {
+		t.Fatalf("新版回调 = %+v, %v", cb, err)
+	}
+	action, got := ParseItemAction(cb.Value)
+	if action != ActionWatch || !reflect.DeepEqual(got, data) {
+		t.Errorf("ParseItemAction() = %s, %+v", action, got)
+	}
+	resp, _ := json.Marshal(CardActionResponse(cb, "success", "已关注", ItemCard(got)))
+	if !strings.Contains(string(resp), `"toast":{"content":"已关注","type":"success"}`) || !strings.Contains(string(resp), `"type":"raw"`) {
+		t.Errorf("新版响应 = %s", resp)
+	}
+
+	// 旧版卡片回调直接返回卡片
+	legacy := `{"open_id":"ou_1","open_message_id":"om_1","token":"vt","action":{"value":{"action":"ignore","item_id":"1","price":12}}}`
+	cb, err = ParseCallback([]byte(legacy), "")
+	if err != nil || !cb.Legacy {
+		t.Fatalf("旧版回调 = %+v, %v", cb, err)
+	}
+	if action, d := ParseItemAction(cb.Value); action != ActionIgnore || d.Price != 12 {
+		t.Errorf("旧版按钮数据 = %s, %+v", action, d)
+	}
+	if _, ok := CardActionResponse(cb, "success", "x", NewCard("t", "")).(*Card); !ok {
+		t.Error("旧版回调响应应为卡片本身")
+	}
+}
+
+// statusClient 模拟包含商品记录的多维表格
+type statusClient struct {
+	MockClient
+	records map[string][]map[string]interface{} // 数据表ID -> 记录
+	updated map[string]interface{}
+}
+
+func (c *statusClient) SearchRecords(appToken, tableToken string, filter FilterInfo) ([]map[string]interface{}, error) {
+	return c.records[tableToken], nil
+}
+
+func (c *statusClient) UpdateRecord(appToken, tableToken, recordID string, fields map[string]interface{}) error {
+	c.updated[tableToken+"/"+recordID] = fields[StatusField]
+	return nil
+}
+
+func TestBitableService_SetItemStatus(t *testing.T) {
+	client := &statusClient{
+		MockClient: MockClient{
+			tables: []TableInfo{{TableID: "t1", Name: "2024-06-01"}, {TableID: "t3", Name: "2024-06-03"}, {TableID: "t2", Name: "2024-06-02"}},
+			fields: map[string]string{StatusField: "fld"},
+		},
+		records: map[string][]map[string]interface{}{
+			"t1": {{RecordIDKey: "r1"}},
+			"t2": {{RecordIDKey: "r2"}, {RecordIDKey: "r3"}},
+		},
+		updated: make(map[string]interface{}),
+	}
+	service := NewBitableService(client, BitableConfig{AppToken: "app"})
+
+	tableID, n, err := service.SetItemStatus("1", "已购买")
+	if err != nil || tableID != "t2" || n != 2 {
+		t.Fatalf("SetItemStatus() = %s, %d, %v", tableID, n, err)
+	}
+	if client.updated["t2/r2"] != "已购买" || len(client.updated) != 2 {
+		t.Errorf("更新记录 = %v", client.updated)
+	}
+}
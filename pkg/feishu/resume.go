@@ -0,0 +1,99 @@
+package feishu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"xianyu_aner/pkg/util"
+)
+
+// PendingPush 推送失败、待下次运行时重新推送的商品
+type PendingPush struct {
+	ID       string    `json:"id"`   // 续传记录ID（写入时生成），重新推送后据此从文件中移除
+	Date     string    `json:"date"` // 目标数据表日期（YYYY-MM-DD）
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+	Products []Product `json:"products"`
+
+	// ClientToken 失败批次的 batch_create client_token，重新推送时沿用（整体失败时为空）
+	ClientToken string `json:"client_token,omitempty"`
+}
+
+// resumeFile 续传文件格式
+type resumeFile struct {
+	Pending []PendingPush `json:"pending"`
+}
+
+// ErrResumeCorrupt 续传文件内容无法解析
+var ErrResumeCorrupt = errors.New("续传文件已损坏")
+
+// LoadPendingPushes 读取续传文件，文件不存在时返回空列表，内容无法解析时返回 ErrResumeCorrupt
+func LoadPendingPushes(path string) ([]PendingPush, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取续传文件失败: %w", err)
+	}
+	var file resumeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrResumeCorrupt, err)
+	}
+	return file.Pending, nil
+}
+
+// SavePendingPushes 写入续传文件（先写唯一的临时文件再重命名），列表为空时删除文件
+// 没有 ID 的记录写入前生成 ID
+func SavePendingPushes(path string, pending []PendingPush) error {
+	if len(pending) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除续传文件失败: %w", err)
+		}
+		return nil
+	}
+	for i := range pending {
+		if pending[i].ID == "" {
+			pending[i].ID = newClientToken()
+		}
+	}
+	data, err := json.MarshalIndent(resumeFile{Pending: pending}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化续传文件失败: %w", err)
+	}
+	if err := util.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("写入续传文件失败: %w", err)
+	}
+	return nil
+}
+
+// UpdatePendingPushes 在锁内读取续传文件、调用 update 修改后写回，返回 update 前的记录
+// 文件无法解析时先将其重命名为 <path>.corrupt-<时间戳> 保留原内容，再按空列表继续；其他读取错误直接返回，不覆盖文件
+func UpdatePendingPushes(path string, update func(pending []PendingPush) []PendingPush) ([]PendingPush, error) {
+	unlock, err := util.LockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	pending, err := LoadPendingPushes(path)
+	if errors.Is(err, ErrResumeCorrupt) {
+		aside := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102150405"))
+		if rerr := os.Rename(path, aside); rerr != nil {
+			return nil, fmt.Errorf("%v，且无法移走损坏的文件: %w", err, rerr)
+		}
+		util.Printf("[续传] %v，已移至 %s\n", err, aside)
+		pending = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	before := append([]PendingPush(nil), pending...)
+	if err := SavePendingPushes(path, update(pending)); err != nil {
+		return nil, err
+	}
+	return before, nil
+}
@@ -24,7 +24,7 @@ type FieldSchema struct {
 	FieldName string    `json:"fieldName,omitempty"`
 }
 
-// ProductFields 商品字段定义（优化后保留24个核心字段，移除重复字段）
+// ProductFields 商品字段定义（核心字段 + 实体识别/低价检测/卖家风险/重复检测字段，移除重复字段）
 var ProductFields = []struct {
 	Key      string
 	Schema   FieldSchema
@@ -65,9 +65,43 @@ var ProductFields = []struct {
 	{"tags", FieldSchema{Type: FieldTypeText, Label: "商品标签"}, 22},
 	{"itemStatusStr", FieldSchema{Type: FieldTypeText, Label: "商品状态"}, 23},
 	{"description", FieldSchema{Type: FieldTypeText, Label: "详细描述"}, 24},
+
+	// ==================== 实体识别 ====================
+	{"brand", FieldSchema{Type: FieldTypeText, Label: "品牌"}, 25},
+	{"model", FieldSchema{Type: FieldTypeText, Label: "型号"}, 26},
+	{"spec", FieldSchema{Type: FieldTypeText, Label: "规格"}, 27},
+	{"entityConfidence", FieldSchema{Type: FieldTypeNumber, Label: "识别置信度"}, 28},
+
+	// ==================== 低价检测 ====================
+	{"dealDiscount", FieldSchema{Type: FieldTypeNumber, Label: "低于市场价%"}, 29},
+	{"dealBasis", FieldSchema{Type: FieldTypeText, Label: "比价依据"}, 30},
+
+	// ==================== 卖家风险 ====================
+	{"riskLevel", FieldSchema{Type: FieldTypeText, Label: "风险等级"}, 31},
+	{"riskReasons", FieldSchema{Type: FieldTypeText, Label: "风险原因"}, 32},
+
+	// ==================== 重复检测 ====================
+	{"dupCluster", FieldSchema{Type: FieldTypeText, Label: "重复簇"}, 33},
+	{"dupCount", FieldSchema{Type: FieldTypeNumber, Label: "重复数量"}, 34},
+
+	// ==================== 变化跟踪 ====================
+	{LastPriceField, FieldSchema{Type: FieldTypeText, Label: "上次价格"}, 0},   // upsert 模式下价格或想要人数变化时写入
+	{WantDeltaField, FieldSchema{Type: FieldTypeNumber, Label: "想要变化"}, 0}, // 本次与上次想要人数之差
+
+	// ==================== 处理状态 ====================
+	{StatusField, FieldSchema{Type: FieldTypeText, Label: "处理状态"}, 0}, // 由卡片操作写入（已忽略/已购买）
 }
 
-// Product 商品信息（优化后保留24个核心字段）
+// StatusField 处理状态字段（推送时不写入，由飞书卡片操作更新）
+const StatusField = "handleStatus"
+
+// 变化跟踪字段（推送新记录时不写入，upsert 更新记录时写入）
+const (
+	LastPriceField = "lastPrice"
+	WantDeltaField = "wantDelta"
+)
+
+// Product 商品信息（核心字段 + 实体识别字段）
 type Product struct {
 	// ==================== 基本信息 ====================
 	ItemID        string `json:"itemId"`
@@ -89,6 +123,8 @@ type Product struct {
 	SellerCredit    string `json:"sellerCredit,omitempty"`    // 卖家信用
 	SellerItemCount int    `json:"sellerItemCount,omitempty"` // 在售商品数
 	SellerSoldCount int    `json:"sellerSoldCount,omitempty"` // 已售数量
+	SellerRegDays   int    `json:"sellerRegDays,omitempty"`   // 注册天数（不推送，用于风险评估）
+	ShopLevel       string `json:"shopLevel,omitempty"`       // 店铺级别（不推送，用于风险评估）
 	FreeShip        string `json:"freeShip"`
 
 	// ==================== 时间信息 ====================
@@ -96,14 +132,34 @@ type Product struct {
 	CaptureTimeMs int64 `json:"captureTimeMs,omitempty"` // 采集时间戳
 
 	// ==================== 链接资源 ====================
-	CoverURL  string `json:"coverUrl"`
-	DetailURL string `json:"detailUrl"`
-	VideoURL  string `json:"videoUrl,omitempty"` // 视频URL
+	CoverURL  string   `json:"coverUrl"`
+	DetailURL string   `json:"detailUrl"`
+	VideoURL  string   `json:"videoUrl,omitempty"`  // 视频URL
+	ImageList []string `json:"imageList,omitempty"` // 图片列表（不推送，用于重复检测）
 
 	// ==================== 其他 ====================
 	Tags          string `json:"tags"`
 	ItemStatusStr string `json:"itemStatusStr,omitempty"` // 商品状态
 	Description   string `json:"description,omitempty"`   // 详细描述
+	CategoryID    int    `json:"categoryId,omitempty"`    // 分类ID（不推送，用于实体识别）
+
+	// ==================== 实体识别 ====================
+	Brand            string  `json:"brand,omitempty"`            // 品牌
+	Model            string  `json:"model,omitempty"`            // 型号
+	Spec             string  `json:"spec,omitempty"`             // 规格/容量
+	EntityConfidence float64 `json:"entityConfidence,omitempty"` // 识别置信度 0-1
+
+	// ==================== 低价检测 ====================
+	DealDiscount float64 `json:"dealDiscount,omitempty"` // 低于同类中位价的百分比
+	DealBasis    string  `json:"dealBasis,omitempty"`    // 比价依据
+
+	// ==================== 卖家风险 ====================
+	RiskLevel   string `json:"riskLevel,omitempty"`   // 风险等级 low/medium/high
+	RiskReasons string `json:"riskReasons,omitempty"` // 风险原因
+
+	// ==================== 重复检测 ====================
+	DupCluster string `json:"dupCluster,omitempty"` // 重复簇ID（同簇商品疑似同一物品或盗图）
+	DupCount   int    `json:"dupCount,omitempty"`   // 簇内商品数
 }
 
 // PushToBitableRequest 推送到飞书多维表格请求
@@ -117,12 +173,25 @@ type PushToBitableResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message,omitempty"`
 	Data    struct {
-		RecordsCreated int    `json:"recordsCreated"`
-		RecordsUpdated int    `json:"recordsUpdated"`
-		TableToken     string `json:"tableToken"`
+		RecordsCreated   int           `json:"recordsCreated"`
+		RecordsUpdated   int           `json:"recordsUpdated"`
+		RecordsUnchanged int           `json:"recordsUnchanged"`        // upsert 模式下字段无变化而跳过的记录数
+		RecordsFailed    int           `json:"recordsFailed,omitempty"` // 推送失败的记录数
+		TableToken       string        `json:"tableToken"`
+		Chunks           []ChunkResult `json:"chunks,omitempty"` // 分批推送的各批结果
 	} `json:"data,omitempty"`
 }
 
+// ChunkResult 分批推送中单批的结果
+type ChunkResult struct {
+	Index       int      `json:"index"`                 // 批次序号（从 0 开始）
+	Records     int      `json:"records"`               // 本批记录数
+	Created     int      `json:"created"`               // 创建成功的记录数
+	Error       string   `json:"error,omitempty"`       // 失败原因
+	ItemIDs     []string `json:"itemIds,omitempty"`     // 失败时本批的商品ID
+	ClientToken string   `json:"clientToken,omitempty"` // 本批 batch_create 的幂等 client_token，重新推送失败批次时沿用
+}
+
 // NewProduct 从 FeedItem 创建 Product（用于从 mtop.FeedItem 转换）
 func NewProduct(itemID, title, price, originalPrice string, wantCnt int, publishTime, sellerNick, sellerCity, coverURL, detailURL string) Product {
 	now := time.Now()
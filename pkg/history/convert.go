@@ -0,0 +1,61 @@
+package history
+
+import (
+	"time"
+
+	"xianyu_aner/pkg/mtop"
+)
+
+// 观测数据来源
+const (
+	SourceFeed   = "feed"
+	SourceDetail = "detail"
+)
+
+// FromFeedItem 将猜你喜欢商品转换为观测记录
+func FromFeedItem(item mtop.FeedItem, at time.Time) Observation {
+	return Observation{
+		ItemID:     item.ItemID,
+		Title:      item.Title,
+		ImageURL:   item.ImageURL,
+		CategoryID: item.CategoryID,
+		Price:      item.Price,
+		PriceValue: ParsePrice(item.Price),
+		WantCount:  item.WantCount,
+		ViewCount:  item.ViewCount,
+		Status:     item.Status,
+		Source:     SourceFeed,
+		CapturedAt: at.UnixMilli(),
+	}
+}
+
+// FromFeedItems 批量转换猜你喜欢商品
+func FromFeedItems(items []mtop.FeedItem, at time.Time) []Observation {
+	observations := make([]Observation, 0, len(items))
+	for _, item := range items {
+		observations = append(observations, FromFeedItem(item, at))
+	}
+	return observations
+}
+
+// FromItemDetail 将商品详情转换为观测记录
+func FromItemDetail(detail *mtop.ItemDetail, at time.Time) Observation {
+	status := detail.ItemStatusStr
+	if status == "" {
+		status = detail.Status
+	}
+	return Observation{
+		ItemID:       detail.ItemID,
+		Title:        detail.Title,
+		ImageURL:     detail.ImageURL,
+		CategoryID:   detail.CategoryID,
+		Price:        detail.Price,
+		PriceValue:   ParsePrice(detail.Price),
+		WantCount:    detail.WantCount,
+		ViewCount:    detail.ViewCount,
+		CollectCount: detail.CollectCount,
+		Status:       status,
+		Source:       SourceDetail,
+		CapturedAt:   at.UnixMilli(),
+	}
+}
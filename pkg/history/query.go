@@ -0,0 +1,208 @@
+package history
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PriceChange 价格变化
+type PriceChange struct {
+	ItemID   string      `json:"itemId"`
+	Title    string      `json:"title,omitempty"`
+	OldPrice float64     `json:"oldPrice"`
+	NewPrice float64     `json:"newPrice"`
+	Delta    float64     `json:"delta"`    // 新价格 - 旧价格（降价为负数）
+	DeltaPct float64     `json:"deltaPct"` // 变化百分比
+	Before   Observation `json:"before"`   // 窗口起点观测
+	After    Observation `json:"after"`    // 最新观测
+}
+
+// WantGrowth 想要人数增长
+type WantGrowth struct {
+	ItemID   string      `json:"itemId"`
+	Title    string      `json:"title,omitempty"`
+	FromWant int         `json:"fromWant"`
+	ToWant   int         `json:"toWant"`
+	Delta    int         `json:"delta"`   // 增长人数
+	PerHour  float64     `json:"perHour"` // 每小时增长
+	Before   Observation `json:"before"`  // 窗口起点观测
+	After    Observation `json:"after"`   // 最新观测
+}
+
+// window 返回商品在时间窗口内的起点观测与最新观测
+// 起点优先取窗口开始前的最后一条观测（即窗口开始时的状态），否则取窗口内第一条
+func window(list []Observation, since time.Time) (Observation, Observation, bool) {
+	if len(list) < 2 {
+		return Observation{}, Observation{}, false
+	}
+	sinceMs := since.UnixMilli()
+	latest := list[len(list)-1]
+	if latest.CapturedAt < sinceMs {
+		return Observation{}, Observation{}, false
+	}
+
+	idx := sort.Search(len(list), func(i int) bool { return list[i].CapturedAt >= sinceMs })
+	var base Observation
+	if idx > 0 {
+		base = list[idx-1]
+	} else {
+		base = list[0]
+	}
+	if base.CapturedAt == latest.CapturedAt {
+		return Observation{}, Observation{}, false
+	}
+	return base, latest, true
+}
+
+// LatestSince 返回最新观测在 since 之后的商品（每个商品取最新一次观测，按商品ID排序）
+func (s *Store) LatestSince(since time.Time) []Observation {
+	s.refresh()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sinceMs := since.UnixMilli()
+	var result []Observation
+	for _, list := range s.byItem {
+		if len(list) == 0 || list[len(list)-1].CapturedAt < sinceMs {
+			continue
+		}
+		result = append(result, list[len(list)-1])
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ItemID < result[j].ItemID })
+	return result
+}
+
+// PriceDrops 返回自 since 以来降价的商品（按降价幅度从大到小排序）
+func (s *Store) PriceDrops(since time.Time) []PriceChange {
+	s.refresh()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var drops []PriceChange
+	for _, list := range s.byItem {
+		if drop, ok := PriceDrop(list, since); ok {
+			drops = append(drops, drop)
+		}
+	}
+	SortPriceDrops(drops)
+	return drops
+}
+
+// FastestWantGrowth 返回自 since 以来想要人数增长最快的商品（按每小时增长排序）
+// limit <= 0 表示不限制数量
+func (s *Store) FastestWantGrowth(since time.Time, limit int) []WantGrowth {
+	s.refresh()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var growth []WantGrowth
+	for _, list := range s.byItem {
+		if g, ok := Growth(list, since); ok {
+			growth = append(growth, g)
+		}
+	}
+	return SortWantGrowth(growth, limit)
+}
+
+// PriceDrop 计算单个商品自 since 以来的降价（list 为该商品按采集时间升序的观测），未降价时 ok 为 false
+// 与 Store 分开，便于其他存储（如 SQLite）按相同口径计算
+func PriceDrop(list []Observation, since time.Time) (PriceChange, bool) {
+	before, after, ok := window(list, since)
+	if !ok || before.PriceValue <= 0 || after.PriceValue <= 0 {
+		return PriceChange{}, false
+	}
+	if after.PriceValue >= before.PriceValue {
+		return PriceChange{}, false
+	}
+	delta := after.PriceValue - before.PriceValue
+	return PriceChange{
+		ItemID:   after.ItemID,
+		Title:    after.Title,
+		OldPrice: before.PriceValue,
+		NewPrice: after.PriceValue,
+		Delta:    delta,
+		DeltaPct: delta / before.PriceValue * 100,
+		Before:   before,
+		After:    after,
+	}, true
+}
+
+// Growth 计算单个商品自 since 以来的想要人数增长，未增长时 ok 为 false
+func Growth(list []Observation, since time.Time) (WantGrowth, bool) {
+	before, after, ok := window(list, since)
+	if !ok {
+		return WantGrowth{}, false
+	}
+	delta := after.WantCount - before.WantCount
+	if delta <= 0 {
+		return WantGrowth{}, false
+	}
+	hours := after.CapturedTime().Sub(before.CapturedTime()).Hours()
+	if hours <= 0 {
+		return WantGrowth{}, false
+	}
+	return WantGrowth{
+		ItemID:   after.ItemID,
+		Title:    after.Title,
+		FromWant: before.WantCount,
+		ToWant:   after.WantCount,
+		Delta:    delta,
+		PerHour:  float64(delta) / hours,
+		Before:   before,
+		After:    after,
+	}, true
+}
+
+// SortPriceDrops 按降价幅度从大到小排序
+func SortPriceDrops(drops []PriceChange) {
+	sort.Slice(drops, func(i, j int) bool {
+		if drops[i].DeltaPct != drops[j].DeltaPct {
+			return drops[i].DeltaPct < drops[j].DeltaPct
+		}
+		return drops[i].ItemID < drops[j].ItemID
+	})
+}
+
+// SortWantGrowth 按每小时增长从快到慢排序，limit > 0 时截断
+func SortWantGrowth(growth []WantGrowth, limit int) []WantGrowth {
+	sort.Slice(growth, func(i, j int) bool {
+		if growth[i].PerHour != growth[j].PerHour {
+			return growth[i].PerHour > growth[j].PerHour
+		}
+		return growth[i].ItemID < growth[j].ItemID
+	})
+	if limit > 0 && len(growth) > limit {
+		growth = growth[:limit]
+	}
+	return growth
+}
+
+// ParsePrice 解析价格字符串（去除 ¥ 符号与千分位），失败返回 0
+func ParsePrice(price string) float64 {
+	price = strings.TrimSpace(price)
+	price = strings.NewReplacer("¥", "", "￥", "", ",", "", " ", "").Replace(price)
+	v, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// WantVelocity 返回商品自 since 以来想要人数的每小时增长，观测不足时 ok 为 false
+func (s *Store) WantVelocity(itemID string, since time.Time) (float64, bool) {
+	s.refresh()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	before, after, ok := window(s.byItem[itemID], since)
+	if !ok {
+		return 0, false
+	}
+	hours := after.CapturedTime().Sub(before.CapturedTime()).Hours()
+	if hours <= 0 {
+		return 0, false
+	}
+	return float64(after.WantCount-before.WantCount) / hours, true
+}
@@ -0,0 +1,309 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// filePrefix 观测数据文件前缀，按天滚动: observations-2025-01-15.jsonl
+const filePrefix = "observations-"
+
+// Observation 单次商品观测快照
+type Observation struct {
+	ItemID       string  `json:"itemId"`
+	Title        string  `json:"title,omitempty"`
+	ImageURL     string  `json:"imageUrl,omitempty"`     // 主图链接
+	CategoryID   int     `json:"categoryId,omitempty"`   // 叶子分类ID
+	Price        string  `json:"price"`                  // 原始价格字符串
+	PriceValue   float64 `json:"priceValue"`             // 解析后的价格数值
+	WantCount    int     `json:"wantCount"`              // 想要人数
+	ViewCount    int     `json:"viewCount,omitempty"`    // 浏览次数
+	CollectCount int     `json:"collectCount,omitempty"` // 收藏次数
+	Status       string  `json:"status,omitempty"`       // 商品状态
+	Source       string  `json:"source,omitempty"`       // 数据来源: feed / detail
+	CapturedAt   int64   `json:"capturedAt"`             // 采集时间戳（毫秒）
+}
+
+// CapturedTime 采集时间
+func (o Observation) CapturedTime() time.Time {
+	return time.UnixMilli(o.CapturedAt)
+}
+
+// Store 商品观测快照存储（按天追加写入 JSONL 文件，内存中按商品建立索引）
+// 其他进程（如 crawl）追加到同一目录的观测在每次查询前增量读入
+type Store struct {
+	mu      sync.RWMutex
+	dir     string
+	file    *os.File
+	fileDay string
+	byItem  map[string][]Observation
+	files   map[string]fileState
+}
+
+// fileState 观测文件的读取进度
+type fileState struct {
+	offset int64 // 已读入的字节数（只计完整的行）
+	size   int64 // 上次读取时的文件大小
+}
+
+// Open 打开（或创建）存储目录并加载已有观测数据
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建历史数据目录失败: %w", err)
+	}
+
+	s := &Store{
+		dir:    dir,
+		byItem: make(map[string][]Observation),
+		files:  make(map[string]fileState),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load 加载目录下所有观测文件
+func (s *Store) load() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, filePrefix+"*.jsonl"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		err := s.loadFile(path, func(obs Observation) {
+			s.byItem[obs.ItemID] = append(s.byItem[obs.ItemID], obs)
+		})
+		if err != nil {
+			return fmt.Errorf("加载历史文件 %s 失败: %w", filepath.Base(path), err)
+		}
+	}
+
+	for itemID := range s.byItem {
+		obs := s.byItem[itemID]
+		sort.SliceStable(obs, func(i, j int) bool { return obs[i].CapturedAt < obs[j].CapturedAt })
+	}
+	return nil
+}
+
+// refresh 增量读入其他进程新追加的观测（新文件或变大的文件），读取失败时保留已有索引
+func (s *Store) refresh() {
+	paths, err := filepath.Glob(filepath.Join(s.dir, filePrefix+"*.jsonl"))
+	if err != nil {
+		return
+	}
+
+	var grown []string
+	s.mu.RLock()
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err == nil && info.Size() != s.files[path].size {
+			grown = append(grown, path)
+		}
+	}
+	s.mu.RUnlock()
+	if len(grown) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sort.Strings(grown)
+	for _, path := range grown {
+		// 本进程 Record 写入的行已在索引中，insert 按 (商品ID, 采集时间) 去重
+		s.loadFile(path, s.insert)
+	}
+}
+
+// loadFile 从上次读到的位置逐行读取观测文件，跳过损坏的行（例如进程崩溃时写了一半）
+// 末尾没有换行的行可能仍在写入，留到下次读取
+func (s *Store) loadFile(path string, add func(Observation)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := s.files[path].offset
+	if info.Size() < offset {
+		offset = 0 // 文件被截断或替换，重新读取
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		offset += int64(len(line))
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var obs Observation
+		if err := json.Unmarshal(line, &obs); err != nil || obs.ItemID == "" {
+			continue
+		}
+		add(obs)
+	}
+	s.files[path] = fileState{offset: offset, size: info.Size()}
+	return nil
+}
+
+// Record 追加观测记录
+func (s *Store) Record(observations ...Observation) error {
+	if len(observations) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf strings.Builder
+	for i := range observations {
+		obs := &observations[i]
+		if obs.ItemID == "" {
+			continue
+		}
+		if obs.CapturedAt == 0 {
+			obs.CapturedAt = time.Now().UnixMilli()
+		}
+		if obs.PriceValue == 0 && obs.Price != "" {
+			obs.PriceValue = ParsePrice(obs.Price)
+		}
+		data, err := json.Marshal(obs)
+		if err != nil {
+			return fmt.Errorf("序列化观测记录失败: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	f, err := s.currentFile(time.Now())
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(buf.String()); err != nil {
+		return fmt.Errorf("写入历史文件失败: %w", err)
+	}
+
+	for _, obs := range observations {
+		if obs.ItemID == "" {
+			continue
+		}
+		s.insert(obs)
+	}
+	return nil
+}
+
+// insert 按采集时间有序插入内存索引，同一商品同一采集时间的记录已存在时跳过
+func (s *Store) insert(obs Observation) {
+	list := s.byItem[obs.ItemID]
+	idx := sort.Search(len(list), func(i int) bool { return list[i].CapturedAt > obs.CapturedAt })
+	if idx > 0 && list[idx-1].CapturedAt == obs.CapturedAt {
+		return
+	}
+	list = append(list, Observation{})
+	copy(list[idx+1:], list[idx:])
+	list[idx] = obs
+	s.byItem[obs.ItemID] = list
+}
+
+// currentFile 返回当天的观测文件（跨天自动切换）
+func (s *Store) currentFile(now time.Time) (*os.File, error) {
+	day := now.Format("2006-01-02")
+	if s.file != nil && s.fileDay == day {
+		return s.file, nil
+	}
+	if s.file != nil {
+		s.file.Close()
+	}
+
+	path := filepath.Join(s.dir, filePrefix+day+".jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开历史文件失败: %w", err)
+	}
+	// 上次写入中断留下的半行补上换行，避免与本次写入拼成一行
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			f.WriteString("\n")
+		}
+	}
+	s.file = f
+	s.fileDay = day
+	return f, nil
+}
+
+// History 返回商品的全部观测记录（按采集时间升序）
+func (s *Store) History(itemID string) []Observation {
+	s.refresh()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := s.byItem[itemID]
+	result := make([]Observation, len(list))
+	copy(result, list)
+	return result
+}
+
+// Latest 返回商品最近一次观测
+func (s *Store) Latest(itemID string) (Observation, bool) {
+	s.refresh()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := s.byItem[itemID]
+	if len(list) == 0 {
+		return Observation{}, false
+	}
+	return list[len(list)-1], true
+}
+
+// ItemIDs 返回所有有观测记录的商品ID
+func (s *Store) ItemIDs() []string {
+	s.refresh()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.byItem))
+	for id := range s.byItem {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Close 关闭当前写入的文件
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,185 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"xianyu_aner/pkg/mtop"
+)
+
+func TestStore_RecordAndReload(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now().Add(-2 * time.Hour)
+
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// 乱序写入，读取时应按采集时间排序
+	err = store.Record(
+		Observation{ItemID: "1", Price: "¥100.00", WantCount: 5, CapturedAt: base.Add(time.Hour).UnixMilli()},
+		Observation{ItemID: "1", Price: "120", WantCount: 1, CapturedAt: base.UnixMilli()},
+		Observation{ItemID: "2", Price: "50", WantCount: 3, CapturedAt: base.UnixMilli()},
+	)
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	hist := store.History("1")
+	if len(hist) != 2 {
+		t.Fatalf("History() 返回 %d 条, want 2", len(hist))
+	}
+	if hist[0].PriceValue != 120 || hist[1].PriceValue != 100 {
+		t.Errorf("History() 未按时间排序或价格解析错误: %+v", hist)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// 追加一行损坏数据，重新加载时应跳过
+	files, _ := filepath.Glob(filepath.Join(dir, filePrefix+"*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("期望 1 个历史文件, got %d", len(files))
+	}
+	f, _ := os.OpenFile(files[0], os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"itemId":"3","pri`)
+	f.Close()
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("重新 Open() error = %v", err)
+	}
+	defer reopened.Close()
+
+	if got := len(reopened.History("1")); got != 2 {
+		t.Errorf("重新加载后 History(1) = %d 条, want 2", got)
+	}
+	if got := reopened.ItemIDs(); len(got) != 2 {
+		t.Errorf("ItemIDs() = %v, want 2 个商品", got)
+	}
+	latest, ok := reopened.Latest("1")
+	if !ok || latest.WantCount != 5 {
+		t.Errorf("Latest(1) = %+v, %v", latest, ok)
+	}
+}
+
+func TestStore_PicksUpObservationsFromOtherWriters(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+
+	reader, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	writer, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	if got := reader.ItemIDs(); len(got) != 0 {
+		t.Fatalf("初始 ItemIDs() = %v, want 空", got)
+	}
+
+	// 另一个进程（如 crawl）写入的观测应在下次查询时可见
+	writer.Record(Observation{ItemID: "1", Price: "100", WantCount: 1, CapturedAt: base.UnixMilli()})
+	if got := reader.History("1"); len(got) != 1 {
+		t.Fatalf("History(1) = %d 条, want 1", len(got))
+	}
+
+	// 自己写入的记录不会因增量读取而重复
+	reader.Record(Observation{ItemID: "1", Price: "90", WantCount: 3, CapturedAt: base.Add(30 * time.Minute).UnixMilli()})
+	writer.Record(Observation{ItemID: "2", Price: "50", CapturedAt: base.UnixMilli()})
+	if got := reader.History("1"); len(got) != 2 {
+		t.Errorf("History(1) = %d 条, want 2", len(got))
+	}
+	if latest, ok := reader.Latest("1"); !ok || latest.WantCount != 3 {
+		t.Errorf("Latest(1) = %+v, %v", latest, ok)
+	}
+	if got := reader.ItemIDs(); len(got) != 2 {
+		t.Errorf("ItemIDs() = %v, want 2 个商品", got)
+	}
+	if got := writer.History("1"); len(got) != 2 {
+		t.Errorf("writer History(1) = %d 条, want 2", len(got))
+	}
+	if got := writer.LatestSince(base.Add(10 * time.Minute)); len(got) != 1 || got[0].ItemID != "1" || got[0].WantCount != 3 {
+		t.Errorf("writer LatestSince() = %+v, want 仅商品1的最新观测", got)
+	}
+}
+
+func TestStore_PriceDropsAndWantGrowth(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	at := func(d time.Duration) int64 { return now.Add(-d).UnixMilli() }
+
+	store.Record(
+		// 商品A: 窗口前 200，窗口内降到 150，想要 10 -> 40
+		Observation{ItemID: "A", Price: "200", WantCount: 10, CapturedAt: at(30 * time.Hour)},
+		Observation{ItemID: "A", Price: "150", WantCount: 40, CapturedAt: at(1 * time.Hour)},
+		// 商品B: 窗口内 100 -> 90，想要 1 -> 2
+		Observation{ItemID: "B", Price: "100", WantCount: 1, CapturedAt: at(20 * time.Hour)},
+		Observation{ItemID: "B", Price: "90", WantCount: 2, CapturedAt: at(2 * time.Hour)},
+		// 商品C: 涨价
+		Observation{ItemID: "C", Price: "10", WantCount: 1, CapturedAt: at(20 * time.Hour)},
+		Observation{ItemID: "C", Price: "12", WantCount: 1, CapturedAt: at(1 * time.Hour)},
+		// 商品D: 最新观测在窗口外
+		Observation{ItemID: "D", Price: "100", CapturedAt: at(50 * time.Hour)},
+		Observation{ItemID: "D", Price: "10", CapturedAt: at(48 * time.Hour)},
+	)
+
+	drops := store.PriceDrops(now.Add(-24 * time.Hour))
+	if len(drops) != 2 {
+		t.Fatalf("PriceDrops() 返回 %d 条, want 2: %+v", len(drops), drops)
+	}
+	if drops[0].ItemID != "A" || drops[0].OldPrice != 200 || drops[0].NewPrice != 150 {
+		t.Errorf("降价幅度最大的应为 A(200->150), got %+v", drops[0])
+	}
+	if drops[0].DeltaPct != -25 {
+		t.Errorf("DeltaPct = %.2f, want -25", drops[0].DeltaPct)
+	}
+
+	growth := store.FastestWantGrowth(now.Add(-24*time.Hour), 1)
+	if len(growth) != 1 || growth[0].ItemID != "A" || growth[0].Delta != 30 {
+		t.Errorf("FastestWantGrowth() = %+v, want A(+30)", growth)
+	}
+}
+
+func TestFromFeedItemAndDetail(t *testing.T) {
+	at := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	obs := FromFeedItem(mtop.FeedItem{ItemID: "1", Price: "¥1,299", WantCount: 3}, at)
+	if obs.PriceValue != 1299 || obs.Source != SourceFeed || obs.CapturedAt != at.UnixMilli() {
+		t.Errorf("FromFeedItem() = %+v", obs)
+	}
+
+	detail := &mtop.ItemDetail{ItemID: "2", Price: "88.50", Status: "online", ItemStatusStr: "在售", CollectCount: 7}
+	obs = FromItemDetail(detail, at)
+	if obs.Status != "在售" || obs.CollectCount != 7 || obs.Source != SourceDetail {
+		t.Errorf("FromItemDetail() = %+v", obs)
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := map[string]float64{
+		"100":    100,
+		"¥99.90": 99.9,
+		"￥1,000": 1000,
+		" 12.5 ": 12.5,
+		"面议":     0,
+		"":       0,
+	}
+	for in, want := range tests {
+		if got := ParsePrice(in); got != want {
+			t.Errorf("ParsePrice(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
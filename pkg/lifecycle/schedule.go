@@ -0,0 +1,48 @@
+package lifecycle
+
+import (
+	"sort"
+	"time"
+)
+
+// ScheduleTier 退避档位：商品年龄小于 MaxAge 时使用 Interval
+type ScheduleTier struct {
+	MaxAge   time.Duration
+	Interval time.Duration
+}
+
+// Schedule 检查计划，商品越老检查越稀疏
+type Schedule struct {
+	Tiers   []ScheduleTier // 按 MaxAge 升序的档位
+	Default time.Duration  // 超过所有档位后的检查间隔
+	MaxAge  time.Duration  // 跟踪超过该时长（从首次跟踪算起）后停止（0 表示不限制）
+}
+
+// DefaultSchedule 默认检查计划
+// 1天内每小时、3天内每4小时、7天内每12小时，之后每天一次，30天后停止跟踪
+func DefaultSchedule() Schedule {
+	return Schedule{
+		Tiers: []ScheduleTier{
+			{MaxAge: 24 * time.Hour, Interval: time.Hour},
+			{MaxAge: 3 * 24 * time.Hour, Interval: 4 * time.Hour},
+			{MaxAge: 7 * 24 * time.Hour, Interval: 12 * time.Hour},
+		},
+		Default: 24 * time.Hour,
+		MaxAge:  30 * 24 * time.Hour,
+	}
+}
+
+// Interval 返回给定商品年龄对应的检查间隔
+func (s Schedule) Interval(age time.Duration) time.Duration {
+	tiers := append([]ScheduleTier(nil), s.Tiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MaxAge < tiers[j].MaxAge })
+	for _, tier := range tiers {
+		if age < tier.MaxAge {
+			return tier.Interval
+		}
+	}
+	if s.Default > 0 {
+		return s.Default
+	}
+	return 24 * time.Hour
+}
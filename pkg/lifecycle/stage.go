@@ -0,0 +1,55 @@
+package lifecycle
+
+import "strings"
+
+// Stage 商品生命周期阶段
+type Stage string
+
+const (
+	StageUnknown  Stage = "unknown"  // 未知
+	StageOnSale   Stage = "on_sale"  // 在售
+	StageReserved Stage = "reserved" // 已拍下/交易中
+	StageSold     Stage = "sold"     // 已售出
+	StageOffline  Stage = "offline"  // 已下架（可能重新上架）
+	StageDeleted  Stage = "deleted"  // 已删除/商品不存在
+)
+
+// IsTerminal 是否为终态（终态商品不再继续跟踪）
+func (s Stage) IsTerminal() bool {
+	return s == StageSold || s == StageDeleted
+}
+
+// stageKeywords 状态文本关键词，按优先级排列
+var stageKeywords = []struct {
+	stage    Stage
+	keywords []string
+}{
+	{StageDeleted, []string{"删除", "不存在", "deleted"}},
+	{StageSold, []string{"已售", "卖掉", "售出", "sold"}},
+	{StageReserved, []string{"拍下", "交易中", "预定", "已预订", "reserved", "trading"}},
+	{StageOffline, []string{"下架", "offline", "invalid"}},
+	{StageOnSale, []string{"在售", "出售中", "online", "onsale"}},
+}
+
+// ClassifyStatus 根据详情接口返回的状态码与状态文本判定生命周期阶段
+// 优先使用状态文本；文本无法识别时回退到状态码（0 在售，负数视为删除）
+func ClassifyStatus(itemStatus int, itemStatusStr string) Stage {
+	text := strings.ToLower(strings.TrimSpace(itemStatusStr))
+	if text != "" {
+		for _, rule := range stageKeywords {
+			for _, kw := range rule.keywords {
+				if strings.Contains(text, kw) {
+					return rule.stage
+				}
+			}
+		}
+	}
+
+	switch {
+	case itemStatus == 0 && text != "":
+		return StageOnSale
+	case itemStatus < 0:
+		return StageDeleted
+	}
+	return StageUnknown
+}
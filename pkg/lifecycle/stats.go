@@ -0,0 +1,61 @@
+package lifecycle
+
+import (
+	"sort"
+)
+
+// Stats 跟踪统计
+type Stats struct {
+	Tracked          int           `json:"tracked"`          // 跟踪商品总数
+	Active           int           `json:"active"`           // 仍在跟踪的商品数
+	ByStage          map[Stage]int `json:"byStage"`          // 各阶段商品数
+	Sold             int           `json:"sold"`             // 已售出数量
+	PriceEdits       int           `json:"priceEdits"`       // 改价次数
+	AvgDaysToSell    float64       `json:"avgDaysToSell"`    // 平均成交天数
+	MedianDaysToSell float64       `json:"medianDaysToSell"` // 成交天数中位数
+}
+
+// Stats 计算跟踪统计（成交天数 = 售出时间 - 发布时间，发布时间未知时使用首次跟踪时间）
+func (t *Tracker) Stats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := Stats{ByStage: make(map[Stage]int)}
+	var days []float64
+	for _, item := range t.items {
+		stats.Tracked++
+		if !item.Done {
+			stats.Active++
+		}
+		stats.ByStage[item.Stage]++
+		for _, tr := range item.Transitions {
+			if tr.Kind == ChangePrice {
+				stats.PriceEdits++
+			}
+		}
+		if soldAt, ok := item.SoldAt(); ok {
+			stats.Sold++
+			d := soldAt.Sub(item.startTime()).Hours() / 24
+			if d < 0 {
+				d = 0
+			}
+			days = append(days, d)
+		}
+	}
+
+	if len(days) > 0 {
+		sort.Float64s(days)
+		sum := 0.0
+		for _, d := range days {
+			sum += d
+		}
+		stats.AvgDaysToSell = sum / float64(len(days))
+		mid := len(days) / 2
+		if len(days)%2 == 0 {
+			stats.MedianDaysToSell = (days[mid-1] + days[mid]) / 2
+		} else {
+			stats.MedianDaysToSell = days[mid]
+		}
+	}
+	return stats
+}
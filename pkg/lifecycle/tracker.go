@@ -0,0 +1,337 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/util"
+)
+
+// DetailFetcher 商品详情获取接口（*mtop.Client 实现了该接口）
+type DetailFetcher interface {
+	FetchItemDetail(itemID string) (*mtop.ItemDetail, error)
+}
+
+// 变化类型
+const (
+	ChangeStatus = "status" // 状态变化
+	ChangePrice  = "price"  // 价格修改
+)
+
+// Transition 一次状态或价格变化
+type Transition struct {
+	At   int64  `json:"at"`   // 发现变化的时间戳（毫秒）
+	Kind string `json:"kind"` // status / price
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// TrackedItem 被跟踪的商品
+type TrackedItem struct {
+	ItemID      string       `json:"itemId"`
+	Title       string       `json:"title,omitempty"`
+	Price       string       `json:"price,omitempty"`
+	Stage       Stage        `json:"stage"`
+	StatusText  string       `json:"statusText,omitempty"`
+	PublishedAt int64        `json:"publishedAt,omitempty"` // 发布时间戳（毫秒，未知时为 0）
+	FirstSeen   int64        `json:"firstSeen"`             // 首次跟踪时间戳（毫秒）
+	LastChecked int64        `json:"lastChecked,omitempty"` // 最近一次检查时间戳（毫秒）
+	NextCheck   int64        `json:"nextCheck"`             // 下次检查时间戳（毫秒）
+	Checks      int          `json:"checks"`                // 已检查次数
+	Failures    int          `json:"failures"`              // 连续失败次数
+	Done        bool         `json:"done"`                  // 是否已结束跟踪
+	Transitions []Transition `json:"transitions,omitempty"`
+}
+
+// startTime 商品生命周期起点（检查间隔按此计算年龄）：优先使用发布时间，否则使用首次跟踪时间
+func (t *TrackedItem) startTime() time.Time {
+	if t.PublishedAt > 0 {
+		return time.UnixMilli(t.PublishedAt)
+	}
+	return time.UnixMilli(t.FirstSeen)
+}
+
+// SoldAt 返回商品售出的时间（未售出返回 false）
+func (t *TrackedItem) SoldAt() (time.Time, bool) {
+	for _, tr := range t.Transitions {
+		if tr.Kind == ChangeStatus && tr.To == string(StageSold) {
+			return time.UnixMilli(tr.At), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Tracker 商品生命周期跟踪器
+type Tracker struct {
+	mu        sync.Mutex
+	saveMu    sync.Mutex // 串行化状态文件写入，保证后取的快照后写入
+	fetcher   DetailFetcher
+	schedule  Schedule
+	statePath string
+	items     map[string]*TrackedItem
+	now       func() time.Time
+}
+
+// NewTracker 创建跟踪器，statePath 不为空时从文件恢复状态
+func NewTracker(fetcher DetailFetcher, schedule Schedule, statePath string) (*Tracker, error) {
+	t := &Tracker{
+		fetcher:   fetcher,
+		schedule:  schedule,
+		statePath: statePath,
+		items:     make(map[string]*TrackedItem),
+		now:       time.Now,
+	}
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Track 开始跟踪商品（已跟踪的商品忽略），publishTimeMs 未知时传 0
+// 首次检查间隔按商品的实际年龄选择，发布已久的商品不会按新商品的频率检查
+func (t *Tracker) Track(itemID, title, price string, publishTimeMs int64) bool {
+	if itemID == "" {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.items[itemID]; exists {
+		return false
+	}
+	now := t.now()
+	item := &TrackedItem{
+		ItemID:      itemID,
+		Title:       title,
+		Price:       price,
+		Stage:       StageOnSale,
+		PublishedAt: publishTimeMs,
+		FirstSeen:   now.UnixMilli(),
+	}
+	item.NextCheck = now.Add(t.schedule.Interval(now.Sub(item.startTime()))).UnixMilli()
+	t.items[itemID] = item
+	return true
+}
+
+// TrackFeedItems 批量跟踪猜你喜欢商品，返回新增数量
+func (t *Tracker) TrackFeedItems(items []mtop.FeedItem) int {
+	added := 0
+	for _, item := range items {
+		if t.Track(item.ItemID, item.Title, item.Price, item.PublishTimeTS) {
+			added++
+		}
+	}
+	return added
+}
+
+// Item 返回单个商品的跟踪记录
+func (t *Tracker) Item(itemID string) (TrackedItem, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	item, ok := t.items[itemID]
+	if !ok {
+		return TrackedItem{}, false
+	}
+	return copyItem(item), true
+}
+
+// dueItems 返回到期需要检查的商品ID（按到期时间排序）
+func (t *Tracker) dueItems(now time.Time) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var due []*TrackedItem
+	for _, item := range t.items {
+		if !item.Done && item.NextCheck <= now.UnixMilli() {
+			due = append(due, item)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextCheck < due[j].NextCheck })
+
+	ids := make([]string, 0, len(due))
+	for _, item := range due {
+		ids = append(ids, item.ItemID)
+	}
+	return ids
+}
+
+// CheckDue 检查所有到期商品，返回本轮检查数量
+// 商品不存在会被归类为已删除，其他请求错误仅增加失败计数并延后重试
+func (t *Tracker) CheckDue(ctx context.Context) (int, error) {
+	ids := t.dueItems(t.now())
+	checked := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		detail, err := t.fetcher.FetchItemDetail(id)
+		t.apply(id, detail, err)
+		checked++
+	}
+
+	if checked > 0 {
+		if err := t.Save(); err != nil {
+			return checked, err
+		}
+	}
+	return checked, nil
+}
+
+// apply 根据详情结果更新跟踪状态
+func (t *Tracker) apply(itemID string, detail *mtop.ItemDetail, fetchErr error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	item, ok := t.items[itemID]
+	if !ok {
+		return
+	}
+	now := t.now()
+	item.LastChecked = now.UnixMilli()
+	item.Checks++
+
+	switch {
+	case mtop.IsItemNotFound(fetchErr):
+		item.Failures = 0
+		t.transition(item, ChangeStatus, string(item.Stage), string(StageDeleted), now)
+		item.Stage = StageDeleted
+		item.StatusText = "商品不存在"
+	case fetchErr != nil:
+		item.Failures++
+	default:
+		item.Failures = 0
+		stage := ClassifyStatus(detail.ItemStatus, detail.ItemStatusStr)
+		if stage != StageUnknown && stage != item.Stage {
+			t.transition(item, ChangeStatus, string(item.Stage), string(stage), now)
+			item.Stage = stage
+		}
+		item.StatusText = detail.ItemStatusStr
+		if detail.Price != "" && item.Price != "" && detail.Price != item.Price {
+			t.transition(item, ChangePrice, item.Price, detail.Price, now)
+		}
+		if detail.Price != "" {
+			item.Price = detail.Price
+		}
+		if item.Title == "" {
+			item.Title = detail.Title
+		}
+		if item.PublishedAt == 0 && detail.PublishTimeTS > 0 {
+			item.PublishedAt = detail.PublishTimeTS
+		}
+	}
+
+	// 最长跟踪时长从首次跟踪算起，发布已久才被发现的商品也会跟踪满 MaxAge
+	tracked := now.Sub(time.UnixMilli(item.FirstSeen))
+	if item.Stage.IsTerminal() || (t.schedule.MaxAge > 0 && tracked > t.schedule.MaxAge) {
+		item.Done = true
+		return
+	}
+	interval := t.schedule.Interval(now.Sub(item.startTime()))
+	// 连续失败时按失败次数成倍退避
+	for i := 0; i < item.Failures && i < 4; i++ {
+		interval *= 2
+	}
+	item.NextCheck = now.Add(interval).UnixMilli()
+}
+
+// transition 记录一次变化
+func (t *Tracker) transition(item *TrackedItem, kind, from, to string, at time.Time) {
+	if from == to {
+		return
+	}
+	item.Transitions = append(item.Transitions, Transition{
+		At:   at.UnixMilli(),
+		Kind: kind,
+		From: from,
+		To:   to,
+	})
+}
+
+// Run 按 tick 周期检查到期商品，直到 ctx 取消
+func (t *Tracker) Run(ctx context.Context, tick time.Duration, onError func(error)) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		if _, err := t.CheckDue(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// trackerState 持久化的跟踪状态
+type trackerState struct {
+	Items []*TrackedItem `json:"items"`
+}
+
+// load 从状态文件恢复
+func (t *Tracker) load() error {
+	if t.statePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(t.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取跟踪状态失败: %w", err)
+	}
+
+	var state trackerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("解析跟踪状态失败: %w", err)
+	}
+	for _, item := range state.Items {
+		t.items[item.ItemID] = item
+	}
+	return nil
+}
+
+// Save 保存跟踪状态（先写临时文件再重命名，避免写坏状态文件）
+func (t *Tracker) Save() error {
+	if t.statePath == "" {
+		return nil
+	}
+
+	t.saveMu.Lock()
+	defer t.saveMu.Unlock()
+
+	t.mu.Lock()
+	state := trackerState{Items: make([]*TrackedItem, 0, len(t.items))}
+	for _, item := range t.items {
+		c := copyItem(item)
+		state.Items = append(state.Items, &c)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(state.Items, func(i, j int) bool { return state.Items[i].ItemID < state.Items[j].ItemID })
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化跟踪状态失败: %w", err)
+	}
+
+	if err := util.WriteFileAtomic(t.statePath, data, 0644); err != nil {
+		return fmt.Errorf("写入跟踪状态失败: %w", err)
+	}
+	return nil
+}
+
+// copyItem 深拷贝跟踪记录
+func copyItem(item *TrackedItem) TrackedItem {
+	c := *item
+	c.Transitions = append([]Transition(nil), item.Transitions...)
+	return c
+}
@@ -0,0 +1,267 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"xianyu_aner/pkg/mtop"
+)
+
+// fakeFetcher 按商品ID返回预设详情或错误
+type fakeFetcher struct {
+	details map[string]*mtop.ItemDetail
+	errs    map[string]error
+	calls   map[string]int
+}
+
+func newFakeFetcher() *fakeFetcher {
+	return &fakeFetcher{
+		details: make(map[string]*mtop.ItemDetail),
+		errs:    make(map[string]error),
+		calls:   make(map[string]int),
+	}
+}
+
+func (f *fakeFetcher) FetchItemDetail(itemID string) (*mtop.ItemDetail, error) {
+	f.calls[itemID]++
+	if err, ok := f.errs[itemID]; ok {
+		return nil, err
+	}
+	return f.details[itemID], nil
+}
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestTracker(t *testing.T, fetcher DetailFetcher, statePath string) (*Tracker, *fakeClock) {
+	t.Helper()
+	tracker, err := NewTracker(fetcher, DefaultSchedule(), statePath)
+	if err != nil {
+		t.Fatalf("NewTracker() error = %v", err)
+	}
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 10, 0, 0, 0, time.Local)}
+	tracker.now = clock.Now
+	return tracker, clock
+}
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		text   string
+		want   Stage
+	}{
+		{0, "在售", StageOnSale},
+		{1, "已售出", StageSold},
+		{1, "卖掉了", StageSold},
+		{2, "已下架", StageOffline},
+		{3, "已拍下", StageReserved},
+		{0, "宝贝已删除", StageDeleted},
+		{0, "", StageUnknown},
+		{-1, "", StageDeleted},
+		{0, "随便", StageOnSale},
+	}
+	for _, tt := range tests {
+		if got := ClassifyStatus(tt.status, tt.text); got != tt.want {
+			t.Errorf("ClassifyStatus(%d, %q) = %s, want %s", tt.status, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSchedule_Interval(t *testing.T) {
+	s := DefaultSchedule()
+	tests := []struct {
+		age  time.Duration
+		want time.Duration
+	}{
+		{0, time.Hour},
+		{23 * time.Hour, time.Hour},
+		{2 * 24 * time.Hour, 4 * time.Hour},
+		{5 * 24 * time.Hour, 12 * time.Hour},
+		{10 * 24 * time.Hour, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := s.Interval(tt.age); got != tt.want {
+			t.Errorf("Interval(%v) = %v, want %v", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestTracker_Lifecycle(t *testing.T) {
+	fetcher := newFakeFetcher()
+	tracker, clock := newTestTracker(t, fetcher, "")
+	ctx := context.Background()
+
+	publishAt := clock.now.Add(-12 * time.Hour).UnixMilli()
+	if !tracker.Track("1", "iPhone 13", "3000", publishAt) {
+		t.Fatal("Track() 新商品应返回 true")
+	}
+	if tracker.Track("1", "iPhone 13", "3000", publishAt) {
+		t.Error("Track() 重复商品应返回 false")
+	}
+	tracker.Track("2", "Switch", "1200", 0)
+
+	// 未到期不检查
+	if n, _ := tracker.CheckDue(ctx); n != 0 {
+		t.Fatalf("CheckDue() 未到期检查了 %d 个", n)
+	}
+
+	// 第一次检查: 商品1 改价，商品2 已被删除
+	clock.Advance(time.Hour)
+	fetcher.details["1"] = &mtop.ItemDetail{ItemID: "1", Price: "2800", ItemStatusStr: "在售"}
+	fetcher.errs["2"] = fmt.Errorf("详情API返回错误: %w", mtop.ErrItemNotFound)
+	if n, err := tracker.CheckDue(ctx); err != nil || n != 2 {
+		t.Fatalf("CheckDue() = %d, %v, want 2, nil", n, err)
+	}
+
+	item2, _ := tracker.Item("2")
+	if item2.Stage != StageDeleted || !item2.Done || item2.Failures != 0 {
+		t.Errorf("商品不存在应归类为已删除: %+v", item2)
+	}
+
+	// 第二次检查: 商品1 售出
+	clock.Advance(2 * time.Hour)
+	fetcher.details["1"] = &mtop.ItemDetail{ItemID: "1", Price: "2800", ItemStatusStr: "已售出"}
+	if n, _ := tracker.CheckDue(ctx); n != 1 {
+		t.Fatalf("CheckDue() 应只检查商品1, got %d", n)
+	}
+
+	item1, _ := tracker.Item("1")
+	if item1.Stage != StageSold || !item1.Done {
+		t.Fatalf("商品1 应为已售出: %+v", item1)
+	}
+	if len(item1.Transitions) != 2 || item1.Transitions[0].Kind != ChangePrice || item1.Transitions[1].To != string(StageSold) {
+		t.Errorf("商品1 变化记录错误: %+v", item1.Transitions)
+	}
+
+	stats := tracker.Stats()
+	if stats.Tracked != 2 || stats.Sold != 1 || stats.PriceEdits != 1 || stats.Active != 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+	// 发布于 12 小时前，3 小时后售出 -> 0.625 天
+	if stats.MedianDaysToSell != 0.625 || stats.AvgDaysToSell != 0.625 {
+		t.Errorf("成交天数 = %v / %v, want 0.625", stats.AvgDaysToSell, stats.MedianDaysToSell)
+	}
+}
+
+func TestTracker_RelistAndBackoff(t *testing.T) {
+	fetcher := newFakeFetcher()
+	tracker, clock := newTestTracker(t, fetcher, "")
+	ctx := context.Background()
+
+	tracker.Track("1", "相机", "500", 0)
+
+	// 下架后重新上架
+	clock.Advance(time.Hour)
+	fetcher.details["1"] = &mtop.ItemDetail{ItemStatusStr: "已下架"}
+	tracker.CheckDue(ctx)
+	clock.Advance(time.Hour)
+	fetcher.details["1"] = &mtop.ItemDetail{ItemStatusStr: "在售"}
+	tracker.CheckDue(ctx)
+
+	item, _ := tracker.Item("1")
+	if item.Done || item.Stage != StageOnSale || len(item.Transitions) != 2 {
+		t.Fatalf("下架后重新上架应继续跟踪: %+v", item)
+	}
+
+	// 请求失败时退避翻倍，且不改变阶段
+	clock.Advance(time.Hour)
+	fetcher.errs["1"] = errors.New("网络错误")
+	tracker.CheckDue(ctx)
+	item, _ = tracker.Item("1")
+	wantNext := clock.now.Add(2 * time.Hour).UnixMilli()
+	if item.Failures != 1 || item.NextCheck != wantNext || item.Stage != StageOnSale {
+		t.Errorf("失败退避错误: failures=%d next=%d want=%d", item.Failures, item.NextCheck, wantNext)
+	}
+}
+
+func TestTracker_MaxAgeAndPersistence(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "tracker.json")
+	fetcher := newFakeFetcher()
+	tracker, clock := newTestTracker(t, fetcher, statePath)
+
+	// 发布于 31 天前的商品按实际年龄的间隔（每天）检查，不会立即结束跟踪
+	tracker.Track("1", "旧商品", "100", clock.now.Add(-31*24*time.Hour).UnixMilli())
+	tracker.Track("2", "新商品", "100", 0)
+	old, _ := tracker.Item("1")
+	if want := clock.now.Add(24 * time.Hour).UnixMilli(); old.NextCheck != want {
+		t.Errorf("旧商品首次检查时间 = %d, want %d", old.NextCheck, want)
+	}
+	clock.Advance(time.Hour)
+	fetcher.details["1"] = &mtop.ItemDetail{ItemStatusStr: "在售"}
+	fetcher.details["2"] = &mtop.ItemDetail{ItemStatusStr: "在售"}
+	if n, err := tracker.CheckDue(context.Background()); err != nil || n != 1 {
+		t.Fatalf("CheckDue() = %d, %v, want 1, nil", n, err)
+	}
+
+	reloaded, err := NewTracker(fetcher, DefaultSchedule(), statePath)
+	if err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	fresh, ok := reloaded.Item("2")
+	if !ok || fresh.Done || fresh.Checks != 1 {
+		t.Errorf("状态未正确恢复: %+v", fresh)
+	}
+
+	clock.Advance(24 * time.Hour)
+	tracker.CheckDue(context.Background())
+	if old, _ = tracker.Item("1"); old.Done || old.Checks != 1 {
+		t.Errorf("旧商品应继续跟踪: %+v", old)
+	}
+
+	// 从首次跟踪算起超过最大跟踪时长后结束
+	clock.Advance(30 * 24 * time.Hour)
+	tracker.CheckDue(context.Background())
+	for _, id := range []string{"1", "2"} {
+		if item, _ := tracker.Item(id); !item.Done {
+			t.Errorf("超过最大跟踪时长应结束跟踪: %+v", item)
+		}
+	}
+}
+
+// TestTracker_ConcurrentSave 测试并发保存不会互相覆盖临时文件，最后一次保存包含全部记录
+func TestTracker_ConcurrentSave(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "tracker.json")
+	tracker, _ := newTestTracker(t, newFakeFetcher(), statePath)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tracker.Track(fmt.Sprint(i), "商品", "100", 0)
+			errs <- tracker.Save()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	if err := tracker.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reloaded, err := NewTracker(newFakeFetcher(), DefaultSchedule(), statePath)
+	if err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	if n := len(reloaded.items); n != 20 {
+		t.Errorf("恢复的记录数 = %d, want 20", n)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("临时文件未清理: %v", entries)
+	}
+}
@@ -0,0 +1,359 @@
+package media
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// partialDir 未下载完成文件的存放目录（相对归档目录）
+const partialDir = ".partial"
+
+var (
+	// ErrTooLarge 单个文件超过大小上限
+	ErrTooLarge = errors.New("文件超过大小上限")
+	// ErrArchiveFull 归档总大小已达上限
+	ErrArchiveFull = errors.New("归档总大小已达上限")
+)
+
+// Options 归档选项
+type Options struct {
+	Concurrency   int    // 并发下载数（默认 4）
+	MaxFileBytes  int64  // 单个文件大小上限（0 表示不限制）
+	MaxTotalBytes int64  // 归档总大小上限，达到后不再开始新的下载（0 表示不限制）
+	Throttle      func() // 每次请求前调用（如 mtop.Client.Throttle，复用反爬虫延迟）
+	UserAgent     string // 请求 User-Agent（可选）
+}
+
+// Report 一次归档的结果统计
+type Report struct {
+	Downloaded int               `json:"downloaded"` // 新下载的文件数
+	Skipped    int               `json:"skipped"`    // 清单中已存在的文件数
+	Failed     int               `json:"failed"`     // 下载失败的文件数
+	Bytes      int64             `json:"bytes"`      // 本次新增字节数
+	Errors     map[string]string `json:"errors,omitempty"`
+}
+
+// Archiver 媒体归档器：按内容哈希命名文件，支持断点续传，并维护商品与本地文件的清单
+type Archiver struct {
+	dir      string
+	client   *http.Client
+	opts     Options
+	manifest *Manifest
+	total    int64 // 已归档总字节数（原子访问）
+}
+
+// NewArchiver 创建归档器，client 为 nil 时使用默认客户端
+func NewArchiver(dir string, client *http.Client, opts Options) (*Archiver, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("归档目录不能为空")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, partialDir), 0755); err != nil {
+		return nil, fmt.Errorf("创建归档目录失败: %w", err)
+	}
+	manifest, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	return &Archiver{
+		dir:      dir,
+		client:   client,
+		opts:     opts,
+		manifest: manifest,
+		total:    manifest.TotalBytes(),
+	}, nil
+}
+
+// Manifest 返回归档清单
+func (a *Archiver) Manifest() *Manifest {
+	return a.manifest
+}
+
+// pending 一个待下载地址及引用它的商品
+type pending struct {
+	url     string
+	kind    Kind
+	itemIDs []string
+}
+
+// Archive 下载尚未归档的文件并更新清单，ctx 取消时停止开始新的下载
+// 单个文件失败不影响其他文件，失败原因记录在 Report.Errors 中
+func (a *Archiver) Archive(ctx context.Context, assets []Asset) (Report, error) {
+	report := Report{Errors: make(map[string]string)}
+
+	// 按地址合并，同一文件只下载一次
+	byURL := make(map[string]*pending)
+	var order []string
+	for _, asset := range assets {
+		u := NormalizeURL(asset.URL)
+		if u == "" {
+			continue
+		}
+		p, ok := byURL[u]
+		if !ok {
+			p = &pending{url: u, kind: asset.Kind}
+			byURL[u] = p
+			order = append(order, u)
+		}
+		if asset.ItemID != "" {
+			p.itemIDs = append(p.itemIDs, asset.ItemID)
+		}
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, a.opts.Concurrency)
+	)
+	record := func(fn func()) {
+		mu.Lock()
+		fn()
+		mu.Unlock()
+	}
+
+	for _, u := range order {
+		p := byURL[u]
+		if _, ok := a.manifest.Path(u); ok {
+			a.manifest.addItems(u, p.itemIDs)
+			report.Skipped++
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(p *pending) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			entry, err := a.fetch(ctx, p)
+			if err != nil {
+				record(func() {
+					report.Failed++
+					report.Errors[p.url] = err.Error()
+				})
+				return
+			}
+			a.manifest.put(entry)
+			record(func() {
+				report.Downloaded++
+				report.Bytes += entry.Size
+			})
+		}(p)
+	}
+	wg.Wait()
+
+	if len(report.Errors) == 0 {
+		report.Errors = nil
+	}
+	if err := a.manifest.Save(); err != nil {
+		return report, err
+	}
+	return report, ctx.Err()
+}
+
+// fetch 下载单个文件并移动到内容寻址路径
+func (a *Archiver) fetch(ctx context.Context, p *pending) (Entry, error) {
+	if a.opts.MaxTotalBytes > 0 && atomic.LoadInt64(&a.total) >= a.opts.MaxTotalBytes {
+		return Entry{}, ErrArchiveFull
+	}
+	if a.opts.Throttle != nil {
+		a.opts.Throttle()
+	}
+
+	part := filepath.Join(a.dir, partialDir, partName(p.url))
+	contentType, err := a.download(ctx, p.url, part)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	sum, size, err := hashFile(part)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	rel := filepath.Join(string(p.kind), sum[:2], sum+extension(p.url, contentType))
+	dest := filepath.Join(a.dir, rel)
+	if _, err := os.Stat(dest); err == nil {
+		// 相同内容已归档（如不同地址指向同一图片），只保留一份
+		os.Remove(part)
+	} else {
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return Entry{}, fmt.Errorf("创建目录失败: %w", err)
+		}
+		if err := os.Rename(part, dest); err != nil {
+			return Entry{}, fmt.Errorf("移动文件失败: %w", err)
+		}
+		atomic.AddInt64(&a.total, size)
+	}
+
+	return Entry{
+		URL:       p.url,
+		Kind:      p.kind,
+		Path:      filepath.ToSlash(rel),
+		SHA256:    sum,
+		Size:      size,
+		ItemIDs:   mergeIDs(nil, p.itemIDs),
+		FetchedAt: time.Now().UnixMilli(),
+	}, nil
+}
+
+// download 下载到临时文件，已有部分内容时通过 Range 请求续传，返回 Content-Type
+// 网络中断时保留临时文件供下次续传，超过大小上限时删除临时文件
+func (a *Archiver) download(ctx context.Context, rawURL, part string) (string, error) {
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %w", err)
+	}
+	if a.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", a.opts.UserAgent)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// 临时文件已完整
+		return contentType, nil
+	case resp.StatusCode == http.StatusOK:
+		// 服务端不支持续传，从头下载
+		offset = 0
+		flags |= os.O_TRUNC
+	default:
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	max := a.opts.MaxFileBytes
+	if max > 0 && resp.ContentLength > 0 && offset+resp.ContentLength > max {
+		os.Remove(part)
+		return "", ErrTooLarge
+	}
+
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return "", fmt.Errorf("打开临时文件失败: %w", err)
+	}
+
+	var body io.Reader = resp.Body
+	if max > 0 {
+		body = io.LimitReader(resp.Body, max-offset+1)
+	}
+	n, copyErr := io.Copy(f, body)
+	closeErr := f.Close()
+
+	if max > 0 && offset+n > max {
+		os.Remove(part)
+		return "", ErrTooLarge
+	}
+	if copyErr != nil {
+		return "", fmt.Errorf("下载中断（已保留 %d 字节待续传）: %w", offset+n, copyErr)
+	}
+	if closeErr != nil {
+		return "", fmt.Errorf("写入临时文件失败: %w", closeErr)
+	}
+	return contentType, nil
+}
+
+// partName 临时文件名（按地址哈希，保证同一地址可续传）
+func partName(rawURL string) string {
+	sum := sha1.Sum([]byte(rawURL))
+	return hex.EncodeToString(sum[:]) + ".part"
+}
+
+// hashFile 计算文件 SHA-256 与大小
+func hashFile(p string) (string, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", 0, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("读取文件失败: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// extension 文件扩展名：优先取地址中的扩展名，其次按 Content-Type 推断
+// 闲鱼图片地址常带处理后缀，如 xxx.jpg_790x10000Q75.jpg_.webp，取最后一个有效扩展名
+func extension(rawURL, contentType string) string {
+	name := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		name = u.Path
+	}
+	ext := strings.ToLower(path.Ext(path.Base(name)))
+	if ext != "" && len(ext) <= 6 && isAlnum(ext[1:]) {
+		return ext
+	}
+	if contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if ext, ok := knownTypes[mediaType]; ok {
+			return ext
+		}
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	return ".bin"
+}
+
+// knownTypes 常见媒体类型的首选扩展名
+var knownTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+	"video/mp4":  ".mp4",
+}
+
+func isAlnum(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
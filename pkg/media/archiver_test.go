@@ -0,0 +1,194 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fileServer 提供固定内容的测试服务器，支持 Range 请求并记录请求头
+type fileServer struct {
+	mu     sync.Mutex
+	files  map[string][]byte
+	ranges map[string]string
+	hits   map[string]int
+}
+
+func newFileServer(files map[string][]byte) (*fileServer, *httptest.Server) {
+	fs := &fileServer{files: files, ranges: make(map[string]string), hits: make(map[string]int)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fs.mu.Lock()
+		fs.hits[r.URL.Path]++
+		fs.ranges[r.URL.Path] = r.Header.Get("Range")
+		data, ok := fs.files[r.URL.Path]
+		fs.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(data))
+	}))
+	return fs, srv
+}
+
+func sha(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestArchiver_ArchiveAndManifest(t *testing.T) {
+	photo := bytes.Repeat([]byte("photo-a"), 100)
+	files := map[string][]byte{
+		"/a.jpg":      photo,
+		"/a_copy.jpg": photo, // 不同地址相同内容
+		"/b.png":      []byte("photo-b"),
+		"/movie.mp4":  []byte("video"),
+	}
+	fs, srv := newFileServer(files)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	archiver, err := NewArchiver(dir, srv.Client(), Options{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("NewArchiver() error = %v", err)
+	}
+
+	assets := []Asset{
+		{ItemID: "1", URL: srv.URL + "/a.jpg", Kind: KindImage},
+		{ItemID: "2", URL: srv.URL + "/a.jpg", Kind: KindImage},
+		{ItemID: "2", URL: srv.URL + "/a_copy.jpg", Kind: KindImage},
+		{ItemID: "3", URL: srv.URL + "/b.png", Kind: KindImage},
+		{ItemID: "3", URL: srv.URL + "/movie.mp4", Kind: KindVideo},
+		{ItemID: "4", URL: srv.URL + "/missing.jpg", Kind: KindImage},
+	}
+	report, err := archiver.Archive(context.Background(), assets)
+	if err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	if report.Downloaded != 4 || report.Failed != 1 || report.Skipped != 0 {
+		t.Fatalf("Archive() report = %+v", report)
+	}
+	if fs.hits["/a.jpg"] != 1 {
+		t.Errorf("同一地址应只下载一次, got %d", fs.hits["/a.jpg"])
+	}
+
+	entry, ok := archiver.Manifest().Lookup(srv.URL + "/a.jpg")
+	if !ok {
+		t.Fatal("清单缺少 a.jpg")
+	}
+	wantPath := "image/" + sha(photo)[:2] + "/" + sha(photo) + ".jpg"
+	if entry.Path != wantPath || entry.SHA256 != sha(photo) || strings.Join(entry.ItemIDs, ",") != "1,2" {
+		t.Errorf("清单条目错误: %+v", entry)
+	}
+	copyEntry, _ := archiver.Manifest().Lookup(srv.URL + "/a_copy.jpg")
+	if copyEntry.Path != entry.Path {
+		t.Errorf("相同内容应指向同一文件: %s vs %s", copyEntry.Path, entry.Path)
+	}
+	video, _ := archiver.Manifest().Lookup(srv.URL + "/movie.mp4")
+	if !strings.HasPrefix(video.Path, "video/") {
+		t.Errorf("视频路径错误: %s", video.Path)
+	}
+	if got := len(archiver.Manifest().Item("2")); got != 2 {
+		t.Errorf("Item(2) 文件数 = %d, want 2", got)
+	}
+
+	// 重新加载后已归档文件直接跳过
+	reloaded, err := NewArchiver(dir, srv.Client(), Options{})
+	if err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	report, _ = reloaded.Archive(context.Background(), assets[:1])
+	if report.Skipped != 1 || report.Downloaded != 0 || fs.hits["/a.jpg"] != 1 {
+		t.Errorf("已归档文件应跳过: %+v, hits=%d", report, fs.hits["/a.jpg"])
+	}
+	if _, ok := reloaded.Manifest().Path(srv.URL + "/b.png"); !ok {
+		t.Error("Path() 应返回已归档文件的本地路径")
+	}
+}
+
+func TestArchiver_ResumePartial(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 50)
+	fs, srv := newFileServer(map[string][]byte{"/big.jpg": data})
+	defer srv.Close()
+
+	dir := t.TempDir()
+	archiver, err := NewArchiver(dir, srv.Client(), Options{})
+	if err != nil {
+		t.Fatalf("NewArchiver() error = %v", err)
+	}
+
+	url := srv.URL + "/big.jpg"
+	part := filepath.Join(dir, partialDir, partName(url))
+	if err := os.WriteFile(part, data[:200], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := archiver.Archive(context.Background(), []Asset{{ItemID: "1", URL: url, Kind: KindImage}})
+	if err != nil || report.Downloaded != 1 {
+		t.Fatalf("Archive() = %+v, %v", report, err)
+	}
+	if got := fs.ranges["/big.jpg"]; got != "bytes=200-" {
+		t.Errorf("续传 Range = %q, want bytes=200-", got)
+	}
+	entry, _ := archiver.Manifest().Lookup(url)
+	if entry.SHA256 != sha(data) || entry.Size != int64(len(data)) {
+		t.Errorf("续传后内容不完整: %+v", entry)
+	}
+	if _, err := os.Stat(part); !os.IsNotExist(err) {
+		t.Error("完成后应删除临时文件")
+	}
+}
+
+func TestArchiver_SizeCaps(t *testing.T) {
+	_, srv := newFileServer(map[string][]byte{
+		"/small.jpg": []byte("small"),
+		"/large.jpg": bytes.Repeat([]byte("x"), 1024),
+		"/other.jpg": []byte("other"),
+	})
+	defer srv.Close()
+
+	archiver, err := NewArchiver(t.TempDir(), srv.Client(), Options{Concurrency: 1, MaxFileBytes: 100, MaxTotalBytes: 5})
+	if err != nil {
+		t.Fatalf("NewArchiver() error = %v", err)
+	}
+	report, _ := archiver.Archive(context.Background(), []Asset{
+		{ItemID: "1", URL: srv.URL + "/large.jpg", Kind: KindImage},
+		{ItemID: "2", URL: srv.URL + "/small.jpg", Kind: KindImage},
+		{ItemID: "3", URL: srv.URL + "/other.jpg", Kind: KindImage},
+	})
+	if report.Downloaded != 1 || report.Failed != 2 {
+		t.Fatalf("Archive() report = %+v", report)
+	}
+	if report.Errors[srv.URL+"/large.jpg"] != ErrTooLarge.Error() {
+		t.Errorf("超大文件错误 = %q", report.Errors[srv.URL+"/large.jpg"])
+	}
+	if report.Errors[srv.URL+"/other.jpg"] != ErrArchiveFull.Error() {
+		t.Errorf("总量上限错误 = %q", report.Errors[srv.URL+"/other.jpg"])
+	}
+}
+
+func TestExtension(t *testing.T) {
+	tests := []struct {
+		url, contentType, want string
+	}{
+		{"https://img.alicdn.com/bao/O1CN01.jpg", "", ".jpg"},
+		{"https://img.alicdn.com/bao/O1CN01.jpg_790x10000Q75.jpg_.webp", "", ".webp"},
+		{"https://img.alicdn.com/bao/O1CN01", "image/png", ".png"},
+		{"https://img.alicdn.com/bao/O1CN01", "image/jpeg; charset=binary", ".jpg"},
+		{"https://img.alicdn.com/bao/O1CN01", "", ".bin"},
+	}
+	for _, tt := range tests {
+		if got := extension(tt.url, tt.contentType); got != tt.want {
+			t.Errorf("extension(%q, %q) = %q, want %q", tt.url, tt.contentType, got, tt.want)
+		}
+	}
+}
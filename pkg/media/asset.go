@@ -0,0 +1,71 @@
+package media
+
+import (
+	"strings"
+
+	"xianyu_aner/pkg/mtop"
+)
+
+// Kind 媒体类型
+type Kind string
+
+const (
+	KindImage Kind = "image"
+	KindVideo Kind = "video"
+)
+
+// Asset 待归档的媒体文件
+type Asset struct {
+	ItemID string
+	URL    string
+	Kind   Kind
+}
+
+// NormalizeURL 补全协议相对地址（//img.alicdn.com/... -> https://img.alicdn.com/...）
+func NormalizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "//") {
+		return "https:" + raw
+	}
+	return raw
+}
+
+// assetList 收集媒体文件并忽略空地址
+type assetList []Asset
+
+func (l *assetList) add(itemID, rawURL string, kind Kind) {
+	u := NormalizeURL(rawURL)
+	if u == "" {
+		return
+	}
+	*l = append(*l, Asset{ItemID: itemID, URL: u, Kind: kind})
+}
+
+// FeedAssets 提取猜你喜欢商品的主图、视频封面，videos 为 true 时包含视频
+func FeedAssets(items []mtop.FeedItem, videos bool) []Asset {
+	var list assetList
+	for _, item := range items {
+		list.add(item.ItemID, item.ImageURL, KindImage)
+		list.add(item.ItemID, item.VideoCoverURL, KindImage)
+		if videos {
+			list.add(item.ItemID, item.VideoURL, KindVideo)
+		}
+	}
+	return list
+}
+
+// DetailAssets 提取商品详情的全部图片，videos 为 true 时包含视频
+func DetailAssets(detail *mtop.ItemDetail, videos bool) []Asset {
+	if detail == nil {
+		return nil
+	}
+	var list assetList
+	list.add(detail.ItemID, detail.ImageURL, KindImage)
+	for _, img := range detail.ImageList {
+		list.add(detail.ItemID, img, KindImage)
+	}
+	if videos {
+		list.add(detail.ItemID, detail.VideoURL, KindVideo)
+	}
+	return list
+}
@@ -0,0 +1,194 @@
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"xianyu_aner/pkg/util"
+)
+
+// ManifestFile 归档目录下的清单文件名
+const ManifestFile = "manifest.json"
+
+// Entry 清单条目：一个远程地址对应的本地文件
+type Entry struct {
+	URL       string   `json:"url"`
+	Kind      Kind     `json:"kind"`
+	Path      string   `json:"path"`   // 相对归档目录的路径
+	SHA256    string   `json:"sha256"` // 文件内容哈希（即文件名）
+	Size      int64    `json:"size"`
+	ItemIDs   []string `json:"itemIds"`   // 引用该文件的商品ID
+	FetchedAt int64    `json:"fetchedAt"` // 下载完成时间戳（毫秒）
+}
+
+// Manifest 商品ID、远程地址与本地文件的对应关系
+type Manifest struct {
+	mu      sync.RWMutex
+	dir     string
+	entries map[string]*Entry
+}
+
+// manifestState 持久化的清单
+type manifestState struct {
+	Entries []*Entry `json:"entries"`
+}
+
+// LoadManifest 加载归档目录下的清单（不存在时返回空清单）
+func LoadManifest(dir string) (*Manifest, error) {
+	m := &Manifest{dir: dir, entries: make(map[string]*Entry)}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取媒体清单失败: %w", err)
+	}
+
+	var state manifestState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析媒体清单失败: %w", err)
+	}
+	for _, e := range state.Entries {
+		m.entries[e.URL] = e
+	}
+	return m, nil
+}
+
+// Lookup 按远程地址查找条目
+func (m *Manifest) Lookup(url string) (Entry, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, ok := m.entries[url]
+	if !ok {
+		return Entry{}, false
+	}
+	return copyEntry(e), true
+}
+
+// Path 返回远程地址对应的本地绝对路径（文件不存在时返回 false），可作为 duplicate.ImageSource 使用
+func (m *Manifest) Path(url string) (string, bool) {
+	e, ok := m.Lookup(NormalizeURL(url))
+	if !ok {
+		return "", false
+	}
+	p := filepath.Join(m.dir, e.Path)
+	if _, err := os.Stat(p); err != nil {
+		return "", false
+	}
+	return p, true
+}
+
+// Item 返回商品引用的全部文件（按地址排序）
+func (m *Manifest) Item(itemID string) []Entry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []Entry
+	for _, e := range m.entries {
+		for _, id := range e.ItemIDs {
+			if id == itemID {
+				result = append(result, copyEntry(e))
+				break
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].URL < result[j].URL })
+	return result
+}
+
+// Len 条目数量
+func (m *Manifest) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.entries)
+}
+
+// TotalBytes 已归档文件总大小（相同内容只计算一次）
+func (m *Manifest) TotalBytes() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var total int64
+	for _, e := range m.entries {
+		if !seen[e.SHA256] {
+			seen[e.SHA256] = true
+			total += e.Size
+		}
+	}
+	return total
+}
+
+// put 写入或覆盖条目（保留已有的商品引用）
+func (m *Manifest) put(e Entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.entries[e.URL]; ok {
+		e.ItemIDs = mergeIDs(old.ItemIDs, e.ItemIDs)
+	}
+	m.entries[e.URL] = &e
+}
+
+// addItems 为已有条目追加商品引用
+func (m *Manifest) addItems(url string, itemIDs []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[url]; ok {
+		e.ItemIDs = mergeIDs(e.ItemIDs, itemIDs)
+	}
+}
+
+// Save 保存清单（写入唯一临时文件后重命名，避免写坏清单）
+func (m *Manifest) Save() error {
+	m.mu.RLock()
+	state := manifestState{Entries: make([]*Entry, 0, len(m.entries))}
+	for _, e := range m.entries {
+		c := copyEntry(e)
+		state.Entries = append(state.Entries, &c)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(state.Entries, func(i, j int) bool { return state.Entries[i].URL < state.Entries[j].URL })
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化媒体清单失败: %w", err)
+	}
+
+	if err := util.WriteFileAtomic(filepath.Join(m.dir, ManifestFile), data, 0644); err != nil {
+		return fmt.Errorf("写入媒体清单失败: %w", err)
+	}
+	return nil
+}
+
+// mergeIDs 合并去重商品ID并排序
+func mergeIDs(a, b []string) []string {
+	set := make(map[string]bool, len(a)+len(b))
+	for _, id := range a {
+		set[id] = true
+	}
+	for _, id := range b {
+		if id != "" {
+			set[id] = true
+		}
+	}
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// copyEntry 深拷贝条目
+func copyEntry(e *Entry) Entry {
+	c := *e
+	c.ItemIDs = append([]string(nil), e.ItemIDs...)
+	return c
+}
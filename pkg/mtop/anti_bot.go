@@ -64,6 +64,10 @@ func (d *DelayManager) Wait() {
 	}
 	min := time.Duration(d.minMs) * time.Millisecond
 	max := time.Duration(d.maxMs) * time.Millisecond
+	if max <= min {
+		time.Sleep(min)
+		return
+	}
 	rand.Seed(time.Now().UnixNano())
 	delay := min + time.Duration(rand.Int63n(int64(max-min)))
 	time.Sleep(delay)
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/playwright-community/playwright-go"
+
+	"xianyu_aner/pkg/util"
 )
 
 // BrowserConfig 浏览器配置
@@ -123,17 +125,17 @@ func GetCookiesWithBrowser(config BrowserConfig) (*CookieResult, error) {
 	if needLogin != nil && needLogin.(bool) {
 		// 非无头模式下提示用户登录
 		if !headless {
-			fmt.Println("\n========================================")
-			fmt.Println("  请在浏览器中登录闲鱼账号")
-			fmt.Println("========================================")
-			fmt.Println("等待用户登录...")
+			util.Println("\n========================================")
+			util.Println("  请在浏览器中登录闲鱼账号")
+			util.Println("========================================")
+			util.Println("等待用户登录...")
 
 			// 等待用户登录（最多等待5分钟）
 			_, err := page.WaitForFunction("() => { return !document.querySelector('.login-guide') && !document.body.innerText.includes('立即登录') }", nil)
 			if err != nil {
 				return nil, fmt.Errorf("等待登录超时，请确保已登录闲鱼账号")
 			}
-			fmt.Println("✅ 检测到登录成功！")
+			util.Println("✅ 检测到登录成功！")
 			// 登录后再等待一下让 cookie 生成
 			time.Sleep(2 * time.Second)
 		} else {
@@ -171,14 +173,14 @@ func GetCookiesWithBrowser(config BrowserConfig) (*CookieResult, error) {
 	}
 
 	if !hasCookie2 || !hasUnb {
-		fmt.Println("\n⚠️  警告: 检测到登录状态不完整")
+		util.Println("\n⚠️  警告: 检测到登录状态不完整")
 		if !hasCookie2 {
-			fmt.Println("   - 缺少 cookie2")
+			util.Println("   - 缺少 cookie2")
 		}
 		if !hasUnb {
-			fmt.Println("   - 缺少 unb (用户ID)")
+			util.Println("   - 缺少 unb (用户ID)")
 		}
-		fmt.Println("   可能导致 API 调用失败")
+		util.Println("   可能导致 API 调用失败")
 	}
 
 	// 转换为 http.Cookie 格式
@@ -211,10 +213,10 @@ func GetCookiesWithBrowser(config BrowserConfig) (*CookieResult, error) {
 
 // PrintStartupInfo 打印启动信息
 func PrintStartupInfo(token string) {
-	fmt.Println("🌐 闲鱼 API 服务")
-	fmt.Println("=================")
+	util.Println("🌐 闲鱼 API 服务")
+	util.Println("=================")
 	if token != "" {
-		fmt.Printf("✅ 获取到 Token: %s...\n", token[:10])
+		util.Printf("✅ 获取到 Token: %s...\n", token[:10])
 	}
 }
 
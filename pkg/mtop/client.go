@@ -108,6 +108,18 @@ func NewClient(token string, appKey string, opts ...ClientOption) *Client {
 	return client
 }
 
+// HTTPClient 返回底层 HTTP 客户端（媒体下载等复用其代理与超时设置）
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// Throttle 按反爬虫延迟配置等待一次（未启用反爬虫时立即返回）
+func (c *Client) Throttle() {
+	if c.delayManager != nil {
+		c.delayManager.Wait()
+	}
+}
+
 // SetToken 设置 token
 func (c *Client) SetToken(token string) {
 	c.token = token
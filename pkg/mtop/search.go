@@ -0,0 +1,232 @@
+package mtop
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// searchPageSize 搜索每页条数
+const searchPageSize = 30
+
+// SearchRequest 关键词搜索请求参数
+type SearchRequest struct {
+	PageNumber        int               `json:"pageNumber"`
+	Keyword           string            `json:"keyword"`
+	FromFilter        bool              `json:"fromFilter"`
+	RowsPerPage       int               `json:"rowsPerPage"`
+	SortValue         string            `json:"sortValue"` // desc / asc
+	SortField         string            `json:"sortField"` // create / price
+	CustomDistance    string            `json:"customDistance"`
+	GPS               string            `json:"gps"`
+	PropValueStr      map[string]string `json:"propValueStr"`
+	CustomGPS         string            `json:"customGps"`
+	SearchReqFromPage string            `json:"searchReqFromPage"`
+	ExtraFilterValue  string            `json:"extraFilterValue"`
+	UserPositionJSON  string            `json:"userPositionJson"`
+}
+
+// SellerItemsRequest 卖家在售商品列表请求参数
+type SellerItemsRequest struct {
+	NeedGroupInfo bool   `json:"needGroupInfo"`
+	PageNumber    int    `json:"pageNumber"`
+	UserID        string `json:"userId"`
+	PageSize      int    `json:"pageSize"`
+}
+
+// Search 按关键词搜索商品（按发布时间倒序），返回本页商品与是否有下一页
+// API: mtop.taobao.idlemtopsearch.pc.search
+func (c *Client) Search(keyword string, page int) ([]FeedItem, bool, error) {
+	if strings.TrimSpace(keyword) == "" {
+		return nil, false, fmt.Errorf("keyword 不能为空")
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	resp, err := c.Do(Request{
+		API: "mtop.taobao.idlemtopsearch.pc.search",
+		Data: SearchRequest{
+			PageNumber:        page,
+			Keyword:           keyword,
+			RowsPerPage:       searchPageSize,
+			SortValue:         "desc",
+			SortField:         "create",
+			PropValueStr:      map[string]string{},
+			SearchReqFromPage: "pcSearch",
+			ExtraFilterValue:  "{}",
+			UserPositionJSON:  "{}",
+		},
+		Method: "POST",
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("请求搜索API失败: %w", err)
+	}
+	if err := CheckResponseStatus(resp); err != nil {
+		return nil, false, err
+	}
+	return ParseSearchResponse(resp)
+}
+
+// ParseSearchResponse 解析搜索 API 响应
+func ParseSearchResponse(resp *Response) ([]FeedItem, bool, error) {
+	var data struct {
+		ResultList []struct {
+			Data struct {
+				Item struct {
+					Main struct {
+						ExContent struct {
+							ItemID       string `json:"itemId"`
+							Title        string `json:"title"`
+							PicURL       string `json:"picUrl"`
+							Area         string `json:"area"`
+							UserNickName string `json:"userNickName"`
+							Price        []struct {
+								Text string `json:"text"`
+							} `json:"price"`
+						} `json:"exContent"`
+						ClickParam struct {
+							Args struct {
+								PublishTime string `json:"publishTime"`
+								Price       string `json:"price"`
+								WantNum     string `json:"wantNum"`
+								CatID       string `json:"cCatId"`
+								SellerID    string `json:"seller_id"`
+							} `json:"args"`
+						} `json:"clickParam"`
+					} `json:"main"`
+				} `json:"item"`
+			} `json:"data"`
+		} `json:"resultList"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		return nil, false, fmt.Errorf("解析搜索数据失败: %w", err)
+	}
+
+	items := make([]FeedItem, 0, len(data.ResultList))
+	for _, r := range data.ResultList {
+		main := r.Data.Item.Main
+		content := main.ExContent
+		args := main.ClickParam.Args
+		if content.ItemID == "" {
+			continue
+		}
+
+		price := args.Price
+		if price == "" {
+			var parts []string
+			for _, p := range content.Price {
+				parts = append(parts, p.Text)
+			}
+			price = strings.TrimPrefix(strings.Join(parts, ""), "¥")
+		}
+		item := FeedItem{
+			ItemID:     content.ItemID,
+			Title:      content.Title,
+			Price:      price,
+			ImageURL:   content.PicURL,
+			Location:   content.Area,
+			SellerNick: content.UserNickName,
+			Tags:       []string{},
+		}
+		item.WantCount, _ = strconv.Atoi(args.WantNum)
+		item.CategoryID, _ = strconv.Atoi(args.CatID)
+		if ts, err := strconv.ParseInt(args.PublishTime, 10, 64); err == nil {
+			item.PublishTimeTS = ts
+			item.PublishTime = formatTimestamp(ts)
+		}
+		items = append(items, item)
+	}
+
+	return items, len(data.ResultList) >= searchPageSize, nil
+}
+
+// SellerItems 获取卖家的商品列表，返回本页商品与是否有下一页
+// API: mtop.idle.web.xyh.item.list
+func (c *Client) SellerItems(sellerID string, page int) ([]FeedItem, bool, error) {
+	if sellerID == "" {
+		return nil, false, fmt.Errorf("sellerID 不能为空")
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	resp, err := c.Do(Request{
+		API: "mtop.idle.web.xyh.item.list",
+		Data: SellerItemsRequest{
+			PageNumber: page,
+			UserID:     sellerID,
+			PageSize:   20,
+		},
+		Method: "POST",
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("请求卖家商品API失败: %w", err)
+	}
+	if err := CheckResponseStatus(resp); err != nil {
+		return nil, false, err
+	}
+	return ParseSellerItemsResponse(resp)
+}
+
+// ParseSellerItemsResponse 解析卖家商品列表响应
+func ParseSellerItemsResponse(resp *Response) ([]FeedItem, bool, error) {
+	var data struct {
+		CardList []struct {
+			CardData struct {
+				ID         string `json:"id"`
+				Title      string `json:"title"`
+				ItemStatus int    `json:"itemStatus"` // 0 在售 / 1 已售出 / 2 已下架
+				CategoryID int    `json:"categoryId"`
+				PriceInfo  struct {
+					Price string `json:"price"`
+				} `json:"priceInfo"`
+				PicInfo struct {
+					PicURL string `json:"picUrl"`
+				} `json:"picInfo"`
+				DetailParams struct {
+					UserNick string `json:"userNick"`
+				} `json:"detailParams"`
+			} `json:"cardData"`
+		} `json:"cardList"`
+		NextPage bool `json:"nextPage"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		return nil, false, fmt.Errorf("解析卖家商品数据失败: %w", err)
+	}
+
+	items := make([]FeedItem, 0, len(data.CardList))
+	for _, card := range data.CardList {
+		cd := card.CardData
+		if cd.ID == "" {
+			continue
+		}
+		items = append(items, FeedItem{
+			ItemID:     cd.ID,
+			Title:      cd.Title,
+			Price:      cd.PriceInfo.Price,
+			ImageURL:   cd.PicInfo.PicURL,
+			CategoryID: cd.CategoryID,
+			SellerNick: cd.DetailParams.UserNick,
+			Status:     sellerItemStatus(cd.ItemStatus),
+			SoldOut:    cd.ItemStatus == 1,
+			Tags:       []string{},
+		})
+	}
+	return items, data.NextPage, nil
+}
+
+// sellerItemStatus 卖家商品状态码转文字
+func sellerItemStatus(status int) string {
+	switch status {
+	case 0:
+		return "在售"
+	case 1:
+		return "已售出"
+	case 2:
+		return "已下架"
+	default:
+		return ""
+	}
+}
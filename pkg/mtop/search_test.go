@@ -0,0 +1,71 @@
+package mtop
+
+import (
+	"testing"
+)
+
+// TestParseSearchResponse 测试搜索响应解析
+func TestParseSearchResponse(t *testing.T) {
+	resp := &Response{Data: []byte(`{
+		"resultList": [
+			{"data": {"item": {"main": {
+				"exContent": {
+					"itemId": "1001", "title": "iPhone 13 128G", "picUrl": "//img.alicdn.com/a.jpg",
+					"area": "杭州", "userNickName": "卖家A",
+					"price": [{"text": "¥"}, {"text": "2800"}]
+				},
+				"clickParam": {"args": {"publishTime": "1717200000000", "wantNum": "12", "cCatId": "126862528"}}
+			}}}},
+			{"data": {"item": {"main": {
+				"exContent": {"itemId": "1002", "title": "Switch"},
+				"clickParam": {"args": {"price": "1200"}}
+			}}}},
+			{"data": {"item": {"main": {"exContent": {"title": "广告位"}}}}}
+		]
+	}`)}
+
+	items, hasNext, err := ParseSearchResponse(resp)
+	if err != nil {
+		t.Fatalf("ParseSearchResponse() error = %v", err)
+	}
+	if hasNext {
+		t.Error("不足一页时 hasNext 应为 false")
+	}
+	if len(items) != 2 {
+		t.Fatalf("解析条数 = %d, want 2", len(items))
+	}
+
+	first := items[0]
+	if first.ItemID != "1001" || first.Price != "2800" || first.WantCount != 12 ||
+		first.CategoryID != 126862528 || first.PublishTimeTS != 1717200000000 || first.SellerNick != "卖家A" {
+		t.Errorf("第一条解析错误: %+v", first)
+	}
+	if items[1].Price != "1200" {
+		t.Errorf("应优先使用 clickParam 中的价格, got %q", items[1].Price)
+	}
+}
+
+// TestParseSellerItemsResponse 测试卖家商品列表解析
+func TestParseSellerItemsResponse(t *testing.T) {
+	resp := &Response{Data: []byte(`{
+		"cardList": [
+			{"cardData": {"id": "2001", "title": "相机", "itemStatus": 0, "priceInfo": {"price": "500"}}},
+			{"cardData": {"id": "2002", "title": "镜头", "itemStatus": 1, "priceInfo": {"price": "300"}}}
+		],
+		"nextPage": true
+	}`)}
+
+	items, hasNext, err := ParseSellerItemsResponse(resp)
+	if err != nil {
+		t.Fatalf("ParseSellerItemsResponse() error = %v", err)
+	}
+	if !hasNext || len(items) != 2 {
+		t.Fatalf("got %d items, hasNext=%v", len(items), hasNext)
+	}
+	if items[0].Status != "在售" || items[0].SoldOut {
+		t.Errorf("在售商品解析错误: %+v", items[0])
+	}
+	if items[1].Status != "已售出" || !items[1].SoldOut || items[1].Price != "300" {
+		t.Errorf("已售商品解析错误: %+v", items[1])
+	}
+}
@@ -2,13 +2,24 @@ package mtop
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
+
+	"xianyu_aner/pkg/util"
 )
 
 // ==================== 商品详情 API ====================
 
+// ErrItemNotFound 商品不存在（已删除或被屏蔽），属于正常业务结果而非请求失败
+var ErrItemNotFound = errors.New("商品不存在")
+
+// IsItemNotFound 判断错误是否为商品不存在
+func IsItemNotFound(err error) bool {
+	return errors.Is(err, ErrItemNotFound)
+}
+
 // ItemDetailRequest 商品详情请求参数
 type ItemDetailRequest struct {
 	ItemID string `json:"itemId"`
@@ -225,6 +236,9 @@ type GuessYouLikeOptions struct {
 	StartPage    int // 起始页
 	MinWantCount int // 最低想要人数（0表示不限制）
 	DaysWithin   int // 发布时间范围（天数，0表示不限制，默认7天）
+
+	// OnPage 每页解析并过滤后立即回调（可选），用于边爬取边输出
+	OnPage func(page int, items []FeedItem)
 }
 
 // GuessYouLike 获取猜你喜欢商品列表
@@ -254,6 +268,9 @@ func (c *Client) GuessYouLike(machID string, totalPages int, opts ...GuessYouLik
 		// 过滤数据
 		filteredItems := FilterItems(pageItems, options)
 		allItems = append(allItems, filteredItems...)
+		if options.OnPage != nil {
+			options.OnPage(page, filteredItems)
+		}
 
 		// 如果没有下一页，提前结束
 		if !hasNext {
@@ -392,7 +409,7 @@ func (c *Client) FetchItemDetailWithRetry(itemID string, maxRetries int) (*ItemD
 		if attempt > 0 {
 			// 指数退避：等待一段时间后重试
 			waitTime := time.Duration(attempt) * time.Second
-			fmt.Printf("[重试 %d/%d] 等待 %v 后重试...\n", attempt, maxRetries, waitTime)
+			util.Printf("[重试 %d/%d] 等待 %v 后重试...\n", attempt, maxRetries, waitTime)
 			time.Sleep(waitTime)
 		}
 
@@ -405,7 +422,7 @@ func (c *Client) FetchItemDetailWithRetry(itemID string, maxRetries int) (*ItemD
 
 		// 检查是否是限流错误，如果是则重试
 		if strings.Contains(err.Error(), "RGV587_ERROR") || strings.Contains(err.Error(), "被挤爆") {
-			fmt.Printf("[限流 %d/%d] 遇到限流，将重试...\n", attempt+1, maxRetries)
+			util.Printf("[限流 %d/%d] 遇到限流，将重试...\n", attempt+1, maxRetries)
 			continue
 		}
 
@@ -451,6 +468,11 @@ func (c *Client) FetchItemDetail(itemID string) (*ItemDetail, error) {
 	if !success {
 		// 打印原始响应用于调试
 		// fmt.Printf("[调试] 详情API原始响应: %s\n", string(resp.Data))
+		for _, r := range resp.Ret {
+			if strings.Contains(r, "商品不存在") || strings.Contains(r, "ITEM_NOT_FOUND") {
+				return nil, fmt.Errorf("详情API返回错误: %w: ret=%v", ErrItemNotFound, resp.Ret)
+			}
+		}
 		return nil, fmt.Errorf("详情API返回错误: ret=%v", resp.Ret)
 	}
 
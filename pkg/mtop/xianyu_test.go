@@ -1046,6 +1046,35 @@ func TestFetchItemDetailWithErrorStatus(t *testing.T) {
 	}
 }
 
+// TestFetchItemDetailNotFound 测试商品不存在时返回可识别的错误
+func TestFetchItemDetailNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := Response{
+			Ret:  []string{"FAIL_BIZ_ITEM_NOT_FOUND::商品不存在"},
+			V:    "1.0",
+			Data: json.RawMessage(`{}`),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token_123", "34839810",
+		WithBaseURL(server.URL),
+	)
+
+	_, err := client.FetchItemDetail("item123")
+	if err == nil {
+		t.Fatal("FetchItemDetail() should return error when item not found")
+	}
+	if !IsItemNotFound(err) {
+		t.Errorf("IsItemNotFound() = false, err = %v", err)
+	}
+	if IsItemNotFound(fmt.Errorf("详情API返回错误: ret=%v", []string{"ERROR::系统错误"})) {
+		t.Error("普通错误不应识别为商品不存在")
+	}
+}
+
 // TestFetchItemDetailMinimalData 测试最小数据响应
 func TestFetchItemDetailMinimalData(t *testing.T) {
 	// 最小必需数据的响应 - 匹配实际 API 返回结构
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+)
+
+// 提醒类型
+const (
+	KindDeal        = "deal"          // 低于市场价
+	KindNewMatch    = "new_match"     // 关注的关键词/卖家出现新商品
+	KindPriceDrop   = "price_drop"    // 关注商品降价
+	KindBackInStock = "back_in_stock" // 关注商品重新在售
+	KindSold        = "sold"          // 关注商品已售出
+)
+
+// kindLabels 提醒类型的中文名称
+var kindLabels = map[string]string{
+	KindDeal:        "低价商品",
+	KindNewMatch:    "新商品",
+	KindPriceDrop:   "降价",
+	KindBackInStock: "重新在售",
+	KindSold:        "已售出",
+}
+
+// Alert 一条商品提醒
+type Alert struct {
+	Kind      string    `json:"kind"`
+	ItemID    string    `json:"itemId"`
+	Title     string    `json:"title"`
+	Price     float64   `json:"price"`
+	OldPrice  float64   `json:"oldPrice,omitempty"` // 原价（降价提醒）
+	WantCount int       `json:"wantCount,omitempty"`
+	Seller    string    `json:"seller,omitempty"`    // 卖家昵称
+	RiskLevel string    `json:"riskLevel,omitempty"` // 卖家风险等级 low/medium/high
+	URL       string    `json:"url"`                 // 商品详情链接
+	ImageURL  string    `json:"imageUrl,omitempty"`  // 封面图
+	Reason    string    `json:"reason,omitempty"`    // 提醒原因，如比价依据
+	Source    string    `json:"source,omitempty"`    // 来源，如关注项名称
+	At        time.Time `json:"at"`
+	Key       string    `json:"key,omitempty"` // 去重键（为空时按类型+商品+价格生成）
+}
+
+// KindLabel 提醒类型的中文名称
+func (a Alert) KindLabel() string {
+	if label, ok := kindLabels[a.Kind]; ok {
+		return label
+	}
+	return a.Kind
+}
+
+// DedupKey 去重键：同一商品同一价格的同类提醒只发送一次
+func (a Alert) DedupKey() string {
+	if a.Key != "" {
+		return a.Key
+	}
+	return fmt.Sprintf("%s:%s:%.2f", a.Kind, a.ItemID, a.Price)
+}
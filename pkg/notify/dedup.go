@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"xianyu_aner/pkg/util"
+)
+
+// errDedupCorrupt 去重记录文件内容无法解析
+var errDedupCorrupt = errors.New("去重记录文件已损坏")
+
+// dedupFile 去重记录文件格式：通道名 -> 去重键 -> 发送时间
+type dedupFile struct {
+	Channels map[string]map[string]time.Time `json:"channels"`
+}
+
+// loadDedupFile 读取去重记录文件，文件不存在时返回空记录
+func loadDedupFile(path string) (map[string]map[string]time.Time, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取去重记录失败: %w", err)
+	}
+	var file dedupFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %v", errDedupCorrupt, err)
+	}
+	return file.Channels, nil
+}
+
+// LoadDedup 从 RouterOptions.DedupPath 读取已发送提醒的去重记录（添加完通道后调用），过期记录忽略
+// crawl 每次运行都新建路由器，据此避免相邻两次运行重复提醒
+func (r *Router) LoadDedup() error {
+	if r == nil || r.opts.DedupPath == "" || r.opts.DedupTTL <= 0 {
+		return nil
+	}
+	saved, err := loadDedupFile(r.opts.DedupPath)
+	if err != nil {
+		return err
+	}
+	r.mergeDedup(saved)
+	return nil
+}
+
+// mergeDedup 将未过期的记录合并到各通道（同一去重键取较晚的发送时间）
+func (r *Router) mergeDedup(saved map[string]map[string]time.Time) {
+	now := r.now()
+	for _, ch := range r.channels {
+		keys := saved[ch.notifier.Name()]
+		if len(keys) == 0 {
+			continue
+		}
+		ch.mu.Lock()
+		for key, at := range keys {
+			if now.Sub(at) < r.opts.DedupTTL && at.After(ch.seen[key]) {
+				ch.seen[key] = at
+			}
+		}
+		ch.mu.Unlock()
+	}
+}
+
+// saveDedup 在文件锁内与文件中的记录合并后写回，保留其他进程（server 与 crawl）写入的记录
+// 文件无法解析时以当前记录覆盖（去重记录丢失只会导致重复提醒）
+func (r *Router) saveDedup() error {
+	if r.opts.DedupPath == "" || r.opts.DedupTTL <= 0 {
+		return nil
+	}
+	unlock, err := util.LockFile(r.opts.DedupPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	saved, err := loadDedupFile(r.opts.DedupPath)
+	if err != nil && !errors.Is(err, errDedupCorrupt) {
+		return err
+	}
+	r.mergeDedup(saved)
+
+	now := r.now()
+	live := func(src map[string]time.Time) map[string]time.Time {
+		dst := make(map[string]time.Time, len(src))
+		for key, at := range src {
+			if now.Sub(at) < r.opts.DedupTTL {
+				dst[key] = at
+			}
+		}
+		return dst
+	}
+	file := dedupFile{Channels: make(map[string]map[string]time.Time)}
+	for _, ch := range r.channels {
+		ch.mu.Lock()
+		file.Channels[ch.notifier.Name()] = live(ch.seen)
+		ch.mu.Unlock()
+	}
+	// 本进程未配置的通道原样保留
+	for name, keys := range saved {
+		if _, ok := file.Channels[name]; !ok {
+			file.Channels[name] = live(keys)
+		}
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化去重记录失败: %w", err)
+	}
+	if err := util.WriteFileAtomic(r.opts.DedupPath, data, 0644); err != nil {
+		return fmt.Errorf("写入去重记录失败: %w", err)
+	}
+	return nil
+}
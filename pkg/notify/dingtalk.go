@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DingTalk 钉钉自定义机器人
+type DingTalk struct {
+	name     string
+	webhook  string
+	secret   string // 加签密钥（为空时不签名）
+	template *Template
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewDingTalk 创建钉钉通道
+func NewDingTalk(name, webhook, secret string, tmpl *Template) *DingTalk {
+	if name == "" {
+		name = "dingtalk"
+	}
+	return &DingTalk{name: name, webhook: webhook, secret: secret, template: tmpl, now: time.Now}
+}
+
+// Name 通道名称
+func (d *DingTalk) Name() string { return d.name }
+
+// DingTalkSign 计算钉钉加签：base64(HmacSHA256(timestamp+"\n"+secret, secret))
+func DingTalkSign(timestamp int64, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signedURL 拼接签名参数后的 webhook 地址
+func (d *DingTalk) signedURL() string {
+	if d.secret == "" {
+		return d.webhook
+	}
+	ts := d.now().UnixMilli()
+	sep := "?"
+	if strings.Contains(d.webhook, "?") {
+		sep = "&"
+	}
+	return d.webhook + sep + "timestamp=" + strconv.FormatInt(ts, 10) + "&sign=" + url.QueryEscape(DingTalkSign(ts, d.secret))
+}
+
+// Send 以 Markdown 消息发送提醒
+func (d *DingTalk) Send(ctx context.Context, alert Alert) error {
+	msg, err := Render(d.template, alert)
+	if err != nil {
+		return err
+	}
+
+	var text strings.Builder
+	text.WriteString("### " + msg.Title + "\n\n")
+	if alert.ImageURL != "" {
+		text.WriteString("![封面](" + alert.ImageURL + ")\n\n")
+	}
+	text.WriteString(strings.ReplaceAll(msg.Body, "\n", "\n\n"))
+	if alert.URL != "" {
+		text.WriteString("\n\n[查看商品](" + alert.URL + ")")
+	}
+
+	payload := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": msg.Title,
+			"text":  text.String(),
+		},
+	}
+	body, err := postJSON(ctx, d.client, d.signedURL(), payload)
+	if err != nil {
+		return fmt.Errorf("钉钉发送失败: %w", err)
+	}
+	if err := checkErrcode(body); err != nil {
+		return fmt.Errorf("钉钉发送失败: %w", err)
+	}
+	return nil
+}
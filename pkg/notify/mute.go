@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"xianyu_aner/pkg/util"
+)
+
+// MuteList 屏蔽的卖家列表，屏蔽卖家的商品不再提醒
+type MuteList struct {
+	mu      sync.RWMutex
+	path    string // 持久化文件（为空时仅保存在内存）
+	sellers map[string]bool
+}
+
+// LoadMuteList 加载屏蔽列表，文件不存在时返回空列表
+func LoadMuteList(path string) (*MuteList, error) {
+	m := &MuteList{path: path, sellers: make(map[string]bool)}
+	if path == "" {
+		return m, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取屏蔽列表失败: %w", err)
+	}
+	var sellers []string
+	if err := json.Unmarshal(data, &sellers); err != nil {
+		return nil, fmt.Errorf("解析屏蔽列表失败: %w", err)
+	}
+	for _, s := range sellers {
+		m.sellers[normalizeSeller(s)] = true
+	}
+	return m, nil
+}
+
+// normalizeSeller 卖家名称规范化（忽略首尾空白与大小写）
+func normalizeSeller(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// Contains 卖家是否已屏蔽（nil 安全）
+func (m *MuteList) Contains(seller string) bool {
+	if m == nil || seller == "" {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sellers[normalizeSeller(seller)]
+}
+
+// Add 屏蔽卖家并保存，返回是否为新增
+func (m *MuteList) Add(seller string) (bool, error) {
+	key := normalizeSeller(seller)
+	if key == "" {
+		return false, fmt.Errorf("卖家不能为空")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sellers[key] {
+		return false, nil
+	}
+	m.sellers[key] = true
+	if err := m.saveLocked(); err != nil {
+		delete(m.sellers, key)
+		return false, err
+	}
+	return true, nil
+}
+
+// Remove 取消屏蔽并保存
+func (m *MuteList) Remove(seller string) error {
+	key := normalizeSeller(seller)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.sellers[key] {
+		return nil
+	}
+	delete(m.sellers, key)
+	return m.saveLocked()
+}
+
+// List 已屏蔽的卖家（按名称排序）
+func (m *MuteList) List() []string {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sellers := make([]string, 0, len(m.sellers))
+	for s := range m.sellers {
+		sellers = append(sellers, s)
+	}
+	sort.Strings(sellers)
+	return sellers
+}
+
+// saveLocked 写入文件（先写唯一的临时文件再重命名），调用方需持有写锁，整个写入过程在锁内完成
+func (m *MuteList) saveLocked() error {
+	if m.path == "" {
+		return nil
+	}
+	sellers := make([]string, 0, len(m.sellers))
+	for s := range m.sellers {
+		sellers = append(sellers, s)
+	}
+	sort.Strings(sellers)
+	data, err := json.MarshalIndent(sellers, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化屏蔽列表失败: %w", err)
+	}
+	if err := util.WriteFileAtomic(m.path, data, 0644); err != nil {
+		return fmt.Errorf("写入屏蔽列表失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Notifier 消息通道
+type Notifier interface {
+	// Name 通道名称（用于日志与统计）
+	Name() string
+	// Send 发送一条提醒
+	Send(ctx context.Context, alert Alert) error
+}
+
+// Message 渲染后的消息，供各通道组装请求体
+type Message struct {
+	Title string
+	Body  string
+	Alert Alert
+}
+
+// Render 用模板渲染提醒（tmpl 为 nil 时使用默认模板）
+func Render(tmpl *Template, alert Alert) (Message, error) {
+	if tmpl == nil {
+		tmpl = defaultTemplate
+	}
+	body, err := tmpl.Body(alert)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Title: tmpl.Title(alert), Body: body, Alert: alert}, nil
+}
+
+// defaultHTTPClient 通道默认使用的 HTTP 客户端
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// postJSON 发送 JSON 请求并返回响应体，非 2xx 状态码视为失败
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) ([]byte, error) {
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化消息失败: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return body, fmt.Errorf("HTTP %d: %s", resp.StatusCode, truncate(200, string(body)))
+	}
+	return body, nil
+}
+
+// checkErrcode 检查钉钉/企业微信风格的 {"errcode":0,"errmsg":"ok"} 响应
+func checkErrcode(body []byte) error {
+	var resp struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("errcode=%d, errmsg=%s", resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookServer 记录收到的请求并返回固定响应
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bodies   []map[string]interface{}
+	queries  []string
+	response string
+	status   int
+}
+
+func newWebhookServer(t *testing.T, response string) *webhookServer {
+	t.Helper()
+	s := &webhookServer{response: response, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		json.Unmarshal(data, &body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, body)
+		s.queries = append(s.queries, r.URL.RawQuery)
+		status := s.status
+		s.mu.Unlock()
+		w.WriteHeader(status)
+		io.WriteString(w, s.response)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func testAlert() Alert {
+	return Alert{
+		Kind:      KindDeal,
+		ItemID:    "123",
+		Title:     "索尼 A7M3 单机身",
+		Price:     6800,
+		WantCount: 12,
+		URL:       "https://www.goofish.com/item?id=123",
+		ImageURL:  "https://img.example.com/a.jpg",
+		Reason:    "低于中位价 20%",
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	body, err := defaultTemplate.Body(Alert{Title: "镜头", Price: 1299.5, OldPrice: 1500, WantCount: 3})
+	if err != nil {
+		t.Fatalf("Body() error = %v", err)
+	}
+	for _, want := range []string{"¥1299.5", "原价 ¥1500", "想要: 3 人"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("正文缺少 %q: %s", want, body)
+		}
+	}
+
+	tmpl, err := ParseTemplate("{{.Title}} 仅 {{price .Price}} 元")
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if got, _ := tmpl.Body(Alert{Title: "相机", Price: 88}); got != "相机 仅 88 元" {
+		t.Errorf("自定义模板 = %q", got)
+	}
+	if got := tmpl.Title(Alert{Kind: KindPriceDrop, Title: "相机"}); got != "【降价】相机" {
+		t.Errorf("Title() = %q", got)
+	}
+	if _, err := ParseTemplate("{{.Title"); err == nil {
+		t.Error("无效模板应返回错误")
+	}
+}
+
+func TestDingTalk_Sign(t *testing.T) {
+	srv := newWebhookServer(t, `{"errcode":0,"errmsg":"ok"}`)
+	d := NewDingTalk("", srv.URL+"/robot/send?access_token=abc", "SECtest", nil)
+	d.now = func() time.Time { return time.UnixMilli(1700000000000) }
+
+	if err := d.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	q, _ := url.ParseQuery(srv.queries[0])
+	if q.Get("access_token") != "abc" || q.Get("timestamp") != "1700000000000" || q.Get("sign") != DingTalkSign(1700000000000, "SECtest") {
+		t.Errorf("签名参数错误: %s", srv.queries[0])
+	}
+
+	md := srv.bodies[0]["markdown"].(map[string]interface{})
+	text := md["text"].(string)
+	for _, want := range []string{"【低价商品】索尼", "![封面](https://img.example.com/a.jpg)", "想要: 12 人", "item?id=123"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("消息缺少 %q: %s", want, text)
+		}
+	}
+
+	// errcode 非 0 视为失败
+	srv.response = `{"errcode":310000,"errmsg":"sign not match"}`
+	if err := d.Send(context.Background(), testAlert()); err == nil || !strings.Contains(err.Error(), "310000") {
+		t.Errorf("errcode 非 0 应返回错误, got %v", err)
+	}
+}
+
+func TestWeComAndSlack(t *testing.T) {
+	wecomSrv := newWebhookServer(t, `{"errcode":0,"errmsg":"ok"}`)
+	if err := NewWeCom("", wecomSrv.URL, nil).Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("WeCom Send() error = %v", err)
+	}
+	article := wecomSrv.bodies[0]["news"].(map[string]interface{})["articles"].([]interface{})[0].(map[string]interface{})
+	if article["picurl"] != "https://img.example.com/a.jpg" || article["url"] != "https://www.goofish.com/item?id=123" {
+		t.Errorf("图文消息错误: %v", article)
+	}
+
+	slackSrv := newWebhookServer(t, "ok")
+	slack := NewSlack("", slackSrv.URL, nil)
+	if err := slack.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Slack Send() error = %v", err)
+	}
+	block := slackSrv.bodies[0]["blocks"].([]interface{})[0].(map[string]interface{})
+	if block["accessory"].(map[string]interface{})["image_url"] != "https://img.example.com/a.jpg" {
+		t.Errorf("Slack 消息缺少封面: %v", block)
+	}
+	slackSrv.status = http.StatusForbidden
+	if err := slack.Send(context.Background(), testAlert()); err == nil {
+		t.Error("非 200 响应应返回错误")
+	}
+}
+
+// fakeNotifier 记录发送的提醒
+type fakeNotifier struct {
+	name string
+	err  error
+	mu   sync.Mutex
+	sent []Alert
+}
+
+func (f *fakeNotifier) Name() string { return f.name }
+
+func (f *fakeNotifier) Send(ctx context.Context, a Alert) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, a)
+	return nil
+}
+
+func TestRouter_DedupAndKinds(t *testing.T) {
+	all := &fakeNotifier{name: "all"}
+	deals := &fakeNotifier{name: "deals"}
+	broken := &fakeNotifier{name: "broken", err: io.ErrUnexpectedEOF}
+
+	r := NewRouter(RouterOptions{DedupTTL: time.Hour})
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.Local)
+	r.now = func() time.Time { return now }
+	r.Add(all, Route{})
+	r.Add(deals, Route{Kinds: []string{KindDeal}})
+	r.Add(broken, Route{})
+
+	ctx := context.Background()
+	rep := r.Notify(ctx, testAlert())
+	if rep.Sent != 2 || rep.Failed != 1 || rep.Errors["broken"] == "" {
+		t.Errorf("首次发送 = %+v", rep)
+	}
+
+	// 相同提醒被去重；失败的通道允许重试
+	rep = r.Notify(ctx, testAlert())
+	if rep.Deduped != 2 || rep.Failed != 1 {
+		t.Errorf("重复发送 = %+v", rep)
+	}
+
+	// 价格变化视为新提醒；类型不匹配的通道跳过
+	drop := testAlert()
+	drop.Kind = KindPriceDrop
+	drop.Price = 6500
+	rep = r.Notify(ctx, drop)
+	if rep.Sent != 1 || rep.Skipped != 1 {
+		t.Errorf("降价提醒 = %+v", rep)
+	}
+
+	// 去重过期后再次发送
+	now = now.Add(2 * time.Hour)
+	if rep := r.Notify(ctx, testAlert()); rep.Sent != 2 {
+		t.Errorf("去重过期后 = %+v", rep)
+	}
+	if len(all.sent) != 3 || len(deals.sent) != 2 {
+		t.Errorf("发送次数 all=%d deals=%d", len(all.sent), len(deals.sent))
+	}
+}
+
+// crawl 每次运行新建路由器：去重记录持久化后，下次运行不再重复提醒
+func TestRouter_PersistedDedup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify_dedup.json")
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.Local)
+	newRouter := func(n Notifier) *Router {
+		r := NewRouter(RouterOptions{DedupTTL: time.Hour, DedupPath: path})
+		r.now = func() time.Time { return now }
+		r.Add(n, Route{})
+		if err := r.LoadDedup(); err != nil {
+			t.Fatalf("LoadDedup() error = %v", err)
+		}
+		return r
+	}
+	ctx := context.Background()
+
+	first := &fakeNotifier{name: "n"}
+	if rep := newRouter(first).Notify(ctx, testAlert()); rep.Sent != 1 {
+		t.Fatalf("首次运行 = %+v", rep)
+	}
+
+	second := &fakeNotifier{name: "n"}
+	r := newRouter(second)
+	if rep := r.Notify(ctx, testAlert()); rep.Deduped != 1 || len(second.sent) != 0 {
+		t.Errorf("第二次运行应去重: %+v", rep)
+	}
+	drop := testAlert()
+	drop.Price = 6500
+	if rep := r.Notify(ctx, drop); rep.Sent != 1 {
+		t.Errorf("新提醒 = %+v", rep)
+	}
+
+	// 第三次运行：两条记录都已保存；过期后再次发送
+	third := &fakeNotifier{name: "n"}
+	r = newRouter(third)
+	if rep := r.NotifyAll(ctx, []Alert{testAlert(), drop}); rep.Deduped != 2 {
+		t.Errorf("第三次运行 = %+v", rep)
+	}
+	now = now.Add(2 * time.Hour)
+	if rep := newRouter(third).Notify(ctx, testAlert()); rep.Sent != 1 {
+		t.Errorf("去重过期后 = %+v", rep)
+	}
+
+	// 损坏的文件不影响发送，下次保存时覆盖
+	os.WriteFile(path, []byte("{"), 0644)
+	r = NewRouter(RouterOptions{DedupTTL: time.Hour, DedupPath: path})
+	r.Add(&fakeNotifier{name: "n"}, Route{})
+	if err := r.LoadDedup(); err == nil {
+		t.Error("损坏的去重记录应返回错误")
+	}
+	if rep := r.Notify(ctx, drop); rep.Sent != 1 {
+		t.Errorf("损坏文件时发送 = %+v", rep)
+	}
+	if _, err := loadDedupFile(path); err != nil {
+		t.Errorf("保存后应可读取: %v", err)
+	}
+}
+
+func TestRouter_RateLimit(t *testing.T) {
+	l := newLimiter(2, time.Second)
+	start := time.Now()
+	if w := l.reserve(start); w != 0 {
+		t.Errorf("第1个令牌等待 %v", w)
+	}
+	l.reserve(start)
+	if w := l.reserve(start); w < 400*time.Millisecond || w > 600*time.Millisecond {
+		t.Errorf("令牌耗尽后等待 %v, want 约 500ms", w)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx); err == nil {
+		t.Error("ctx 取消后 Wait 应返回错误")
+	}
+
+	srv := newWebhookServer(t, "ok")
+	r := NewRouter(RouterOptions{})
+	r.Add(NewSlack("", srv.URL, nil), Route{RatePerMinute: 600})
+	rep := r.NotifyAll(context.Background(), []Alert{testAlert(), {Kind: KindSold, ItemID: "9", Title: "x"}})
+	if rep.Sent != 2 || srv.count() != 2 {
+		t.Errorf("NotifyAll = %+v, 请求数 %d", rep, srv.count())
+	}
+}
+
+func TestRouter_MuteList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "muted.json")
+	muted, err := LoadMuteList(path)
+	if err != nil {
+		t.Fatalf("LoadMuteList() error = %v", err)
+	}
+	n := &fakeNotifier{name: "n"}
+	r := NewRouter(RouterOptions{Muted: muted})
+	r.Add(n, Route{})
+
+	alert := testAlert()
+	alert.Seller = "数码小王"
+	if added, err := muted.Add(" 数码小王 "); !added || err != nil {
+		t.Fatalf("Add() = %v, %v", added, err)
+	}
+	if added, _ := muted.Add("数码小王"); added {
+		t.Error("重复屏蔽应返回 false")
+	}
+	if rep := r.Notify(context.Background(), alert); rep.Muted != 1 || len(n.sent) != 0 {
+		t.Errorf("屏蔽卖家仍发送: %+v", rep)
+	}
+
+	// 重新加载后仍生效，取消屏蔽后恢复发送
+	reloaded, err := LoadMuteList(path)
+	if err != nil || !reloaded.Contains("数码小王") {
+		t.Fatalf("重新加载屏蔽列表失败: %v, %v", reloaded.List(), err)
+	}
+	if err := muted.Remove("数码小王"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if rep := r.Notify(context.Background(), alert); rep.Sent != 1 {
+		t.Errorf("取消屏蔽后 = %+v", rep)
+	}
+}
+
+// TestMuteList_ConcurrentAdd 测试并发屏蔽（多个卡片回调同时到达）时全部写入文件
+func TestMuteList_ConcurrentAdd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "muted.json")
+	muted, _ := LoadMuteList(path)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := muted.Add(fmt.Sprintf("卖家%d", i)); err != nil {
+				t.Errorf("Add() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	reloaded, err := LoadMuteList(path)
+	if err != nil || len(reloaded.List()) != 20 {
+		t.Fatalf("重新加载屏蔽列表 = %v, %v", reloaded.List(), err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("临时文件未清理: %v", entries)
+	}
+}
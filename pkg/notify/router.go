@@ -0,0 +1,251 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"xianyu_aner/pkg/util"
+)
+
+// Route 通道路由规则
+type Route struct {
+	Kinds         []string // 接收的提醒类型（为空表示全部）
+	RatePerMinute int      // 每分钟最多发送条数（<=0 表示不限）
+}
+
+// RouterOptions 路由器配置
+type RouterOptions struct {
+	DedupTTL  time.Duration // 同一提醒在此时间内不重复发送（<=0 表示不去重）
+	DedupPath string        // 去重记录文件（为空时仅在内存中去重，见 LoadDedup）
+	Muted     *MuteList     // 屏蔽的卖家（为 nil 时不过滤）
+}
+
+// Report 一次通知的发送结果
+type Report struct {
+	Sent    int               `json:"sent"`
+	Deduped int               `json:"deduped"`
+	Skipped int               `json:"skipped"` // 类型不匹配
+	Muted   int               `json:"muted"`   // 卖家已屏蔽
+	Failed  int               `json:"failed"`
+	Errors  map[string]string `json:"errors,omitempty"` // 通道名 -> 错误
+}
+
+// channel 路由器中的一个通道
+type channel struct {
+	notifier Notifier
+	kinds    map[string]bool
+	limiter  *limiter
+
+	mu   sync.Mutex
+	seen map[string]time.Time // 去重键 -> 发送时间
+}
+
+// Router 将提醒分发到多个通道，按通道去重与限流
+type Router struct {
+	opts     RouterOptions
+	channels []*channel
+	now      func() time.Time
+}
+
+// NewRouter 创建路由器
+func NewRouter(opts RouterOptions) *Router {
+	return &Router{opts: opts, now: time.Now}
+}
+
+// Add 添加通道
+func (r *Router) Add(n Notifier, route Route) {
+	ch := &channel{notifier: n, seen: make(map[string]time.Time)}
+	if len(route.Kinds) > 0 {
+		ch.kinds = make(map[string]bool, len(route.Kinds))
+		for _, k := range route.Kinds {
+			ch.kinds[k] = true
+		}
+	}
+	if route.RatePerMinute > 0 {
+		ch.limiter = newLimiter(route.RatePerMinute, time.Minute)
+	}
+	r.channels = append(r.channels, ch)
+}
+
+// Len 通道数量
+func (r *Router) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.channels)
+}
+
+// MuteList 路由器使用的屏蔽列表
+func (r *Router) MuteList() *MuteList {
+	if r == nil {
+		return nil
+	}
+	return r.opts.Muted
+}
+
+// Notify 将提醒并发发送到所有匹配的通道（卖家已屏蔽时不发送）
+func (r *Router) Notify(ctx context.Context, alert Alert) Report {
+	report := Report{}
+	if r == nil {
+		return report
+	}
+	if r.opts.Muted.Contains(alert.Seller) {
+		report.Muted++
+		return report
+	}
+	if alert.At.IsZero() {
+		alert.At = r.now()
+	}
+	key := alert.DedupKey()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, ch := range r.channels {
+		if ch.kinds != nil && !ch.kinds[alert.Kind] {
+			report.Skipped++
+			continue
+		}
+		if !ch.claim(key, r.now(), r.opts.DedupTTL) {
+			report.Deduped++
+			continue
+		}
+
+		wg.Add(1)
+		go func(ch *channel) {
+			defer wg.Done()
+			err := ch.send(ctx, alert)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				ch.release(key)
+				report.Failed++
+				if report.Errors == nil {
+					report.Errors = make(map[string]string)
+				}
+				report.Errors[ch.notifier.Name()] = err.Error()
+				return
+			}
+			report.Sent++
+		}(ch)
+	}
+	wg.Wait()
+
+	if report.Sent > 0 {
+		if err := r.saveDedup(); err != nil {
+			util.Printf("[通知] 保存去重记录失败: %v\n", err)
+		}
+	}
+	return report
+}
+
+// NotifyAll 依次发送多条提醒并汇总结果
+func (r *Router) NotifyAll(ctx context.Context, alerts []Alert) Report {
+	total := Report{}
+	for _, a := range alerts {
+		rep := r.Notify(ctx, a)
+		total.Sent += rep.Sent
+		total.Deduped += rep.Deduped
+		total.Skipped += rep.Skipped
+		total.Failed += rep.Failed
+		total.Muted += rep.Muted
+		for name, e := range rep.Errors {
+			if total.Errors == nil {
+				total.Errors = make(map[string]string)
+			}
+			total.Errors[name] = e
+		}
+	}
+	return total
+}
+
+// claim 检查去重并占用去重键，返回 false 表示已发送过
+func (c *channel) claim(key string, now time.Time, ttl time.Duration) bool {
+	if ttl <= 0 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if at, ok := c.seen[key]; ok && now.Sub(at) < ttl {
+		return false
+	}
+	// 顺带清理过期记录
+	for k, at := range c.seen {
+		if now.Sub(at) >= ttl {
+			delete(c.seen, k)
+		}
+	}
+	c.seen[key] = now
+	return true
+}
+
+// release 发送失败时释放去重键，允许下次重试
+func (c *channel) release(key string) {
+	c.mu.Lock()
+	delete(c.seen, key)
+	c.mu.Unlock()
+}
+
+// send 限流后发送
+func (c *channel) send(ctx context.Context, alert Alert) error {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("等待限流失败: %w", err)
+		}
+	}
+	return c.notifier.Send(ctx, alert)
+}
+
+// limiter 令牌桶限流器：容量为 n，每 per/n 补充一个令牌
+type limiter struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	interval time.Duration // 每个令牌的补充间隔
+	last     time.Time
+}
+
+func newLimiter(n int, per time.Duration) *limiter {
+	return &limiter{
+		capacity: float64(n),
+		tokens:   float64(n),
+		interval: per / time.Duration(n),
+		last:     time.Now(),
+	}
+}
+
+// reserve 取一个令牌，返回需要等待的时间
+func (l *limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens * float64(l.interval))
+}
+
+// Wait 阻塞直到获得令牌或 ctx 结束
+func (l *limiter) Wait(ctx context.Context) error {
+	wait := l.reserve(time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
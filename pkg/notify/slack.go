@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Slack Slack Incoming Webhook
+type Slack struct {
+	name     string
+	webhook  string
+	template *Template
+	client   *http.Client
+}
+
+// NewSlack 创建 Slack 通道
+func NewSlack(name, webhook string, tmpl *Template) *Slack {
+	if name == "" {
+		name = "slack"
+	}
+	return &Slack{name: name, webhook: webhook, template: tmpl}
+}
+
+// Name 通道名称
+func (s *Slack) Name() string { return s.name }
+
+// Send 以 Block Kit 消息发送提醒（Slack 成功时返回 200 "ok"）
+func (s *Slack) Send(ctx context.Context, alert Alert) error {
+	msg, err := Render(s.template, alert)
+	if err != nil {
+		return err
+	}
+
+	title := "*" + msg.Title + "*"
+	if alert.URL != "" {
+		title = "*<" + alert.URL + "|" + msg.Title + ">*"
+	}
+	section := map[string]interface{}{
+		"type": "section",
+		"text": map[string]string{"type": "mrkdwn", "text": title + "\n" + msg.Body},
+	}
+	if alert.ImageURL != "" {
+		section["accessory"] = map[string]string{
+			"type":      "image",
+			"image_url": alert.ImageURL,
+			"alt_text":  truncate(40, alert.Title),
+		}
+	}
+	payload := map[string]interface{}{
+		"text":   msg.Title,
+		"blocks": []interface{}{section},
+	}
+
+	if _, err := postJSON(ctx, s.client, s.webhook, payload); err != nil {
+		return fmt.Errorf("Slack 发送失败: %w", err)
+	}
+	return nil
+}
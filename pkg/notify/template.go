@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultTemplate 默认消息模板（Markdown），可用字段见 Alert
+const DefaultTemplate = `{{if .Source}}[{{.Source}}] {{end}}{{.Title}}
+价格: ¥{{price .Price}}{{if gt .OldPrice 0.0}}（原价 ¥{{price .OldPrice}}）{{end}}{{if gt .WantCount 0}}
+想要: {{.WantCount}} 人{{end}}{{if .Reason}}
+{{.Reason}}{{end}}`
+
+// Template 消息模板：标题固定为 "【类型】商品标题"，正文由模板渲染
+type Template struct {
+	tmpl *template.Template
+}
+
+// templateFuncs 模板函数
+var templateFuncs = template.FuncMap{
+	"price": func(v float64) string {
+		s := fmt.Sprintf("%.2f", v)
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+		return s
+	},
+	"truncate": truncate,
+}
+
+// truncate 按字符截断文本
+func truncate(n int, s string) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
+
+// ParseTemplate 解析消息模板，text 为空时使用 DefaultTemplate
+func ParseTemplate(text string) (*Template, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New("alert").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析消息模板失败: %w", err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// defaultTemplate 默认模板（包初始化时解析，保证可用）
+var defaultTemplate = func() *Template {
+	t, err := ParseTemplate("")
+	if err != nil {
+		panic(err)
+	}
+	return t
+}()
+
+// Title 消息标题
+func (t *Template) Title(a Alert) string {
+	return fmt.Sprintf("【%s】%s", a.KindLabel(), truncate(40, a.Title))
+}
+
+// Body 渲染消息正文
+func (t *Template) Body(a Alert) (string, error) {
+	if t == nil {
+		t = defaultTemplate
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, a); err != nil {
+		return "", fmt.Errorf("渲染消息模板失败: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// WeCom 企业微信群机器人
+type WeCom struct {
+	name     string
+	webhook  string
+	template *Template
+	client   *http.Client
+}
+
+// NewWeCom 创建企业微信通道（群机器人通过 webhook key 鉴权，无需签名）
+func NewWeCom(name, webhook string, tmpl *Template) *WeCom {
+	if name == "" {
+		name = "wecom"
+	}
+	return &WeCom{name: name, webhook: webhook, template: tmpl}
+}
+
+// Name 通道名称
+func (w *WeCom) Name() string { return w.name }
+
+// Send 以图文消息发送提醒（无封面时使用 Markdown 消息）
+func (w *WeCom) Send(ctx context.Context, alert Alert) error {
+	msg, err := Render(w.template, alert)
+	if err != nil {
+		return err
+	}
+
+	var payload map[string]interface{}
+	if alert.ImageURL != "" && alert.URL != "" {
+		payload = map[string]interface{}{
+			"msgtype": "news",
+			"news": map[string]interface{}{
+				"articles": []map[string]string{{
+					"title":       msg.Title,
+					"description": truncate(120, msg.Body),
+					"url":         alert.URL,
+					"picurl":      alert.ImageURL,
+				}},
+			},
+		}
+	} else {
+		content := "**" + msg.Title + "**\n" + msg.Body
+		if alert.URL != "" {
+			content += "\n[查看商品](" + alert.URL + ")"
+		}
+		payload = map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"content": content},
+		}
+	}
+
+	body, err := postJSON(ctx, w.client, w.webhook, payload)
+	if err != nil {
+		return fmt.Errorf("企业微信发送失败: %w", err)
+	}
+	if err := checkErrcode(body); err != nil {
+		return fmt.Errorf("企业微信发送失败: %w", err)
+	}
+	return nil
+}
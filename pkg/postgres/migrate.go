@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockID 迁移使用的事务级 advisory lock，避免多个 crawl 进程同时建表
+const migrationLockID = 0x78696179 // "xiay"
+
+// migration 数据库结构版本
+// 已应用的版本记录在 xianyu_schema_migrations 表中，只能追加新版本，不能修改已发布的版本
+type migration struct {
+	version int
+	name    string
+	stmts   []string
+}
+
+var migrations = []migration{
+	{1, "初始结构", []string{
+		`CREATE TABLE xianyu_products (
+			item_id       TEXT PRIMARY KEY,
+			title         TEXT          NOT NULL DEFAULT '',
+			price         TEXT          NOT NULL DEFAULT '',
+			price_value   NUMERIC(12,2) NOT NULL DEFAULT 0,
+			want_count    INTEGER       NOT NULL DEFAULT 0,
+			view_count    INTEGER       NOT NULL DEFAULT 0,
+			collect_count INTEGER       NOT NULL DEFAULT 0,
+			category_id   BIGINT        NOT NULL DEFAULT 0,
+			location      TEXT          NOT NULL DEFAULT '',
+			seller_nick   TEXT          NOT NULL DEFAULT '',
+			status        TEXT          NOT NULL DEFAULT '',
+			condition     TEXT          NOT NULL DEFAULT '',
+			tags          TEXT[]        NOT NULL DEFAULT '{}',
+			free_shipping BOOLEAN       NOT NULL DEFAULT FALSE,
+			image_url     TEXT          NOT NULL DEFAULT '',
+			video_url     TEXT          NOT NULL DEFAULT '',
+			publish_time  TIMESTAMPTZ,
+			first_seen    TIMESTAMPTZ   NOT NULL,
+			last_seen     TIMESTAMPTZ   NOT NULL,
+			seen_count    INTEGER       NOT NULL DEFAULT 1
+		)`,
+		`CREATE INDEX xianyu_products_last_seen_idx ON xianyu_products (last_seen)`,
+		`CREATE INDEX xianyu_products_seller_idx ON xianyu_products (seller_nick)`,
+		`CREATE TABLE xianyu_observations (
+			item_id       TEXT          NOT NULL REFERENCES xianyu_products (item_id) ON DELETE CASCADE,
+			captured_at   TIMESTAMPTZ   NOT NULL,
+			price         TEXT          NOT NULL DEFAULT '',
+			price_value   NUMERIC(12,2) NOT NULL DEFAULT 0,
+			want_count    INTEGER       NOT NULL DEFAULT 0,
+			view_count    INTEGER       NOT NULL DEFAULT 0,
+			collect_count INTEGER       NOT NULL DEFAULT 0,
+			status        TEXT          NOT NULL DEFAULT '',
+			source        TEXT          NOT NULL DEFAULT '',
+			PRIMARY KEY (item_id, captured_at)
+		)`,
+		`CREATE INDEX xianyu_observations_captured_idx ON xianyu_observations (captured_at)`,
+		`CREATE TABLE xianyu_skus (
+			item_id      TEXT        NOT NULL,
+			sku_id       BIGINT      NOT NULL,
+			inventory_id BIGINT      NOT NULL DEFAULT 0,
+			price_cent   INTEGER     NOT NULL DEFAULT 0,
+			quantity     INTEGER     NOT NULL DEFAULT 0,
+			spec         TEXT        NOT NULL DEFAULT '',
+			properties   JSONB       NOT NULL DEFAULT '[]',
+			updated_at   TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (item_id, sku_id)
+		)`,
+	}},
+}
+
+// SchemaVersion 当前代码对应的数据库结构版本
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate 在一个事务中执行全部未应用的版本（PostgreSQL 的 DDL 支持事务，失败时整体回滚）
+func migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("获取迁移锁失败: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS xianyu_schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT        NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`); err != nil {
+		return fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+
+	var current int
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM xianyu_schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("读取数据库版本失败: %w", err)
+	}
+	if current > SchemaVersion() {
+		return fmt.Errorf("数据库版本 %d 高于程序支持的版本 %d，请升级程序", current, SchemaVersion())
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		for _, stmt := range m.stmts {
+			if _, err := tx.Exec(ctx, stmt); err != nil {
+				return fmt.Errorf("数据库迁移 %d（%s）失败: %w", m.version, m.name, err)
+			}
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO xianyu_schema_migrations (version, name) VALUES ($1, $2)`,
+			m.version, m.name); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
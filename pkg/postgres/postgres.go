@@ -0,0 +1,451 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+)
+
+// DefaultBatchSize 默认每条 INSERT 语句的最大行数
+const DefaultBatchSize = 500
+
+// maxParams PostgreSQL 单条语句的参数上限
+const maxParams = 65535
+
+// Options 连接选项
+type Options struct {
+	DSN       string // 连接串（URL 或 key=value 格式）
+	MaxConns  int    // 连接池最大连接数，<=0 时使用 pgxpool 默认值
+	BatchSize int    // 每条 INSERT 语句的最大行数，<=0 时使用 DefaultBatchSize
+}
+
+// Store 写入 PostgreSQL 数据仓库：商品（按商品ID更新）、观测（按商品ID + 采集时间更新）与 SKU
+type Store struct {
+	pool      *pgxpool.Pool
+	batchSize int
+}
+
+// Result 写入统计
+type Result struct {
+	Inserted     int `json:"inserted"`     // 新增商品数
+	Updated      int `json:"updated"`      // 已有商品更新数
+	Observations int `json:"observations"` // 写入的观测数
+	SKUs         int `json:"skus"`         // 写入的 SKU 数
+}
+
+// Open 创建连接池、检查连接并执行迁移
+func Open(ctx context.Context, opts Options) (*Store, error) {
+	cfg, err := pgxpool.ParseConfig(opts.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("解析连接串失败: %w", err)
+	}
+	if opts.MaxConns > 0 {
+		cfg.MaxConns = int32(opts.MaxConns)
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("创建连接池失败: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+	if err := migrate(ctx, pool); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &Store{pool: pool, batchSize: batchSize}, nil
+}
+
+// Close 关闭连接池
+func (s *Store) Close() {
+	s.pool.Close()
+}
+
+// UpsertItems 在一个事务中写入一批猜你喜欢商品，at 为采集时间
+func (s *Store) UpsertItems(ctx context.Context, items []mtop.FeedItem, at time.Time) (Result, error) {
+	var products []product
+	var observations []history.Observation
+	for _, item := range items {
+		if item.ItemID == "" {
+			continue
+		}
+		products = append(products, productFromFeedItem(item, at))
+		observations = append(observations, history.FromFeedItem(item, at))
+	}
+	return s.write(ctx, products, observations, nil, nil, at)
+}
+
+// UpsertDetails 在一个事务中写入一批商品详情（含收藏数），并用详情中的 SKU 列表替换商品原有的 SKU
+func (s *Store) UpsertDetails(ctx context.Context, details []*mtop.ItemDetail, at time.Time) (Result, error) {
+	var products []product
+	var observations []history.Observation
+	var skus []sku
+	var itemIDs []string
+	for _, detail := range details {
+		if detail == nil || detail.ItemID == "" {
+			continue
+		}
+		products = append(products, productFromDetail(detail, at))
+		observations = append(observations, history.FromItemDetail(detail, at))
+		skus = append(skus, skusFromDetail(detail)...)
+		itemIDs = append(itemIDs, detail.ItemID)
+	}
+	return s.write(ctx, products, observations, skus, itemIDs, at)
+}
+
+// write 分批写入商品、观测与 SKU；skuItems 为需要替换 SKU 的商品（本次未出现的 SKU 会被删除）
+func (s *Store) write(ctx context.Context, products []product, observations []history.Observation,
+	skus []sku, skuItems []string, at time.Time) (Result, error) {
+	var result Result
+	if len(products) == 0 {
+		return result, nil
+	}
+
+	// 同一条 ON CONFLICT DO UPDATE 语句不能两次更新同一行，批内按主键去重（保留最后一次）
+	products = dedupe(products, func(p product) string { return p.ItemID })
+	observations = dedupe(observations, func(o history.Observation) string {
+		return o.ItemID + "/" + strconv.FormatInt(o.CapturedAt, 10)
+	})
+	skus = dedupe(skus, func(k sku) string { return k.ItemID + "/" + strconv.FormatInt(k.SKUID, 10) })
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = s.batches(len(products), len(productColumns), func(lo, hi int) error {
+		return upsertProducts(ctx, tx, products[lo:hi], &result)
+	})
+	if err != nil {
+		return result, fmt.Errorf("写入商品失败: %w", err)
+	}
+	err = s.batches(len(observations), len(observationColumns), func(lo, hi int) error {
+		n, err := upsertObservations(ctx, tx, observations[lo:hi])
+		result.Observations += n
+		return err
+	})
+	if err != nil {
+		return result, fmt.Errorf("写入观测失败: %w", err)
+	}
+	err = s.batches(len(skus), len(skuColumns), func(lo, hi int) error {
+		n, err := upsertSKUs(ctx, tx, skus[lo:hi], at)
+		result.SKUs += n
+		return err
+	})
+	if err != nil {
+		return result, fmt.Errorf("写入 SKU 失败: %w", err)
+	}
+	if len(skuItems) > 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM xianyu_skus WHERE item_id = ANY($1) AND updated_at < $2`,
+			skuItems, at); err != nil {
+			return result, fmt.Errorf("清理 SKU 失败: %w", err)
+		}
+	}
+	return result, tx.Commit(ctx)
+}
+
+// batches 将 n 行按批次大小（同时不超过参数上限）分段执行
+func (s *Store) batches(n, columns int, fn func(lo, hi int) error) error {
+	size := s.batchSize
+	if size*columns > maxParams {
+		size = maxParams / columns
+	}
+	for lo := 0; lo < n; lo += size {
+		hi := lo + size
+		if hi > n {
+			hi = n
+		}
+		if err := fn(lo, hi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// product 商品表的一行
+type product struct {
+	ItemID       string
+	Title        string
+	Price        string
+	PriceValue   float64
+	WantCount    int
+	ViewCount    int
+	CollectCount int
+	CategoryID   int
+	Location     string
+	SellerNick   string
+	Status       string
+	Condition    string
+	Tags         []string
+	FreeShipping bool
+	ImageURL     string
+	VideoURL     string
+	PublishTime  *time.Time
+	SeenAt       time.Time
+}
+
+var productColumns = []string{
+	"item_id", "title", "price", "price_value", "want_count", "view_count", "collect_count",
+	"category_id", "location", "seller_nick", "status", "condition", "tags", "free_shipping",
+	"image_url", "video_url", "publish_time", "first_seen", "last_seen",
+}
+
+func (p product) values() []any {
+	tags := p.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return []any{
+		p.ItemID, p.Title, p.Price, p.PriceValue, p.WantCount, p.ViewCount, p.CollectCount,
+		p.CategoryID, p.Location, p.SellerNick, p.Status, p.Condition, tags, p.FreeShipping,
+		p.ImageURL, p.VideoURL, p.PublishTime, p.SeenAt, p.SeenAt,
+	}
+}
+
+func productFromFeedItem(item mtop.FeedItem, at time.Time) product {
+	return product{
+		ItemID:       item.ItemID,
+		Title:        item.Title,
+		Price:        item.Price,
+		PriceValue:   history.ParsePrice(item.Price),
+		WantCount:    item.WantCount,
+		ViewCount:    item.ViewCount,
+		CategoryID:   item.CategoryID,
+		Location:     item.Location,
+		SellerNick:   item.SellerNick,
+		Status:       item.Status,
+		Condition:    item.Condition,
+		Tags:         item.Tags,
+		FreeShipping: item.FreeShipping,
+		ImageURL:     item.ImageURL,
+		VideoURL:     item.VideoURL,
+		PublishTime:  timestamp(item.PublishTimeTS),
+		SeenAt:       at,
+	}
+}
+
+func productFromDetail(detail *mtop.ItemDetail, at time.Time) product {
+	status := detail.ItemStatusStr
+	if status == "" {
+		status = detail.Status
+	}
+	return product{
+		ItemID:       detail.ItemID,
+		Title:        detail.Title,
+		Price:        detail.Price,
+		PriceValue:   history.ParsePrice(detail.Price),
+		WantCount:    detail.WantCount,
+		ViewCount:    detail.ViewCount,
+		CollectCount: detail.CollectCount,
+		CategoryID:   detail.CategoryID,
+		Location:     detail.Location,
+		SellerNick:   detail.SellerNick,
+		Status:       status,
+		Condition:    detail.Condition,
+		Tags:         detail.Tags,
+		FreeShipping: detail.FreeShipping,
+		ImageURL:     detail.ImageURL,
+		VideoURL:     detail.VideoURL,
+		PublishTime:  timestamp(detail.PublishTimeTS),
+		SeenAt:       at,
+	}
+}
+
+// upsertProducts 新增或更新商品：文本字段为空时保留已有值，同一采集时间重复写入（如先列表后详情）不重复计数
+func upsertProducts(ctx context.Context, tx pgx.Tx, products []product, result *Result) error {
+	args := make([]any, 0, len(products)*len(productColumns))
+	for _, p := range products {
+		args = append(args, p.values()...)
+	}
+	query := insertSQL("xianyu_products AS p", productColumns, len(products)) + `
+		ON CONFLICT (item_id) DO UPDATE SET
+			title         = COALESCE(NULLIF(EXCLUDED.title, ''), p.title),
+			price         = COALESCE(NULLIF(EXCLUDED.price, ''), p.price),
+			price_value   = CASE WHEN EXCLUDED.price = '' THEN p.price_value ELSE EXCLUDED.price_value END,
+			want_count    = EXCLUDED.want_count,
+			view_count    = EXCLUDED.view_count,
+			collect_count = CASE WHEN EXCLUDED.collect_count = 0 THEN p.collect_count ELSE EXCLUDED.collect_count END,
+			category_id   = CASE WHEN EXCLUDED.category_id = 0 THEN p.category_id ELSE EXCLUDED.category_id END,
+			location      = COALESCE(NULLIF(EXCLUDED.location, ''), p.location),
+			seller_nick   = COALESCE(NULLIF(EXCLUDED.seller_nick, ''), p.seller_nick),
+			status        = COALESCE(NULLIF(EXCLUDED.status, ''), p.status),
+			condition     = COALESCE(NULLIF(EXCLUDED.condition, ''), p.condition),
+			tags          = CASE WHEN cardinality(EXCLUDED.tags) = 0 THEN p.tags ELSE EXCLUDED.tags END,
+			free_shipping = EXCLUDED.free_shipping,
+			image_url     = COALESCE(NULLIF(EXCLUDED.image_url, ''), p.image_url),
+			video_url     = COALESCE(NULLIF(EXCLUDED.video_url, ''), p.video_url),
+			publish_time  = COALESCE(EXCLUDED.publish_time, p.publish_time),
+			last_seen     = GREATEST(p.last_seen, EXCLUDED.last_seen),
+			seen_count    = p.seen_count + CASE WHEN EXCLUDED.last_seen > p.last_seen THEN 1 ELSE 0 END
+		RETURNING (xmax = 0)`
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var inserted bool
+		if err := rows.Scan(&inserted); err != nil {
+			return err
+		}
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+	return rows.Err()
+}
+
+var observationColumns = []string{
+	"item_id", "captured_at", "price", "price_value", "want_count", "view_count", "collect_count", "status", "source",
+}
+
+// upsertObservations 写入观测，同一商品同一采集时间的观测以后写入的为准
+func upsertObservations(ctx context.Context, tx pgx.Tx, observations []history.Observation) (int, error) {
+	args := make([]any, 0, len(observations)*len(observationColumns))
+	for _, o := range observations {
+		args = append(args, o.ItemID, o.CapturedTime(), o.Price, o.PriceValue, o.WantCount, o.ViewCount,
+			o.CollectCount, o.Status, o.Source)
+	}
+	query := insertSQL("xianyu_observations AS o", observationColumns, len(observations)) + `
+		ON CONFLICT (item_id, captured_at) DO UPDATE SET
+			price         = EXCLUDED.price,
+			price_value   = EXCLUDED.price_value,
+			want_count    = EXCLUDED.want_count,
+			view_count    = EXCLUDED.view_count,
+			collect_count = CASE WHEN EXCLUDED.collect_count = 0 THEN o.collect_count ELSE EXCLUDED.collect_count END,
+			status        = COALESCE(NULLIF(EXCLUDED.status, ''), o.status),
+			source        = EXCLUDED.source`
+	tag, err := tx.Exec(ctx, query, args...)
+	return int(tag.RowsAffected()), err
+}
+
+// sku SKU 表的一行
+type sku struct {
+	ItemID      string
+	SKUID       int64
+	InventoryID int64
+	PriceInCent int
+	Quantity    int
+	Spec        string
+	Properties  []mtop.SKUProperty
+}
+
+var skuColumns = []string{
+	"item_id", "sku_id", "inventory_id", "price_cent", "quantity", "spec", "properties", "updated_at",
+}
+
+// skusFromDetail 提取商品详情中的 SKU，规格文本形如 "颜色:黑色 容量:256G"
+func skusFromDetail(detail *mtop.ItemDetail) []sku {
+	list := make([]sku, 0, len(detail.SKUList))
+	for _, s := range detail.SKUList {
+		specs := make([]string, 0, len(s.PropertyList))
+		for _, prop := range s.PropertyList {
+			specs = append(specs, prop.PropertyText+":"+prop.ValueText)
+		}
+		props := s.PropertyList
+		if props == nil {
+			props = []mtop.SKUProperty{}
+		}
+		price := s.PriceInCent
+		if price == 0 {
+			price = s.Price
+		}
+		list = append(list, sku{
+			ItemID:      detail.ItemID,
+			SKUID:       s.SKUID,
+			InventoryID: s.InventoryID,
+			PriceInCent: price,
+			Quantity:    s.Quantity,
+			Spec:        strings.Join(specs, " "),
+			Properties:  props,
+		})
+	}
+	return list
+}
+
+// upsertSKUs 新增或更新 SKU
+func upsertSKUs(ctx context.Context, tx pgx.Tx, skus []sku, at time.Time) (int, error) {
+	args := make([]any, 0, len(skus)*len(skuColumns))
+	for _, k := range skus {
+		args = append(args, k.ItemID, k.SKUID, k.InventoryID, k.PriceInCent, k.Quantity, k.Spec, k.Properties, at)
+	}
+	query := insertSQL("xianyu_skus", skuColumns, len(skus)) + `
+		ON CONFLICT (item_id, sku_id) DO UPDATE SET
+			inventory_id = EXCLUDED.inventory_id,
+			price_cent   = EXCLUDED.price_cent,
+			quantity     = EXCLUDED.quantity,
+			spec         = EXCLUDED.spec,
+			properties   = EXCLUDED.properties,
+			updated_at   = EXCLUDED.updated_at`
+	tag, err := tx.Exec(ctx, query, args...)
+	return int(tag.RowsAffected()), err
+}
+
+// insertSQL 生成多行 INSERT 语句: INSERT INTO table (a, b) VALUES ($1, $2), ($3, $4)
+func insertSQL(table string, columns []string, rows int) string {
+	var b strings.Builder
+	b.WriteString("INSERT INTO ")
+	b.WriteString(table)
+	b.WriteString(" (")
+	b.WriteString(strings.Join(columns, ", "))
+	b.WriteString(") VALUES ")
+	n := 1
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for c := range columns {
+			if c > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			n++
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+// dedupe 按 key 去重，保留最后一次出现的值、第一次出现的位置
+func dedupe[T any](list []T, key func(T) string) []T {
+	index := make(map[string]int, len(list))
+	out := make([]T, 0, len(list))
+	for _, v := range list {
+		k := key(v)
+		if i, ok := index[k]; ok {
+			out[i] = v
+			continue
+		}
+		index[k] = len(out)
+		out = append(out, v)
+	}
+	return out
+}
+
+// timestamp 毫秒时间戳转为时间，0 表示未知
+func timestamp(ms int64) *time.Time {
+	if ms <= 0 {
+		return nil
+	}
+	t := time.UnixMilli(ms)
+	return &t
+}
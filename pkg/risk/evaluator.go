@@ -0,0 +1,213 @@
+package risk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Subject 待评估的商品与卖家信息
+type Subject struct {
+	ItemID            string
+	Title             string
+	Description       string
+	Price             float64
+	SellerNick        string
+	SellerCredit      string
+	SellerRegDays     int
+	SellerItemCount   int
+	SellerSoldCount   int
+	ShopLevel         string
+	DealDiscount      float64 // 低于同类中位价的百分比
+	IdenticalListings int     // 同批次中标题与价格完全相同的其他商品数
+}
+
+// Assessment 风险评估结果
+type Assessment struct {
+	Level   string   `json:"level"`   // low / medium / high
+	Score   int      `json:"score"`   // 命中规则分数之和
+	Rules   []string `json:"rules"`   // 命中的规则标识
+	Reasons []string `json:"reasons"` // 命中的原因说明
+}
+
+// ReasonText 原因说明合并为一行文本（用于飞书列）
+func (a Assessment) ReasonText() string {
+	return strings.Join(a.Reasons, "；")
+}
+
+// fieldKinds 字段类型：true 为数值字段
+var fieldKinds = map[string]bool{
+	FieldTitle:             false,
+	FieldDescription:       false,
+	FieldPrice:             true,
+	FieldSellerNick:        false,
+	FieldSellerCredit:      false,
+	FieldSellerRegDays:     true,
+	FieldSellerItemCount:   true,
+	FieldSellerSoldCount:   true,
+	FieldShopLevel:         false,
+	FieldDealDiscount:      true,
+	FieldIdenticalListings: true,
+}
+
+// field 读取字段值
+func (s Subject) field(name string) (string, float64) {
+	switch name {
+	case FieldTitle:
+		return s.Title, 0
+	case FieldDescription:
+		return s.Description, 0
+	case FieldPrice:
+		return "", s.Price
+	case FieldSellerNick:
+		return s.SellerNick, 0
+	case FieldSellerCredit:
+		return s.SellerCredit, 0
+	case FieldSellerRegDays:
+		return "", float64(s.SellerRegDays)
+	case FieldSellerItemCount:
+		return "", float64(s.SellerItemCount)
+	case FieldSellerSoldCount:
+		return "", float64(s.SellerSoldCount)
+	case FieldShopLevel:
+		return s.ShopLevel, 0
+	case FieldDealDiscount:
+		return "", s.DealDiscount
+	case FieldIdenticalListings:
+		return "", float64(s.IdenticalListings)
+	}
+	return "", 0
+}
+
+// Evaluator 风险评估器
+type Evaluator struct {
+	rules *RuleSet
+}
+
+// NewEvaluator 创建风险评估器
+func NewEvaluator(rules *RuleSet) *Evaluator {
+	return &Evaluator{rules: rules}
+}
+
+// Evaluate 按规则评估单个商品
+func (e *Evaluator) Evaluate(s Subject) Assessment {
+	a := Assessment{Level: LevelLow}
+	minLevel := LevelLow
+	for _, rule := range e.rules.Rules {
+		if !rule.matches(s) {
+			continue
+		}
+		a.Score += rule.Score
+		a.Rules = append(a.Rules, rule.Name)
+		reason := rule.Reason
+		if reason == "" {
+			reason = rule.Name
+		}
+		a.Reasons = append(a.Reasons, reason)
+		if rule.Level != "" && levelRank(rule.Level) > levelRank(minLevel) {
+			minLevel = strings.ToLower(rule.Level)
+		}
+	}
+
+	switch {
+	case a.Score >= e.rules.Levels.High:
+		a.Level = LevelHigh
+	case a.Score >= e.rules.Levels.Medium:
+		a.Level = LevelMedium
+	}
+	if levelRank(minLevel) > levelRank(a.Level) {
+		a.Level = minLevel
+	}
+	return a
+}
+
+// matches 规则的所有条件是否都满足
+func (r Rule) matches(s Subject) bool {
+	for _, cond := range r.Conditions {
+		if !cond.matches(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches 判断单个条件
+func (c Condition) matches(s Subject) bool {
+	text, num := s.field(c.Field)
+	if fieldKinds[c.Field] {
+		if num == 0 && !c.AllowZero {
+			return false
+		}
+		return c.compareNumber(num)
+	}
+	return c.compareText(text)
+}
+
+func (c Condition) compareNumber(v float64) bool {
+	target, err := strconv.ParseFloat(fmt.Sprint(c.Value), 64)
+	if err != nil {
+		return false
+	}
+	switch c.Op {
+	case "lt":
+		return v < target
+	case "lte":
+		return v <= target
+	case "gt":
+		return v > target
+	case "gte":
+		return v >= target
+	case "eq":
+		return v == target
+	case "ne":
+		return v != target
+	}
+	return false
+}
+
+func (c Condition) compareText(v string) bool {
+	if v == "" {
+		return false
+	}
+	lower := strings.ToLower(v)
+	switch c.Op {
+	case "eq":
+		return v == fmt.Sprint(c.Value)
+	case "ne":
+		return v != fmt.Sprint(c.Value)
+	case "in":
+		for _, candidate := range c.Values {
+			if v == candidate {
+				return true
+			}
+		}
+	case "contains":
+		for _, kw := range c.Values {
+			if kw != "" && strings.Contains(lower, strings.ToLower(kw)) {
+				return true
+			}
+		}
+	case "matches":
+		return c.re != nil && c.re.MatchString(v)
+	}
+	return false
+}
+
+// CountIdentical 统计每个商品在同批次中标题与价格完全相同的其他商品数，写入 IdenticalListings
+func CountIdentical(subjects []Subject) {
+	counts := make(map[string]int)
+	keys := make([]string, len(subjects))
+	for i, s := range subjects {
+		title := strings.Join(strings.Fields(strings.ToLower(s.Title)), "")
+		if title == "" {
+			continue
+		}
+		keys[i] = fmt.Sprintf("%s|%.2f", title, s.Price)
+		counts[keys[i]]++
+	}
+	for i := range subjects {
+		if keys[i] != "" {
+			subjects[i].IdenticalListings = counts[keys[i]] - 1
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package risk
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testRules = `
+levels: {medium: 30, high: 60}
+rules:
+  - name: new_account
+    reason: 新账号
+    score: 30
+    conditions:
+      - { field: seller_reg_days, op: lt, value: 30 }
+  - name: wechat
+    reason: 引导加微信
+    score: 40
+    conditions:
+      - { field: description, op: contains, values: [微信, VX] }
+  - name: no_sales
+    reason: 无成交
+    score: 10
+    conditions:
+      - { field: seller_sold_count, op: eq, value: 0, allow_zero: true }
+  - name: cheap
+    reason: 远低于市场价
+    level: high
+    conditions:
+      - { field: deal_discount, op: gte, value: 50 }
+`
+
+func mustEvaluator(t *testing.T, data string) *Evaluator {
+	t.Helper()
+	rules, err := ParseRules([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	return NewEvaluator(rules)
+}
+
+func TestEvaluate(t *testing.T) {
+	e := mustEvaluator(t, testRules)
+
+	tests := []struct {
+		name      string
+		subject   Subject
+		wantLevel string
+		wantRules []string
+	}{
+		{
+			name:      "正常卖家",
+			subject:   Subject{SellerRegDays: 1000, SellerSoldCount: 50, Description: "自用闲置"},
+			wantLevel: LevelLow,
+		},
+		{
+			name:      "注册天数缺失不视为新账号",
+			subject:   Subject{SellerSoldCount: 5},
+			wantLevel: LevelLow,
+		},
+		{
+			name:      "新账号",
+			subject:   Subject{SellerRegDays: 3, SellerSoldCount: 5},
+			wantLevel: LevelMedium,
+			wantRules: []string{"new_account"},
+		},
+		{
+			name:      "新账号+加微信+无成交",
+			subject:   Subject{SellerRegDays: 3, Description: "加vx详聊"},
+			wantLevel: LevelHigh,
+			wantRules: []string{"new_account", "wechat", "no_sales"},
+		},
+		{
+			name:      "强制高风险",
+			subject:   Subject{SellerRegDays: 500, SellerSoldCount: 5, DealDiscount: 60},
+			wantLevel: LevelHigh,
+			wantRules: []string{"cheap"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := e.Evaluate(tt.subject)
+			if got.Level != tt.wantLevel {
+				t.Errorf("Level = %s, want %s (%+v)", got.Level, tt.wantLevel, got)
+			}
+			if !reflect.DeepEqual(got.Rules, tt.wantRules) {
+				t.Errorf("Rules = %v, want %v", got.Rules, tt.wantRules)
+			}
+			if len(got.Reasons) != len(got.Rules) {
+				t.Errorf("Reasons 与 Rules 数量不一致: %v", got.Reasons)
+			}
+		})
+	}
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	invalid := []string{
+		`rules: [{name: a, conditions: [{field: unknown, op: eq, value: 1}]}]`,
+		`rules: [{name: a, conditions: [{field: price, op: between, value: 1}]}]`,
+		`rules: [{name: a, conditions: [{field: title, op: matches, value: "("}]}]`,
+		`rules: [{name: a}]`,
+		`rules: [{name: a, level: extreme, conditions: [{field: price, op: gt, value: 1}]}]`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseRules([]byte(data)); err == nil {
+			t.Errorf("ParseRules(%q) 应返回错误", data)
+		}
+	}
+}
+
+func TestCountIdentical(t *testing.T) {
+	subjects := []Subject{
+		{Title: "全新 iPhone 15", Price: 999},
+		{Title: "全新 iphone15", Price: 999},
+		{Title: "全新 iPhone 15", Price: 999},
+		{Title: "全新 iPhone 15", Price: 5000},
+	}
+	CountIdentical(subjects)
+	want := []int{2, 2, 2, 0}
+	for i, s := range subjects {
+		if s.IdenticalListings != want[i] {
+			t.Errorf("subjects[%d].IdenticalListings = %d, want %d", i, s.IdenticalListings, want[i])
+		}
+	}
+}
+
+func TestLoadExampleRules(t *testing.T) {
+	rules, err := LoadRules("../../configs/risk_rules.example.yaml")
+	if err != nil {
+		t.Fatalf("加载示例规则失败: %v", err)
+	}
+	e := NewEvaluator(rules)
+	got := e.Evaluate(Subject{SellerRegDays: 2, DealDiscount: 40, Description: "拍前加微信"})
+	if got.Level != LevelHigh {
+		t.Errorf("示例规则评估 = %+v, want high", got)
+	}
+}
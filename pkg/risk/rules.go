@@ -0,0 +1,138 @@
+package risk
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 风险等级
+const (
+	LevelLow    = "low"
+	LevelMedium = "medium"
+	LevelHigh   = "high"
+)
+
+// 可用于条件判断的字段
+const (
+	FieldTitle             = "title"
+	FieldDescription       = "description"
+	FieldPrice             = "price"
+	FieldSellerNick        = "seller_nick"
+	FieldSellerCredit      = "seller_credit"
+	FieldSellerRegDays     = "seller_reg_days"
+	FieldSellerItemCount   = "seller_item_count"
+	FieldSellerSoldCount   = "seller_sold_count"
+	FieldShopLevel         = "shop_level"
+	FieldDealDiscount      = "deal_discount"
+	FieldIdenticalListings = "identical_listings"
+)
+
+// RuleSet 风险规则集（由用户维护的 YAML 文件加载）
+type RuleSet struct {
+	Levels Levels `yaml:"levels"` // 等级分数线
+	Rules  []Rule `yaml:"rules"`  // 规则列表
+}
+
+// Levels 风险等级分数线：总分达到 Medium 为中风险，达到 High 为高风险
+type Levels struct {
+	Medium int `yaml:"medium"`
+	High   int `yaml:"high"`
+}
+
+// Rule 单条风险规则，所有条件同时满足时命中
+type Rule struct {
+	Name       string      `yaml:"name"`       // 规则标识
+	Reason     string      `yaml:"reason"`     // 命中时的原因说明
+	Score      int         `yaml:"score"`      // 命中时累加的风险分
+	Level      string      `yaml:"level"`      // 命中时的最低风险等级（可选）
+	Conditions []Condition `yaml:"conditions"` // 条件列表（AND）
+}
+
+// Condition 规则条件
+type Condition struct {
+	Field     string   `yaml:"field"`      // 字段名，见 Field* 常量
+	Op        string   `yaml:"op"`         // lt/lte/gt/gte/eq/ne/in/contains/matches
+	Value     any      `yaml:"value"`      // 比较值（数值或字符串）
+	Values    []string `yaml:"values"`     // in/contains 使用的候选值
+	AllowZero bool     `yaml:"allow_zero"` // 数值字段为 0 时默认视为缺失（条件不成立），设为 true 时按 0 参与比较
+
+	re *regexp.Regexp
+}
+
+// LoadRules 从 YAML 文件加载风险规则
+func LoadRules(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取风险规则文件失败: %w", err)
+	}
+	return ParseRules(data)
+}
+
+// ParseRules 解析 YAML 格式的风险规则并校验
+func ParseRules(data []byte) (*RuleSet, error) {
+	var rs RuleSet
+	if err := yaml.Unmarshal(data, &rs); err != nil {
+		return nil, fmt.Errorf("解析风险规则失败: %w", err)
+	}
+	if err := rs.compile(); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+// compile 校验规则并编译正则
+func (rs *RuleSet) compile() error {
+	if rs.Levels.Medium <= 0 {
+		rs.Levels.Medium = 30
+	}
+	if rs.Levels.High <= 0 {
+		rs.Levels.High = 60
+	}
+	for ri := range rs.Rules {
+		rule := &rs.Rules[ri]
+		if rule.Name == "" {
+			return fmt.Errorf("第 %d 条风险规则缺少 name", ri+1)
+		}
+		if rule.Level != "" && levelRank(rule.Level) < 0 {
+			return fmt.Errorf("风险规则 [%s] 等级无效: %s", rule.Name, rule.Level)
+		}
+		if len(rule.Conditions) == 0 {
+			return fmt.Errorf("风险规则 [%s] 缺少条件", rule.Name)
+		}
+		for ci := range rule.Conditions {
+			cond := &rule.Conditions[ci]
+			if _, ok := fieldKinds[cond.Field]; !ok {
+				return fmt.Errorf("风险规则 [%s] 字段无效: %s", rule.Name, cond.Field)
+			}
+			switch cond.Op {
+			case "lt", "lte", "gt", "gte", "eq", "ne", "in", "contains":
+			case "matches":
+				re, err := regexp.Compile(fmt.Sprint(cond.Value))
+				if err != nil {
+					return fmt.Errorf("风险规则 [%s] 正则无效: %w", rule.Name, err)
+				}
+				cond.re = re
+			default:
+				return fmt.Errorf("风险规则 [%s] 操作符无效: %s", rule.Name, cond.Op)
+			}
+		}
+	}
+	return nil
+}
+
+// levelRank 风险等级排序，未知等级返回 -1
+func levelRank(level string) int {
+	switch strings.ToLower(level) {
+	case LevelLow:
+		return 0
+	case LevelMedium:
+		return 1
+	case LevelHigh:
+		return 2
+	}
+	return -1
+}
@@ -0,0 +1,47 @@
+package scoring
+
+import (
+	"strconv"
+	"strings"
+
+	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+)
+
+// FromFeedItem 从猜你喜欢商品构建评分输入（同类分组使用叶子分类）
+func FromFeedItem(item mtop.FeedItem) Signals {
+	return Signals{
+		ItemID:        item.ItemID,
+		WantCount:     item.WantCount,
+		ViewCount:     item.ViewCount,
+		PublishTimeMs: item.PublishTimeTS,
+		Price:         history.ParsePrice(item.Price),
+		PeerGroup:     categoryGroup(item.CategoryID),
+	}
+}
+
+// FromProduct 从飞书产品构建评分输入
+// 识别出品牌/型号时按品牌型号分组，否则按叶子分类分组
+func FromProduct(p feishu.Product) Signals {
+	group := categoryGroup(p.CategoryID)
+	if p.Brand != "" || p.Model != "" {
+		group = "entity:" + strings.ToLower(p.Brand+"|"+p.Model)
+	}
+	return Signals{
+		ItemID:        p.ItemID,
+		WantCount:     p.WantCnt,
+		ViewCount:     p.ViewCount,
+		CollectCount:  p.CollectCount,
+		PublishTimeMs: p.PublishTimeMs,
+		Price:         history.ParsePrice(p.Price),
+		PeerGroup:     group,
+	}
+}
+
+func categoryGroup(categoryID int) string {
+	if categoryID == 0 {
+		return ""
+	}
+	return "cat:" + strconv.Itoa(categoryID)
+}
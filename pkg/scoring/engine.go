@@ -0,0 +1,179 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// minPeers 计算同类价格中位数所需的最少商品数
+const minPeers = 3
+
+// Weights 因子权重（因子名 -> 权重），权重为 0 的因子不参与评分
+type Weights map[string]float64
+
+// Signals 单个商品的评分输入
+type Signals struct {
+	ItemID        string
+	WantCount     int
+	ViewCount     int
+	CollectCount  int
+	PublishTimeMs int64
+	Price         float64
+	PeerGroup     string  // 同类分组键（品牌型号或分类），用于相对价格
+	WantPerHour   float64 // 想要人数每小时增长（来自历史观测）
+	HasVelocity   bool    // 是否有增长速度数据
+}
+
+// Contribution 单个因子对总分的贡献
+type Contribution struct {
+	Factor     string  `json:"factor"`
+	Raw        float64 `json:"raw"`        // 原始值
+	Normalized float64 `json:"normalized"` // 0-1 归一化值
+	Weight     float64 `json:"weight"`     // 配置权重
+	Points     float64 `json:"points"`     // 对总分(0-100)的贡献
+}
+
+// Result 评分结果
+type Result struct {
+	ItemID        string         `json:"itemId"`
+	Score         float64        `json:"score"` // 0-100
+	Contributions []Contribution `json:"contributions"`
+}
+
+// Heat 返回取整后的热度值（用于飞书"曝光热度"字段）
+func (r Result) Heat() int {
+	return int(math.Round(r.Score))
+}
+
+// Engine 热度评分引擎
+type Engine struct {
+	factors []Factor
+	weights Weights
+	now     func() time.Time
+}
+
+// NewEngine 使用内置因子创建评分引擎，weights 为空时使用默认权重
+// weights 中出现未知因子时返回错误
+func NewEngine(weights Weights) (*Engine, error) {
+	e := &Engine{now: time.Now, weights: Weights{}}
+	for _, f := range DefaultFactors() {
+		e.factors = append(e.factors, f)
+	}
+	if len(weights) == 0 {
+		weights = DefaultWeights()
+	}
+	for name, w := range weights {
+		if !e.hasFactor(name) {
+			return nil, fmt.Errorf("未知的评分因子: %s", name)
+		}
+		if w < 0 {
+			return nil, fmt.Errorf("评分因子 %s 的权重不能为负数", name)
+		}
+		e.weights[name] = w
+	}
+	return e, nil
+}
+
+// Register 注册自定义因子（同名因子会被替换）
+func (e *Engine) Register(f Factor, weight float64) {
+	for i, existing := range e.factors {
+		if existing.Name() == f.Name() {
+			e.factors[i] = f
+			e.weights[f.Name()] = weight
+			return
+		}
+	}
+	e.factors = append(e.factors, f)
+	e.weights[f.Name()] = weight
+}
+
+// Weights 返回当前权重副本
+func (e *Engine) Weights() Weights {
+	w := make(Weights, len(e.weights))
+	for k, v := range e.weights {
+		w[k] = v
+	}
+	return w
+}
+
+func (e *Engine) hasFactor(name string) bool {
+	for _, f := range e.factors {
+		if f.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NewEnv 根据一批商品构建评分环境（计算各同类分组的价格中位数）
+func (e *Engine) NewEnv(batch []Signals) Env {
+	groups := make(map[string][]float64)
+	for _, s := range batch {
+		if s.PeerGroup != "" && s.Price > 0 {
+			groups[s.PeerGroup] = append(groups[s.PeerGroup], s.Price)
+		}
+	}
+	medians := make(map[string]float64)
+	for key, prices := range groups {
+		if len(prices) >= minPeers {
+			medians[key] = median(prices)
+		}
+	}
+	return Env{Now: e.now(), PeerMedians: medians}
+}
+
+// Score 计算单个商品得分
+// 总分 = 100 * Σ(权重 * 归一化值) / Σ(可用因子权重)，数据缺失的因子不参与且不拉低总分
+func (e *Engine) Score(s Signals, env Env) Result {
+	result := Result{ItemID: s.ItemID}
+	totalWeight := 0.0
+	for _, f := range e.factors {
+		w := e.weights[f.Name()]
+		if w <= 0 {
+			continue
+		}
+		raw, norm, ok := f.Evaluate(s, env)
+		if !ok {
+			continue
+		}
+		totalWeight += w
+		result.Contributions = append(result.Contributions, Contribution{
+			Factor:     f.Name(),
+			Raw:        raw,
+			Normalized: norm,
+			Weight:     w,
+		})
+	}
+	if totalWeight == 0 {
+		return result
+	}
+
+	for i := range result.Contributions {
+		c := &result.Contributions[i]
+		c.Points = 100 * c.Weight * c.Normalized / totalWeight
+		result.Score += c.Points
+	}
+	return result
+}
+
+// ScoreAll 批量计算得分（结果与输入顺序一致）
+func (e *Engine) ScoreAll(batch []Signals) []Result {
+	env := e.NewEnv(batch)
+	results := make([]Result, len(batch))
+	for i, s := range batch {
+		results[i] = e.Score(s, env)
+	}
+	return results
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,112 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func newTestEngine(t *testing.T, weights Weights) *Engine {
+	t.Helper()
+	e, err := NewEngine(weights)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	e.now = func() time.Time { return now }
+	return e
+}
+
+func TestNewEngine_InvalidWeights(t *testing.T) {
+	if _, err := NewEngine(Weights{"unknown": 1}); err == nil {
+		t.Error("未知因子应返回错误")
+	}
+	if _, err := NewEngine(Weights{FactorWant: -1}); err == nil {
+		t.Error("负权重应返回错误")
+	}
+}
+
+func TestEngine_ScoreContributions(t *testing.T) {
+	e := newTestEngine(t, nil)
+	now := e.now()
+
+	hot := Signals{
+		ItemID:        "hot",
+		WantCount:     200,
+		ViewCount:     1000,
+		CollectCount:  50,
+		PublishTimeMs: now.Add(-time.Hour).UnixMilli(),
+		WantPerHour:   5,
+		HasVelocity:   true,
+	}
+	cold := Signals{
+		ItemID:        "cold",
+		WantCount:     1,
+		ViewCount:     500,
+		PublishTimeMs: now.Add(-10 * 24 * time.Hour).UnixMilli(),
+	}
+
+	results := e.ScoreAll([]Signals{hot, cold})
+	if results[0].Score <= results[1].Score {
+		t.Fatalf("热门商品得分应更高: hot=%.2f cold=%.2f", results[0].Score, results[1].Score)
+	}
+
+	// 各因子贡献之和等于总分
+	for _, r := range results {
+		sum := 0.0
+		for _, c := range r.Contributions {
+			sum += c.Points
+		}
+		if math.Abs(sum-r.Score) > 1e-9 {
+			t.Errorf("%s 贡献之和 %.4f != 总分 %.4f", r.ItemID, sum, r.Score)
+		}
+		if r.Score < 0 || r.Score > 100 {
+			t.Errorf("%s 得分超出范围: %.2f", r.ItemID, r.Score)
+		}
+	}
+
+	// 冷门商品缺少收藏与增长速度数据，这两个因子不应出现
+	for _, c := range results[1].Contributions {
+		if c.Factor == FactorCollect || c.Factor == FactorWantVelocity {
+			t.Errorf("缺失数据的因子不应参与评分: %s", c.Factor)
+		}
+	}
+}
+
+func TestEngine_RelativePrice(t *testing.T) {
+	e := newTestEngine(t, Weights{FactorRelativePrice: 1})
+
+	batch := []Signals{
+		{ItemID: "cheap", Price: 50, PeerGroup: "cat:1"},
+		{ItemID: "mid", Price: 100, PeerGroup: "cat:1"},
+		{ItemID: "high", Price: 200, PeerGroup: "cat:1"},
+		{ItemID: "alone", Price: 10, PeerGroup: "cat:2"},
+	}
+	results := e.ScoreAll(batch)
+
+	want := map[string]float64{"cheap": 75, "mid": 50, "high": 0, "alone": 0}
+	for _, r := range results {
+		if math.Abs(r.Score-want[r.ItemID]) > 1e-9 {
+			t.Errorf("%s 得分 = %.2f, want %.2f", r.ItemID, r.Score, want[r.ItemID])
+		}
+	}
+	// 同类商品不足时不计算相对价格
+	if len(results[3].Contributions) != 0 {
+		t.Errorf("同类不足时不应有相对价格贡献: %+v", results[3].Contributions)
+	}
+}
+
+func TestEngine_RegisterCustomFactor(t *testing.T) {
+	e := newTestEngine(t, Weights{FactorWant: 1})
+	e.Register(FactorFunc{"video", func(s Signals, _ Env) (float64, float64, bool) {
+		return 1, 1, true
+	}}, 1)
+
+	r := e.Score(Signals{ItemID: "1", WantCount: 0}, e.NewEnv(nil))
+	if r.Score != 50 {
+		t.Errorf("自定义因子得分 = %.2f, want 50", r.Score)
+	}
+	if r.Heat() != 50 {
+		t.Errorf("Heat() = %d, want 50", r.Heat())
+	}
+}
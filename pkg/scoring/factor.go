@@ -0,0 +1,123 @@
+package scoring
+
+import (
+	"math"
+	"time"
+)
+
+// 内置因子名称（同时作为权重配置的键）
+const (
+	FactorWant          = "want"            // 想要人数
+	FactorView          = "view"            // 浏览人数
+	FactorWantViewRatio = "want_view_ratio" // 想要/浏览转化率
+	FactorCollect       = "collect"         // 收藏人数
+	FactorFreshness     = "freshness"       // 发布新鲜度
+	FactorWantVelocity  = "want_velocity"   // 想要人数增长速度
+	FactorRelativePrice = "relative_price"  // 相对同类商品的价格
+)
+
+// Env 评分环境（同一批商品共享）
+type Env struct {
+	Now         time.Time
+	PeerMedians map[string]float64 // 同类分组 -> 价格中位数
+}
+
+// Factor 评分因子，可自定义实现后通过 Engine.Register 注册
+// Evaluate 返回原始值与 0-1 归一化值；数据缺失时 ok 返回 false，该因子不参与本商品评分
+type Factor interface {
+	Name() string
+	Evaluate(s Signals, env Env) (raw, norm float64, ok bool)
+}
+
+// FactorFunc 函数式因子
+type FactorFunc struct {
+	FactorName string
+	Fn         func(s Signals, env Env) (raw, norm float64, ok bool)
+}
+
+// Name 因子名称
+func (f FactorFunc) Name() string { return f.FactorName }
+
+// Evaluate 计算因子
+func (f FactorFunc) Evaluate(s Signals, env Env) (float64, float64, bool) { return f.Fn(s, env) }
+
+// DefaultFactors 内置因子
+func DefaultFactors() []Factor {
+	return []Factor{
+		FactorFunc{FactorWant, func(s Signals, _ Env) (float64, float64, bool) {
+			return float64(s.WantCount), logScale(float64(s.WantCount), 200), true
+		}},
+		FactorFunc{FactorView, func(s Signals, _ Env) (float64, float64, bool) {
+			if s.ViewCount <= 0 {
+				return 0, 0, false
+			}
+			return float64(s.ViewCount), logScale(float64(s.ViewCount), 5000), true
+		}},
+		FactorFunc{FactorWantViewRatio, func(s Signals, _ Env) (float64, float64, bool) {
+			// 浏览量太少时转化率没有参考价值
+			if s.ViewCount < 20 {
+				return 0, 0, false
+			}
+			ratio := float64(s.WantCount) / float64(s.ViewCount)
+			return ratio, clamp01(ratio / 0.1), true
+		}},
+		FactorFunc{FactorCollect, func(s Signals, _ Env) (float64, float64, bool) {
+			if s.CollectCount <= 0 {
+				return 0, 0, false
+			}
+			return float64(s.CollectCount), logScale(float64(s.CollectCount), 200), true
+		}},
+		FactorFunc{FactorFreshness, func(s Signals, env Env) (float64, float64, bool) {
+			if s.PublishTimeMs <= 0 {
+				return 0, 0, false
+			}
+			hours := env.Now.Sub(time.UnixMilli(s.PublishTimeMs)).Hours()
+			if hours < 0 {
+				hours = 0
+			}
+			// 半衰期 72 小时
+			return hours, math.Pow(0.5, hours/72), true
+		}},
+		FactorFunc{FactorWantVelocity, func(s Signals, _ Env) (float64, float64, bool) {
+			if !s.HasVelocity {
+				return 0, 0, false
+			}
+			v := math.Max(s.WantPerHour, 0)
+			return s.WantPerHour, logScale(v, 10), true
+		}},
+		FactorFunc{FactorRelativePrice, func(s Signals, env Env) (float64, float64, bool) {
+			median, ok := env.PeerMedians[s.PeerGroup]
+			if !ok || median <= 0 || s.Price <= 0 {
+				return 0, 0, false
+			}
+			// 价格/中位数：0 -> 1 分，等于中位数 -> 0.5 分，两倍及以上 -> 0 分
+			r := s.Price / median
+			return r, clamp01(1 - r/2), true
+		}},
+	}
+}
+
+// DefaultWeights 内置因子的默认权重
+func DefaultWeights() Weights {
+	return Weights{
+		FactorWant:          0.25,
+		FactorView:          0.10,
+		FactorWantViewRatio: 0.20,
+		FactorCollect:       0.10,
+		FactorFreshness:     0.10,
+		FactorWantVelocity:  0.15,
+		FactorRelativePrice: 0.10,
+	}
+}
+
+// logScale 对数归一化，v 达到 saturation 时为 1
+func logScale(v, saturation float64) float64 {
+	if v <= 0 {
+		return 0
+	}
+	return clamp01(math.Log1p(v) / math.Log1p(saturation))
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,187 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"xianyu_aner/pkg/mtop"
+)
+
+// Stats 单个输出目标的统计
+type Stats struct {
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Items    int           `json:"items"`           // 成功写入的商品数
+	Failed   int           `json:"failed"`          // 写入失败的商品数
+	Batches  int           `json:"batches"`         // 写入批次数
+	Errors   int           `json:"errors"`          // 出错次数（含 Open/Flush/Close）
+	Err      string        `json:"error,omitempty"` // 第一个错误
+	Disabled bool          `json:"disabled"`        // 创建或打开失败，未写入任何数据
+	Duration time.Duration `json:"duration"`        // 累计耗时
+}
+
+// OK 是否全部成功
+func (s Stats) OK() bool {
+	return s.Errors == 0 && !s.Disabled
+}
+
+// entry 输出目标及其统计
+type entry struct {
+	sink     Sink
+	stats    Stats
+	streamed bool // 已通过 Stream 写入，Write 时跳过
+}
+
+// Fanout 将每批数据依次写入多个输出目标
+// 各目标相互隔离：某个目标出错（含 panic）只记录到它自己的统计，不影响其他目标
+type Fanout struct {
+	entries []*entry
+	closed  bool
+}
+
+// NewFanout 创建多目标输出
+func NewFanout() *Fanout {
+	return &Fanout{}
+}
+
+// Add 添加输出目标
+func (f *Fanout) Add(spec Spec, s Sink) {
+	f.entries = append(f.entries, &entry{sink: s, stats: Stats{Name: spec.DisplayName(), Type: spec.Type}})
+}
+
+// AddFailed 记录创建失败的输出目标（计入统计，不写入数据）
+func (f *Fanout) AddFailed(spec Spec, err error) {
+	f.entries = append(f.entries, &entry{stats: Stats{
+		Name:     spec.DisplayName(),
+		Type:     spec.Type,
+		Errors:   1,
+		Err:      err.Error(),
+		Disabled: true,
+	}})
+}
+
+// Len 输出目标数量
+func (f *Fanout) Len() int {
+	return len(f.entries)
+}
+
+// Open 打开所有输出目标，打开失败的目标被禁用；返回可用目标数
+func (f *Fanout) Open(ctx context.Context) int {
+	active := 0
+	for _, e := range f.entries {
+		if e.stats.Disabled {
+			continue
+		}
+		if err := e.call(func() error { return e.sink.Open(ctx) }); err != nil {
+			e.stats.Disabled = true
+			continue
+		}
+		active++
+	}
+	return active
+}
+
+// Stream 将一页数据写入流式目标（实现 Streaming）并立即刷新
+// 收到过 Stream 的目标之后不再接收 Write，以免重复写入
+func (f *Fanout) Stream(ctx context.Context, items []mtop.FeedItem) {
+	if len(items) == 0 {
+		return
+	}
+	for _, e := range f.entries {
+		if e.stats.Disabled {
+			continue
+		}
+		if s, ok := e.sink.(Streaming); !ok || !s.Streaming() {
+			continue
+		}
+		e.streamed = true
+		e.write(ctx, items)
+		e.call(func() error { return e.sink.Flush(ctx) })
+	}
+}
+
+// Write 将一批数据写入所有可用目标（已通过 Stream 写入的目标除外）
+func (f *Fanout) Write(ctx context.Context, items []mtop.FeedItem) {
+	if len(items) == 0 {
+		return
+	}
+	for _, e := range f.entries {
+		if e.stats.Disabled || e.streamed {
+			continue
+		}
+		e.write(ctx, items)
+	}
+}
+
+// Flush 刷新所有可用目标
+func (f *Fanout) Flush(ctx context.Context) {
+	for _, e := range f.entries {
+		if !e.stats.Disabled {
+			e.call(func() error { return e.sink.Flush(ctx) })
+		}
+	}
+}
+
+// Close 关闭所有已打开的目标（重复调用无操作）
+func (f *Fanout) Close() {
+	if f.closed {
+		return
+	}
+	f.closed = true
+	for _, e := range f.entries {
+		if e.sink != nil && !e.stats.Disabled {
+			e.call(e.sink.Close)
+		}
+	}
+}
+
+// Abort 放弃所有已打开的目标（爬取失败时代替 Close；已 Close 时无操作）
+func (f *Fanout) Abort() {
+	if f.closed {
+		return
+	}
+	f.closed = true
+	for _, e := range f.entries {
+		if e.sink != nil && !e.stats.Disabled {
+			e.call(e.sink.Abort)
+		}
+	}
+}
+
+// Stats 各输出目标的统计（按添加顺序）
+func (f *Fanout) Stats() []Stats {
+	stats := make([]Stats, len(f.entries))
+	for i, e := range f.entries {
+		stats[i] = e.stats
+	}
+	return stats
+}
+
+// write 写入一批数据并计入统计
+func (e *entry) write(ctx context.Context, items []mtop.FeedItem) {
+	e.stats.Batches++
+	if err := e.call(func() error { return e.sink.Write(ctx, items) }); err != nil {
+		e.stats.Failed += len(items)
+		return
+	}
+	e.stats.Items += len(items)
+}
+
+// call 执行一次调用，记录耗时与错误，panic 转换为错误
+func (e *entry) call(fn func() error) (err error) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+		e.stats.Duration += time.Since(start)
+		if err != nil {
+			e.stats.Errors++
+			if e.stats.Err == "" {
+				e.stats.Err = err.Error()
+			}
+		}
+	}()
+	return fn()
+}
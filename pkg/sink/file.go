@@ -0,0 +1,252 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"xianyu_aner/pkg/mtop"
+)
+
+// StdoutPath 表示输出到标准输出的路径
+const StdoutPath = "-"
+
+// Stdout 路径为 StdoutPath 时的输出（测试时可替换）
+// 横幅、进度等提示信息经 util.Progress 输出，不写入这里
+var Stdout io.Writer = os.Stdout
+
+// fileSink 文件输出的公共部分：写入临时文件，Close 时重命名，失败时不覆盖已有文件
+// direct 时直接写入目标文件，中途崩溃时已写入的数据保留（适用于 JSON Lines 这类逐行可读的格式）
+type fileSink struct {
+	path   string
+	direct bool // 直接写入目标文件，不经临时文件
+	append bool // 追加到已有文件（仅 direct）
+	tmp    string
+	file   *os.File
+	w      *bufio.Writer
+}
+
+func (f *fileSink) open() error {
+	if f.path == "" {
+		return fmt.Errorf("缺少输出文件路径")
+	}
+	if f.path == StdoutPath {
+		f.w = bufio.NewWriter(Stdout)
+		return nil
+	}
+	if dir := filepath.Dir(f.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建输出目录失败: %w", err)
+		}
+	}
+	name, flag := f.path+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC
+	if f.direct {
+		name = f.path
+		if f.append {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+	}
+	file, err := os.OpenFile(name, flag, 0644)
+	if err != nil {
+		return fmt.Errorf("创建输出文件失败: %w", err)
+	}
+	f.tmp = name
+	f.file = file
+	f.w = bufio.NewWriter(file)
+	return nil
+}
+
+func (f *fileSink) flush() error {
+	if f.w == nil {
+		return nil
+	}
+	return f.w.Flush()
+}
+
+func (f *fileSink) close() error {
+	if f.w == nil {
+		return nil
+	}
+	err := f.w.Flush()
+	f.w = nil
+	if f.file == nil {
+		// 标准输出不关闭
+		if err != nil {
+			return fmt.Errorf("写入标准输出失败: %w", err)
+		}
+		return nil
+	}
+	if cerr := f.file.Close(); err == nil {
+		err = cerr
+	}
+	f.file = nil
+	if err != nil {
+		if !f.direct {
+			os.Remove(f.tmp)
+		}
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	if f.direct {
+		return nil
+	}
+	return os.Rename(f.tmp, f.path)
+}
+
+// abort 放弃输出：关闭文件并删除临时文件，不替换目标文件
+// 直接写入目标文件时先刷新缓冲，已写入的数据保留
+func (f *fileSink) abort() {
+	if f.file != nil {
+		if f.direct {
+			f.w.Flush()
+		}
+		f.file.Close()
+		if !f.direct {
+			os.Remove(f.tmp)
+		}
+	}
+	f.file = nil
+	f.w = nil
+}
+
+// Abort 放弃输出，不替换已有文件
+func (f *fileSink) Abort() error {
+	f.abort()
+	return nil
+}
+
+// JSONSink 输出为 JSON 数组（与 MarshalIndent 两空格缩进的格式一致）
+type JSONSink struct {
+	fileSink
+	count int
+}
+
+func newJSONSink(spec Spec) (Sink, error) {
+	return &JSONSink{fileSink: fileSink{path: spec.Path}}, nil
+}
+
+// Open 创建输出文件
+func (s *JSONSink) Open(ctx context.Context) error {
+	if err := s.open(); err != nil {
+		return err
+	}
+	_, err := s.w.WriteString("[")
+	return err
+}
+
+// Write 追加一批商品
+func (s *JSONSink) Write(ctx context.Context, items []mtop.FeedItem) error {
+	for _, item := range items {
+		data, err := json.MarshalIndent(item, "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化商品 %s 失败: %w", item.ItemID, err)
+		}
+		sep := ",\n  "
+		if s.count == 0 {
+			sep = "\n  "
+		}
+		s.w.WriteString(sep)
+		if _, err := s.w.Write(data); err != nil {
+			return err
+		}
+		s.count++
+	}
+	return nil
+}
+
+// Flush 刷新缓冲
+func (s *JSONSink) Flush(ctx context.Context) error {
+	return s.flush()
+}
+
+// Close 写入数组结尾并替换目标文件
+func (s *JSONSink) Close() error {
+	if s.w != nil {
+		if s.count > 0 {
+			s.w.WriteString("\n")
+		}
+		s.w.WriteString("]")
+	}
+	return s.close()
+}
+
+// JSONLSink 输出为 JSON Lines（每行一个商品）
+// 流式输出：每页解析后立即写入并刷新，直接写入目标文件，爬取中途失败时已写入的行保留
+type JSONLSink struct {
+	fileSink
+	convert ProductConverter // 非 nil 时输出 feishu.Product
+	enc     *json.Encoder
+}
+
+func newJSONLSink(spec Spec) (Sink, error) {
+	return NewJSONLFactory(nil)(spec)
+}
+
+// NewJSONLFactory 创建 JSON Lines 输出工厂
+// 选项 shape=product 时经 convert 输出 feishu.Product（默认 raw 输出原始 mtop.FeedItem），
+// append=true 时追加到已有文件
+func NewJSONLFactory(convert ProductConverter) Factory {
+	return func(spec Spec) (Sink, error) {
+		s := &JSONLSink{fileSink: fileSink{
+			path:   spec.Path,
+			direct: true,
+			append: spec.Option("append", "false") == "true",
+		}}
+		switch shape := spec.Option("shape", "raw"); shape {
+		case "raw":
+		case "product":
+			if convert == nil {
+				return nil, fmt.Errorf("jsonl 不支持 shape=product")
+			}
+			s.convert = convert
+		default:
+			return nil, fmt.Errorf("无效的 shape 选项 %q（支持: raw, product）", shape)
+		}
+		return s, nil
+	}
+}
+
+// Streaming 每页解析后立即写入
+func (s *JSONLSink) Streaming() bool {
+	return true
+}
+
+// Open 创建输出文件
+func (s *JSONLSink) Open(ctx context.Context) error {
+	if err := s.open(); err != nil {
+		return err
+	}
+	s.enc = json.NewEncoder(s.w)
+	return nil
+}
+
+// Write 追加一批商品
+func (s *JSONLSink) Write(ctx context.Context, items []mtop.FeedItem) error {
+	if s.convert != nil {
+		for _, p := range s.convert(items) {
+			if err := s.enc.Encode(p); err != nil {
+				return fmt.Errorf("序列化商品 %s 失败: %w", p.ItemID, err)
+			}
+		}
+		return nil
+	}
+	for _, item := range items {
+		if err := s.enc.Encode(item); err != nil {
+			return fmt.Errorf("序列化商品 %s 失败: %w", item.ItemID, err)
+		}
+	}
+	return nil
+}
+
+// Flush 刷新缓冲
+func (s *JSONLSink) Flush(ctx context.Context) error {
+	return s.flush()
+}
+
+// Close 关闭输出文件
+func (s *JSONLSink) Close() error {
+	return s.close()
+}
@@ -0,0 +1,99 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"xianyu_aner/pkg/export"
+	"xianyu_aner/pkg/mtop"
+)
+
+// DetailFetcher 获取商品详情，获取失败的商品跳过
+type DetailFetcher func(items []mtop.FeedItem) []*mtop.ItemDetail
+
+// ParquetSink 输出为 Parquet（见 export.ParquetItem）
+// path 以 .parquet 结尾或为 - 时写入单个文件；否则视为目录，按采集日期分区写入 date=YYYY-MM-DD/part-N.parquet，
+// 可直接用 DuckDB read_parquet('dir/*/*.parquet', hive_partitioning = true) 或 Spark 读取
+// Parquet 在文件结尾写入元数据，数据在 Close 时一次写出
+type ParquetSink struct {
+	fileSink
+	dir   string        // 分区目录（为空时写入单个文件）
+	fetch DetailFetcher // 非 nil 时补充商品详情与 SKU 列表
+	rows  []export.ParquetItem
+	now   func() time.Time
+}
+
+// NewParquetFactory 创建 Parquet 输出工厂，fetch 用于选项 details=true 时获取商品详情
+func NewParquetFactory(fetch DetailFetcher) Factory {
+	return func(spec Spec) (Sink, error) {
+		s := &ParquetSink{now: time.Now}
+		if spec.Option("details", "false") == "true" {
+			if fetch == nil {
+				return nil, fmt.Errorf("当前环境不支持获取商品详情")
+			}
+			s.fetch = fetch
+		}
+		if spec.Path == StdoutPath || strings.HasSuffix(strings.ToLower(spec.Path), ".parquet") {
+			s.path = spec.Path
+		} else {
+			s.dir = spec.Path
+		}
+		return s, nil
+	}
+}
+
+// Open 创建输出文件或分区目录
+func (s *ParquetSink) Open(ctx context.Context) error {
+	if s.dir == "" {
+		return s.open()
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	return nil
+}
+
+// Write 追加一批商品，采集时间为写入时间
+func (s *ParquetSink) Write(ctx context.Context, items []mtop.FeedItem) error {
+	var details []*mtop.ItemDetail
+	if s.fetch != nil {
+		details = s.fetch(items)
+	}
+	s.rows = append(s.rows, export.ParquetItems(items, details, s.now())...)
+	return nil
+}
+
+// Flush Parquet 在 Close 时写出，此处无操作
+func (s *ParquetSink) Flush(ctx context.Context) error {
+	return nil
+}
+
+// Abort 放弃输出，不写出 Parquet 文件
+func (s *ParquetSink) Abort() error {
+	s.rows = nil
+	s.abort()
+	return nil
+}
+
+// Close 写出 Parquet 文件
+func (s *ParquetSink) Close() error {
+	if s.dir != "" {
+		if len(s.rows) == 0 {
+			return nil
+		}
+		rows := s.rows
+		s.rows = nil
+		_, err := export.WriteParquetPartitions(s.dir, rows)
+		return err
+	}
+	if s.w != nil {
+		if err := export.WriteParquet(s.w, s.rows); err != nil {
+			s.abort()
+			return fmt.Errorf("生成 Parquet 失败: %w", err)
+		}
+	}
+	return s.close()
+}
@@ -0,0 +1,113 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"xianyu_aner/pkg/mtop"
+)
+
+// Sink 爬取结果输出目标
+// 调用顺序: Open → Write（可多次，每次一批）→ Flush → Close；实现不得修改传入的商品切片
+// 爬取失败时以 Abort 代替 Close：放弃本次输出，不替换已有文件（已流式写入的数据保留）
+type Sink interface {
+	Open(ctx context.Context) error
+	Write(ctx context.Context, items []mtop.FeedItem) error
+	Flush(ctx context.Context) error
+	Close() error
+	Abort() error
+}
+
+// Streaming 可选接口：Streaming 返回 true 的输出目标在每页解析后立即写入（见 Fanout.Stream），
+// 其余目标在爬取与后处理完成后一次写入
+type Streaming interface {
+	Streaming() bool
+}
+
+// Spec 输出目标配置
+type Spec struct {
+	Type    string            `yaml:"type"`    // 类型，如 json、jsonl、feishu、webhook
+	Name    string            `yaml:"name"`    // 名称（统计显示），默认为 类型(路径)
+	Path    string            `yaml:"path"`    // 文件路径（文件类输出），- 表示标准输出
+	Options map[string]string `yaml:"options"` // 类型相关选项
+}
+
+// ParseSpec 解析命令行 --sink 参数: type 或 type:path，如 json:out.json、feishu
+func ParseSpec(s string) (Spec, error) {
+	s = strings.TrimSpace(s)
+	typ, path, _ := strings.Cut(s, ":")
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if typ == "" {
+		return Spec{}, fmt.Errorf("无效的输出目标: %q（格式: type 或 type:path）", s)
+	}
+	return Spec{Type: typ, Path: strings.TrimSpace(path)}, nil
+}
+
+// DisplayName 统计中显示的名称
+func (s Spec) DisplayName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	if s.Path != "" {
+		return s.Type + "(" + s.Path + ")"
+	}
+	return s.Type
+}
+
+// Option 读取选项，不存在时返回默认值
+func (s Spec) Option(key, def string) string {
+	if v, ok := s.Options[key]; ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// Factory 根据配置创建输出目标
+type Factory func(spec Spec) (Sink, error)
+
+// Registry 输出目标类型注册表
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry 创建注册表，已注册内置的 json、jsonl 文件输出
+func NewRegistry() *Registry {
+	r := &Registry{factories: make(map[string]Factory)}
+	r.Register("json", newJSONSink)
+	r.Register("jsonl", newJSONLSink)
+	return r
+}
+
+// Register 注册（或覆盖）输出目标类型
+func (r *Registry) Register(typ string, f Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[strings.ToLower(typ)] = f
+}
+
+// Types 已注册的类型（排序）
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.factories))
+	for t := range r.factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New 创建输出目标
+func (r *Registry) New(spec Spec) (Sink, error) {
+	r.mu.RLock()
+	f, ok := r.factories[strings.ToLower(spec.Type)]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未知的输出类型 %q（支持: %s）", spec.Type, strings.Join(r.Types(), ", "))
+	}
+	return f(spec)
+}
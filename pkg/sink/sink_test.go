@@ -0,0 +1,448 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xianyu_aner/pkg/export"
+	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/mtop"
+)
+
+// memorySink 记录收到的数据，可预设错误或 panic
+type memorySink struct {
+	items     []mtop.FeedItem
+	openErr   error
+	writeErr  error
+	panicky   bool
+	streaming bool
+	flushed   bool
+	flushes   int
+	closed    bool
+	closes    int
+	aborted   bool
+}
+
+func (m *memorySink) Streaming() bool { return m.streaming }
+
+func (m *memorySink) Open(ctx context.Context) error { return m.openErr }
+func (m *memorySink) Write(ctx context.Context, items []mtop.FeedItem) error {
+	if m.panicky {
+		panic("boom")
+	}
+	if m.writeErr != nil {
+		return m.writeErr
+	}
+	m.items = append(m.items, items...)
+	return nil
+}
+func (m *memorySink) Flush(ctx context.Context) error { m.flushed = true; m.flushes++; return nil }
+func (m *memorySink) Close() error                    { m.closed = true; m.closes++; return nil }
+func (m *memorySink) Abort() error                    { m.aborted = true; return nil }
+
+func testItems(ids ...string) []mtop.FeedItem {
+	items := make([]mtop.FeedItem, len(ids))
+	for i, id := range ids {
+		items[i] = mtop.FeedItem{ItemID: id, Title: "item " + id, Price: "9.9", WantCount: i}
+	}
+	return items
+}
+
+func TestParseSpec(t *testing.T) {
+	cases := map[string]Spec{
+		"json:out/feed.json": {Type: "json", Path: "out/feed.json"},
+		"FEISHU":             {Type: "feishu"},
+		" jsonl : a.jsonl ":  {Type: "jsonl", Path: "a.jsonl"},
+	}
+	for in, want := range cases {
+		got, err := ParseSpec(in)
+		if err != nil || got.Type != want.Type || got.Path != want.Path {
+			t.Errorf("ParseSpec(%q) = %+v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseSpec(":x"); err == nil {
+		t.Errorf("expected error for empty type")
+	}
+	if name := (Spec{Type: "json", Path: "a.json"}).DisplayName(); name != "json(a.json)" {
+		t.Errorf("DisplayName = %q", name)
+	}
+}
+
+func TestFanout_Isolation(t *testing.T) {
+	good := &memorySink{}
+	failing := &memorySink{writeErr: errors.New("disk full")}
+	panicky := &memorySink{panicky: true}
+	closed := &memorySink{openErr: errors.New("no permission")}
+
+	f := NewFanout()
+	f.Add(Spec{Type: "good"}, good)
+	f.Add(Spec{Type: "failing"}, failing)
+	f.Add(Spec{Type: "panicky"}, panicky)
+	f.Add(Spec{Type: "closed"}, closed)
+	f.AddFailed(Spec{Type: "unknown"}, errors.New("未知的输出类型"))
+
+	if n := f.Open(context.Background()); n != 3 {
+		t.Fatalf("Open active = %d, want 3", n)
+	}
+	f.Write(context.Background(), testItems("1", "2"))
+	f.Write(context.Background(), testItems("3"))
+	f.Flush(context.Background())
+	f.Close()
+
+	if len(good.items) != 3 || !good.flushed || !good.closed {
+		t.Errorf("good sink lost data: %+v", good)
+	}
+	if closed.closed {
+		t.Errorf("sink that failed to open should not be closed")
+	}
+
+	stats := f.Stats()
+	want := []struct {
+		items, failed, errs int
+		disabled            bool
+	}{
+		{3, 0, 0, false},
+		{0, 3, 2, false},
+		{0, 3, 2, false},
+		{0, 0, 1, true},
+		{0, 0, 1, true},
+	}
+	for i, w := range want {
+		s := stats[i]
+		if s.Items != w.items || s.Failed != w.failed || s.Errors != w.errs || s.Disabled != w.disabled {
+			t.Errorf("stats[%d] (%s) = %+v, want %+v", i, s.Name, s, w)
+		}
+	}
+	if !stats[0].OK() || stats[1].OK() {
+		t.Errorf("OK() mismatch")
+	}
+	if !strings.Contains(stats[2].Err, "panic") {
+		t.Errorf("panic not recorded: %q", stats[2].Err)
+	}
+}
+
+func TestFanout_Stream(t *testing.T) {
+	streaming := &memorySink{streaming: true}
+	buffered := &memorySink{}
+
+	f := NewFanout()
+	f.Add(Spec{Type: "streaming"}, streaming)
+	f.Add(Spec{Type: "buffered"}, buffered)
+	f.Open(context.Background())
+
+	all := testItems("1", "2", "3")
+	f.Stream(context.Background(), all[:2])
+	f.Stream(context.Background(), all[2:])
+	if len(streaming.items) != 3 || streaming.flushes != 2 {
+		t.Errorf("streaming sink: items=%d flushes=%d", len(streaming.items), streaming.flushes)
+	}
+	if len(buffered.items) != 0 {
+		t.Errorf("buffered sink received pages early")
+	}
+
+	f.Write(context.Background(), all)
+	f.Close()
+	f.Close()
+	if len(streaming.items) != 3 || len(buffered.items) != 3 {
+		t.Errorf("after Write: streaming=%d buffered=%d", len(streaming.items), len(buffered.items))
+	}
+	if streaming.closes != 1 || buffered.closes != 1 {
+		t.Errorf("Close not idempotent: %d %d", streaming.closes, buffered.closes)
+	}
+	if stats := f.Stats(); stats[0].Items != 3 || stats[0].Batches != 2 || stats[1].Batches != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+// 爬取失败时 Abort：已有文件保持不变，流式输出已写入的行保留
+func TestFanout_AbortKeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRegistry()
+	r.Register("xlsx", NewXLSXFactory(convertBasic))
+	r.Register("parquet", NewParquetFactory(nil))
+
+	specs := []Spec{
+		{Type: "json", Path: filepath.Join(dir, "feed.json")},
+		{Type: "xlsx", Path: filepath.Join(dir, "feed.xlsx")},
+		{Type: "parquet", Path: filepath.Join(dir, "feed.parquet")},
+		{Type: "jsonl", Path: filepath.Join(dir, "feed.jsonl")},
+	}
+	previous := "previous run"
+	for _, spec := range specs {
+		if err := os.WriteFile(spec.Path, []byte(previous), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mem := &memorySink{}
+	f := NewFanout()
+	for _, spec := range specs {
+		s, err := r.New(spec)
+		if err != nil {
+			t.Fatalf("New(%s): %v", spec.Type, err)
+		}
+		f.Add(spec, s)
+	}
+	f.Add(Spec{Type: "memory"}, mem)
+	f.Open(context.Background())
+	f.Stream(context.Background(), testItems("1"))
+	f.Abort()
+	f.Close()
+
+	for _, spec := range specs[:3] {
+		if data, _ := os.ReadFile(spec.Path); string(data) != previous {
+			t.Errorf("%s 被覆盖: %q", spec.Type, data)
+		}
+		if tmps, _ := filepath.Glob(spec.Path + "*.tmp"); len(tmps) != 0 {
+			t.Errorf("%s 遗留临时文件: %v", spec.Type, tmps)
+		}
+	}
+	// jsonl 直接写入目标文件（非追加模式时打开即截断），已流式写入的行保留
+	data, _ := os.ReadFile(specs[3].Path)
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Errorf("jsonl lines = %d, want 1: %q", lines, data)
+	}
+	if !mem.aborted || mem.closed {
+		t.Errorf("memory sink aborted=%v closed=%v, want aborted only", mem.aborted, mem.closed)
+	}
+	for _, st := range f.Stats() {
+		if !st.OK() {
+			t.Errorf("stats = %+v", st)
+		}
+	}
+}
+
+func TestJSONLSink_AppendAndStdout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.jsonl")
+	r := NewRegistry()
+	for _, opts := range []map[string]string{nil, {"append": "true"}} {
+		s, err := r.New(Spec{Type: "jsonl", Path: path, Options: opts})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		s.Open(context.Background())
+		s.Write(context.Background(), testItems("1"))
+		s.Flush(context.Background())
+		// 未关闭前数据已在目标文件中
+		if data, _ := os.ReadFile(path); !strings.Contains(string(data), `"item 1"`) {
+			t.Errorf("data not flushed to target file: %q", data)
+		}
+		s.Close()
+	}
+	if data, _ := os.ReadFile(path); strings.Count(string(data), "\n") != 2 {
+		t.Errorf("append: %q", data)
+	}
+	if _, err := r.New(Spec{Type: "jsonl", Path: path, Options: map[string]string{"shape": "product"}}); err == nil {
+		t.Errorf("expected error for shape=product without converter")
+	}
+
+	var buf strings.Builder
+	old := Stdout
+	Stdout = &buf
+	defer func() { Stdout = old }()
+	s, _ := NewJSONLFactory(convertBasic)(Spec{Type: "jsonl", Path: StdoutPath, Options: map[string]string{"shape": "product"}})
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatalf("Open stdout: %v", err)
+	}
+	s.Write(context.Background(), testItems("7"))
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close stdout: %v", err)
+	}
+	var p feishu.Product
+	if err := json.Unmarshal([]byte(buf.String()), &p); err != nil || p.ItemID != "7" {
+		t.Errorf("stdout = %q (%v)", buf.String(), err)
+	}
+}
+
+func TestFileSinks(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRegistry()
+	jsonPath := filepath.Join(dir, "out", "feed.json")
+	jsonlPath := filepath.Join(dir, "feed.jsonl")
+
+	f := NewFanout()
+	for _, spec := range []Spec{{Type: "json", Path: jsonPath}, {Type: "jsonl", Path: jsonlPath}} {
+		s, err := r.New(spec)
+		if err != nil {
+			t.Fatalf("New(%s): %v", spec.Type, err)
+		}
+		f.Add(spec, s)
+	}
+	if _, err := r.New(Spec{Type: "parquet"}); err == nil || !strings.Contains(err.Error(), "json, jsonl") {
+		t.Errorf("expected unknown type error, got %v", err)
+	}
+
+	f.Open(context.Background())
+	all := testItems("1", "2", "3")
+	f.Write(context.Background(), all[:2])
+	f.Write(context.Background(), all[2:])
+	f.Flush(context.Background())
+	f.Close()
+
+	// JSON 输出与一次性 MarshalIndent 的结果一致
+	got, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("read json: %v", err)
+	}
+	want, _ := json.MarshalIndent(all, "", "  ")
+	if string(got) != string(want) {
+		t.Errorf("json output mismatch:\n%s\nwant:\n%s", got, want)
+	}
+	if _, err := os.Stat(jsonPath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind")
+	}
+
+	file, err := os.Open(jsonlPath)
+	if err != nil {
+		t.Fatalf("open jsonl: %v", err)
+	}
+	defer file.Close()
+	var lines int
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var item mtop.FeedItem
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			t.Fatalf("line %d: %v", lines+1, err)
+		}
+		lines++
+	}
+	if lines != 3 {
+		t.Errorf("jsonl lines = %d, want 3", lines)
+	}
+
+	// 空结果输出空数组
+	empty, _ := r.New(Spec{Type: "json", Path: filepath.Join(dir, "empty.json")})
+	empty.Open(context.Background())
+	empty.Close()
+	if data, _ := os.ReadFile(filepath.Join(dir, "empty.json")); string(data) != "[]" {
+		t.Errorf("empty json = %q", data)
+	}
+}
+
+// convertBasic 测试用的商品转换
+func convertBasic(items []mtop.FeedItem) []feishu.Product {
+	products := make([]feishu.Product, len(items))
+	for i, item := range items {
+		products[i] = feishu.Product{ItemID: item.ItemID, Title: item.Title, Price: item.Price}
+	}
+	return products
+}
+
+func TestCSVSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.csv")
+	convert := convertBasic
+	r := NewRegistry()
+	r.Register("csv", NewCSVFactory(',', convert))
+
+	s, err := r.New(Spec{Type: "csv", Path: path})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	all := testItems("1", "2", "3")
+	s.Write(context.Background(), all[:2])
+	s.Write(context.Background(), all[2:])
+	s.Flush(context.Background())
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(string(data), export.BOM)), "\n")
+	if !strings.HasPrefix(string(data), export.BOM) || len(lines) != 4 {
+		t.Fatalf("csv output = %q", data)
+	}
+	if !strings.HasPrefix(lines[0], "商品ID,商品标题") || !strings.HasPrefix(lines[3], "3,item 3,") {
+		t.Errorf("unexpected rows: %q", lines)
+	}
+}
+
+func TestXLSXSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.xlsx")
+	r := NewRegistry()
+	r.Register("xlsx", NewXLSXFactory(convertBasic))
+	if _, err := r.New(Spec{Type: "xlsx", Path: path, Options: map[string]string{"hot_want": "x"}}); err == nil {
+		t.Errorf("expected error for invalid hot_want")
+	}
+
+	f := NewFanout()
+	s, err := r.New(Spec{Type: "xlsx", Path: path})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	f.Add(Spec{Type: "xlsx", Path: path}, s)
+	f.Open(context.Background())
+	f.Write(context.Background(), testItems("1", "2"))
+	f.Flush(context.Background())
+	f.Close()
+	if stats := f.Stats(); !stats[0].OK() || stats[0].Items != 2 {
+		t.Fatalf("stats = %+v", stats[0])
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read xlsx: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "PK") {
+		t.Errorf("output is not a zip archive")
+	}
+}
+
+func TestParquetSink(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRegistry()
+	r.Register("parquet", NewParquetFactory(nil))
+	if _, err := r.New(Spec{Type: "parquet", Path: dir, Options: map[string]string{"details": "true"}}); err == nil {
+		t.Errorf("expected error for details without fetcher")
+	}
+
+	var fetched int
+	r.Register("parquet", NewParquetFactory(func(items []mtop.FeedItem) []*mtop.ItemDetail {
+		fetched += len(items)
+		return []*mtop.ItemDetail{{ItemID: items[0].ItemID, CollectCount: 1}}
+	}))
+	specs := []Spec{
+		{Type: "parquet", Path: filepath.Join(dir, "lake"), Options: map[string]string{"details": "true"}},
+		{Type: "parquet", Path: filepath.Join(dir, "feed.parquet")},
+	}
+	f := NewFanout()
+	for _, spec := range specs {
+		s, err := r.New(spec)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		f.Add(spec, s)
+	}
+	f.Open(context.Background())
+	f.Write(context.Background(), testItems("1", "2"))
+	f.Flush(context.Background())
+	f.Close()
+	for _, st := range f.Stats() {
+		if !st.OK() || st.Items != 2 {
+			t.Errorf("stats = %+v", st)
+		}
+	}
+	if fetched != 2 {
+		t.Errorf("fetched details for %d items, want 2", fetched)
+	}
+
+	parts, _ := filepath.Glob(filepath.Join(dir, "lake", "date=*", "part-0.parquet"))
+	if len(parts) != 1 {
+		t.Errorf("partitions = %v", parts)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "feed.parquet"))
+	if err != nil || !strings.HasPrefix(string(data), "PAR1") {
+		t.Errorf("feed.parquet is not a parquet file: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"xianyu_aner/pkg/export"
+	"xianyu_aner/pkg/feishu"
+	"xianyu_aner/pkg/mtop"
+)
+
+// ProductConverter 将爬取结果转换为飞书商品结构（表格类输出按 feishu.ProductFields 导出）
+type ProductConverter func(items []mtop.FeedItem) []feishu.Product
+
+// CSVSink 输出为 CSV/TSV（UTF-8 BOM + 中文表头，列顺序同 feishu.ProductFields 的 CSVOrder）
+type CSVSink struct {
+	fileSink
+	opts    export.CSVOptions
+	convert ProductConverter
+	csv     *export.CSVWriter
+}
+
+// Streaming 每页解析后立即写入
+func (s *CSVSink) Streaming() bool {
+	return true
+}
+
+// NewCSVFactory 创建 CSV/TSV 输出工厂，comma 为分隔符
+// 选项 bom=false 时不写入 BOM
+func NewCSVFactory(comma rune, convert ProductConverter) Factory {
+	return func(spec Spec) (Sink, error) {
+		return &CSVSink{
+			fileSink: fileSink{path: spec.Path},
+			opts:     export.CSVOptions{Comma: comma, NoBOM: spec.Option("bom", "true") == "false"},
+			convert:  convert,
+		}, nil
+	}
+}
+
+// Open 创建输出文件并写入表头
+func (s *CSVSink) Open(ctx context.Context) error {
+	if err := s.open(); err != nil {
+		return err
+	}
+	s.csv = export.NewCSVWriter(s.w, s.opts)
+	return s.csv.WriteHeader()
+}
+
+// Write 追加一批商品
+func (s *CSVSink) Write(ctx context.Context, items []mtop.FeedItem) error {
+	return s.csv.Write(s.convert(items))
+}
+
+// Flush 刷新缓冲
+func (s *CSVSink) Flush(ctx context.Context) error {
+	if err := s.csv.Flush(); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// Close 替换目标文件
+func (s *CSVSink) Close() error {
+	if s.csv != nil {
+		s.csv.Flush()
+	}
+	return s.close()
+}
+
+// XLSXSink 输出为 Excel 报表（按采集日期分工作表，见 export.XLSXWriter）
+// xlsx 无法追加写入，数据在 Close 时一次写出
+type XLSXSink struct {
+	fileSink
+	convert ProductConverter
+	xlsx    *export.XLSXWriter
+}
+
+// NewXLSXFactory 创建 xlsx 输出工厂
+// 选项 hot_want 为热门高亮的想要人数阈值，默认 export.DefaultHotWantCount
+func NewXLSXFactory(convert ProductConverter) Factory {
+	return func(spec Spec) (Sink, error) {
+		hot, err := strconv.Atoi(spec.Option("hot_want", "0"))
+		if err != nil {
+			return nil, fmt.Errorf("无效的 hot_want 选项: %w", err)
+		}
+		return &XLSXSink{
+			fileSink: fileSink{path: spec.Path},
+			convert:  convert,
+			xlsx:     export.NewXLSXWriter(export.XLSXOptions{HotWantCount: hot}),
+		}, nil
+	}
+}
+
+// Open 创建输出文件
+func (s *XLSXSink) Open(ctx context.Context) error {
+	return s.open()
+}
+
+// Write 追加一批商品
+func (s *XLSXSink) Write(ctx context.Context, items []mtop.FeedItem) error {
+	s.xlsx.Write(s.convert(items))
+	return nil
+}
+
+// Flush xlsx 在 Close 时写出，此处无操作
+func (s *XLSXSink) Flush(ctx context.Context) error {
+	return nil
+}
+
+// Close 生成工作簿并替换目标文件
+func (s *XLSXSink) Close() error {
+	if s.w != nil {
+		if _, err := s.xlsx.WriteTo(s.w); err != nil {
+			s.abort()
+			return fmt.Errorf("生成 xlsx 失败: %w", err)
+		}
+	}
+	return s.close()
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migration 数据库结构版本
+// 版本号记录在 PRAGMA user_version 中，只能追加新版本，不能修改已发布的版本
+type migration struct {
+	version int
+	name    string
+	stmts   []string
+}
+
+var migrations = []migration{
+	{1, "初始结构", []string{
+		`CREATE TABLE crawl_runs (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			source      TEXT    NOT NULL DEFAULT '',
+			status      TEXT    NOT NULL DEFAULT 'running',
+			items       INTEGER NOT NULL DEFAULT 0,
+			started_at  INTEGER NOT NULL,
+			finished_at INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE sellers (
+			nick       TEXT PRIMARY KEY,
+			credit     TEXT    NOT NULL DEFAULT '',
+			city       TEXT    NOT NULL DEFAULT '',
+			shop_level TEXT    NOT NULL DEFAULT '',
+			first_seen INTEGER NOT NULL,
+			last_seen  INTEGER NOT NULL
+		)`,
+		`CREATE TABLE items (
+			item_id       TEXT PRIMARY KEY,
+			title         TEXT    NOT NULL DEFAULT '',
+			price         TEXT    NOT NULL DEFAULT '',
+			price_value   REAL    NOT NULL DEFAULT 0,
+			want_count    INTEGER NOT NULL DEFAULT 0,
+			view_count    INTEGER NOT NULL DEFAULT 0,
+			category_id   INTEGER NOT NULL DEFAULT 0,
+			location      TEXT    NOT NULL DEFAULT '',
+			seller_nick   TEXT    NOT NULL DEFAULT '',
+			status        TEXT    NOT NULL DEFAULT '',
+			condition     TEXT    NOT NULL DEFAULT '',
+			tags          TEXT    NOT NULL DEFAULT '',
+			free_shipping INTEGER NOT NULL DEFAULT 0,
+			image_url     TEXT    NOT NULL DEFAULT '',
+			video_url     TEXT    NOT NULL DEFAULT '',
+			publish_time  INTEGER NOT NULL DEFAULT 0,
+			first_seen    INTEGER NOT NULL,
+			last_seen     INTEGER NOT NULL,
+			seen_count    INTEGER NOT NULL DEFAULT 1
+		)`,
+		`CREATE INDEX idx_items_last_seen ON items(last_seen)`,
+		`CREATE INDEX idx_items_seller ON items(seller_nick)`,
+		`CREATE TABLE observations (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			item_id     TEXT    NOT NULL REFERENCES items(item_id) ON DELETE CASCADE,
+			run_id      INTEGER REFERENCES crawl_runs(id) ON DELETE SET NULL,
+			price       TEXT    NOT NULL DEFAULT '',
+			price_value REAL    NOT NULL DEFAULT 0,
+			want_count  INTEGER NOT NULL DEFAULT 0,
+			view_count  INTEGER NOT NULL DEFAULT 0,
+			status      TEXT    NOT NULL DEFAULT '',
+			source      TEXT    NOT NULL DEFAULT '',
+			captured_at INTEGER NOT NULL
+		)`,
+		`CREATE INDEX idx_observations_item ON observations(item_id, captured_at)`,
+		`CREATE INDEX idx_observations_time ON observations(captured_at)`,
+	}},
+}
+
+// SchemaVersion 当前代码对应的数据库结构版本
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate 依次执行未应用的版本，每个版本在一个事务中完成
+func migrate(ctx context.Context, db *sql.DB) error {
+	var current int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&current); err != nil {
+		return fmt.Errorf("读取数据库版本失败: %w", err)
+	}
+	if current > SchemaVersion() {
+		return fmt.Errorf("数据库版本 %d 高于程序支持的版本 %d，请升级程序", current, SchemaVersion())
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, stmt := range m.stmts {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("数据库迁移 %d（%s）失败: %w", m.version, m.name, err)
+			}
+		}
+		// PRAGMA 不支持参数绑定
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", m.version)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("数据库迁移 %d（%s）失败: %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"xianyu_aner/pkg/history"
+)
+
+// 商品列表排序方式
+const (
+	SortLastSeen = "last_seen" // 最近出现（默认）
+	SortWant     = "want"      // 想要人数降序
+	SortPrice    = "price"     // 价格升序
+	SortNew      = "new"       // 首次出现时间降序
+)
+
+// defaultLimit 未指定数量时的默认条数
+const defaultLimit = 50
+
+// Item 商品（最近一次观测的状态）
+type Item struct {
+	ItemID       string    `json:"itemId"`
+	Title        string    `json:"title"`
+	Price        string    `json:"price"`
+	PriceValue   float64   `json:"priceValue"`
+	WantCount    int       `json:"wantCount"`
+	ViewCount    int       `json:"viewCount,omitempty"`
+	CategoryID   int       `json:"categoryId,omitempty"`
+	Location     string    `json:"location,omitempty"`
+	SellerNick   string    `json:"sellerNick,omitempty"`
+	Status       string    `json:"status,omitempty"`
+	Condition    string    `json:"condition,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	FreeShipping bool      `json:"freeShipping"`
+	ImageURL     string    `json:"imageUrl,omitempty"`
+	VideoURL     string    `json:"videoUrl,omitempty"`
+	PublishTime  int64     `json:"publishTimeMs,omitempty"` // 发布时间戳（毫秒）
+	FirstSeen    time.Time `json:"firstSeen"`               // 首次采集时间
+	LastSeen     time.Time `json:"lastSeen"`                // 最近采集时间
+	SeenCount    int       `json:"seenCount"`               // 采集次数
+}
+
+// Seller 卖家
+type Seller struct {
+	Nick      string    `json:"nick"`
+	Credit    string    `json:"credit,omitempty"`
+	City      string    `json:"city,omitempty"`
+	ShopLevel string    `json:"shopLevel,omitempty"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Items     int       `json:"items"` // 已采集的商品数
+}
+
+// Run 爬取批次
+type Run struct {
+	ID         int64      `json:"id"`
+	Source     string     `json:"source"`
+	Status     string     `json:"status"`
+	Items      int        `json:"items"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"` // 未结束时为空
+}
+
+// Filter 商品查询条件，零值表示不限制
+type Filter struct {
+	Keyword  string    // 标题包含
+	Seller   string    // 卖家昵称
+	Status   string    // 商品状态
+	MinPrice float64   // 最低价格
+	MaxPrice float64   // 最高价格
+	MinWant  int       // 最低想要人数
+	Since    time.Time // 最近采集时间不早于
+	Until    time.Time // 最近采集时间早于
+	Sort     string    // 排序方式，见 Sort* 常量
+	Limit    int       // 返回条数，<= 0 时为 50
+	Offset   int
+}
+
+// where 生成查询条件与参数
+func (f Filter) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.Keyword != "" {
+		conds = append(conds, "title LIKE ? ESCAPE '\\'")
+		args = append(args, "%"+escapeLike(f.Keyword)+"%")
+	}
+	if f.Seller != "" {
+		conds = append(conds, "seller_nick = ?")
+		args = append(args, f.Seller)
+	}
+	if f.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.MinPrice > 0 {
+		conds = append(conds, "price_value >= ?")
+		args = append(args, f.MinPrice)
+	}
+	if f.MaxPrice > 0 {
+		conds = append(conds, "price_value <= ?")
+		args = append(args, f.MaxPrice)
+	}
+	if f.MinWant > 0 {
+		conds = append(conds, "want_count >= ?")
+		args = append(args, f.MinWant)
+	}
+	if !f.Since.IsZero() {
+		conds = append(conds, "last_seen >= ?")
+		args = append(args, f.Since.UnixMilli())
+	}
+	if !f.Until.IsZero() {
+		conds = append(conds, "last_seen < ?")
+		args = append(args, f.Until.UnixMilli())
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// orderBy 排序子句
+func (f Filter) orderBy() string {
+	switch f.Sort {
+	case SortWant:
+		return " ORDER BY want_count DESC, item_id"
+	case SortPrice:
+		return " ORDER BY price_value, item_id"
+	case SortNew:
+		return " ORDER BY first_seen DESC, item_id"
+	default:
+		return " ORDER BY last_seen DESC, item_id"
+	}
+}
+
+const itemColumns = `item_id, title, price, price_value, want_count, view_count, category_id, location, seller_nick,
+	status, condition, tags, free_shipping, image_url, video_url, publish_time, first_seen, last_seen, seen_count`
+
+// Items 按条件查询商品，同时返回满足条件的总数
+func (d *DB) Items(ctx context.Context, f Filter) ([]Item, int, error) {
+	where, args := f.where()
+	var total int
+	if err := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM items"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	rows, err := d.db.QueryContext(ctx, "SELECT "+itemColumns+" FROM items"+where+f.orderBy()+" LIMIT ? OFFSET ?",
+		append(args, limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+	}
+	return items, total, rows.Err()
+}
+
+// Item 查询单个商品，不存在时 ok 为 false
+func (d *DB) Item(ctx context.Context, itemID string) (Item, bool, error) {
+	row := d.db.QueryRowContext(ctx, "SELECT "+itemColumns+" FROM items WHERE item_id = ?", itemID)
+	item, err := scanItem(row)
+	if err == sql.ErrNoRows {
+		return Item{}, false, nil
+	}
+	if err != nil {
+		return Item{}, false, err
+	}
+	return item, true, nil
+}
+
+// Observations 查询商品在 [from, to) 内的观测（按采集时间升序），时间为零值表示不限制
+func (d *DB) Observations(ctx context.Context, itemID string, from, to time.Time) ([]history.Observation, error) {
+	query := observationQuery + " WHERE o.item_id = ?"
+	args := []interface{}{itemID}
+	if !from.IsZero() {
+		query += " AND o.captured_at >= ?"
+		args = append(args, from.UnixMilli())
+	}
+	if !to.IsZero() {
+		query += " AND o.captured_at < ?"
+		args = append(args, to.UnixMilli())
+	}
+	return d.queryObservations(ctx, query+" ORDER BY o.captured_at, o.id", args...)
+}
+
+// PriceDrops 自 since 以来降价的商品，口径与 history.Store.PriceDrops 一致
+func (d *DB) PriceDrops(ctx context.Context, since time.Time) ([]history.PriceChange, error) {
+	lists, err := d.activeHistories(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	var drops []history.PriceChange
+	for _, list := range lists {
+		if drop, ok := history.PriceDrop(list, since); ok {
+			drops = append(drops, drop)
+		}
+	}
+	history.SortPriceDrops(drops)
+	return drops, nil
+}
+
+// FastestWantGrowth 自 since 以来想要人数增长最快的商品，口径与 history.Store.FastestWantGrowth 一致
+func (d *DB) FastestWantGrowth(ctx context.Context, since time.Time, limit int) ([]history.WantGrowth, error) {
+	lists, err := d.activeHistories(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	var growth []history.WantGrowth
+	for _, list := range lists {
+		if g, ok := history.Growth(list, since); ok {
+			growth = append(growth, g)
+		}
+	}
+	return history.SortWantGrowth(growth, limit), nil
+}
+
+// Sellers 按最近出现时间倒序查询卖家
+func (d *DB) Sellers(ctx context.Context, limit int) ([]Seller, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	rows, err := d.db.QueryContext(ctx, `SELECT s.nick, s.credit, s.city, s.shop_level, s.first_seen, s.last_seen,
+			(SELECT COUNT(*) FROM items i WHERE i.seller_nick = s.nick)
+		FROM sellers s ORDER BY s.last_seen DESC, s.nick LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sellers := []Seller{}
+	for rows.Next() {
+		var s Seller
+		var first, last int64
+		if err := rows.Scan(&s.Nick, &s.Credit, &s.City, &s.ShopLevel, &first, &last, &s.Items); err != nil {
+			return nil, err
+		}
+		s.FirstSeen, s.LastSeen = time.UnixMilli(first), time.UnixMilli(last)
+		sellers = append(sellers, s)
+	}
+	return sellers, rows.Err()
+}
+
+// Runs 最近的爬取批次（按开始时间倒序）
+func (d *DB) Runs(ctx context.Context, limit int) ([]Run, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	rows, err := d.db.QueryContext(ctx,
+		`SELECT id, source, status, items, started_at, finished_at FROM crawl_runs ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []Run{}
+	for rows.Next() {
+		var r Run
+		var started, finished int64
+		if err := rows.Scan(&r.ID, &r.Source, &r.Status, &r.Items, &started, &finished); err != nil {
+			return nil, err
+		}
+		r.StartedAt = time.UnixMilli(started)
+		if finished > 0 {
+			t := time.UnixMilli(finished)
+			r.FinishedAt = &t
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// observationQuery 观测查询（标题、主图、分类取自商品表）
+const observationQuery = `SELECT o.item_id, i.title, i.image_url, i.category_id, o.price, o.price_value,
+	o.want_count, o.view_count, o.status, o.source, o.captured_at
+	FROM observations o JOIN items i ON i.item_id = o.item_id`
+
+// activeHistories 自 since 以来有观测的商品的全部观测（按商品分组，采集时间升序）
+func (d *DB) activeHistories(ctx context.Context, since time.Time) (map[string][]history.Observation, error) {
+	list, err := d.queryObservations(ctx, observationQuery+`
+		WHERE o.item_id IN (SELECT DISTINCT item_id FROM observations WHERE captured_at >= ?)
+		ORDER BY o.item_id, o.captured_at, o.id`, since.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	byItem := make(map[string][]history.Observation)
+	for _, obs := range list {
+		byItem[obs.ItemID] = append(byItem[obs.ItemID], obs)
+	}
+	return byItem, nil
+}
+
+func (d *DB) queryObservations(ctx context.Context, query string, args ...interface{}) ([]history.Observation, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []history.Observation{}
+	for rows.Next() {
+		var o history.Observation
+		if err := rows.Scan(&o.ItemID, &o.Title, &o.ImageURL, &o.CategoryID, &o.Price, &o.PriceValue,
+			&o.WantCount, &o.ViewCount, &o.Status, &o.Source, &o.CapturedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, o)
+	}
+	return list, rows.Err()
+}
+
+// scanner sql.Row 与 sql.Rows 的共同接口
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanItem(row scanner) (Item, error) {
+	var item Item
+	var tags string
+	var first, last int64
+	err := row.Scan(&item.ItemID, &item.Title, &item.Price, &item.PriceValue, &item.WantCount, &item.ViewCount,
+		&item.CategoryID, &item.Location, &item.SellerNick, &item.Status, &item.Condition, &tags, &item.FreeShipping,
+		&item.ImageURL, &item.VideoURL, &item.PublishTime, &first, &last, &item.SeenCount)
+	if err != nil {
+		return Item{}, err
+	}
+	if tags != "" {
+		json.Unmarshal([]byte(tags), &item.Tags)
+	}
+	item.FirstSeen, item.LastSeen = time.UnixMilli(first), time.UnixMilli(last)
+	return item, nil
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // 纯 Go SQLite 驱动，无需 CGO
+
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+)
+
+// 爬取批次状态
+const (
+	RunRunning  = "running"  // 进行中（进程中途退出时保持此状态）
+	RunFinished = "finished" // 已完成
+	RunFailed   = "failed"   // 失败
+)
+
+// DB 基于 SQLite 的商品存储：商品（按 ItemID 更新）、观测快照、卖家与爬取批次
+type DB struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// UpsertResult 写入统计
+type UpsertResult struct {
+	Inserted int `json:"inserted"` // 新增商品数
+	Updated  int `json:"updated"`  // 已有商品更新数
+}
+
+// Open 打开（或创建）数据库文件并执行迁移
+func Open(path string) (*DB, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建数据库目录失败: %w", err)
+		}
+	}
+	// WAL 允许查询与写入并发；单连接避免多个写连接互相等待（SQLITE_BUSY）
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() +
+		"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(context.Background(), db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &DB{db: db, now: time.Now}, nil
+}
+
+// Close 关闭数据库
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// StartRun 记录一次爬取批次的开始，返回批次ID
+func (d *DB) StartRun(ctx context.Context, source string) (int64, error) {
+	res, err := d.db.ExecContext(ctx,
+		`INSERT INTO crawl_runs (source, status, started_at) VALUES (?, ?, ?)`,
+		source, RunRunning, d.now().UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("记录爬取批次失败: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// FinishRun 记录爬取批次结束，商品数为该批次写入的观测数
+func (d *DB) FinishRun(ctx context.Context, runID int64, status string) error {
+	_, err := d.db.ExecContext(ctx,
+		`UPDATE crawl_runs SET status = ?, finished_at = ?,
+			items = (SELECT COUNT(*) FROM observations WHERE run_id = ?)
+		WHERE id = ?`,
+		status, d.now().UnixMilli(), runID, runID)
+	if err != nil {
+		return fmt.Errorf("更新爬取批次失败: %w", err)
+	}
+	return nil
+}
+
+// UpsertItems 在一个事务中写入一批商品：按 ItemID 新增或更新商品与卖家，并追加一条观测快照
+// runID 为 0 表示不关联爬取批次；source 为观测来源（如 feed）
+func (d *DB) UpsertItems(ctx context.Context, runID int64, source string, items []mtop.FeedItem) (UpsertResult, error) {
+	var result UpsertResult
+	if len(items) == 0 {
+		return result, nil
+	}
+	now := d.now()
+	at := now.UnixMilli()
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	var run interface{}
+	if runID > 0 {
+		run = runID
+	}
+	for _, item := range items {
+		if item.ItemID == "" {
+			continue
+		}
+		var exists int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM items WHERE item_id = ?`, item.ItemID).Scan(&exists)
+		if err != nil {
+			return result, err
+		}
+		if err := upsertItem(ctx, tx, item, at); err != nil {
+			return result, fmt.Errorf("写入商品 %s 失败: %w", item.ItemID, err)
+		}
+		if err := upsertSeller(ctx, tx, item, at); err != nil {
+			return result, fmt.Errorf("写入卖家 %s 失败: %w", item.SellerNick, err)
+		}
+		obs := history.FromFeedItem(item, now)
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO observations (item_id, run_id, price, price_value, want_count, view_count, status, source, captured_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			obs.ItemID, run, obs.Price, obs.PriceValue, obs.WantCount, obs.ViewCount, obs.Status, source, at); err != nil {
+			return result, fmt.Errorf("写入观测 %s 失败: %w", item.ItemID, err)
+		}
+		if exists > 0 {
+			result.Updated++
+		} else {
+			result.Inserted++
+		}
+	}
+	return result, tx.Commit()
+}
+
+// upsertItem 新增或更新商品；文本字段为空时保留已有值，首次出现时间不变
+func upsertItem(ctx context.Context, tx *sql.Tx, item mtop.FeedItem, at int64) error {
+	tags := ""
+	if len(item.Tags) > 0 {
+		data, _ := json.Marshal(item.Tags)
+		tags = string(data)
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO items (
+			item_id, title, price, price_value, want_count, view_count, category_id, location, seller_nick,
+			status, condition, tags, free_shipping, image_url, video_url, publish_time, first_seen, last_seen
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(item_id) DO UPDATE SET
+			title         = COALESCE(NULLIF(excluded.title, ''), items.title),
+			price         = COALESCE(NULLIF(excluded.price, ''), items.price),
+			price_value   = CASE WHEN excluded.price = '' THEN items.price_value ELSE excluded.price_value END,
+			want_count    = excluded.want_count,
+			view_count    = excluded.view_count,
+			category_id   = CASE WHEN excluded.category_id = 0 THEN items.category_id ELSE excluded.category_id END,
+			location      = COALESCE(NULLIF(excluded.location, ''), items.location),
+			seller_nick   = COALESCE(NULLIF(excluded.seller_nick, ''), items.seller_nick),
+			status        = COALESCE(NULLIF(excluded.status, ''), items.status),
+			condition     = COALESCE(NULLIF(excluded.condition, ''), items.condition),
+			tags          = COALESCE(NULLIF(excluded.tags, ''), items.tags),
+			free_shipping = excluded.free_shipping,
+			image_url     = COALESCE(NULLIF(excluded.image_url, ''), items.image_url),
+			video_url     = COALESCE(NULLIF(excluded.video_url, ''), items.video_url),
+			publish_time  = CASE WHEN excluded.publish_time = 0 THEN items.publish_time ELSE excluded.publish_time END,
+			last_seen     = MAX(items.last_seen, excluded.last_seen),
+			seen_count    = items.seen_count + 1`,
+		item.ItemID, item.Title, item.Price, history.ParsePrice(item.Price), item.WantCount, item.ViewCount,
+		item.CategoryID, item.Location, item.SellerNick, item.Status, item.Condition, tags, item.FreeShipping,
+		item.ImageURL, item.VideoURL, item.PublishTimeTS, at, at)
+	return err
+}
+
+// upsertSeller 新增或更新卖家（以昵称为键，昵称为空时跳过）
+func upsertSeller(ctx context.Context, tx *sql.Tx, item mtop.FeedItem, at int64) error {
+	if item.SellerNick == "" {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO sellers (nick, credit, city, shop_level, first_seen, last_seen)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(nick) DO UPDATE SET
+			credit     = COALESCE(NULLIF(excluded.credit, ''), sellers.credit),
+			city       = COALESCE(NULLIF(excluded.city, ''), sellers.city),
+			shop_level = COALESCE(NULLIF(excluded.shop_level, ''), sellers.shop_level),
+			last_seen  = MAX(sellers.last_seen, excluded.last_seen)`,
+		item.SellerNick, item.SellerCredit, item.Location, item.ShopLevel, at, at)
+	return err
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"xianyu_aner/pkg/mtop"
+)
+
+// openTest 打开临时数据库，时钟由 *now 控制
+func openTest(t *testing.T, now *time.Time) *DB {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "sub", "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	db.now = func() time.Time { return *now }
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestOpen_Migrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "xianyu.db")
+	for i := 0; i < 2; i++ {
+		db, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open #%d: %v", i+1, err)
+		}
+		var version int
+		db.db.QueryRow("PRAGMA user_version").Scan(&version)
+		if version != SchemaVersion() {
+			t.Errorf("user_version = %d, want %d", version, SchemaVersion())
+		}
+		db.Close()
+	}
+
+	db, _ := Open(path)
+	db.db.Exec("PRAGMA user_version = 999")
+	db.Close()
+	if _, err := Open(path); err == nil {
+		t.Errorf("expected error for newer schema version")
+	}
+}
+
+func TestUpsertItems(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.Local)
+	db := openTest(t, &now)
+
+	run, err := db.StartRun(ctx, "crawl")
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	first := []mtop.FeedItem{
+		{ItemID: "1", Title: "索尼相机", Price: "¥1,999", WantCount: 5, SellerNick: "alice", Location: "上海", Tags: []string{"包邮"}},
+		{ItemID: "2", Title: "iPad", Price: "2500", WantCount: 30, SellerNick: "bob"},
+	}
+	res, err := db.UpsertItems(ctx, run, "feed", first)
+	if err != nil || res.Inserted != 2 || res.Updated != 0 {
+		t.Fatalf("first upsert = %+v, %v", res, err)
+	}
+	db.FinishRun(ctx, run, RunFinished)
+
+	// 两小时后：商品1降价且标题为空（保留原标题），新增商品3
+	now = now.Add(2 * time.Hour)
+	res, err = db.UpsertItems(ctx, 0, "feed", []mtop.FeedItem{
+		{ItemID: "1", Price: "1500", WantCount: 9},
+		{ItemID: "3", Title: "Switch_OLED 100%新", Price: "1800", WantCount: 1},
+	})
+	if err != nil || res.Inserted != 1 || res.Updated != 1 {
+		t.Fatalf("second upsert = %+v, %v", res, err)
+	}
+
+	item, ok, err := db.Item(ctx, "1")
+	if err != nil || !ok {
+		t.Fatalf("Item: %v %v", ok, err)
+	}
+	if item.Title != "索尼相机" || item.PriceValue != 1500 || item.WantCount != 9 || item.SeenCount != 2 ||
+		item.Location != "上海" || len(item.Tags) != 1 || !item.LastSeen.After(item.FirstSeen) {
+		t.Errorf("item after upsert = %+v", item)
+	}
+	if _, ok, _ := db.Item(ctx, "404"); ok {
+		t.Errorf("missing item reported as found")
+	}
+
+	obs, err := db.Observations(ctx, "1", time.Time{}, time.Time{})
+	if err != nil || len(obs) != 2 || obs[0].PriceValue != 1999 || obs[1].Title != "索尼相机" {
+		t.Fatalf("Observations = %+v, %v", obs, err)
+	}
+	if obs, _ := db.Observations(ctx, "1", now.Add(-time.Hour), time.Time{}); len(obs) != 1 {
+		t.Errorf("time range not applied: %d", len(obs))
+	}
+
+	since := now.Add(-time.Hour)
+	drops, err := db.PriceDrops(ctx, since)
+	if err != nil || len(drops) != 1 || drops[0].ItemID != "1" || drops[0].OldPrice != 1999 {
+		t.Errorf("PriceDrops = %+v, %v", drops, err)
+	}
+	growth, err := db.FastestWantGrowth(ctx, since, 10)
+	if err != nil || len(growth) != 1 || growth[0].Delta != 4 || growth[0].PerHour != 2 {
+		t.Errorf("FastestWantGrowth = %+v, %v", growth, err)
+	}
+
+	runs, err := db.Runs(ctx, 10)
+	if err != nil || len(runs) != 1 || runs[0].Items != 2 || runs[0].Status != RunFinished || runs[0].FinishedAt == nil {
+		t.Errorf("Runs = %+v, %v", runs, err)
+	}
+	sellers, err := db.Sellers(ctx, 10)
+	if err != nil || len(sellers) != 2 || sellers[0].Items != 1 {
+		t.Errorf("Sellers = %+v, %v", sellers, err)
+	}
+}
+
+func TestItems_Filter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.Local)
+	db := openTest(t, &now)
+
+	db.UpsertItems(ctx, 0, "feed", []mtop.FeedItem{
+		{ItemID: "1", Title: "索尼相机", Price: "1999", WantCount: 5, SellerNick: "alice"},
+		{ItemID: "2", Title: "iPad 100%新", Price: "2500", WantCount: 30, SellerNick: "bob"},
+	})
+	now = now.Add(24 * time.Hour)
+	db.UpsertItems(ctx, 0, "feed", []mtop.FeedItem{
+		{ItemID: "3", Title: "相机包", Price: "99", WantCount: 12, SellerNick: "alice"},
+	})
+
+	cases := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all", Filter{}, []string{"3", "1", "2"}},
+		{"keyword", Filter{Keyword: "相机", Sort: SortPrice}, []string{"3", "1"}},
+		{"like wildcard escaped", Filter{Keyword: "100%"}, []string{"2"}},
+		{"seller", Filter{Seller: "alice", Sort: SortWant}, []string{"3", "1"}},
+		{"price range", Filter{MinPrice: 100, MaxPrice: 2000}, []string{"1"}},
+		{"min want", Filter{MinWant: 10, Sort: SortWant}, []string{"2", "3"}},
+		{"since", Filter{Since: now.Add(-time.Hour)}, []string{"3"}},
+		{"until", Filter{Until: now.Add(-time.Hour), Sort: SortWant}, []string{"2", "1"}},
+		{"limit offset", Filter{Sort: SortWant, Limit: 1, Offset: 1}, []string{"3"}},
+	}
+	for _, c := range cases {
+		items, total, err := db.Items(ctx, c.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		var got []string
+		for _, item := range items {
+			got = append(got, item.ItemID)
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+				break
+			}
+		}
+		if c.filter.Limit == 0 && total != len(c.want) {
+			t.Errorf("%s: total = %d", c.name, total)
+		}
+	}
+}
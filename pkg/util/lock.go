@@ -0,0 +1,56 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// lockWait 等待其他进程释放文件锁的最长时间
+	lockWait = 10 * time.Second
+	// lockStale 锁文件超过该时长未释放视为进程异常退出遗留（持锁期间只应读写文件，不做网络请求）
+	lockStale = 30 * time.Second
+)
+
+// lockMus 进程内每个文件一把互斥锁，跨进程（server 与 crawl）由锁文件保证
+var lockMus sync.Map
+
+// LockFile 获取文件锁：进程内互斥锁 + 以 O_EXCL 创建的 <path>.lock，返回释放函数
+// 用于多个进程对同一状态文件的读改写
+func LockFile(path string) (func(), error) {
+	v, _ := lockMus.LoadOrStore(path, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		mu.Unlock()
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() {
+				os.Remove(lockPath)
+				mu.Unlock()
+			}, nil
+		}
+		if !os.IsExist(err) {
+			mu.Unlock()
+			return nil, fmt.Errorf("创建文件锁失败: %w", err)
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			mu.Unlock()
+			return nil, fmt.Errorf("等待文件锁超时: %s", lockPath)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,21 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Progress 横幅、进度等提示信息的输出（默认标准输出）
+// 数据写入标准输出时（如 crawl --output -）调用方将其设为标准错误，提示信息不会混入数据
+var Progress io.Writer = os.Stdout
+
+// Printf 按格式向 Progress 输出提示信息
+func Printf(format string, a ...any) {
+	fmt.Fprintf(Progress, format, a...)
+}
+
+// Println 向 Progress 输出一行提示信息
+func Println(a ...any) {
+	fmt.Fprintln(Progress, a...)
+}
@@ -0,0 +1,114 @@
+package watch
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"xianyu_aner/pkg/util"
+)
+
+// Kind 关注项类型
+type Kind string
+
+const (
+	KindItem    Kind = "item"    // 单个商品（按详情检查状态与价格）
+	KindKeyword Kind = "keyword" // 关键词（按搜索结果发现新商品）
+	KindSeller  Kind = "seller"  // 卖家（按卖家商品列表发现新商品）
+)
+
+// Entry 关注项
+type Entry struct {
+	ID              string `yaml:"id" json:"id"`
+	Kind            Kind   `yaml:"kind" json:"kind"`
+	Target          string `yaml:"target" json:"target"`                                        // 商品ID / 关键词 / 卖家ID
+	Name            string `yaml:"name,omitempty" json:"name,omitempty"`                        // 备注名称
+	Filter          string `yaml:"filter,omitempty" json:"filter,omitempty"`                    // 过滤表达式，见 Filter
+	IntervalMinutes int    `yaml:"interval_minutes,omitempty" json:"intervalMinutes,omitempty"` // 检查间隔（0 使用默认值）
+	Disabled        bool   `yaml:"disabled,omitempty" json:"disabled,omitempty"`                // 暂停检查
+}
+
+// DisplayName 展示名称：优先使用备注名称
+func (e Entry) DisplayName() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	return e.Target
+}
+
+// normalize 校验并补全关注项，返回编译后的过滤器
+func (e *Entry) normalize() (*Filter, error) {
+	e.Kind = Kind(strings.ToLower(strings.TrimSpace(string(e.Kind))))
+	e.Target = strings.TrimSpace(e.Target)
+	switch e.Kind {
+	case KindItem, KindKeyword, KindSeller:
+	default:
+		return nil, fmt.Errorf("未知的关注类型: %q（可选 item/keyword/seller）", e.Kind)
+	}
+	if e.Target == "" {
+		return nil, fmt.Errorf("关注目标不能为空")
+	}
+	if e.IntervalMinutes < 0 {
+		return nil, fmt.Errorf("检查间隔不能为负数")
+	}
+	filter, err := ParseFilter(e.Filter)
+	if err != nil {
+		return nil, err
+	}
+	if e.ID == "" {
+		e.ID = defaultID(e.Kind, e.Target)
+	}
+	return filter, nil
+}
+
+// interval 检查间隔
+func (e Entry) interval(def time.Duration) time.Duration {
+	if e.IntervalMinutes > 0 {
+		return time.Duration(e.IntervalMinutes) * time.Minute
+	}
+	return def
+}
+
+// defaultID 由类型与目标生成稳定的ID，如 keyword-3f2a9c1b
+func defaultID(kind Kind, target string) string {
+	sum := sha1.Sum([]byte(strings.ToLower(target)))
+	return string(kind) + "-" + hex.EncodeToString(sum[:4])
+}
+
+// listFile 关注列表文件结构
+type listFile struct {
+	Entries []Entry `yaml:"entries"`
+}
+
+// LoadList 从 YAML 文件加载关注列表（文件不存在时返回空列表）
+func LoadList(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取关注列表失败: %w", err)
+	}
+
+	var list listFile
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("解析关注列表失败: %w", err)
+	}
+	return list.Entries, nil
+}
+
+// SaveList 保存关注列表到 YAML 文件（先写临时文件再重命名）
+func SaveList(path string, entries []Entry) error {
+	data, err := yaml.Marshal(listFile{Entries: entries})
+	if err != nil {
+		return fmt.Errorf("序列化关注列表失败: %w", err)
+	}
+	if err := util.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("写入关注列表失败: %w", err)
+	}
+	return nil
+}
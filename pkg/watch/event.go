@@ -0,0 +1,87 @@
+package watch
+
+import (
+	"fmt"
+	"time"
+
+	"xianyu_aner/pkg/lifecycle"
+)
+
+// EventType 事件类型
+type EventType string
+
+const (
+	EventNewMatch    EventType = "new_match"     // 关键词/卖家出现新商品
+	EventPriceDrop   EventType = "price_drop"    // 降价
+	EventBackInStock EventType = "back_in_stock" // 下架/已拍下后重新在售
+	EventSold        EventType = "sold"          // 已售出
+)
+
+// Event 关注项触发的事件
+type Event struct {
+	Type         EventType `json:"type"`
+	EntryID      string    `json:"entryId"`
+	EntryName    string    `json:"entryName"`
+	Kind         Kind      `json:"kind"`
+	ItemID       string    `json:"itemId"`
+	Title        string    `json:"title,omitempty"`
+	Price        float64   `json:"price,omitempty"`
+	OldPrice     float64   `json:"oldPrice,omitempty"` // 降价前价格（仅 price_drop）
+	ImageURL     string    `json:"imageUrl,omitempty"`
+	Seller       string    `json:"seller,omitempty"`       // 卖家昵称（用于卖家风险评估）
+	SellerCredit string    `json:"sellerCredit,omitempty"` // 卖家信用
+	At           int64     `json:"at"`                     // 触发时间戳（毫秒）
+}
+
+// Time 触发时间
+func (e Event) Time() time.Time {
+	return time.UnixMilli(e.At)
+}
+
+// Summary 一句话描述，用于日志与通知
+func (e Event) Summary() string {
+	switch e.Type {
+	case EventNewMatch:
+		return fmt.Sprintf("[%s] 新商品: %s ¥%.2f", e.EntryName, e.Title, e.Price)
+	case EventPriceDrop:
+		return fmt.Sprintf("[%s] 降价: %s ¥%.2f -> ¥%.2f", e.EntryName, e.Title, e.OldPrice, e.Price)
+	case EventBackInStock:
+		return fmt.Sprintf("[%s] 重新在售: %s ¥%.2f", e.EntryName, e.Title, e.Price)
+	case EventSold:
+		return fmt.Sprintf("[%s] 已售出: %s", e.EntryName, e.Title)
+	}
+	return fmt.Sprintf("[%s] %s: %s", e.EntryName, e.Type, e.Title)
+}
+
+// ItemState 关注项下单个商品的最近状态
+type ItemState struct {
+	Title     string          `json:"title,omitempty"`
+	Price     float64         `json:"price"`
+	Stage     lifecycle.Stage `json:"stage"`
+	FirstSeen int64           `json:"firstSeen"`
+	LastSeen  int64           `json:"lastSeen"`
+}
+
+// EntryState 关注项的检查状态
+type EntryState struct {
+	Initialized bool                  `json:"initialized"` // 是否已完成首次检查（首次检查只建立基线，不触发事件）
+	LastRun     int64                 `json:"lastRun,omitempty"`
+	NextRun     int64                 `json:"nextRun"`
+	Failures    int                   `json:"failures"`
+	LastError   string                `json:"lastError,omitempty"`
+	Items       map[string]*ItemState `json:"items"`
+}
+
+// isAvailable 是否为可购买状态
+func isAvailable(stage lifecycle.Stage) bool {
+	return stage == lifecycle.StageOnSale
+}
+
+// isUnavailable 是否为暂不可购买状态（恢复在售时触发 back_in_stock）
+func isUnavailable(stage lifecycle.Stage) bool {
+	switch stage {
+	case lifecycle.StageOffline, lifecycle.StageReserved, lifecycle.StageSold, lifecycle.StageDeleted:
+		return true
+	}
+	return false
+}
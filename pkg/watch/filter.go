@@ -0,0 +1,354 @@
+package watch
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/mtop"
+)
+
+// Filter 关注项的过滤表达式
+//
+// 语法：比较式用 &&、||、! 和括号组合，例如
+//
+//	price < 3000 && want >= 5 && !(title ~ "配件" || title ~ "壳")
+//
+// 数值字段：price（价格）、want（想要人数）、view（浏览）、category（分类ID）、days（发布天数，未知时比较结果为假）
+// 文本字段：title、city、seller、status，支持 == != ~（包含）!~（不包含），不区分大小写
+type Filter struct {
+	src  string
+	root node
+}
+
+// numericFields 数值字段
+var numericFields = map[string]bool{"price": true, "want": true, "view": true, "category": true, "days": true}
+
+// textFields 文本字段
+var textFields = map[string]bool{"title": true, "city": true, "seller": true, "status": true}
+
+// ParseFilter 解析过滤表达式，空表达式返回 nil（匹配全部）
+func ParseFilter(expr string) (*Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("过滤表达式多余的内容: %q", p.tokens[p.pos].text)
+	}
+	return &Filter{src: expr, root: root}, nil
+}
+
+// String 返回原始表达式
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.src
+}
+
+// Match 判断商品是否满足过滤条件（nil 过滤器匹配全部）
+func (f *Filter) Match(item mtop.FeedItem, now time.Time) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.eval(itemValues{item: item, now: now})
+}
+
+// itemValues 表达式求值时的商品字段
+type itemValues struct {
+	item mtop.FeedItem
+	now  time.Time
+}
+
+// number 取数值字段，未知时返回 NaN
+func (v itemValues) number(field string) float64 {
+	switch field {
+	case "price":
+		if p := history.ParsePrice(v.item.Price); p > 0 {
+			return p
+		}
+	case "want":
+		return float64(v.item.WantCount)
+	case "view":
+		return float64(v.item.ViewCount)
+	case "category":
+		return float64(v.item.CategoryID)
+	case "days":
+		if v.item.PublishTimeTS > 0 {
+			return v.now.Sub(time.UnixMilli(v.item.PublishTimeTS)).Hours() / 24
+		}
+	}
+	return math.NaN()
+}
+
+// text 取文本字段（已转小写）
+func (v itemValues) text(field string) string {
+	switch field {
+	case "title":
+		return strings.ToLower(v.item.Title)
+	case "city":
+		return strings.ToLower(v.item.Location)
+	case "seller":
+		return strings.ToLower(v.item.SellerNick)
+	case "status":
+		return strings.ToLower(v.item.Status)
+	}
+	return ""
+}
+
+// ==================== 语法树 ====================
+
+type node interface {
+	eval(v itemValues) bool
+}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ inner node }
+
+func (n andNode) eval(v itemValues) bool { return n.left.eval(v) && n.right.eval(v) }
+func (n orNode) eval(v itemValues) bool  { return n.left.eval(v) || n.right.eval(v) }
+func (n notNode) eval(v itemValues) bool { return !n.inner.eval(v) }
+
+// compareNode 字段比较
+type compareNode struct {
+	field string
+	op    string
+	num   float64
+	str   string
+}
+
+func (n compareNode) eval(v itemValues) bool {
+	if numericFields[n.field] {
+		x := v.number(n.field)
+		if math.IsNaN(x) {
+			return false
+		}
+		switch n.op {
+		case "<":
+			return x < n.num
+		case "<=":
+			return x <= n.num
+		case ">":
+			return x > n.num
+		case ">=":
+			return x >= n.num
+		case "==":
+			return x == n.num
+		case "!=":
+			return x != n.num
+		}
+		return false
+	}
+
+	s := v.text(n.field)
+	switch n.op {
+	case "==":
+		return s == n.str
+	case "!=":
+		return s != n.str
+	case "~":
+		return strings.Contains(s, n.str)
+	case "!~":
+		return !strings.Contains(s, n.str)
+	}
+	return false
+}
+
+// ==================== 词法分析 ====================
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize 将表达式拆分为词法单元
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("过滤表达式字符串未闭合")
+			}
+			tokens = append(tokens, token{tokString, string(runes[i+1 : j])})
+			i = j + 1
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, token{tokIdent, strings.ToLower(string(runes[i:j]))})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "<=", ">=", "==", "!=", "!~", "<", ">", "~", "!", "(", ")"} {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("过滤表达式无法识别的字符: %q", r)
+			}
+			tokens = append(tokens, token{tokOp, op})
+			i += len([]rune(op))
+		}
+	}
+	return tokens, nil
+}
+
+// ==================== 语法分析 ====================
+
+// parser 递归下降解析器，优先级: ! > && > ||
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peekOp(op string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokOp && p.tokens[p.pos].text == op
+}
+
+func (p *parser) next() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, true
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("||") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("&&") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peekOp("!") {
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	if p.peekOp("(") {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekOp(")") {
+			return nil, fmt.Errorf("过滤表达式缺少右括号")
+		}
+		p.pos++
+		return inner, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	field, ok := p.next()
+	if !ok || field.kind != tokIdent {
+		return nil, fmt.Errorf("过滤表达式应为字段名")
+	}
+	if !numericFields[field.text] && !textFields[field.text] {
+		return nil, fmt.Errorf("过滤表达式未知字段: %s", field.text)
+	}
+	op, ok := p.next()
+	if !ok || op.kind != tokOp {
+		return nil, fmt.Errorf("字段 %s 后应为比较运算符", field.text)
+	}
+	value, ok := p.next()
+	if !ok || (value.kind != tokNumber && value.kind != tokString && value.kind != tokIdent) {
+		return nil, fmt.Errorf("字段 %s 缺少比较值", field.text)
+	}
+
+	n := compareNode{field: field.text, op: op.text}
+	if numericFields[field.text] {
+		switch op.text {
+		case "<", "<=", ">", ">=", "==", "!=":
+		default:
+			return nil, fmt.Errorf("数值字段 %s 不支持运算符 %s", field.text, op.text)
+		}
+		num, err := strconv.ParseFloat(value.text, 64)
+		if err != nil || value.kind != tokNumber {
+			return nil, fmt.Errorf("数值字段 %s 的比较值无效: %q", field.text, value.text)
+		}
+		n.num = num
+		return n, nil
+	}
+
+	switch op.text {
+	case "==", "!=", "~", "!~":
+	default:
+		return nil, fmt.Errorf("文本字段 %s 不支持运算符 %s", field.text, op.text)
+	}
+	n.str = strings.ToLower(value.text)
+	return n, nil
+}
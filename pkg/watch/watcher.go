@@ -0,0 +1,588 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"xianyu_aner/pkg/history"
+	"xianyu_aner/pkg/lifecycle"
+	"xianyu_aner/pkg/mtop"
+	"xianyu_aner/pkg/util"
+)
+
+// Source 关注项数据来源（*mtop.Client 实现了该接口）
+type Source interface {
+	FetchItemDetail(itemID string) (*mtop.ItemDetail, error)
+	Search(keyword string, page int) ([]mtop.FeedItem, bool, error)
+	SellerItems(sellerID string, page int) ([]mtop.FeedItem, bool, error)
+}
+
+// ErrEntryNotFound 关注项不存在
+var ErrEntryNotFound = errors.New("关注项不存在")
+
+// staleAfter 关键词/卖家下超过该时长未再出现的商品从状态中移除
+const staleAfter = 30 * 24 * time.Hour
+
+// Options 调度选项
+type Options struct {
+	Interval  time.Duration // 默认检查间隔（默认 30 分钟）
+	Pages     int           // 关键词/卖家每次检查的页数（默认 1）
+	MaxEvents int           // 保留的最近事件数（默认 200）
+}
+
+// Watcher 关注列表调度器：定期检查关注项并触发事件，状态持久化避免重启后重复提醒
+type Watcher struct {
+	mu        sync.Mutex
+	saveMu    sync.Mutex // 串行化状态文件写入（HTTP 检查与定时检查可能同时保存），保证后取的快照后写入
+	source    Source
+	listPath  string
+	statePath string
+	opts      Options
+	entries   []Entry
+	filters   map[string]*Filter
+	states    map[string]*EntryState
+	events    []Event
+	handlers  []func(Event)
+	now       func() time.Time
+}
+
+// NewWatcher 创建调度器，从 listPath 加载关注列表、从 statePath 恢复状态（路径为空时不持久化）
+func NewWatcher(source Source, listPath, statePath string, opts Options) (*Watcher, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Minute
+	}
+	if opts.Pages <= 0 {
+		opts.Pages = 1
+	}
+	if opts.MaxEvents <= 0 {
+		opts.MaxEvents = 200
+	}
+
+	w := &Watcher{
+		source:    source,
+		listPath:  listPath,
+		statePath: statePath,
+		opts:      opts,
+		filters:   make(map[string]*Filter),
+		states:    make(map[string]*EntryState),
+		now:       time.Now,
+	}
+
+	if listPath != "" {
+		entries, err := LoadList(listPath)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, e := range entries {
+			filter, err := e.normalize()
+			if err != nil {
+				return nil, fmt.Errorf("关注项 %q 无效: %w", e.DisplayName(), err)
+			}
+			if seen[e.ID] {
+				return nil, fmt.Errorf("关注项ID重复: %s", e.ID)
+			}
+			seen[e.ID] = true
+			w.entries = append(w.entries, e)
+			w.filters[e.ID] = filter
+		}
+	}
+	if err := w.load(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// OnEvent 注册事件处理函数（在检查完成后按顺序调用）
+func (w *Watcher) OnEvent(fn func(Event)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers = append(w.handlers, fn)
+}
+
+// ==================== 关注列表 CRUD ====================
+
+// List 返回全部关注项
+func (w *Watcher) List() []Entry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Entry(nil), w.entries...)
+}
+
+// Get 返回单个关注项及其状态
+func (w *Watcher) Get(id string) (Entry, EntryState, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i := w.indexOf(id)
+	if i < 0 {
+		return Entry{}, EntryState{}, false
+	}
+	var state EntryState
+	if st, ok := w.states[id]; ok {
+		state = copyState(st)
+	}
+	return w.entries[i], state, true
+}
+
+// Add 添加关注项（ID 为空时自动生成），并写回关注列表文件
+func (w *Watcher) Add(e Entry) (Entry, error) {
+	filter, err := e.normalize()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.indexOf(e.ID) >= 0 {
+		return Entry{}, fmt.Errorf("关注项已存在: %s", e.ID)
+	}
+	w.entries = append(w.entries, e)
+	w.filters[e.ID] = filter
+	return e, w.saveListLocked()
+}
+
+// Update 更新关注项；类型或目标变化时清空其状态（重新建立基线）
+func (w *Watcher) Update(id string, e Entry) (Entry, error) {
+	e.ID = id
+	filter, err := e.normalize()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i := w.indexOf(id)
+	if i < 0 {
+		return Entry{}, ErrEntryNotFound
+	}
+	old := w.entries[i]
+	if old.Kind != e.Kind || old.Target != e.Target {
+		delete(w.states, id)
+	} else if st, ok := w.states[id]; ok && old.IntervalMinutes != e.IntervalMinutes {
+		st.NextRun = 0
+	}
+	w.entries[i] = e
+	w.filters[id] = filter
+	return e, w.saveListLocked()
+}
+
+// Remove 删除关注项及其状态
+func (w *Watcher) Remove(id string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i := w.indexOf(id)
+	if i < 0 {
+		return ErrEntryNotFound
+	}
+	w.entries = append(w.entries[:i], w.entries[i+1:]...)
+	delete(w.filters, id)
+	delete(w.states, id)
+	return w.saveListLocked()
+}
+
+// Events 返回最近的事件（新事件在前），limit <= 0 时返回全部
+func (w *Watcher) Events(limit int) []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(w.events)
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	result := make([]Event, 0, limit)
+	for i := n - 1; i >= n-limit; i-- {
+		result = append(result, w.events[i])
+	}
+	return result
+}
+
+func (w *Watcher) indexOf(id string) int {
+	for i, e := range w.entries {
+		if e.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (w *Watcher) saveListLocked() error {
+	if w.listPath == "" {
+		return nil
+	}
+	return SaveList(w.listPath, w.entries)
+}
+
+// ==================== 调度 ====================
+
+// observation 一次检查中看到的商品
+type observation struct {
+	itemID   string
+	title    string
+	price    float64
+	stage    lifecycle.Stage
+	imageURL string
+	seller   string // 卖家昵称
+	credit   string // 卖家信用
+}
+
+// Check 立即检查单个关注项，返回触发的事件
+func (w *Watcher) Check(ctx context.Context, id string) ([]Event, error) {
+	w.mu.Lock()
+	i := w.indexOf(id)
+	if i < 0 {
+		w.mu.Unlock()
+		return nil, ErrEntryNotFound
+	}
+	entry := w.entries[i]
+	filter := w.filters[id]
+	w.mu.Unlock()
+
+	events, err := w.check(ctx, entry, filter)
+	if saveErr := w.Save(); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	w.dispatch(events)
+	return events, err
+}
+
+// CheckDue 检查所有到期的关注项，返回触发的事件；单个关注项失败只记录在其状态中
+func (w *Watcher) CheckDue(ctx context.Context) ([]Event, error) {
+	now := w.now().UnixMilli()
+
+	w.mu.Lock()
+	var due []Entry
+	for _, e := range w.entries {
+		if e.Disabled {
+			continue
+		}
+		if st, ok := w.states[e.ID]; !ok || st.NextRun <= now {
+			due = append(due, e)
+		}
+	}
+	filters := make(map[string]*Filter, len(due))
+	for _, e := range due {
+		filters[e.ID] = w.filters[e.ID]
+	}
+	w.mu.Unlock()
+
+	var all []Event
+	for _, e := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		events, _ := w.check(ctx, e, filters[e.ID])
+		all = append(all, events...)
+	}
+
+	var err error
+	if len(due) > 0 {
+		err = w.Save()
+	}
+	w.dispatch(all)
+	return all, err
+}
+
+// Run 按 tick 周期检查到期关注项，直到 ctx 取消
+func (w *Watcher) Run(ctx context.Context, tick time.Duration, onError func(error)) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.CheckDue(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check 拉取数据并更新状态（不持久化、不分发事件）
+func (w *Watcher) check(ctx context.Context, entry Entry, filter *Filter) ([]Event, error) {
+	observations, err := w.observe(ctx, entry, filter)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// 检查期间关注项可能被删除或修改
+	i := w.indexOf(entry.ID)
+	if i < 0 || w.entries[i].Kind != entry.Kind || w.entries[i].Target != entry.Target {
+		return nil, err
+	}
+
+	now := w.now()
+	st, ok := w.states[entry.ID]
+	if !ok {
+		st = &EntryState{Items: make(map[string]*ItemState)}
+		w.states[entry.ID] = st
+	}
+	st.LastRun = now.UnixMilli()
+
+	if err != nil {
+		st.Failures++
+		st.LastError = err.Error()
+		backoff := entry.interval(w.opts.Interval)
+		for i := 0; i < st.Failures && i < 4; i++ {
+			backoff *= 2
+		}
+		st.NextRun = now.Add(backoff).UnixMilli()
+		return nil, err
+	}
+
+	st.Failures = 0
+	st.LastError = ""
+	st.NextRun = now.Add(entry.interval(w.opts.Interval)).UnixMilli()
+
+	events := w.applyLocked(entry, st, observations, now)
+	st.Initialized = true
+	return events, nil
+}
+
+// observe 按关注项类型拉取商品
+func (w *Watcher) observe(ctx context.Context, entry Entry, filter *Filter) ([]observation, error) {
+	switch entry.Kind {
+	case KindItem:
+		detail, err := w.source.FetchItemDetail(entry.Target)
+		if mtop.IsItemNotFound(err) {
+			return []observation{{itemID: entry.Target, stage: lifecycle.StageDeleted}}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []observation{{
+			itemID:   entry.Target,
+			title:    detail.Title,
+			price:    history.ParsePrice(detail.Price),
+			stage:    lifecycle.ClassifyStatus(detail.ItemStatus, detail.ItemStatusStr),
+			imageURL: detail.ImageURL,
+			seller:   detail.SellerNick,
+			credit:   detail.SellerCredit,
+		}}, nil
+
+	case KindKeyword, KindSeller:
+		fetch := w.source.Search
+		if entry.Kind == KindSeller {
+			fetch = w.source.SellerItems
+		}
+		now := w.now()
+		var result []observation
+		for page := 1; page <= w.opts.Pages; page++ {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			items, hasNext, err := fetch(entry.Target, page)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 页请求失败: %w", page, err)
+			}
+			for _, item := range items {
+				if !filter.Match(item, now) {
+					continue
+				}
+				stage := lifecycle.StageOnSale
+				if item.SoldOut {
+					stage = lifecycle.StageSold
+				} else if s := lifecycle.ClassifyStatus(0, item.Status); s != lifecycle.StageUnknown {
+					stage = s
+				}
+				result = append(result, observation{
+					itemID:   item.ItemID,
+					title:    item.Title,
+					price:    history.ParsePrice(item.Price),
+					stage:    stage,
+					imageURL: item.ImageURL,
+					seller:   item.SellerNick,
+					credit:   item.SellerCredit,
+				})
+			}
+			if !hasNext {
+				break
+			}
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("未知的关注类型: %s", entry.Kind)
+}
+
+// applyLocked 将观测结果与上次状态比较生成事件；首次检查只建立基线
+func (w *Watcher) applyLocked(entry Entry, st *EntryState, observations []observation, now time.Time) []Event {
+	var events []Event
+	emit := func(t EventType, o observation, oldPrice float64) {
+		events = append(events, Event{
+			Type:         t,
+			EntryID:      entry.ID,
+			EntryName:    entry.DisplayName(),
+			Kind:         entry.Kind,
+			ItemID:       o.itemID,
+			Title:        o.title,
+			Price:        o.price,
+			OldPrice:     oldPrice,
+			ImageURL:     o.imageURL,
+			Seller:       o.seller,
+			SellerCredit: o.credit,
+			At:           now.UnixMilli(),
+		})
+	}
+
+	for _, o := range observations {
+		prev, seen := st.Items[o.itemID]
+		if !seen {
+			st.Items[o.itemID] = &ItemState{
+				Title:     o.title,
+				Price:     o.price,
+				Stage:     o.stage,
+				FirstSeen: now.UnixMilli(),
+				LastSeen:  now.UnixMilli(),
+			}
+			if st.Initialized && entry.Kind != KindItem && isAvailable(o.stage) {
+				emit(EventNewMatch, o, 0)
+			}
+			continue
+		}
+
+		if o.title == "" {
+			o.title = prev.Title
+		}
+		if st.Initialized {
+			if o.price > 0 && prev.Price > 0 && o.price < prev.Price && isAvailable(o.stage) {
+				emit(EventPriceDrop, o, prev.Price)
+			}
+			if o.stage != prev.Stage {
+				switch {
+				case o.stage == lifecycle.StageSold:
+					emit(EventSold, o, 0)
+				case isAvailable(o.stage) && isUnavailable(prev.Stage):
+					emit(EventBackInStock, o, 0)
+				}
+			}
+		}
+
+		prev.Title = o.title
+		if o.price > 0 {
+			prev.Price = o.price
+		}
+		if o.stage != lifecycle.StageUnknown {
+			prev.Stage = o.stage
+		}
+		prev.LastSeen = now.UnixMilli()
+	}
+
+	// 清理长期未出现的商品，避免关键词状态无限增长
+	for id, item := range st.Items {
+		if now.Sub(time.UnixMilli(item.LastSeen)) > staleAfter {
+			delete(st.Items, id)
+		}
+	}
+
+	w.events = append(w.events, events...)
+	if over := len(w.events) - w.opts.MaxEvents; over > 0 {
+		w.events = append([]Event(nil), w.events[over:]...)
+	}
+	return events
+}
+
+// dispatch 调用事件处理函数
+func (w *Watcher) dispatch(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	w.mu.Lock()
+	handlers := make([]func(Event), len(w.handlers))
+	copy(handlers, w.handlers)
+	w.mu.Unlock()
+
+	for _, e := range events {
+		for _, fn := range handlers {
+			fn(e)
+		}
+	}
+}
+
+// ==================== 持久化 ====================
+
+// watchState 持久化的调度状态
+type watchState struct {
+	Entries map[string]*EntryState `json:"entries"`
+	Events  []Event                `json:"events"`
+}
+
+// load 从状态文件恢复
+func (w *Watcher) load() error {
+	if w.statePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(w.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取关注状态失败: %w", err)
+	}
+
+	var state watchState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("解析关注状态失败: %w", err)
+	}
+	for id, st := range state.Entries {
+		if w.indexOf(id) < 0 {
+			continue
+		}
+		if st.Items == nil {
+			st.Items = make(map[string]*ItemState)
+		}
+		w.states[id] = st
+	}
+	w.events = state.Events
+	return nil
+}
+
+// Save 保存调度状态（先写临时文件再重命名，避免写坏状态文件）
+func (w *Watcher) Save() error {
+	if w.statePath == "" {
+		return nil
+	}
+
+	w.saveMu.Lock()
+	defer w.saveMu.Unlock()
+
+	w.mu.Lock()
+	state := watchState{
+		Entries: make(map[string]*EntryState, len(w.states)),
+		Events:  append([]Event(nil), w.events...),
+	}
+	for id, st := range w.states {
+		c := copyState(st)
+		state.Entries[id] = &c
+	}
+	w.mu.Unlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化关注状态失败: %w", err)
+	}
+	if err := util.WriteFileAtomic(w.statePath, data, 0644); err != nil {
+		return fmt.Errorf("写入关注状态失败: %w", err)
+	}
+	return nil
+}
+
+// copyState 深拷贝关注项状态
+func copyState(st *EntryState) EntryState {
+	c := *st
+	c.Items = make(map[string]*ItemState, len(st.Items))
+	for id, item := range st.Items {
+		it := *item
+		c.Items[id] = &it
+	}
+	return c
+}
@@ -0,0 +1,307 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"xianyu_aner/pkg/mtop"
+)
+
+// fakeSource 返回预设的详情、搜索与卖家商品
+type fakeSource struct {
+	details map[string]*mtop.ItemDetail
+	errs    map[string]error
+	search  map[string][]mtop.FeedItem
+	sellers map[string][]mtop.FeedItem
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		details: make(map[string]*mtop.ItemDetail),
+		errs:    make(map[string]error),
+		search:  make(map[string][]mtop.FeedItem),
+		sellers: make(map[string][]mtop.FeedItem),
+	}
+}
+
+func (f *fakeSource) FetchItemDetail(itemID string) (*mtop.ItemDetail, error) {
+	if err, ok := f.errs[itemID]; ok {
+		return nil, err
+	}
+	return f.details[itemID], nil
+}
+
+func (f *fakeSource) Search(keyword string, page int) ([]mtop.FeedItem, bool, error) {
+	if err, ok := f.errs[keyword]; ok {
+		return nil, false, err
+	}
+	return f.search[keyword], false, nil
+}
+
+func (f *fakeSource) SellerItems(sellerID string, page int) ([]mtop.FeedItem, bool, error) {
+	return f.sellers[sellerID], false, nil
+}
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestWatcher(t *testing.T, source Source, dir string) (*Watcher, *fakeClock) {
+	t.Helper()
+	var listPath, statePath string
+	if dir != "" {
+		listPath = filepath.Join(dir, "watchlist.yaml")
+		statePath = filepath.Join(dir, "watch_state.json")
+	}
+	w, err := NewWatcher(source, listPath, statePath, Options{Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 10, 0, 0, 0, time.Local)}
+	w.now = clock.Now
+	return w, clock
+}
+
+func eventTypes(events []Event) string {
+	var parts []string
+	for _, e := range events {
+		parts = append(parts, string(e.Type)+":"+e.ItemID)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestParseFilter(t *testing.T) {
+	now := time.Date(2024, 6, 10, 0, 0, 0, 0, time.Local)
+	item := mtop.FeedItem{
+		Title:         "iPhone 13 Pro 256G",
+		Price:         "¥4,200",
+		WantCount:     8,
+		Location:      "杭州",
+		PublishTimeTS: now.Add(-48 * time.Hour).UnixMilli(),
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"", true},
+		{"price < 5000 && want >= 5", true},
+		{"price < 4000 || want > 10", false},
+		{`title ~ "pro" && !(title ~ '壳' || city == "上海")`, true},
+		{"title !~ iphone", false},
+		{"days <= 3", true},
+		{"view > 0 || category == 0", true},
+	}
+	for _, tt := range tests {
+		f, err := ParseFilter(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseFilter(%q) error = %v", tt.expr, err)
+		}
+		if got := f.Match(item, now); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	// 发布时间未知时 days 比较为假
+	f, _ := ParseFilter("days < 3")
+	if f.Match(mtop.FeedItem{}, now) {
+		t.Error("发布时间未知时 days 比较应为假")
+	}
+
+	for _, bad := range []string{"price <", "price ~ 3", "title > 'a'", "foo == 1", "(price < 1", "price < 'abc'", "title == 'a' extra"} {
+		if _, err := ParseFilter(bad); err == nil {
+			t.Errorf("ParseFilter(%q) 应返回错误", bad)
+		}
+	}
+}
+
+func TestWatcher_KeywordEvents(t *testing.T) {
+	source := newFakeSource()
+	w, clock := newTestWatcher(t, source, "")
+	ctx := context.Background()
+
+	entry, err := w.Add(Entry{Kind: KindKeyword, Target: "switch", Filter: "price <= 1500"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if entry.ID == "" {
+		t.Fatal("Add() 应自动生成ID")
+	}
+	if _, err := w.Add(Entry{Kind: KindKeyword, Target: "Switch"}); err == nil {
+		t.Error("重复关注同一关键词应返回错误")
+	}
+
+	var received []Event
+	w.OnEvent(func(e Event) { received = append(received, e) })
+
+	// 首次检查只建立基线
+	source.search["switch"] = []mtop.FeedItem{
+		{ItemID: "1", Title: "Switch 续航版", Price: "1400"},
+		{ItemID: "2", Title: "Switch OLED", Price: "1800"},
+	}
+	if events, err := w.CheckDue(ctx); err != nil || len(events) != 0 {
+		t.Fatalf("首次检查 = %v, %v, want 无事件", eventTypes(events), err)
+	}
+
+	// 未到期不检查
+	source.search["switch"] = append(source.search["switch"], mtop.FeedItem{ItemID: "3", Title: "Switch Lite", Price: "700"})
+	if events, _ := w.CheckDue(ctx); len(events) != 0 {
+		t.Fatalf("未到期不应检查: %v", eventTypes(events))
+	}
+
+	// 到期: 商品3 为新匹配，商品2 降价后进入过滤范围也算新匹配，商品1 降价
+	clock.Advance(time.Hour)
+	source.search["switch"] = []mtop.FeedItem{
+		{ItemID: "1", Title: "Switch 续航版", Price: "1300"},
+		{ItemID: "2", Title: "Switch OLED", Price: "1500"},
+		{ItemID: "3", Title: "Switch Lite", Price: "700"},
+	}
+	events, err := w.CheckDue(ctx)
+	if err != nil {
+		t.Fatalf("CheckDue() error = %v", err)
+	}
+	if got := eventTypes(events); got != "price_drop:1,new_match:2,new_match:3" {
+		t.Errorf("事件 = %s", got)
+	}
+	if events[0].OldPrice != 1400 || events[0].Price != 1300 {
+		t.Errorf("降价事件价格错误: %+v", events[0])
+	}
+	if len(received) != 3 {
+		t.Errorf("OnEvent 收到 %d 个事件, want 3", len(received))
+	}
+	if recent := w.Events(2); len(recent) != 2 || recent[0].ItemID != "3" {
+		t.Errorf("Events(2) = %v", eventTypes(recent))
+	}
+}
+
+func TestWatcher_ItemLifecycleAndBackoff(t *testing.T) {
+	source := newFakeSource()
+	w, clock := newTestWatcher(t, source, "")
+	ctx := context.Background()
+
+	entry, _ := w.Add(Entry{Kind: KindItem, Target: "100", Name: "相机"})
+	source.details["100"] = &mtop.ItemDetail{Title: "相机", Price: "500", ItemStatusStr: "在售"}
+	w.Check(ctx, entry.ID)
+
+	steps := []struct {
+		detail *mtop.ItemDetail
+		want   string
+	}{
+		{&mtop.ItemDetail{Price: "500", ItemStatusStr: "已下架"}, ""},
+		{&mtop.ItemDetail{Price: "450", ItemStatusStr: "在售"}, "price_drop:100,back_in_stock:100"},
+		{&mtop.ItemDetail{Price: "450", ItemStatusStr: "已售出"}, "sold:100"},
+	}
+	for i, step := range steps {
+		clock.Advance(time.Hour)
+		source.details["100"] = step.detail
+		events, err := w.CheckDue(ctx)
+		if err != nil {
+			t.Fatalf("第 %d 步 CheckDue() error = %v", i+1, err)
+		}
+		if got := eventTypes(events); got != step.want {
+			t.Errorf("第 %d 步事件 = %q, want %q", i+1, got, step.want)
+		}
+		if len(events) > 0 && events[0].EntryName != "相机" {
+			t.Errorf("事件应使用备注名称: %+v", events[0])
+		}
+	}
+
+	// 请求失败时按失败次数退避
+	clock.Advance(time.Hour)
+	source.errs["100"] = fmt.Errorf("网络错误")
+	w.CheckDue(ctx)
+	_, st, _ := w.Get(entry.ID)
+	if st.Failures != 1 || st.NextRun != clock.now.Add(2*time.Hour).UnixMilli() || st.LastError == "" {
+		t.Errorf("失败退避错误: %+v", st)
+	}
+}
+
+func TestWatcher_PersistenceAndCRUD(t *testing.T) {
+	dir := t.TempDir()
+	source := newFakeSource()
+	w, clock := newTestWatcher(t, source, dir)
+	ctx := context.Background()
+
+	seller, _ := w.Add(Entry{Kind: KindSeller, Target: "u1"})
+	keyword, _ := w.Add(Entry{Kind: KindKeyword, Target: "相机"})
+	source.sellers["u1"] = []mtop.FeedItem{{ItemID: "1", Title: "镜头", Price: "300", Status: "在售"}}
+	w.CheckDue(ctx)
+
+	// 重启后已见过的商品不再提醒
+	clock.Advance(time.Hour)
+	reloaded, err := NewWatcher(source, filepath.Join(dir, "watchlist.yaml"), filepath.Join(dir, "watch_state.json"), Options{Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	reloaded.now = clock.Now
+	if got := len(reloaded.List()); got != 2 {
+		t.Fatalf("重新加载后关注项数 = %d, want 2", got)
+	}
+	source.sellers["u1"] = append(source.sellers["u1"], mtop.FeedItem{ItemID: "2", Title: "三脚架", Price: "80", Status: "在售"})
+	events, _ := reloaded.CheckDue(ctx)
+	if got := eventTypes(events); got != "new_match:2" {
+		t.Errorf("重启后事件 = %q, want new_match:2", got)
+	}
+
+	// 修改目标后重新建立基线；删除后状态一并清除
+	if _, err := reloaded.Update(keyword.ID, Entry{Kind: KindKeyword, Target: "镜头"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, st, _ := reloaded.Get(keyword.ID); st.Initialized {
+		t.Error("修改关注目标后应重置状态")
+	}
+	if err := reloaded.Remove(seller.ID); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := reloaded.Remove(seller.ID); err != ErrEntryNotFound {
+		t.Errorf("重复删除应返回 ErrEntryNotFound, got %v", err)
+	}
+
+	entries, err := LoadList(filepath.Join(dir, "watchlist.yaml"))
+	if err != nil || len(entries) != 1 || entries[0].Target != "镜头" {
+		t.Errorf("关注列表文件未同步: %+v, %v", entries, err)
+	}
+	if _, err := reloaded.Add(Entry{Kind: "shop", Target: "x"}); err == nil {
+		t.Error("未知类型应返回错误")
+	}
+	if _, err := reloaded.Add(Entry{Kind: KindKeyword, Target: "x", Filter: "price <"}); err == nil {
+		t.Error("无效过滤表达式应返回错误")
+	}
+}
+
+// TestWatcher_ConcurrentSave 测试 HTTP 检查与定时检查同时保存状态时不会失败或残留临时文件
+func TestWatcher_ConcurrentSave(t *testing.T) {
+	dir := t.TempDir()
+	w, _ := newTestWatcher(t, newFakeSource(), dir)
+	if _, err := w.Add(Entry{Kind: KindKeyword, Target: "相机"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- w.Save()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("状态目录中的文件 = %v, want watchlist.yaml 与 watch_state.json", entries)
+	}
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DeadLetterEntry 最终失败的推送
+type DeadLetterEntry struct {
+	Name     string  `json:"name"`
+	URL      string  `json:"url"`
+	Attempts int     `json:"attempts"`
+	Status   int     `json:"status,omitempty"`
+	Error    string  `json:"error"`
+	FailedAt int64   `json:"failedAt"` // 毫秒时间戳
+	Payload  Payload `json:"payload"`
+}
+
+// DeadLetter 死信文件（JSONL 追加写入，多个 Webhook 可共用）
+type DeadLetter struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewDeadLetter 创建死信文件
+func NewDeadLetter(path string) *DeadLetter {
+	if path == "" {
+		return nil
+	}
+	return &DeadLetter{path: path}
+}
+
+// Path 文件路径
+func (d *DeadLetter) Path() string { return d.path }
+
+// Append 追加一条死信
+func (d *DeadLetter) Append(entry DeadLetterEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化死信失败: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(d.path), 0755); err != nil {
+		return fmt.Errorf("创建死信目录失败: %w", err)
+	}
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开死信文件失败: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// ReadDeadLetters 读取死信文件，文件不存在时返回空
+func ReadDeadLetters(path string) ([]DeadLetterEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开死信文件失败: %w", err)
+	}
+	defer f.Close()
+
+	var entries []DeadLetterEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e DeadLetterEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("解析死信失败: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
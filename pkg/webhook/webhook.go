@@ -0,0 +1,271 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 请求头
+const (
+	HeaderSignature      = "X-Xianyu-Signature" // sha256=<hex>，HMAC-SHA256(secret, timestamp + "." + body)
+	HeaderTimestamp      = "X-Xianyu-Timestamp" // Unix 秒，接收方可据此拒绝过旧的请求
+	HeaderEvent          = "X-Xianyu-Event"     // 事件类型
+	HeaderIdempotencyKey = "Idempotency-Key"    // 同一批数据（含重试与重放）保持不变，接收方据此去重
+	signaturePrefix      = "sha256="
+)
+
+// Payload 推送的请求体
+type Payload struct {
+	ID     string          `json:"id"`     // 幂等键
+	Event  string          `json:"event"`  // 事件类型，如 crawl.items、watch.price_drop
+	Source string          `json:"source"` // 发送方名称
+	Count  int             `json:"count"`  // items 数量
+	Items  json.RawMessage `json:"items"`
+}
+
+// Options Webhook 选项
+type Options struct {
+	Name       string        // 名称（日志与死信记录使用），默认为 URL
+	Secret     string        // 签名密钥，为空时不签名
+	Source     string        // 请求体中的 source 字段
+	MaxRetries int           // 失败后的最大重试次数（不含首次）
+	Backoff    time.Duration // 首次重试等待时间，之后按 2 倍递增，默认 1 秒
+	MaxBackoff time.Duration // 重试等待上限，默认 1 分钟
+	Timeout    time.Duration // 单次请求超时，默认 10 秒
+	DeadLetter *DeadLetter   // 最终失败的推送写入死信文件，为 nil 时丢弃
+	Client     *http.Client
+}
+
+// Sink 出站 Webhook：将数据批量以 JSON POST 到指定地址
+type Sink struct {
+	url   string
+	opts  Options
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Delivery 一次推送的结果
+type Delivery struct {
+	Key      string `json:"key"`
+	Attempts int    `json:"attempts"`
+	Status   int    `json:"status"` // 最后一次响应状态码（网络错误时为 0）
+}
+
+// New 创建 Webhook
+func New(url string, opts Options) (*Sink, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("无效的 webhook 地址: %q", url)
+	}
+	if opts.Name == "" {
+		opts.Name = url
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Minute
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+	return &Sink{url: url, opts: opts, now: time.Now, sleep: sleepContext}, nil
+}
+
+// Name 名称
+func (s *Sink) Name() string { return s.opts.Name }
+
+// Sign 计算签名：sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名（供接收方参考实现与测试使用）
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// IdempotencyKey 根据事件类型与数据内容生成幂等键
+func IdempotencyKey(event string, items []byte) string {
+	h := sha256.New()
+	h.Write([]byte(event + "\n"))
+	h.Write(items)
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// Deliver 推送一批数据，失败时按指数退避重试；最终失败时写入死信文件并返回错误
+func (s *Sink) Deliver(ctx context.Context, event string, items interface{}) (Delivery, error) {
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("序列化推送数据失败: %w", err)
+	}
+	payload := Payload{
+		ID:     IdempotencyKey(event, raw),
+		Event:  event,
+		Source: s.opts.Source,
+		Count:  countItems(raw),
+		Items:  raw,
+	}
+	return s.DeliverPayload(ctx, payload)
+}
+
+// DeliverPayload 推送已构建的请求体（重放死信时保持原幂等键）
+func (s *Sink) DeliverPayload(ctx context.Context, payload Payload) (Delivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("序列化请求体失败: %w", err)
+	}
+
+	d := Delivery{Key: payload.ID}
+	backoff := s.opts.Backoff
+	var lastErr error
+	for attempt := 0; attempt <= s.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			wait := backoff
+			var re *retryAfterError
+			if errors.As(lastErr, &re) && re.after > wait {
+				wait = re.after
+			}
+			if wait > s.opts.MaxBackoff {
+				wait = s.opts.MaxBackoff
+			}
+			if err := s.sleep(ctx, wait); err != nil {
+				lastErr = err
+				break
+			}
+			backoff *= 2
+		}
+
+		d.Attempts++
+		var retry bool
+		d.Status, retry, lastErr = s.post(ctx, payload, body)
+		if lastErr == nil {
+			return d, nil
+		}
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if s.opts.DeadLetter != nil {
+		if err := s.opts.DeadLetter.Append(DeadLetterEntry{
+			Name:     s.opts.Name,
+			URL:      s.url,
+			Attempts: d.Attempts,
+			Status:   d.Status,
+			Error:    lastErr.Error(),
+			FailedAt: s.now().UnixMilli(),
+			Payload:  payload,
+		}); err != nil {
+			return d, fmt.Errorf("%v（写入死信失败: %v）", lastErr, err)
+		}
+	}
+	return d, lastErr
+}
+
+// post 发送一次请求，返回状态码、是否可重试与错误
+// 网络错误、408、429 与 5xx 可重试，其他非 2xx 状态视为永久失败
+func (s *Sink) post(ctx context.Context, payload Payload, body []byte) (int, bool, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, fmt.Errorf("创建请求失败: %w", err)
+	}
+	ts := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderEvent, payload.Event)
+	req.Header.Set(HeaderIdempotencyKey, payload.ID)
+	if s.opts.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(s.opts.Secret, ts, body))
+	}
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return 0, true, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if secs, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && secs > 0 {
+			return resp.StatusCode, true, &retryAfterError{err: err, after: time.Duration(secs) * time.Second}
+		}
+		return resp.StatusCode, true, err
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode >= 500:
+		return resp.StatusCode, true, err
+	}
+	return resp.StatusCode, false, err
+}
+
+// retryAfterError 服务端通过 Retry-After 指定了等待时间
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryAfterError) Error() string { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error { return e.err }
+
+// countItems 统计 JSON 数组元素个数（非数组时为 1）
+func countItems(raw []byte) int {
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return 1
+	}
+	return len(list)
+}
+
+// Batches 将数据按 size 分批（size <= 0 时不分批）
+func Batches[T any](items []T, size int) [][]T {
+	if len(items) == 0 {
+		return nil
+	}
+	if size <= 0 || size >= len(items) {
+		return [][]T{items}
+	}
+	var batches [][]T
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		batches = append(batches, items[start:end])
+	}
+	return batches
+}
+
+// sleepContext 等待 d 或 ctx 取消
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,187 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder 记录收到的请求，按预设状态码依次响应
+type recorder struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := http.StatusOK
+	if i := len(r.requests); i < len(r.statuses) {
+		status = r.statuses[i]
+	}
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	w.WriteHeader(status)
+}
+
+func newTestSink(t *testing.T, url string, opts Options) (*Sink, *[]time.Duration) {
+	t.Helper()
+	sink, err := New(url, opts)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	sink.now = func() time.Time { return time.Unix(1700000000, 0) }
+	var waits []time.Duration
+	sink.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return sink, &waits
+}
+
+func TestSink_DeliverSigned(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	sink, _ := newTestSink(t, srv.URL, Options{Secret: "s3cret", Source: "xianyu_aner"})
+	items := []map[string]string{{"itemId": "1"}, {"itemId": "2"}}
+	d, err := sink.Deliver(context.Background(), "crawl.items", items)
+	if err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if d.Attempts != 1 || d.Status != http.StatusOK {
+		t.Errorf("delivery = %+v", d)
+	}
+
+	req, body := rec.requests[0], rec.bodies[0]
+	if req.Header.Get(HeaderTimestamp) != "1700000000" {
+		t.Errorf("timestamp = %q", req.Header.Get(HeaderTimestamp))
+	}
+	if !Verify("s3cret", "1700000000", body, req.Header.Get(HeaderSignature)) {
+		t.Errorf("signature mismatch: %q", req.Header.Get(HeaderSignature))
+	}
+	if req.Header.Get(HeaderEvent) != "crawl.items" || req.Header.Get(HeaderIdempotencyKey) != d.Key {
+		t.Errorf("headers = %v", req.Header)
+	}
+
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if p.ID != d.Key || p.Count != 2 || p.Source != "xianyu_aner" {
+		t.Errorf("payload = %+v", p)
+	}
+
+	// 相同数据的幂等键不变
+	again, _ := sink.Deliver(context.Background(), "crawl.items", items)
+	if again.Key != d.Key {
+		t.Errorf("idempotency key changed: %s != %s", again.Key, d.Key)
+	}
+}
+
+func TestSink_RetryAndDeadLetter(t *testing.T) {
+	rec := &recorder{statuses: []int{500, 503, 200}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	dlPath := filepath.Join(t.TempDir(), "dead.jsonl")
+	sink, waits := newTestSink(t, srv.URL, Options{
+		MaxRetries: 3,
+		Backoff:    time.Second,
+		DeadLetter: NewDeadLetter(dlPath),
+	})
+
+	d, err := sink.Deliver(context.Background(), "crawl.items", []int{1})
+	if err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if d.Attempts != 3 {
+		t.Errorf("attempts = %d, want 3", d.Attempts)
+	}
+	if !reflect.DeepEqual(*waits, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Errorf("backoff = %v", *waits)
+	}
+	// 重试使用同一个幂等键
+	if rec.requests[0].Header.Get(HeaderIdempotencyKey) != rec.requests[2].Header.Get(HeaderIdempotencyKey) {
+		t.Errorf("idempotency key changed between retries")
+	}
+
+	// 4xx 不重试，直接写入死信
+	rec.statuses = []int{500, 503, 200, 400}
+	d, err = sink.Deliver(context.Background(), "crawl.items", []int{2})
+	if err == nil || d.Attempts != 1 || d.Status != http.StatusBadRequest {
+		t.Fatalf("expected permanent failure, got %+v, %v", d, err)
+	}
+
+	// 重试耗尽后写入死信
+	rec.statuses = append(rec.statuses, 502, 502, 502, 502)
+	d, err = sink.Deliver(context.Background(), "crawl.items", []int{3})
+	if err == nil || d.Attempts != 4 {
+		t.Fatalf("expected exhausted retries, got %+v, %v", d, err)
+	}
+
+	entries, err := ReadDeadLetters(dlPath)
+	if err != nil {
+		t.Fatalf("ReadDeadLetters: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Status != 400 || entries[1].Attempts != 4 {
+		t.Fatalf("dead letters = %+v", entries)
+	}
+	if string(entries[1].Payload.Items) != "[3]" {
+		t.Errorf("dead letter payload = %s", entries[1].Payload.Items)
+	}
+
+	// 重放死信保持原幂等键
+	rec.statuses = nil
+	replayed, err := sink.DeliverPayload(context.Background(), entries[1].Payload)
+	if err != nil || replayed.Key != entries[1].Payload.ID {
+		t.Errorf("replay = %+v, %v", replayed, err)
+	}
+}
+
+func TestSink_RetryAfter(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink, waits := newTestSink(t, srv.URL, Options{MaxRetries: 1, Backoff: time.Second})
+	if _, err := sink.Deliver(context.Background(), "e", []int{1}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if !reflect.DeepEqual(*waits, []time.Duration{5 * time.Second}) {
+		t.Errorf("waits = %v, want [5s]", *waits)
+	}
+}
+
+func TestBatches(t *testing.T) {
+	got := Batches([]int{1, 2, 3, 4, 5}, 2)
+	want := [][]int{{1, 2}, {3, 4}, {5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Batches = %v, want %v", got, want)
+	}
+	if got := Batches([]int{1, 2}, 0); len(got) != 1 {
+		t.Errorf("Batches(size=0) = %v", got)
+	}
+	if got := Batches([]int(nil), 2); got != nil {
+		t.Errorf("Batches(nil) = %v", got)
+	}
+}